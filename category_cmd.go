@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// CategoryCmd browses the catalog by category instead of search terms
+type CategoryCmd struct {
+	Show      CategoryShowCmd      `cmd:"" default:"withargs" help:"Show a category with its subcategories"`
+	Products  CategoryProductsCmd  `cmd:"" help:"List the products of a category page by page"`
+	Producers CategoryProducersCmd `cmd:"" help:"Show the top manufacturers of a category"`
+	Promo     CategoryPromoCmd     `cmd:"" help:"Show the promoted product sections of a category"`
+}
+
+type CategoryShowCmd struct {
+	Category string `arg:"" help:"Category ID or URL, e.g. 18862660 or https://www.alza.sk/sport/kreatin/18862660.htm"`
+	Tree     bool   `help:"Show the subcategory tree below the category"`
+	Depth    int    `help:"Levels of subcategories for --tree (one request per category)" default:"2"`
+}
+
+func (c *CategoryShowCmd) Run(g *Globals) error {
+	id, err := client.ParseCategoryID(c.Category)
+	if err != nil {
+		return err
+	}
+	if c.Depth < 1 {
+		return fmt.Errorf("invalid --depth %d", c.Depth)
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	cat, err := cl.GetCategoryContext(g.Context(), id)
+	if err != nil {
+		return err
+	}
+	if c.Tree {
+		if err := expandCategoryTree(g, cl, cat, c.Depth-1); err != nil {
+			return err
+		}
+	}
+
+	if g.Format == "json" {
+		outputJSON(cat)
+		return nil
+	}
+
+	fmt.Printf("%s [%d] · %d products\n", cat.Name, cat.ID, cat.ProductCount)
+	if cat.URL != "" {
+		fmt.Printf("%s\n", cat.URL)
+	}
+	if c.Tree {
+		fmt.Println()
+		printCategoryTree(cat.Children, "")
+	} else if len(cat.Children) > 0 {
+		fmt.Println("\nSubcategories:")
+		for _, child := range cat.Children {
+			fmt.Printf("  [%d] %s (%d)\n", child.ID, child.Name, child.ProductCount)
+		}
+	}
+	fmt.Printf("\nProducts: alza category products %d\n", cat.ID)
+	if cat.ParentID != 0 {
+		fmt.Printf("Up:       alza category %d\n", cat.ParentID)
+	}
+	return nil
+}
+
+// expandCategoryTree fills in the subcategories of cat's children, depth more levels down
+func expandCategoryTree(g *Globals, cl *client.TLSClient, cat *client.Category, depth int) error {
+	if depth <= 0 {
+		return nil
+	}
+	for i := range cat.Children {
+		child, err := cl.GetCategoryContext(g.Context(), cat.Children[i].ID)
+		if err != nil {
+			return fmt.Errorf("category %d: %w", cat.Children[i].ID, err)
+		}
+		if err := expandCategoryTree(g, cl, child, depth-1); err != nil {
+			return err
+		}
+		cat.Children[i].Children = child.Children
+	}
+	return nil
+}
+
+func printCategoryTree(categories []client.Category, indent string) {
+	for i, cat := range categories {
+		branch, next := "├── ", "│   "
+		if i == len(categories)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Printf("%s%s[%d] %s (%d)\n", indent, branch, cat.ID, cat.Name, cat.ProductCount)
+		printCategoryTree(cat.Children, indent+next)
+	}
+}
+
+type CategoryProductsCmd struct {
+	Category string `arg:"" help:"Category ID or URL, e.g. 18862660 or https://www.alza.sk/sport/kreatin/18862660.htm"`
+	Limit    int    `help:"Products per page" default:"24" short:"n"`
+	Page     int    `help:"Page to show (1 = first)" default:"1" short:"p"`
+}
+
+func (c *CategoryProductsCmd) Run(g *Globals) error {
+	id, err := client.ParseCategoryID(c.Category)
+	if err != nil {
+		return err
+	}
+	if c.Limit < 1 || c.Page < 1 {
+		return fmt.Errorf("--limit and --page must be at least 1")
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	page, err := cl.GetCategoryProductsContext(g.Context(), id, (c.Page-1)*c.Limit, c.Limit)
+	if err != nil {
+		return err
+	}
+
+	if g.Format == "json" {
+		outputJSON(page)
+		return nil
+	}
+
+	if len(page.Products) == 0 {
+		fmt.Printf("No products in %s on page %d (%d total)\n", categoryName(page), c.Page, page.Total)
+		return nil
+	}
+	last := page.Offset + len(page.Products)
+	fmt.Printf("%s: %d-%d of %d\n\n", categoryName(page), page.Offset+1, last, page.Total)
+	for i, r := range page.Products {
+		fmt.Printf("%d. [%d] %s\n", page.Offset+i+1, r.ID, r.Name)
+		fmt.Printf("   Price: %s | %s\n", r.PriceStr, r.Availability)
+		fmt.Printf("   %s\n\n", r.URL)
+	}
+	if last < page.Total {
+		fmt.Printf("Next: alza category products %d --page %d -n %d\n", id, c.Page+1, c.Limit)
+	}
+	return nil
+}
+
+func categoryName(page *client.CategoryProducts) string {
+	if page.Name != "" {
+		return page.Name
+	}
+	return fmt.Sprintf("category %d", page.CategoryID)
+}
+
+type CategoryProducersCmd struct {
+	Category string `arg:"" help:"Category ID or URL, e.g. 18862660 or https://www.alza.sk/sport/kreatin/18862660.htm"`
+}
+
+func (c *CategoryProducersCmd) Run(g *Globals) error {
+	id, err := client.ParseCategoryID(c.Category)
+	if err != nil {
+		return err
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	producers, err := cl.GetTopProducersContext(g.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if g.Format == "json" {
+		outputJSON(producers)
+		return nil
+	}
+
+	if len(producers) == 0 {
+		fmt.Println("No top producers")
+		return nil
+	}
+	for i, p := range producers {
+		fmt.Printf("%d. %s\n", i+1, p.Name)
+		if p.URL != "" {
+			fmt.Printf("   %s\n", p.URL)
+		}
+	}
+	return nil
+}
+
+type CategoryPromoCmd struct {
+	Category string `arg:"" help:"Category ID or URL, e.g. 18862660 or https://www.alza.sk/sport/kreatin/18862660.htm"`
+}
+
+func (c *CategoryPromoCmd) Run(g *Globals) error {
+	id, err := client.ParseCategoryID(c.Category)
+	if err != nil {
+		return err
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	sections, err := cl.GetPromoSectionsContext(g.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if g.Format == "json" {
+		outputJSON(sections)
+		return nil
+	}
+
+	if len(sections) == 0 {
+		fmt.Println("No promo sections")
+		return nil
+	}
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n%s\n", section.Title, strings.Repeat("─", len([]rune(section.Title))))
+		for _, r := range section.Products {
+			fmt.Printf("  [%d] %s · %s | %s\n", r.ID, r.Name, r.PriceStr, r.Availability)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+)
+
+func TestCLICategoryShowAndTree(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddCategory(alzatest.Category{ID: 18862670, Name: "Mikronizovaný kreatín", Slug: "mikronizovany", ParentID: 18862660})
+
+	out := mustRunCLI(t, "category", "https://www.alza.sk/sport/kreatin/18862660.htm")
+	for _, want := range []string{"Kreatín [18862660] · 1 products", "[18862670] Mikronizovaný kreatín (0)", "alza category products 18862660", "Up:       alza category 18855843"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("category output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = mustRunCLI(t, "category", "18855843", "--tree")
+	for _, want := range []string{"├── [18862660] Kreatín (1)", "│   └── [18862670] Mikronizovaný kreatín (0)", "└── [18862661] Proteíny (0)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("category --tree output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Up:") {
+		t.Errorf("top-level category shows a parent:\n%s", out)
+	}
+
+	var cat client.Category
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "category", "show", "18855843", "--tree", "--depth", "1")), &cat); err != nil {
+		t.Fatal(err)
+	}
+	if len(cat.Children) != 2 || len(cat.Children[0].Children) != 0 {
+		t.Errorf("category --tree --depth 1 JSON = %+v", cat)
+	}
+
+	if _, err := runCLI(t, "category", "kreatin"); err == nil || !strings.Contains(err.Error(), "invalid category") {
+		t.Errorf("category with a name error = %v", err)
+	}
+}
+
+func TestCLICategoryProducts(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddProduct(alzatest.Product{ID: 9200001, Name: "Amix Nutrition Creatine 300 g", Code: "AMIX1", Price: 14.90, Availability: "Skladom > 5 ks"})
+	srv.AddCategory(alzatest.Category{ID: 18862661, Name: "Proteíny", Slug: "proteiny", ParentID: 18855843, Products: []int{9200001}})
+
+	out := mustRunCLI(t, "category", "products", "18855843", "-n", "1")
+	if !strings.Contains(out, "Šport a outdoor: 1-1 of 2") || !strings.Contains(out, "1. [7816725] GymBeam") || !strings.Contains(out, "Next: alza category products 18855843 --page 2 -n 1") {
+		t.Errorf("category products output:\n%s", out)
+	}
+	out = mustRunCLI(t, "category", "products", "18855843", "-n", "1", "--page", "2")
+	if !strings.Contains(out, "2. [9200001] Amix") || strings.Contains(out, "Next:") {
+		t.Errorf("category products page 2 output:\n%s", out)
+	}
+
+	var page client.CategoryProducts
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "category", "products", "18855843", "--page", "3", "-n", "1")), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Offset != 2 || len(page.Products) != 0 {
+		t.Errorf("category products page 3 JSON = %+v", page)
+	}
+}
+
+func TestCLICategoryProducersAndPromo(t *testing.T) {
+	startFakeAlza(t)
+
+	out := mustRunCLI(t, "category", "producers", "18855843")
+	if !strings.Contains(out, "1. GymBeam") || !strings.Contains(out, "2. Amix Nutrition") {
+		t.Errorf("category producers output:\n%s", out)
+	}
+
+	out = mustRunCLI(t, "category", "promo", "18862660")
+	if !strings.Contains(out, "Najpredávanejšie") || !strings.Contains(out, "[7816725] GymBeam Kreatín monohydrát 500 g · 17,90 €") {
+		t.Errorf("category promo output:\n%s", out)
+	}
+	if out := mustRunCLI(t, "category", "promo", "18890188"); !strings.Contains(out, "No promo sections") {
+		t.Errorf("category promo without sections output:\n%s", out)
+	}
+}
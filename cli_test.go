@@ -0,0 +1,550 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+)
+
+// newCLI returns a zero value of the CLI struct so every run parses fresh flags
+func newCLI[T any](_ T) *T {
+	return new(T)
+}
+
+// startFakeAlza runs alzatest and logs the CLI in with its token in a temp HOME
+func startFakeAlza(t *testing.T) *alzatest.Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ALZA_COUNTRY", "")
+	t.Setenv("ALZA_FAVORITES_LIST", "")
+
+	srv := alzatest.NewServer()
+	t.Cleanup(srv.Close)
+	t.Setenv("ALZA_BASE_URL", srv.URL)
+	t.Setenv("ALZA_WEBAPI_URL", srv.URL)
+	t.Setenv("ALZA_RATE_LIMIT", "0")
+	t.Setenv("ALZA_REFRESH_FROM", "off")      // Never reach for the real Chrome profile
+	t.Setenv("ALZA_TOKEN_STORE", "plaintext") // Nor for the desktop keyring
+	t.Setenv("ALZA_PROFILE", "")
+
+	if err := client.SaveToken(alzatest.DefaultToken); err != nil {
+		t.Fatalf("SaveToken() error: %v", err)
+	}
+	return srv
+}
+
+// runCLI parses args like main() and returns what the command printed to stdout
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return runCLIContext(t, context.Background(), args...)
+}
+
+// runCLIContext is runCLI with ctx standing in for the Ctrl+C context
+func runCLIContext(t *testing.T, ctx context.Context, args ...string) (string, error) {
+	t.Helper()
+
+	cli := newCLI(CLI)
+	parser, err := kong.New(cli, kong.Name("alza"), kong.Vars{"version": client.Version})
+	if err != nil {
+		t.Fatalf("kong.New() error: %v", err)
+	}
+	kctx, err := parser.Parse(args)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", args, err)
+	}
+	cli.Globals.ctx = ctx
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+
+	runErr := kctx.Run(&cli.Globals)
+	if closeErr := cli.Globals.Close(); closeErr != nil && runErr == nil {
+		runErr = closeErr
+	}
+
+	os.Stdout = stdout
+	_ = w.Close()
+	return <-done, runErr
+}
+
+func mustRunCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := runCLI(t, args...)
+	if err != nil {
+		t.Fatalf("alza %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+func TestCLIWhoami(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.SetAlzaPlus(true)
+
+	out := mustRunCLI(t, "whoami")
+	for _, want := range []string{"User: Test Používateľ (ID: 1234567)", "Cart items: 0", "AlzaPlus+ member"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("whoami output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCLISearchProductReviews(t *testing.T) {
+	startFakeAlza(t)
+
+	out := mustRunCLI(t, "search", "kávovar")
+	if !strings.Contains(out, "[12345678] De'Longhi") || !strings.Contains(out, "329,00 €") {
+		t.Errorf("search output:\n%s", out)
+	}
+
+	out = mustRunCLI(t, "--format", "json", "product", "7816725")
+	var detail client.ProductDetail
+	if err := json.Unmarshal([]byte(out), &detail); err != nil {
+		t.Fatalf("product json: %v\n%s", err, out)
+	}
+	if detail.Name != "GymBeam Kreatín monohydrát 500 g" || detail.Availability != "Skladom > 5 ks" {
+		t.Errorf("product = %+v", detail)
+	}
+
+	out = mustRunCLI(t, "reviews", "7816725")
+	if !strings.Contains(out, "Martin") || !strings.Contains(out, "Zuzana") {
+		t.Errorf("reviews output:\n%s", out)
+	}
+}
+
+func TestCLISearchOptions(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddProduct(alzatest.Product{ID: 9100001, Name: "Apple iPhone 15 128GB", Code: "TEST1", Category: "mobily", Price: 899, Availability: "Skladom > 5 ks", RatingCount: 40})
+
+	out := mustRunCLI(t, "search", "iphone", "--sort", "price-desc", "-n", "1", "--page", "2")
+	if !strings.Contains(out, "2. [9100001] Apple iPhone 15 128GB") || strings.Contains(out, "8123456") {
+		t.Errorf("search page 2 output:\n%s", out)
+	}
+
+	var results []client.SearchResult
+	out = mustRunCLI(t, "--format", "json", "search", "iphone", "--in-stock", "--manufacturer", "Apple", "--category", "mobily", "--max-price", "1000")
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("search json: %v\n%s", err, out)
+	}
+	if len(results) != 1 || results[0].ID != 9100001 {
+		t.Errorf("filtered search = %+v", results)
+	}
+
+	if out := mustRunCLI(t, "search", "iphone", "--sort", "bestselling"); !strings.Contains(out, "1. [8123456]") || !strings.Contains(out, "★ 0.0 (96)") {
+		t.Errorf("bestselling search output:\n%s", out)
+	}
+	if out := mustRunCLI(t, "search", "iphone", "--min-price", "2000"); !strings.Contains(out, "No results found") {
+		t.Errorf("search above every price output:\n%s", out)
+	}
+	if _, err := runCLI(t, "search", "iphone", "--page", "2", "--offset", "5"); err == nil {
+		t.Error("search with --page and --offset succeeded")
+	}
+	if _, err := runCLI(t, "search", "iphone", "--min-price", "50", "--max-price", "10"); err == nil {
+		t.Error("search with min price above max price succeeded")
+	}
+}
+
+func TestCLIProductReferences(t *testing.T) {
+	srv := startFakeAlza(t)
+
+	out := mustRunCLI(t, "product", "https://www.alza.sk/delonghi-magnifica-s-ecam-22-110-b-kavovar-d12345678.htm")
+	if !strings.Contains(out, "[12345678] De'Longhi") {
+		t.Errorf("product by URL output:\n%s", out)
+	}
+	if out := mustRunCLI(t, "reviews", "GYMB0105", "--stats"); !strings.Contains(out, "Reviews for product 7816725") {
+		t.Errorf("reviews by code output:\n%s", out)
+	}
+
+	mustRunCLI(t, "cart", "add", "8586022211126", "-q", "2")
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].ProductID != 7816725 || cart[0].Count != 2 {
+		t.Errorf("cart after adding by EAN = %+v", cart)
+	}
+	mustRunCLI(t, "cart", "remove", "code:GYMB0105")
+	if cart := srv.Cart(); len(cart) != 0 {
+		t.Errorf("cart after removing by code = %+v", cart)
+	}
+
+	mustRunCLI(t, "favorites", "add", "RI0461b")
+	mustRunCLI(t, "lists", "add", "49098230", "8000153987069")
+	if items := srv.Lists()[1].Items; len(items) != 3 || items[1].ProductID != 8123456 || items[2].ProductID != 12345678 {
+		t.Errorf("AGENT list after adding by code and EAN = %+v", items)
+	}
+
+	// IDs and URLs resolve offline
+	srv.InjectFault(alzatest.Fault{Path: "/", Status: 500})
+	if out := mustRunCLI(t, "product", "--history", "https://www.alza.sk/x-d7816725.htm"); !strings.Contains(out, "No price history for product 7816725") {
+		t.Errorf("product --history by URL output:\n%s", out)
+	}
+	srv.ClearFaults()
+
+	if _, err := runCLI(t, "product", "NOPE0001"); err == nil || !strings.Contains(err.Error(), "no product with Alza code NOPE0001") {
+		t.Errorf("product with an unknown code error = %v", err)
+	}
+	parser, err := kong.New(newCLI(CLI), kong.Name("alza"), kong.Vars{"version": client.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"cart", "add", "8586022211127"},
+		{"favorites", "add", "https://www.alza.sk/sport/kreatin/18862660.htm"},
+		{"quickbuy", "kreatín 500 g"},
+	} {
+		if _, err := parser.Parse(args); err == nil {
+			t.Errorf("%q parsed", args)
+		}
+	}
+}
+
+func TestCLIProductBatch(t *testing.T) {
+	startFakeAlza(t)
+
+	out := mustRunCLI(t, "product", "7816725", "GYMB0105", "--no-description", "-j", "2")
+	if strings.Count(out, "[7816725] GymBeam") != 2 || !strings.Contains(out, "Rating:") {
+		t.Errorf("product batch output:\n%s", out)
+	}
+
+	in := filepath.Join(t.TempDir(), "products.txt")
+	if err := os.WriteFile(in, []byte("# comparison sheet\n12345678, 404\n\nRI0461b NOSUCH99\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	out, err = runCLI(t, "--format", "json", "product", "-", "--no-reviews")
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 products failed") {
+		t.Errorf("product batch with an unknown ID error = %v", err)
+	}
+	var results []struct {
+		Ref     string                `json:"ref"`
+		ID      int                   `json:"id"`
+		Product *client.ProductDetail `json:"product"`
+		Error   string                `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("product batch json: %v\n%s", err, out)
+	}
+	if len(results) != 4 || results[0].Product == nil || results[0].Product.ID != 12345678 || results[2].Product == nil || results[2].Product.ID != 8123456 {
+		t.Fatalf("product batch = %+v", results)
+	}
+	if results[1].Ref != "404" || results[1].ID != 404 || results[1].Product != nil || results[1].Error == "" {
+		t.Errorf("unknown product = %+v", results[1])
+	}
+	if results[3].Ref != "code NOSUCH99" || results[3].ID != 0 || !strings.Contains(results[3].Error, "no product with Alza code NOSUCH99") {
+		t.Errorf("unknown code = %+v", results[3])
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal([]byte(out), &raw); err != nil || raw[3]["id"] != nil {
+		t.Errorf("unknown code JSON = %v, %v, want no id", raw, err)
+	}
+	if results[0].Product.ReviewStats != nil || results[0].Product.Availability == "" {
+		t.Errorf("product with --no-reviews = %+v", results[0].Product)
+	}
+
+	if _, err := runCLI(t, "product", "7816725", "12345678", "--history"); err == nil {
+		t.Error("product --history with two products succeeded")
+	}
+}
+
+func TestReadProductRefs(t *testing.T) {
+	refs, err := readProductRefs([]string{"7816725", "-"}, strings.NewReader("GYMB0105\t8000153987069\n# skip\nhttps://www.alza.sk/x-d8123456.htm\n"))
+	if err != nil {
+		t.Fatalf("readProductRefs() error: %v", err)
+	}
+	want := []client.ProductRef{{ID: 7816725}, {Code: "GYMB0105"}, {GTIN: "8000153987069"}, {ID: 8123456}}
+	if !slices.Equal(refs, want) {
+		t.Errorf("readProductRefs() = %+v, want %+v", refs, want)
+	}
+
+	if _, err := readProductRefs([]string{"-"}, strings.NewReader("7816725\nnot a product!\n")); err == nil || !strings.Contains(err.Error(), "stdin line 2") {
+		t.Errorf("readProductRefs(bad stdin) error = %v", err)
+	}
+	if _, err := readProductRefs([]string{"-"}, strings.NewReader("\n# nothing\n")); err == nil {
+		t.Error("readProductRefs(empty stdin) succeeded")
+	}
+}
+
+func TestCLICartCommands(t *testing.T) {
+	srv := startFakeAlza(t)
+
+	mustRunCLI(t, "cart", "add", "7816725", "-q", "3")
+	mustRunCLI(t, "cart", "add", "8123456")
+
+	out := mustRunCLI(t, "cart")
+	if !strings.Contains(out, "Cart (2 items)") || !strings.Contains(out, "Qty: 3") {
+		t.Errorf("cart output:\n%s", out)
+	}
+
+	mustRunCLI(t, "cart", "remove", "8123456")
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].ProductID != 7816725 {
+		t.Errorf("server cart after remove = %+v", cart)
+	}
+
+	mustRunCLI(t, "cart", "clear")
+	if out := mustRunCLI(t, "cart", "show"); !strings.Contains(out, "Cart is empty") {
+		t.Errorf("cart after clear:\n%s", out)
+	}
+}
+
+func TestCLIFavoritesAndLists(t *testing.T) {
+	srv := startFakeAlza(t)
+
+	// The AGENT list is picked up as the favorites target
+	mustRunCLI(t, "favorites", "add", "12345678")
+	out := mustRunCLI(t, "--format", "json", "favorites")
+	if !strings.Contains(out, "-d12345678.htm") || !strings.Contains(out, "-d7816725.htm") {
+		t.Errorf("favorites json:\n%s", out)
+	}
+	mustRunCLI(t, "favorites", "remove", "7816725")
+
+	mustRunCLI(t, "lists", "create", "Darčeky")
+	out = mustRunCLI(t, "lists")
+	if !strings.Contains(out, "Darčeky") {
+		t.Errorf("lists output:\n%s", out)
+	}
+
+	var created alzatest.List
+	for _, l := range srv.Lists() {
+		switch l.Name {
+		case "Darčeky":
+			created = l
+		case "AGENT":
+			if len(l.Items) != 1 || l.Items[0].ProductID != 12345678 {
+				t.Errorf("AGENT items = %+v, want only 12345678", l.Items)
+			}
+		}
+	}
+	if created.ID == 0 {
+		t.Fatal("list Darčeky not created on the server")
+	}
+}
+
+func TestCLIOrders(t *testing.T) {
+	startFakeAlza(t)
+
+	out := mustRunCLI(t, "--format", "json", "orders", "--with-items")
+	var resp struct {
+		Orders     []client.Order `json:"orders"`
+		TotalCount int            `json:"totalCount"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("orders json: %v\n%s", err, out)
+	}
+	if resp.TotalCount != 2 || len(resp.Orders) != 2 || resp.Orders[0].ID != "1059887711" {
+		t.Errorf("orders = %+v", resp)
+	}
+}
+
+func TestCLIQuickbuy(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddCoupon("ZLAVA10", 10)
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "1009905")
+	t.Setenv("ALZA_QUICKBUY_DELIVERY_ID", "2680")
+	t.Setenv("ALZA_QUICKBUY_PAYMENT_ID", "216")
+	t.Setenv("ALZA_QUICKBUY_CARD_ID", "card-1")
+	t.Setenv("ALZA_QUICKBUY_VISITOR_ID", "visitor-1")
+
+	out := mustRunCLI(t, "quickbuy", "7816725", "--coupon", "ZLAVA10", "-y")
+	if !strings.Contains(out, "OBJEDNÁVKA ÚSPEŠNE VYTVORENÁ") || !strings.Contains(out, "16.11 €") {
+		t.Errorf("quickbuy output:\n%s", out)
+	}
+	if payments := srv.Payments(); len(payments) != 1 || payments[0].CardID != "card-1" {
+		t.Errorf("Payments() = %+v, want one card payment", payments)
+	}
+}
+
+func TestCLIQuickbuyInterruptedCountdown(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddCoupon("ZLAVA10", 10)
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "1009905")
+	t.Setenv("ALZA_QUICKBUY_DELIVERY_ID", "2680")
+	t.Setenv("ALZA_QUICKBUY_PAYMENT_ID", "216")
+	t.Setenv("ALZA_QUICKBUY_CARD_ID", "card-1")
+	t.Setenv("ALZA_QUICKBUY_VISITOR_ID", "visitor-1")
+
+	// Nothing is typed, so only Ctrl+C ends the countdown
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin; _ = w.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(500*time.Millisecond, cancel)
+	out, err := runCLIContext(t, ctx, "quickbuy", "7816725", "--coupon", "ZLAVA10", "-t", "30")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("interrupted quickbuy error = %v, want context.Canceled\n%s", err, out)
+	}
+	if payments := srv.Payments(); len(payments) != 0 {
+		t.Errorf("Payments() = %+v, want none", payments)
+	}
+}
+
+func TestCLIChallengeError(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.InjectFault(alzatest.CloudflareChallenge(""))
+
+	_, err := runCLI(t, "whoami")
+	if !errors.Is(err, client.ErrChallenge) {
+		t.Fatalf("whoami error = %v, want ErrChallenge", err)
+	}
+}
+
+func TestCLITokenStatus(t *testing.T) {
+	startFakeAlza(t)
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := client.SaveToken(alzatest.JWT(exp)); err != nil {
+		t.Fatalf("SaveToken() error: %v", err)
+	}
+
+	out := mustRunCLI(t, "--format", "json", "token", "status")
+	var status struct {
+		UserID           string    `json:"userId"`
+		ExpiresAt        time.Time `json:"expiresAt"`
+		RemainingSeconds int64     `json:"remainingSeconds"`
+		Expired          bool      `json:"expired"`
+	}
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		t.Fatalf("token status json: %v\n%s", err, out)
+	}
+	if status.UserID != "1234567" || !status.ExpiresAt.Equal(exp) || status.Expired {
+		t.Errorf("token status = %+v", status)
+	}
+	if status.RemainingSeconds < 3500 || status.RemainingSeconds > 3600 {
+		t.Errorf("remainingSeconds = %d, want ~3600", status.RemainingSeconds)
+	}
+
+	// The opaque default token has no claims to show
+	if err := client.SaveToken(alzatest.DefaultToken); err != nil {
+		t.Fatalf("SaveToken() error: %v", err)
+	}
+	if _, err := runCLI(t, "token", "status"); !errors.Is(err, client.ErrNotJWT) {
+		t.Errorf("token status error = %v, want ErrNotJWT", err)
+	}
+}
+
+func TestCLIEncryptedTokenStore(t *testing.T) {
+	srv := startFakeAlza(t)
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	srv.SetToken(token)
+	t.Setenv("ALZA_TOKEN_STORE", "encrypted")
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "")
+	t.Setenv("ALZA_TOKEN_PASSPHRASE_FILE", "")
+
+	// The plaintext token of startFakeAlza is not read by the encrypted store
+	if _, err := runCLI(t, "whoami"); err == nil || !strings.Contains(err.Error(), "auth_token.enc") {
+		t.Fatalf("whoami error = %v, want missing auth_token.enc", err)
+	}
+
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "cli-test")
+	if err := (client.EncryptedFileTokenStore{}).Save(context.Background(), token); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if out := mustRunCLI(t, "whoami"); !strings.Contains(out, "User:") {
+		t.Errorf("whoami output = %q", out)
+	}
+	if out := mustRunCLI(t, "token", "status"); !strings.Contains(out, "auth_token.enc (encrypted)") {
+		t.Errorf("token status output = %q", out)
+	}
+
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "wrong")
+	if _, err := runCLI(t, "whoami"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("whoami error = %v, want wrong passphrase", err)
+	}
+}
+
+func TestCLIProfiles(t *testing.T) {
+	srv := startFakeAlza(t)
+
+	out := mustRunCLI(t, "--country", "CZ", "profile", "add", "firma", "--favorites-list", "FIRMA")
+	if !strings.Contains(out, "Profile firma created") {
+		t.Errorf("profile add output = %q", out)
+	}
+	if _, err := runCLI(t, "profile", "add", "firma"); err == nil {
+		t.Error("adding firma twice should fail")
+	}
+
+	out = mustRunCLI(t, "--format", "json", "profile", "list")
+	var profiles []struct {
+		Name          string `json:"name"`
+		Current       bool   `json:"current"`
+		Country       string `json:"country"`
+		FavoritesList string `json:"favoritesList"`
+	}
+	if err := json.Unmarshal([]byte(out), &profiles); err != nil {
+		t.Fatalf("profile list json: %v\n%s", err, out)
+	}
+	if len(profiles) != 2 || !profiles[0].Current || profiles[1].Name != "firma" || profiles[1].Country != "CZ" || profiles[1].FavoritesList != "FIRMA" {
+		t.Errorf("profile list = %+v", profiles)
+	}
+
+	// The new profile has no token of its own yet
+	if _, err := runCLI(t, "--profile", "firma", "whoami"); err == nil || !strings.Contains(err.Error(), "profiles/firma") {
+		t.Errorf("whoami without profile token error = %v", err)
+	}
+
+	firma, err := client.LoadProfile("firma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	companyToken := alzatest.JWT(time.Now().Add(time.Hour))
+	if err := (client.PlaintextTokenStore{Path: firma.TokenPath()}).Save(context.Background(), companyToken); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetToken(companyToken)
+
+	mustRunCLI(t, "profile", "use", "firma")
+	mustRunCLI(t, "whoami")
+	// The profile's favorites list is used instead of AGENT
+	mustRunCLI(t, "lists", "create", "FIRMA")
+	mustRunCLI(t, "favorites", "add", "12345678")
+	out = mustRunCLI(t, "--format", "json", "favorites")
+	if !strings.Contains(out, "-d12345678.htm") || strings.Contains(out, "-d7816725.htm") {
+		t.Errorf("favorites of FIRMA list:\n%s", out)
+	}
+
+	// The default profile still has the fake server's default token, which is no longer accepted
+	if _, err := runCLI(t, "--profile", "default", "whoami"); err == nil {
+		t.Error("whoami with the default profile's token should fail")
+	}
+
+	out = mustRunCLI(t, "profile", "list")
+	if !strings.Contains(out, "* firma") {
+		t.Errorf("profile list output:\n%s", out)
+	}
+	mustRunCLI(t, "profile", "remove", "firma")
+	if _, err := runCLI(t, "whoami"); err == nil {
+		t.Error("whoami after removing the current profile should use the default token and fail")
+	}
+	if _, err := runCLI(t, "--profile", "firma", "whoami"); !errors.Is(err, client.ErrProfileNotFound) {
+		t.Errorf("whoami with removed profile error = %v", err)
+	}
+}
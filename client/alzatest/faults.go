@@ -0,0 +1,96 @@
+package alzatest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// challengePage mimics Cloudflare's managed challenge interstitial.
+const challengePage = `<!DOCTYPE html><html lang="en-US"><head><title>Just a moment...</title>` +
+	`<meta http-equiv="refresh" content="390"></head><body><div id="challenge-body-text">` +
+	`www.alza.sk needs to review the security of your connection before proceeding.</div>` +
+	`<script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1?ray=8f1e2d3c4b5a6978"></script>` +
+	`</body></html>`
+
+// Fault makes matching requests fail before they reach the fake endpoints.
+type Fault struct {
+	Method     string // Empty matches any method
+	Path       string // Substring of the request path; empty matches every request
+	Status     int    // Response status; defaults to 500 (403 for challenges)
+	RetryAfter string // Optional Retry-After header
+	Challenge  bool   // Serve a Cloudflare "Just a moment..." page instead of JSON
+	Times      int    // Number of requests to fail; 0 keeps failing until ClearFaults
+}
+
+// Unauthorized fails requests whose path contains path with 401.
+func Unauthorized(path string) Fault {
+	return Fault{Path: path, Status: http.StatusUnauthorized}
+}
+
+// RateLimited fails requests whose path contains path with 429 and Retry-After.
+func RateLimited(path, retryAfter string) Fault {
+	return Fault{Path: path, Status: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+// CloudflareChallenge answers requests whose path contains path with a challenge page.
+func CloudflareChallenge(path string) Fault {
+	return Fault{Path: path, Challenge: true}
+}
+
+// InjectFault registers f. Faults are checked in the order they were added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// matchFault returns the first fault for r and uses up one of its Times. Caller holds s.mu.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if f.Path != "" && !strings.Contains(r.URL.Path, f.Path) {
+			continue
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+	return nil
+}
+
+func (f Fault) serve(w http.ResponseWriter) {
+	status := f.Status
+	if f.RetryAfter != "" {
+		w.Header().Set("Retry-After", f.RetryAfter)
+	}
+	if f.Challenge {
+		if status == 0 {
+			status = http.StatusForbidden
+		}
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Header().Set("Cf-Mitigated", "challenge")
+		w.Header().Set("Cf-Ray", "8f1e2d3c4b5a6978-VIE")
+		w.Header().Set("Server", "cloudflare")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(challengePage))
+		return
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, errorBody(http.StatusText(status)))
+}
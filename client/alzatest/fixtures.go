@@ -0,0 +1,263 @@
+package alzatest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultToken is the Authorization value the server accepts out of the box.
+const DefaultToken = "Bearer alzatest-token"
+
+// JWT returns an unsigned "Bearer <jwt>" for the default user that expires at
+// expiresAt, issued 90 minutes earlier like the real identity server does.
+// Pass it to SetToken to exercise expiry-aware code such as proactive refresh.
+func JWT(expiresAt time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload := enc.EncodeToString(fmt.Appendf(nil,
+		`{"iss":"https://identity.alza.cz","sub":"%d","client_id":"alza-web","scope":"openid profile alza_api","iat":%d,"exp":%d}`,
+		DefaultUserID, expiresAt.Add(-90*time.Minute).Unix(), expiresAt.Unix()))
+	return "Bearer " + header + "." + payload + ".alzatest"
+}
+
+// Default identity of the fake user.
+const (
+	DefaultUserID   = 1234567
+	DefaultUserName = "Test Používateľ"
+	DefaultBasketID = 1538710316
+)
+
+// Product is a catalog entry served by search, whisper, detail, availability and reviews.
+type Product struct {
+	ID                 int
+	Name               string
+	Code               string
+	EAN                string  // Barcode; search finds the product by it
+	Category           string  // URL slug of its category, e.g. "sport"
+	Price              float64 // With VAT, in the storefront currency
+	Availability       string  // e.g. "Skladom > 5 ks"
+	AvailabilityDetail string  // e.g. "U vás zajtra"
+	ExpectedStockDate  string  // e.g. "2026-03-10", for products not in stock
+	RatingCount        int
+	Reviews            []Review
+	Parameters         []Parameter  // Technical parameters in page order
+	PromoPrices        []PromoPrice // Promo and coupon prices shown next to the main price
+	ComplaintRate      float64      // Share of sold units returned as faulty, e.g. 0.012; 0 = not reported
+}
+
+// Parameter is one technical parameter row of a product page.
+type Parameter struct {
+	Group  string // e.g. "Základné vlastnosti"; rows of a group are served together
+	Name   string
+	Values []string
+}
+
+// PromoPrice is a price available with a promotion or coupon.
+type PromoPrice struct {
+	Name  string
+	Price float64
+	Code  string // Coupon code, empty when the price applies automatically
+}
+
+// Review is one text review of a product.
+type Review struct {
+	Rating      int
+	Name        string
+	Description string
+	Positives   []string
+	Negatives   []string
+	Date        time.Time
+	Verified    bool
+}
+
+// Category is a catalog category. Its product listing covers Products and
+// those of its subcategories (categories with ParentID set to its ID).
+type Category struct {
+	ID        int
+	Name      string
+	Slug      string // URL segment, e.g. "kreatin" in /sport/kreatin/18862660.htm
+	ParentID  int
+	Products  []int // Product IDs listed directly in the category
+	Producers []Producer
+	Promo     []PromoSection
+}
+
+// Producer is a manufacturer listed by topProducers.
+type Producer struct {
+	ID   int
+	Name string
+}
+
+// PromoSection is a promoted group of products on a category page.
+type PromoSection struct {
+	Title    string
+	Products []int
+}
+
+// List is a commodity list (favorites, custom lists, ...).
+type List struct {
+	ID    int
+	Name  string
+	Type  int // 0 custom, 1 favorites
+	Items []ListItem
+}
+
+// ListItem is a product on a list.
+type ListItem struct {
+	ProductID int
+	Count     int
+}
+
+// Order is an active (Active=true) or archived order.
+type Order struct {
+	ID      string
+	Created time.Time
+	Status  string
+	Total   float64
+	Items   []OrderItem
+	Active  bool
+}
+
+// OrderItem is one line of an order.
+type OrderItem struct {
+	ProductID int
+	Name      string
+	Count     int
+	Status    string
+}
+
+// CartLine is one basket row.
+type CartLine struct {
+	BasketItemID int
+	ProductID    int
+	Count        int
+}
+
+// Payment records a call to the recurrent card payment endpoint.
+type Payment struct {
+	OrderID string
+	CardID  string
+}
+
+// DefaultProducts returns the catalog a new Server starts with.
+func DefaultProducts() []Product {
+	reviewDate := time.Date(2026, 2, 14, 10, 30, 0, 0, time.UTC)
+	return []Product{
+		{
+			ID:                 7816725,
+			Name:               "GymBeam Kreatín monohydrát 500 g",
+			Code:               "GYMB0105",
+			EAN:                "8586022211126",
+			Category:           "sport",
+			Price:              17.90,
+			Availability:       "Skladom > 5 ks",
+			AvailabilityDetail: "U vás zajtra",
+			RatingCount:        214,
+			Reviews: []Review{
+				{Rating: 5, Name: "Martin", Description: "Rozpúšťa sa dobre, bez chuti.", Positives: []string{"cena", "čistota"}, Date: reviewDate, Verified: true},
+				{Rating: 4, Name: "Zuzana", Description: "Obal by mohol byť lepší.", Negatives: []string{"obal"}, Date: reviewDate.AddDate(0, 0, -20)},
+			},
+		},
+		{
+			ID:                 12345678,
+			Name:               "De'Longhi Magnifica S ECAM 22.110.B kávovar",
+			Code:               "DELO0231",
+			EAN:                "8000153987069",
+			Category:           "kavovary",
+			Price:              329.00,
+			Availability:       "Skladom 2 ks",
+			AvailabilityDetail: "U vás pozajtra",
+			RatingCount:        1875,
+			Reviews: []Review{
+				{Rating: 5, Name: "Peter", Description: "Výborná káva, jednoduché čistenie.", Positives: []string{"chuť kávy"}, Date: reviewDate, Verified: true},
+			},
+		},
+		{
+			ID:                 8123456,
+			Name:               "Apple iPhone 15 Pro 128GB čierny titán",
+			Code:               "RI0461b",
+			EAN:                "0194253780465",
+			Category:           "mobily",
+			Price:              1099.00,
+			Availability:       "Na objednávku",
+			AvailabilityDetail: "Očakávame do 14 dní",
+			RatingCount:        96,
+		},
+	}
+}
+
+func defaultCategories() []*Category {
+	return []*Category{
+		{
+			ID: 18855843, Name: "Šport a outdoor", Slug: "sport",
+			Producers: []Producer{{ID: 29011, Name: "GymBeam"}, {ID: 28406, Name: "Amix Nutrition"}},
+		},
+		{
+			ID: 18862660, Name: "Kreatín", Slug: "kreatin", ParentID: 18855843, Products: []int{7816725},
+			Producers: []Producer{{ID: 29011, Name: "GymBeam"}},
+			Promo:     []PromoSection{{Title: "Najpredávanejšie", Products: []int{7816725}}},
+		},
+		{ID: 18862661, Name: "Proteíny", Slug: "proteiny", ParentID: 18855843},
+		{ID: 18890188, Name: "Mobilné telefóny", Slug: "mobily", Products: []int{8123456}},
+	}
+}
+
+func defaultLists() []*List {
+	return []*List{
+		{ID: 49098229, Name: "Obľúbené", Type: 1},
+		{ID: 49098230, Name: "AGENT", Type: 0, Items: []ListItem{{ProductID: 7816725, Count: 1}}},
+	}
+}
+
+func defaultOrders() []*Order {
+	return []*Order{
+		{
+			ID:      "1059887711",
+			Created: time.Date(2026, 1, 8, 18, 12, 0, 0, time.UTC),
+			Status:  "Vybavená",
+			Total:   35.80,
+			Items:   []OrderItem{{ProductID: 7816725, Name: "GymBeam Kreatín monohydrát 500 g", Count: 2, Status: "Doručené"}},
+		},
+		{
+			ID:      "1058001234",
+			Created: time.Date(2025, 11, 21, 9, 3, 0, 0, time.UTC),
+			Status:  "Vybavená",
+			Total:   329.00,
+			Items:   []OrderItem{{ProductID: 12345678, Name: "De'Longhi Magnifica S ECAM 22.110.B kávovar", Count: 1, Status: "Doručené"}},
+		},
+	}
+}
+
+// formatPrice renders a price the way Alza SK does, e.g. "1 099,00 €".
+func formatPrice(value float64) string {
+	s := fmt.Sprintf("%.2f", value)
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + "," + frac + " €"
+}
+
+// slug turns a product name into the URL part before "-d<id>.htm".
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
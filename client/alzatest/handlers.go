@@ -0,0 +1,881 @@
+package alzatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// vatRate is the Slovak standard VAT used to derive prices without VAT.
+const vatRate = 0.23
+
+func (s *Server) routes() {
+	// Identity, lists, favorites
+	s.handle(pattern("GET", client.EndpointCommodityLists), false, s.getLists)
+	s.handle(pattern("GET", client.EndpointCommodityListItems, "list"), true, s.getListItems)
+	s.handle(pattern("POST", client.EndpointCommodityListCreate), true, s.createListHandler)
+	s.handle(pattern("POST", client.EndpointCommodityListAddItem), true, s.addListItem)
+	s.handle(pattern("POST", client.EndpointCommodityListDelete), true, s.removeListItem)
+	s.handle(pattern("POST", client.EndpointUserCommodityListItems, "user"), true, s.addToListType)
+	s.handle(pattern("GET", client.EndpointUserStatusSummary, "user"), true, s.statusSummary)
+
+	// Basket
+	s.handle(pattern("GET", client.EndpointCartItems, "basket"), true, s.cartItems)
+	s.handle(pattern("DELETE", client.EndpointCartItems, "basket"), true, s.clearCart)
+	s.handle(pattern("GET", client.EndpointCartPreview, "basket"), true, s.cartPreview)
+	s.handle(pattern("POST", client.EndpointOrderCommodity), true, s.orderCommodity)
+	s.handle(pattern("POST", client.EndpointOrderUpdate), true, s.orderUpdate)
+
+	// Catalog
+	s.handle(pattern("POST", client.EndpointSearchService), false, s.searchV5)
+	s.handle(pattern("GET", client.EndpointWhisperAnon), false, s.whisper)
+	s.handle(pattern("GET", client.EndpointWhisperUser, "user"), true, s.whisper)
+	s.handle(pattern("GET", client.EndpointProductDetail, "id"), false, s.productDetail)
+	s.handle(pattern("GET", client.EndpointProductAvailabilityUser, "user", "id"), true, s.availability)
+	s.handle(pattern("GET", client.EndpointProductAvailabilityAnon, "id"), false, s.availability)
+	s.handle(pattern("GET", client.EndpointReviewStats, "id"), false, s.reviewStats)
+	s.handle(pattern("GET", client.EndpointReviews, "id"), false, s.reviews)
+	s.handle(pattern("GET", client.EndpointCategory, "id"), false, s.category)
+	s.handle(pattern("GET", client.EndpointCategoryTopProducers, "id"), false, s.topProducers)
+	s.handle(pattern("GET", client.EndpointCategoryPromoSections, "id"), false, s.promoSections)
+
+	// Orders
+	s.handle(pattern("GET", client.EndpointOrdersArchive, "user"), true, s.ordersArchive)
+	s.handle(pattern("GET", client.EndpointOrdersActive, "user"), true, s.ordersActive)
+
+	// Quick order and payment
+	s.handle(pattern("POST", client.EndpointFastOrderSave), true, s.fastOrderSave)
+	s.handle(pattern("POST", client.EndpointFastOrderSend), true, s.fastOrderSend)
+	s.handle(pattern("POST", client.EndpointPaymentRepeat), true, s.payment)
+}
+
+// === Lists ===
+
+func (s *Server) listJSON(l *List) map[string]any {
+	count := 0
+	for _, it := range l.Items {
+		count += it.Count
+	}
+	return map[string]any{
+		"id":        l.ID,
+		"name":      l.Name,
+		"itemCount": count,
+		"type":      l.Type,
+		"canModify": l.Type == 0,
+	}
+}
+
+func (s *Server) getLists(r *http.Request, _ []byte) (int, any) {
+	if !s.authorized(r) {
+		// The live API answers anonymous callers with user_id -1, not 401
+		return http.StatusOK, map[string]any{"data_cnt": 0, "data": []any{}, "user_id": -1, "user_name": ""}
+	}
+	data := make([]map[string]any, 0, len(s.lists))
+	for _, l := range s.lists {
+		data = append(data, s.listJSON(l))
+	}
+	return http.StatusOK, map[string]any{
+		"data_cnt":   len(data),
+		"data":       data,
+		"user_id":    s.userID,
+		"user_name":  s.userName,
+		"basket_cnt": s.cartCount(),
+	}
+}
+
+func (s *Server) getListItems(r *http.Request, _ []byte) (int, any) {
+	id, _ := strconv.Atoi(r.PathValue("list"))
+	l := s.findList(id)
+	if l == nil {
+		return http.StatusOK, map[string]any{"data": []any{}}
+	}
+	items := make([]map[string]any, 0, len(l.Items))
+	for _, it := range l.Items {
+		entry := map[string]any{
+			"navigationUrl": s.productURL(it.ProductID),
+			"count":         it.Count,
+		}
+		if p := s.findProduct(it.ProductID); p != nil {
+			entry["priceInfoV2"] = map[string]any{"priceWithVat": formatPrice(p.Price)}
+		}
+		items = append(items, entry)
+	}
+	entry := s.listJSON(l)
+	entry["items"] = items
+	return http.StatusOK, map[string]any{"data": []any{entry}}
+}
+
+func (s *Server) createListHandler(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || strings.TrimSpace(req.Name) == "" {
+		return http.StatusBadRequest, errorBody("invalid list")
+	}
+	l := s.createList(req.Name, req.Type)
+	return http.StatusOK, map[string]any{"data_cnt": 1, "data": []any{s.listJSON(l)}, "user_id": s.userID}
+}
+
+func (s *Server) addListItem(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		ListID int `json:"listID"`
+		CID    int `json:"cId"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	l := s.findList(req.ListID)
+	if l == nil {
+		return http.StatusNotFound, errorBody(fmt.Sprintf("list %d not found", req.ListID))
+	}
+	addListItem(l, req.CID, 1)
+	return http.StatusOK, map[string]any{"d": map[string]any{"IsSuccess": true}}
+}
+
+func (s *Server) removeListItem(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		ID        int `json:"id"`
+		ProductID int `json:"productId"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	l := s.findList(req.ID)
+	if l == nil {
+		return http.StatusNotFound, errorBody(fmt.Sprintf("list %d not found", req.ID))
+	}
+	l.Items = slices.DeleteFunc(l.Items, func(it ListItem) bool { return it.ProductID == req.ProductID })
+	return http.StatusOK, map[string]any{"IsSuccess": true}
+}
+
+func (s *Server) addToListType(r *http.Request, body []byte) (int, any) {
+	if !s.isUser(r) {
+		return http.StatusForbidden, errorBody("user mismatch")
+	}
+	var req struct {
+		Items    map[string]int `json:"items"`
+		ListType int            `json:"listType"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	var target *List
+	for _, l := range s.lists {
+		if l.Type == req.ListType {
+			target = l
+			break
+		}
+	}
+	if target == nil {
+		return http.StatusOK, map[string]any{"IsSuccess": false, "ErrorMessage": "list type not found"}
+	}
+	for id, count := range req.Items {
+		productID, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		addListItem(target, productID, count)
+	}
+	return http.StatusOK, map[string]any{"IsSuccess": true, "ErrorMessage": ""}
+}
+
+func (s *Server) createList(name string, listType int) *List {
+	l := &List{ID: s.nextListID, Name: name, Type: listType}
+	s.nextListID++
+	s.lists = append(s.lists, l)
+	return l
+}
+
+func (s *Server) findList(id int) *List {
+	for _, l := range s.lists {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+func addListItem(l *List, productID, count int) {
+	if count <= 0 {
+		count = 1
+	}
+	for i := range l.Items {
+		if l.Items[i].ProductID == productID {
+			l.Items[i].Count += count
+			return
+		}
+	}
+	l.Items = append(l.Items, ListItem{ProductID: productID, Count: count})
+}
+
+// === Identity & basket ===
+
+func (s *Server) isUser(r *http.Request) bool {
+	return r.PathValue("user") == strconv.Itoa(s.userID)
+}
+
+func (s *Server) isBasket(r *http.Request) bool {
+	return r.PathValue("basket") == strconv.Itoa(s.basketID)
+}
+
+func (s *Server) cartCount() int {
+	n := 0
+	for _, l := range s.cart {
+		n += l.Count
+	}
+	return n
+}
+
+func (s *Server) statusSummary(r *http.Request, _ []byte) (int, any) {
+	if !s.isUser(r) {
+		return http.StatusForbidden, errorBody("user mismatch")
+	}
+	active := 0
+	for _, o := range s.orders {
+		if o.Active {
+			active++
+		}
+	}
+	resp := map[string]any{
+		"notificationsCount":  0,
+		"basketProductsCount": s.cartCount(),
+		"ordersStatusInfo": map[string]int{
+			"activeOrdersCount":   active,
+			"waitingOrdersCount":  0,
+			"overdueOrdersCount":  0,
+			"inactiveOrdersCount": len(s.orders) - active,
+		},
+		"isAlzaPlus": s.alzaPlus,
+	}
+	// Like the live API, an empty basket has no preview action
+	if len(s.cart) > 0 {
+		resp["basketPreviewAction"] = map[string]string{
+			"href": fmt.Sprintf("%s/api/basket/%d/preview", s.URL, s.basketID),
+		}
+	}
+	return http.StatusOK, resp
+}
+
+func (s *Server) cartItems(r *http.Request, _ []byte) (int, any) {
+	if !s.isBasket(r) {
+		return http.StatusNotFound, errorBody("basket not found")
+	}
+	items := make([]map[string]int, 0, len(s.cart))
+	for _, l := range s.cart {
+		items = append(items, map[string]int{
+			"productId":    l.ProductID,
+			"count":        l.Count,
+			"basketItemId": l.BasketItemID,
+		})
+	}
+	return http.StatusOK, map[string]any{"items": items}
+}
+
+func (s *Server) clearCart(r *http.Request, _ []byte) (int, any) {
+	if !s.isBasket(r) {
+		return http.StatusNotFound, errorBody("basket not found")
+	}
+	s.cart = nil
+	return http.StatusOK, map[string]any{"items": []any{}}
+}
+
+func (s *Server) cartPreview(r *http.Request, _ []byte) (int, any) {
+	if !s.isBasket(r) {
+		return http.StatusNotFound, errorBody("basket not found")
+	}
+	items := make([]map[string]any, 0, len(s.cart))
+	for _, l := range s.cart {
+		p := s.findProduct(l.ProductID)
+		if p == nil {
+			continue
+		}
+		items = append(items, map[string]any{
+			"count":        l.Count,
+			"name":         p.Name,
+			"imageUrl":     s.imageURL(p.ID),
+			"price":        formatPrice(p.Price * float64(l.Count)),
+			"detailAction": map[string]string{"webLink": s.productURL(p.ID)},
+		})
+	}
+	return http.StatusOK, map[string]any{"items": items}
+}
+
+func (s *Server) orderCommodity(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		ID    int `json:"id"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	if s.findProduct(req.ID) == nil {
+		return http.StatusNotFound, errorBody(fmt.Sprintf("product %d not found", req.ID))
+	}
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+	for _, l := range s.cart {
+		if l.ProductID == req.ID {
+			l.Count += req.Count
+			return http.StatusOK, map[string]any{"d": map[string]any{"BasketItemId": l.BasketItemID}}
+		}
+	}
+	line := &CartLine{BasketItemID: s.nextBasketItemID, ProductID: req.ID, Count: req.Count}
+	s.nextBasketItemID++
+	s.cart = append(s.cart, line)
+	return http.StatusOK, map[string]any{"d": map[string]any{"BasketItemId": line.BasketItemID}}
+}
+
+func (s *Server) orderUpdate(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		ID    json.Number `json:"id"` // Sent as a quoted string by the client
+		Count int         `json:"count"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	basketItemID, err := strconv.Atoi(req.ID.String())
+	if err != nil {
+		return http.StatusBadRequest, errorBody("invalid basket item id")
+	}
+	for i, l := range s.cart {
+		if l.BasketItemID != basketItemID {
+			continue
+		}
+		if req.Count <= 0 {
+			s.cart = append(s.cart[:i], s.cart[i+1:]...)
+		} else {
+			l.Count = req.Count
+		}
+		return http.StatusOK, map[string]any{"d": map[string]any{"IsSuccess": true}}
+	}
+	return http.StatusNotFound, errorBody(fmt.Sprintf("basket item %d not found", basketItemID))
+}
+
+// === Catalog ===
+
+func (s *Server) findProduct(id int) *Product {
+	for _, p := range s.products {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) productURL(id int) string {
+	name := "produkt"
+	if p := s.findProduct(id); p != nil {
+		name = slug(p.Name)
+		if p.Category != "" {
+			name = p.Category + "/" + name
+		}
+	}
+	return fmt.Sprintf("%s/%s-d%d.htm", s.URL, name, id)
+}
+
+func (s *Server) imageURL(id int) string {
+	return fmt.Sprintf("%s/images/%d.jpg", s.URL, id)
+}
+
+func (s *Server) search(term string) []*Product {
+	term = strings.ToLower(strings.TrimSpace(term))
+	var out []*Product
+	for _, p := range s.products {
+		if term != "" && (strings.Contains(strings.ToLower(p.Name), term) || strings.EqualFold(p.Code, term) || (p.EAN != "" && p.EAN == term)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (s *Server) searchV5(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		SearchTerm string `json:"searchTerm"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	items := []map[string]any{}
+	for _, p := range s.search(req.SearchTerm) {
+		items = append(items, s.commodityJSON(p))
+	}
+	return http.StatusOK, map[string]any{"total": len(items), "data2": items}
+}
+
+// commodityJSON is a product as v5 search and category listings show it
+func (s *Server) commodityJSON(p *Product) map[string]any {
+	return map[string]any{
+		"id":              p.ID,
+		"name":            p.Name,
+		"code":            p.Code,
+		"price":           formatPrice(p.Price),
+		"priceNoCurrency": p.Price,
+		"avail":           p.Availability,
+		"img":             s.imageURL(p.ID),
+		"url":             s.productURL(p.ID),
+	}
+}
+
+func (s *Server) whisper(r *http.Request, _ []byte) (int, any) {
+	items := []map[string]any{}
+	for _, p := range s.search(r.URL.Query().Get("searchTerm")) {
+		items = append(items, map[string]any{
+			"imageUrl": s.imageURL(p.ID),
+			"clickAction": map[string]string{
+				"name":    p.Name,
+				"webLink": s.productURL(p.ID),
+				"href":    s.productURL(p.ID),
+			},
+		})
+	}
+	return http.StatusOK, map[string]any{"commodities": items}
+}
+
+func (s *Server) productFromPath(r *http.Request) *Product {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil
+	}
+	return s.findProduct(id)
+}
+
+func (s *Server) productDetail(r *http.Request, _ []byte) (int, any) {
+	p := s.productFromPath(r)
+	if p == nil {
+		return http.StatusNotFound, errorBody("product not found")
+	}
+	withoutVat := math.Round(p.Price/(1+vatRate)*100) / 100
+	promos := []map[string]any{}
+	for _, promo := range p.PromoPrices {
+		promos = append(promos, map[string]any{
+			"name":               promo.Name,
+			"formattedPrice":     formatPrice(promo.Price),
+			"unformattedPrice":   promo.Price,
+			"discountCouponCode": promo.Code,
+		})
+	}
+	return http.StatusOK, map[string]any{"data": map[string]any{
+		"name":    p.Name,
+		"price":   formatPrice(p.Price),
+		"gaPrice": p.Price,
+		"priceInfoV2": map[string]any{
+			"priceWithVat":    formatPrice(p.Price),
+			"priceWithoutVat": formatPrice(withoutVat),
+			"priceNoCurrency": p.Price,
+			"promoPrices":     promos,
+		},
+		"parameterGroups": parameterGroupsJSON(p.Parameters),
+	}}
+}
+
+// parameterGroupsJSON groups parameters by Group, in order of first appearance
+func parameterGroupsJSON(params []Parameter) []map[string]any {
+	groups := []map[string]any{}
+	index := map[string]int{}
+	for _, param := range params {
+		i, ok := index[param.Group]
+		if !ok {
+			i = len(groups)
+			index[param.Group] = i
+			groups = append(groups, map[string]any{"name": param.Group, "params": []map[string]any{}})
+		}
+		values := []map[string]string{}
+		for _, v := range param.Values {
+			values = append(values, map[string]string{"desc": v})
+		}
+		groups[i]["params"] = append(groups[i]["params"].([]map[string]any), map[string]any{"name": param.Name, "values": values})
+	}
+	return groups
+}
+
+func (s *Server) availability(r *http.Request, _ []byte) (int, any) {
+	if r.PathValue("user") != "" && !s.isUser(r) {
+		return http.StatusForbidden, errorBody("user mismatch")
+	}
+	p := s.productFromPath(r)
+	if p == nil {
+		return http.StatusNotFound, errorBody("product not found")
+	}
+	return http.StatusOK, map[string]string{
+		"title":             p.Availability,
+		"description":       p.AvailabilityDetail,
+		"expectedStockDate": p.ExpectedStockDate,
+	}
+}
+
+func (s *Server) reviewStats(r *http.Request, _ []byte) (int, any) {
+	p := s.productFromPath(r)
+	if p == nil {
+		return http.StatusNotFound, errorBody("product not found")
+	}
+	counts := map[int]int{}
+	sum, recommended := 0, 0
+	for _, rv := range p.Reviews {
+		counts[rv.Rating]++
+		sum += rv.Rating
+		if rv.Rating >= 4 {
+			recommended++
+		}
+	}
+	ratings := []map[string]int{}
+	for star := 5; star >= 1; star-- {
+		ratings = append(ratings, map[string]int{"value": star, "count": counts[star]})
+	}
+	avg, rate := 0.0, 0.0
+	if len(p.Reviews) > 0 {
+		avg = math.Round(float64(sum)/float64(len(p.Reviews))*10) / 10
+		rate = float64(recommended) / float64(len(p.Reviews))
+	}
+	ratingCount := p.RatingCount
+	if ratingCount < len(p.Reviews) {
+		ratingCount = len(p.Reviews)
+	}
+	stats := map[string]any{
+		"ratingAverage":      avg,
+		"ratingCount":        ratingCount,
+		"reviewCount":        len(p.Reviews),
+		"recommendationRate": rate,
+		"ratings":            ratings,
+	}
+	if p.ComplaintRate > 0 {
+		stats["complaint"] = map[string]any{
+			"description": "Reklamovanosť",
+			"rate":        p.ComplaintRate,
+			"tooltip":     "Podiel predaných kusov reklamovaných za posledný rok",
+			"type":        1,
+		}
+	}
+	return http.StatusOK, stats
+}
+
+func (s *Server) reviews(r *http.Request, _ []byte) (int, any) {
+	p := s.productFromPath(r)
+	if p == nil {
+		return http.StatusNotFound, errorBody("product not found")
+	}
+	offset, limit := pageParams(r, 10)
+	page := []map[string]any{}
+	for i := offset; i < len(p.Reviews) && i < offset+limit; i++ {
+		rv := p.Reviews[i]
+		item := map[string]any{
+			"rating":      rv.Rating,
+			"name":        rv.Name,
+			"description": rv.Description,
+			"positives":   rv.Positives,
+			"negatives":   rv.Negatives,
+			"reviewDate":  rv.Date.Format(time.RFC3339),
+			"likeCount":   0,
+		}
+		if rv.Verified {
+			item["verifiedPurchaseTag"] = map[string]string{"label": "Overený nákup"}
+		}
+		page = append(page, item)
+	}
+	return http.StatusOK, map[string]any{
+		"paging": map[string]int{"size": len(p.Reviews)},
+		"value":  page,
+	}
+}
+
+func pageParams(r *http.Request, defaultLimit int) (offset, limit int) {
+	offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	return max(offset, 0), limit
+}
+
+func (s *Server) findCategory(id int) *Category {
+	for _, c := range s.categories {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) categoryFromPath(r *http.Request) *Category {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil
+	}
+	return s.findCategory(id)
+}
+
+// categoryURL nests the slugs of c's ancestors like the live site:
+// /sport/kreatin/18862660.htm
+func (s *Server) categoryURL(c *Category) string {
+	path := fmt.Sprintf("%s/%d.htm", c.Slug, c.ID)
+	for parent := s.findCategory(c.ParentID); parent != nil; parent = s.findCategory(parent.ParentID) {
+		path = parent.Slug + "/" + path
+	}
+	return s.URL + "/" + path
+}
+
+// categoryProducts lists the products of c and its subcategories, each once
+func (s *Server) categoryProducts(c *Category) []*Product {
+	var out []*Product
+	seen := map[int]bool{}
+	var walk func(c *Category)
+	walk = func(c *Category) {
+		for _, id := range c.Products {
+			if p := s.findProduct(id); p != nil && !seen[id] {
+				seen[id] = true
+				out = append(out, p)
+			}
+		}
+		for _, child := range s.categories {
+			if child.ParentID == c.ID {
+				walk(child)
+			}
+		}
+	}
+	walk(c)
+	return out
+}
+
+func (s *Server) categoryJSON(c *Category) map[string]any {
+	return map[string]any{
+		"id":           c.ID,
+		"name":         c.Name,
+		"url":          s.categoryURL(c),
+		"parentId":     c.ParentID,
+		"productCount": len(s.categoryProducts(c)),
+	}
+}
+
+// The category handlers serve the shapes client/category.go decodes, which no
+// captured response has confirmed yet (docs/API-SPEC.md section 6), so they
+// check the client against its own assumptions rather than against Alza.
+func (s *Server) category(r *http.Request, _ []byte) (int, any) {
+	c := s.categoryFromPath(r)
+	if c == nil {
+		return http.StatusNotFound, errorBody("category not found")
+	}
+	data := s.categoryJSON(c)
+	children := []map[string]any{}
+	for _, child := range s.categories {
+		if child.ParentID == c.ID {
+			children = append(children, s.categoryJSON(child))
+		}
+	}
+	data["categories"] = children
+	products := s.categoryProducts(c)
+	offset, limit := pageParams(r, 24)
+	items := []map[string]any{}
+	for i := offset; i < len(products) && i < offset+limit; i++ {
+		items = append(items, s.commodityJSON(products[i]))
+	}
+	data["commodities"] = items
+	return http.StatusOK, map[string]any{"data": data}
+}
+
+func (s *Server) topProducers(r *http.Request, _ []byte) (int, any) {
+	c := s.categoryFromPath(r)
+	if c == nil {
+		return http.StatusNotFound, errorBody("category not found")
+	}
+	items := []map[string]any{}
+	for _, p := range c.Producers {
+		items = append(items, map[string]any{
+			"id":          p.ID,
+			"name":        p.Name,
+			"imageUrl":    fmt.Sprintf("%s/images/producers/%d.png", s.URL, p.ID),
+			"clickAction": map[string]string{"webLink": fmt.Sprintf("%s/%s?producer=%d", s.URL, c.Slug, p.ID)},
+		})
+	}
+	return http.StatusOK, map[string]any{"items": items}
+}
+
+func (s *Server) promoSections(r *http.Request, _ []byte) (int, any) {
+	c := s.categoryFromPath(r)
+	if c == nil {
+		return http.StatusNotFound, errorBody("category not found")
+	}
+	items := []map[string]any{}
+	for _, section := range c.Promo {
+		commodities := []map[string]any{}
+		for _, id := range section.Products {
+			if p := s.findProduct(id); p != nil {
+				commodities = append(commodities, s.commodityJSON(p))
+			}
+		}
+		items = append(items, map[string]any{"title": section.Title, "commodities": commodities})
+	}
+	return http.StatusOK, map[string]any{"items": items}
+}
+
+// === Orders ===
+
+func orderItemsJSON(items []OrderItem) []map[string]any {
+	out := make([]map[string]any, 0, len(items))
+	for _, it := range items {
+		out = append(out, map[string]any{
+			"commodityId":   it.ProductID,
+			"commodityName": it.Name,
+			"count":         it.Count,
+			"status":        it.Status,
+		})
+	}
+	return out
+}
+
+func (s *Server) ordersArchive(r *http.Request, _ []byte) (int, any) {
+	if !s.isUser(r) {
+		return http.StatusForbidden, errorBody("user mismatch")
+	}
+	var archive []*Order
+	for _, o := range s.orders {
+		if !o.Active {
+			archive = append(archive, o)
+		}
+	}
+	sort.SliceStable(archive, func(i, j int) bool { return archive[i].Created.After(archive[j].Created) })
+
+	offset, limit := pageParams(r, 10)
+	page := []map[string]any{}
+	for i := offset; i < len(archive) && i < offset+limit; i++ {
+		o := archive[i]
+		page = append(page, map[string]any{
+			"orderId":    o.ID,
+			"created":    o.Created.Format(time.RFC3339),
+			"state":      o.Status,
+			"totalPrice": formatPrice(o.Total),
+			"items":      orderItemsJSON(o.Items),
+		})
+	}
+	return http.StatusOK, map[string]any{
+		"paging": map[string]int{"size": len(archive)},
+		"value":  page,
+	}
+}
+
+func (s *Server) ordersActive(r *http.Request, _ []byte) (int, any) {
+	if !s.isUser(r) {
+		return http.StatusForbidden, errorBody("user mismatch")
+	}
+	orders := []map[string]any{}
+	for _, o := range s.orders {
+		if !o.Active {
+			continue
+		}
+		orders = append(orders, map[string]any{
+			"orderId": o.ID,
+			"created": o.Created.Format(time.RFC3339),
+			"parts": []map[string]string{{
+				"status":     o.Status,
+				"totalPrice": formatPrice(o.Total),
+			}},
+		})
+	}
+	groups := []map[string]any{}
+	if len(orders) > 0 {
+		groups = append(groups, map[string]any{"orders": orders})
+	}
+	return http.StatusOK, map[string]any{"groups": groups}
+}
+
+// === Quick order ===
+
+type fastOrderBody struct {
+	Options struct {
+		Items []struct {
+			CommodityID int `json:"CommodityId"`
+			Count       int `json:"Count"`
+		} `json:"Items"`
+		PromoCodes []string `json:"PromoCodes"`
+	} `json:"options"`
+}
+
+// quote prices a fast order; a non-empty message is returned as the API's ErrorMessage.
+func (s *Server) quote(body []byte) (float64, []OrderItem, string) {
+	var req fastOrderBody
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 0, nil, "Neplatná požiadavka"
+	}
+	if len(req.Options.Items) == 0 {
+		return 0, nil, "Košík je prázdny"
+	}
+	total := 0.0
+	var items []OrderItem
+	for _, it := range req.Options.Items {
+		p := s.findProduct(it.CommodityID)
+		if p == nil {
+			return 0, nil, fmt.Sprintf("Tovar %d nie je možné objednať", it.CommodityID)
+		}
+		count := max(it.Count, 1)
+		total += p.Price * float64(count)
+		items = append(items, OrderItem{ProductID: p.ID, Name: p.Name, Count: count, Status: "Spracováva sa"})
+	}
+	for _, code := range req.Options.PromoCodes {
+		percent, ok := s.coupons[strings.ToUpper(code)]
+		if !ok {
+			return 0, nil, fmt.Sprintf("Kupón %s nie je platný", code)
+		}
+		total -= total * float64(percent) / 100
+	}
+	return math.Round(total*100) / 100, items, ""
+}
+
+func (s *Server) fastOrderSave(_ *http.Request, body []byte) (int, any) {
+	total, _, msg := s.quote(body)
+	if msg != "" {
+		return http.StatusOK, map[string]any{"d": map[string]any{"ErrorMessage": msg}}
+	}
+	return http.StatusOK, map[string]any{"d": map[string]any{
+		"TotalPrice":          total,
+		"AfterOrderPaymentId": 216,
+		"ErrorMessage":        "",
+	}}
+}
+
+func (s *Server) fastOrderSend(_ *http.Request, body []byte) (int, any) {
+	total, items, msg := s.quote(body)
+	if msg != "" {
+		return http.StatusOK, map[string]any{"d": map[string]any{"ErrorMessage": msg}}
+	}
+	order := &Order{
+		ID:      strconv.Itoa(s.nextOrderID),
+		Created: time.Now().UTC(),
+		Status:  "Čaká na platbu",
+		Total:   total,
+		Items:   items,
+		Active:  true,
+	}
+	s.nextOrderID++
+	s.orders = append(s.orders, order)
+	return http.StatusOK, map[string]any{"d": map[string]any{
+		"Code":                order.ID,
+		"AfterOrderPaymentId": 216,
+		"ErrorMessage":        "",
+	}}
+}
+
+func (s *Server) payment(_ *http.Request, body []byte) (int, any) {
+	var req struct {
+		CardID  string `json:"cardId"`
+		OrderID string `json:"orderId"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, errorBody("invalid request")
+	}
+	for _, o := range s.orders {
+		if o.ID == req.OrderID {
+			o.Status = "Zaplatená"
+			s.payments = append(s.payments, Payment{OrderID: req.OrderID, CardID: req.CardID})
+			return http.StatusOK, map[string]any{"resultCode": "Authorised"}
+		}
+	}
+	return http.StatusNotFound, errorBody("order not found")
+}
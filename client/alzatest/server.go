@@ -0,0 +1,307 @@
+// Package alzatest provides an in-process fake of the Alza endpoints used by
+// package client, for offline integration tests.
+//
+// The server keeps cart, list and order state in memory, serves realistic JSON
+// shaped like the live API, and can inject faults (401, 429, Cloudflare
+// challenge pages). Point a client at it with Server.ClientOptions:
+//
+//	srv := alzatest.NewServer()
+//	defer srv.Close()
+//	cl, err := client.New(ctx, srv.ClientOptions()...)
+package alzatest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// Request is a request the server received, for assertions.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// Server is a stateful fake Alza backend serving both the e-shop origin and webapi.
+type Server struct {
+	*httptest.Server
+
+	mux *http.ServeMux
+
+	mu               sync.Mutex
+	token            string
+	userID           int
+	userName         string
+	basketID         int
+	alzaPlus         bool
+	products         []*Product
+	categories       []*Category
+	cart             []*CartLine
+	nextBasketItemID int
+	lists            []*List
+	nextListID       int
+	orders           []*Order
+	nextOrderID      int
+	payments         []Payment
+	coupons          map[string]int
+	faults           []*Fault
+	requests         []Request
+}
+
+// NewServer starts a server with the default user, catalog, lists and order history.
+func NewServer() *Server {
+	s := &Server{
+		mux:              http.NewServeMux(),
+		token:            DefaultToken,
+		userID:           DefaultUserID,
+		userName:         DefaultUserName,
+		basketID:         DefaultBasketID,
+		nextBasketItemID: 9001,
+		categories:       defaultCategories(),
+		lists:            defaultLists(),
+		nextListID:       49098300,
+		orders:           defaultOrders(),
+		nextOrderID:      1060000001,
+		coupons:          map[string]int{},
+	}
+	for _, p := range DefaultProducts() {
+		s.products = append(s.products, &p)
+	}
+	s.routes()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ClientOptions points a client at the server: both origins, the accepted
+// token and no rate limiting.
+func (s *Server) ClientOptions() []client.Option {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+	return []client.Option{
+		client.WithBaseURL(s.URL),
+		client.WithWebAPIURL(s.URL),
+		client.WithToken(token),
+		client.WithRateLimit(client.RateLimit{}),
+	}
+}
+
+// NewClient is client.New with ClientOptions followed by opts.
+func (s *Server) NewClient(ctx context.Context, opts ...client.Option) (*client.TLSClient, error) {
+	return client.New(ctx, append(s.ClientOptions(), opts...)...)
+}
+
+// SetToken changes the accepted Authorization value; "" accepts any.
+// Clients holding the old token then look logged out (user_id -1, 401s),
+// which is how an expired token behaves on the live site.
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetAlzaPlus toggles the AlzaPlus+ membership flag in statusSummary.
+func (s *Server) SetAlzaPlus(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alzaPlus = on
+}
+
+// AddProduct adds or replaces a catalog entry.
+func (s *Server) AddProduct(p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.products {
+		if existing.ID == p.ID {
+			s.products[i] = &p
+			return
+		}
+	}
+	s.products = append(s.products, &p)
+}
+
+// AddCategory adds or replaces a catalog category.
+func (s *Server) AddCategory(c Category) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.categories {
+		if existing.ID == c.ID {
+			s.categories[i] = &c
+			return
+		}
+	}
+	s.categories = append(s.categories, &c)
+}
+
+// AddList creates a commodity list and returns it.
+func (s *Server) AddList(name string, listType int) List {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.createList(name, listType)
+}
+
+// AddOrder appends an order to the history (or the active orders if o.Active).
+func (s *Server) AddOrder(o Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, &o)
+}
+
+// SetOrderStatus changes the status of order id and whether it is active;
+// false if there is no such order.
+func (s *Server) SetOrderStatus(id, status string, active bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.orders {
+		if o.ID == id {
+			o.Status, o.Active = status, active
+			return true
+		}
+	}
+	return false
+}
+
+// AddCoupon makes FastOrderSave accept code with the given discount.
+func (s *Server) AddCoupon(code string, percentOff int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coupons[strings.ToUpper(code)] = percentOff
+}
+
+// Cart returns a copy of the basket.
+func (s *Server) Cart() []CartLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CartLine, 0, len(s.cart))
+	for _, l := range s.cart {
+		out = append(out, *l)
+	}
+	return out
+}
+
+// Lists returns a copy of all commodity lists.
+func (s *Server) Lists() []List {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]List, 0, len(s.lists))
+	for _, l := range s.lists {
+		c := *l
+		c.Items = slices.Clone(l.Items)
+		out = append(out, c)
+	}
+	return out
+}
+
+// Orders returns a copy of all orders, active and archived.
+func (s *Server) Orders() []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Order, 0, len(s.orders))
+	for _, o := range s.orders {
+		c := *o
+		c.Items = slices.Clone(o.Items)
+		out = append(out, c)
+	}
+	return out
+}
+
+// Payments returns the card payments made through the quick order flow.
+func (s *Server) Payments() []Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.payments)
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// ResetRequests clears the request log.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Body:   string(body),
+	})
+	fault := s.matchFault(r)
+	s.mu.Unlock()
+
+	if fault != nil {
+		fault.serve(w)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// handlerFunc runs with s.mu held and returns the status and JSON body.
+type handlerFunc func(r *http.Request, body []byte) (int, any)
+
+func (s *Server) handle(pattern string, auth bool, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if auth && !s.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthorized"})
+			return
+		}
+		status, v := h(r, body)
+		writeJSON(w, status, v)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	return s.token == "" || r.Header.Get("Authorization") == s.token
+}
+
+// pattern turns a client endpoint into a ServeMux pattern, naming its
+// path verbs in order: pattern("GET", "/api/users/%s/v1/orders/active", "user").
+func pattern(method, endpoint string, names ...string) string {
+	path := strings.TrimPrefix(endpoint, client.WebAPIURL)
+	path, _, _ = strings.Cut(path, "?")
+	for _, name := range names {
+		i := strings.IndexByte(path, '%')
+		if i < 0 {
+			panic("alzatest: endpoint " + endpoint + " has fewer verbs than names")
+		}
+		path = path[:i] + "{" + name + "}" + path[i+2:]
+	}
+	return method + " " + path
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func errorBody(msg string) map[string]string {
+	return map[string]string{"message": msg}
+}
@@ -0,0 +1,594 @@
+package alzatest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+)
+
+func newClient(t *testing.T, srv *alzatest.Server, opts ...client.Option) *client.TLSClient {
+	t.Helper()
+	c, err := srv.NewClient(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	return c
+}
+
+func startServer(t *testing.T) *alzatest.Server {
+	t.Helper()
+	srv := alzatest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewClientValidatesAgainstServer(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	status, err := c.GetUserStatus()
+	if err != nil {
+		t.Fatalf("GetUserStatus() error: %v", err)
+	}
+	if status.UserID != alzatest.DefaultUserID || status.UserName != alzatest.DefaultUserName {
+		t.Errorf("status = %+v, want default user", status)
+	}
+	if status.BasketID != 0 {
+		t.Errorf("BasketID = %d, want 0 for an empty cart", status.BasketID)
+	}
+}
+
+func TestCartLifecycle(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	if err := c.AddToCart(7816725, 2); err != nil {
+		t.Fatalf("AddToCart() error: %v", err)
+	}
+	if err := c.AddToCart(12345678, 1); err != nil {
+		t.Fatalf("AddToCart() error: %v", err)
+	}
+
+	items, err := c.GetCart()
+	if err != nil {
+		t.Fatalf("GetCart() error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("GetCart() = %d items, want 2", len(items))
+	}
+	if items[0].ProductID != 7816725 || items[0].Count != 2 || items[0].Price != "35,80 €" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[0].BasketItemID == 0 {
+		t.Error("items[0].BasketItemID = 0, want id merged from cart items")
+	}
+
+	if err := c.RemoveFromCart(7816725); err != nil {
+		t.Fatalf("RemoveFromCart() error: %v", err)
+	}
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].ProductID != 12345678 {
+		t.Errorf("Cart() after remove = %+v", cart)
+	}
+
+	if err := c.ClearCart(); err != nil {
+		t.Fatalf("ClearCart() error: %v", err)
+	}
+	if cart := srv.Cart(); len(cart) != 0 {
+		t.Errorf("Cart() after clear = %+v, want empty", cart)
+	}
+}
+
+func TestAddToCartUnknownProduct(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	err := c.AddToCart(1, 1)
+	var httpErr *client.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != 404 {
+		t.Fatalf("AddToCart(unknown) error = %v, want HTTP 404", err)
+	}
+}
+
+func TestListsAndFavorites(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	created, err := c.CreateList("Darčeky")
+	if err != nil {
+		t.Fatalf("CreateList() error: %v", err)
+	}
+	if err := c.AddToList(created.ID, 12345678); err != nil {
+		t.Fatalf("AddToList() error: %v", err)
+	}
+	items, err := c.GetListItems(created.ID)
+	if err != nil {
+		t.Fatalf("GetListItems() error: %v", err)
+	}
+	if len(items) != 1 || !strings.HasSuffix(items[0].NavigationURL, "-d12345678.htm") || items[0].Price != "329,00 €" {
+		t.Fatalf("GetListItems() = %+v", items)
+	}
+
+	if err := c.AddToFavorites(8123456); err != nil {
+		t.Fatalf("AddToFavorites() error: %v", err)
+	}
+	if err := c.RemoveFromList(created.ID, 12345678); err != nil {
+		t.Fatalf("RemoveFromList() error: %v", err)
+	}
+
+	lists, err := c.GetLists()
+	if err != nil {
+		t.Fatalf("GetLists() error: %v", err)
+	}
+	counts := map[string]int{}
+	for _, l := range lists {
+		counts[l.Name] = l.ItemCount
+	}
+	want := map[string]int{"Obľúbené": 1, "AGENT": 1, "Darčeky": 0}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("list %q itemCount = %d, want %d (all: %v)", name, counts[name], n, counts)
+		}
+	}
+}
+
+func TestGetListItemsUnknownList(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	if _, err := c.GetListItems(1); err == nil || !strings.Contains(err.Error(), "list not found") {
+		t.Fatalf("GetListItems(unknown) error = %v, want list not found", err)
+	}
+}
+
+func TestSearchProductAndReviews(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	results, err := c.Search("kreatín", 5)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != 7816725 || results[0].Price != 17.90 {
+		t.Fatalf("Search() = %+v", results)
+	}
+
+	detail, err := c.GetProduct(12345678)
+	if err != nil {
+		t.Fatalf("GetProduct() error: %v", err)
+	}
+	if detail.Price != "329,00 €" || detail.PriceWithoutVat != "267,48 €" || detail.Availability != "Skladom 2 ks" {
+		t.Errorf("GetProduct() = %+v", detail)
+	}
+	if detail.ReviewStats == nil || detail.ReviewStats.ReviewCount != 1 || detail.ReviewStats.RatingCount != 1875 {
+		t.Errorf("ReviewStats = %+v", detail.ReviewStats)
+	}
+
+	reviews, err := c.GetReviews(7816725, 1, 10)
+	if err != nil {
+		t.Fatalf("GetReviews() error: %v", err)
+	}
+	if reviews.TotalCount != 2 || len(reviews.Reviews) != 1 || reviews.Reviews[0].Name != "Zuzana" || reviews.Reviews[0].IsVerified {
+		t.Errorf("GetReviews(offset 1) = %+v", reviews)
+	}
+}
+
+func TestGetProducts(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+	srv.ResetRequests()
+
+	ids := []int{12345678, 404, 7816725, 8123456}
+	results := c.GetProducts(ids, client.ProductOptions{Availability: true, Workers: 2})
+	if len(results) != len(ids) {
+		t.Fatalf("GetProducts() returned %d results, want %d", len(results), len(ids))
+	}
+	for i, r := range results {
+		if r.ID != ids[i] {
+			t.Errorf("results[%d].ID = %d, want %d (input order)", i, r.ID, ids[i])
+		}
+	}
+	if r := results[1]; r.Err == nil || r.Product != nil {
+		t.Errorf("unknown product = %+v, want an error", r)
+	}
+	for _, i := range []int{0, 2, 3} {
+		r := results[i]
+		if r.Err != nil || r.Product == nil || r.Product.Availability == "" {
+			t.Fatalf("results[%d] = %+v, want a product with availability", i, r)
+		}
+		if r.Product.ReviewStats != nil {
+			t.Errorf("results[%d].ReviewStats = %+v, reviews were not requested", i, r.Product.ReviewStats)
+		}
+	}
+	if results[3].Product.Availability != "Na objednávku" {
+		t.Errorf("iPhone availability = %q", results[3].Product.Availability)
+	}
+	for _, r := range srv.Requests() {
+		if strings.Contains(r.Path, "/reviewStats") {
+			t.Errorf("unexpected request %s", r.Path)
+		}
+	}
+
+	refs := []client.ProductRef{{Code: "GYMB0105"}, {Code: "NOSUCH99"}, {ID: 12345678}}
+	results = c.GetProductRefs(refs, client.ProductOptions{Workers: 3})
+	if r := results[0]; r.Ref != refs[0] || r.ID != 7816725 || r.Err != nil || r.Product == nil {
+		t.Errorf("GetProductRefs(code GYMB0105) = %+v", r)
+	}
+	if r := results[1]; r.Ref != refs[1] || r.ID != 0 || r.Err == nil || r.Product != nil {
+		t.Errorf("GetProductRefs(unknown code) = %+v, want a resolve error", r)
+	}
+	if r := results[2]; r.ID != 12345678 || r.Product == nil {
+		t.Errorf("GetProductRefs(12345678) = %+v", r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range c.GetProductsContext(ctx, ids, client.DefaultProductOptions()) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("canceled GetProducts(%d) error = %v", r.ID, r.Err)
+		}
+	}
+}
+
+func TestSearchRatingSortCapsLookups(t *testing.T) {
+	srv := startServer(t)
+	for i := range client.MaxRatedSearchResults + 10 {
+		srv.AddProduct(alzatest.Product{ID: 9400000 + i, Name: fmt.Sprintf("Šejker %d", i), Code: fmt.Sprintf("SHAK%04d", i), Price: 5, RatingCount: i})
+	}
+	c := newClient(t, srv)
+	srv.ResetRequests()
+
+	results, err := c.SearchWithOptions("šejker", client.SearchOptions{Sort: client.SortBestselling, Limit: 100})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() error: %v", err)
+	}
+	if len(results) != client.MaxRatedSearchResults || results[0].ID != 9400000+client.MaxRatedSearchResults-1 {
+		t.Errorf("bestselling returned %d results, first %+v", len(results), results[0])
+	}
+	lookups := 0
+	for _, r := range srv.Requests() {
+		if strings.Contains(r.Path, "/reviewStats") {
+			lookups++
+		}
+	}
+	if lookups != client.MaxRatedSearchResults {
+		t.Errorf("%d review stats lookups, want %d", lookups, client.MaxRatedSearchResults)
+	}
+
+	if results, err := c.Search("šejker", 0); err != nil || len(results) != 10 {
+		t.Errorf("Search(limit 0) = %d results, %v, want the default 10", len(results), err)
+	}
+	if _, err := c.Search("šejker", -1); err == nil {
+		t.Error("Search(limit -1) succeeded")
+	}
+}
+
+func TestSearchFallsBackToWhisper(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	// v5 search matches names only, the whisperer is used when it comes back empty
+	results, err := c.Search("nič také", 5)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Search() = %+v, want none", results)
+	}
+	var whisper bool
+	for _, r := range srv.Requests() {
+		if strings.Contains(r.Path, "/whisperer/") {
+			whisper = true
+		}
+	}
+	if !whisper {
+		t.Error("whisperer was not called after an empty v5 search")
+	}
+}
+
+func TestSearchWithOptions(t *testing.T) {
+	srv := startServer(t)
+	for i, p := range []alzatest.Product{
+		{ID: 9100001, Name: "Apple iPhone 15 128GB", Category: "mobily", Price: 899, Availability: "Skladom > 5 ks", RatingCount: 40, Reviews: []alzatest.Review{{Rating: 5}}},
+		{ID: 9100002, Name: "Samsung Galaxy S24 pre iPhone fanúšikov", Category: "mobily", Price: 749, Availability: "Skladom 1 ks", RatingCount: 300, Reviews: []alzatest.Review{{Rating: 4}}},
+		{ID: 9100003, Name: "Kryt na iPhone 15", Category: "puzdra", Price: 12.90, Availability: "Skladom > 5 ks"},
+	} {
+		p.Code = fmt.Sprintf("TEST%d", i)
+		srv.AddProduct(p)
+	}
+	c := newClient(t, srv)
+	search := func(opts client.SearchOptions) []int {
+		t.Helper()
+		results, err := c.SearchWithOptions("iphone", opts)
+		if err != nil {
+			t.Fatalf("SearchWithOptions(%+v) error: %v", opts, err)
+		}
+		ids := []int{}
+		for _, r := range results {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	if got := search(client.SearchOptions{Sort: client.SortPriceAsc, Limit: 2, Page: 2}); !slices.Equal(got, []int{9100001, 8123456}) {
+		t.Errorf("page 2 by price = %v", got)
+	}
+	if got := search(client.SearchOptions{InStockOnly: true, MinPrice: 100}); !slices.Equal(got, []int{9100001, 9100002}) {
+		t.Errorf("in stock over 100 = %v", got)
+	}
+	if got := search(client.SearchOptions{Manufacturer: "apple", Category: "mobily"}); !slices.Equal(got, []int{8123456, 9100001}) {
+		t.Errorf("Apple phones = %v", got)
+	}
+	if got := search(client.SearchOptions{Sort: client.SortBestselling, Category: "mobily"}); !slices.Equal(got, []int{9100002, 8123456, 9100001}) {
+		t.Errorf("bestselling phones = %v", got)
+	}
+
+	results, err := c.SearchWithOptions("iphone", client.SearchOptions{Sort: client.SortRating, Limit: 1})
+	if err != nil || len(results) != 1 || results[0].ID != 9100001 || results[0].Rating != 5 || results[0].RatingCount != 40 {
+		t.Errorf("best rated = %+v, %v", results, err)
+	}
+
+	// v5 search answers with one batch; there are 4 iPhone matches in it
+	if got := search(client.SearchOptions{Limit: 2, Page: 2}); len(got) != 2 {
+		t.Errorf("last page = %v", got)
+	}
+	for _, opts := range []client.SearchOptions{{Limit: 2, Page: 3}, {Offset: 4}} {
+		if _, err := c.SearchWithOptions("iphone", opts); !errors.Is(err, client.ErrSearchPageOutOfRange) {
+			t.Errorf("SearchWithOptions(%+v) error = %v, want ErrSearchPageOutOfRange", opts, err)
+		}
+	}
+	if got := search(client.SearchOptions{MinPrice: 5000}); len(got) != 0 {
+		t.Errorf("first page without matches = %v", got)
+	}
+}
+
+func TestCategories(t *testing.T) {
+	srv := startServer(t)
+	srv.AddProduct(alzatest.Product{ID: 9200001, Name: "Amix Nutrition Creatine 300 g", Code: "AMIX1", Category: "sport", Price: 14.90, Availability: "Skladom > 5 ks"})
+	srv.AddCategory(alzatest.Category{ID: 18862660, Name: "Kreatín", Slug: "kreatin", ParentID: 18855843, Products: []int{7816725, 9200001}})
+	c := newClient(t, srv)
+
+	sport, err := c.GetCategory(18855843)
+	if err != nil {
+		t.Fatalf("GetCategory() error: %v", err)
+	}
+	if sport.Name != "Šport a outdoor" || sport.ProductCount != 2 || len(sport.Children) != 2 {
+		t.Fatalf("GetCategory() = %+v", sport)
+	}
+	creatine := sport.Children[0]
+	if creatine.ID != 18862660 || creatine.ParentID != 18855843 || creatine.ProductCount != 2 || !strings.HasSuffix(creatine.URL, "/sport/kreatin/18862660.htm") {
+		t.Errorf("subcategory = %+v", creatine)
+	}
+
+	page, err := c.GetCategoryProducts(18855843, 1, 10)
+	if err != nil {
+		t.Fatalf("GetCategoryProducts() error: %v", err)
+	}
+	if page.Total != 2 || page.Offset != 1 || len(page.Products) != 1 || page.Products[0].ID != 9200001 || page.Products[0].Price != 14.90 {
+		t.Errorf("GetCategoryProducts(offset 1) = %+v", page)
+	}
+
+	producers, err := c.GetTopProducers(18855843)
+	if err != nil || len(producers) != 2 || producers[0].Name != "GymBeam" || producers[0].URL == "" {
+		t.Errorf("GetTopProducers() = %+v, %v", producers, err)
+	}
+
+	sections, err := c.GetPromoSections(18862660)
+	if err != nil {
+		t.Fatalf("GetPromoSections() error: %v", err)
+	}
+	if len(sections) != 0 {
+		t.Errorf("GetPromoSections() of a replaced category without promo = %+v", sections)
+	}
+
+	if _, err := c.GetCategory(1); err == nil {
+		t.Error("GetCategory() of an unknown category succeeded")
+	}
+}
+
+func TestResolveProduct(t *testing.T) {
+	srv := startServer(t)
+	srv.AddProduct(alzatest.Product{ID: 9300002, Name: "Duplicitný EAN", Code: "DUPL0001", EAN: "8586022211126"})
+	c := newClient(t, srv)
+
+	resolve := func(s string) (int, error) {
+		t.Helper()
+		ref, err := client.ParseProductRef(s)
+		if err != nil {
+			t.Fatalf("ParseProductRef(%q) error: %v", s, err)
+		}
+		return c.ResolveProduct(ref)
+	}
+	for in, want := range map[string]int{
+		"gymb0105":      7816725,
+		"8000153987069": 12345678,
+		srv.URL + "/apple-iphone-15-pro-d8123456.htm": 8123456,
+	} {
+		if got, err := resolve(in); got != want || err != nil {
+			t.Errorf("ResolveProduct(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+
+	if _, err := resolve("NOPE0001"); err == nil || !strings.Contains(err.Error(), "no product with Alza code NOPE0001") {
+		t.Errorf("ResolveProduct(unknown code) error = %v", err)
+	}
+	if _, err := resolve("4006381333931"); err == nil || !strings.Contains(err.Error(), "no product with EAN") {
+		t.Errorf("ResolveProduct(unknown EAN) error = %v", err)
+	}
+	if _, err := resolve("8586022211126"); err == nil || !strings.Contains(err.Error(), "matches 2 products") {
+		t.Errorf("ResolveProduct(shared EAN) error = %v", err)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	srv := startServer(t)
+	srv.AddOrder(alzatest.Order{
+		ID:      "1060009999",
+		Created: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		Status:  "Na ceste",
+		Total:   17.90,
+		Active:  true,
+	})
+	c := newClient(t, srv)
+
+	orders, total, err := c.GetOrders(10)
+	if err != nil {
+		t.Fatalf("GetOrders() error: %v", err)
+	}
+	if total != 3 || len(orders) != 3 {
+		t.Fatalf("GetOrders() = %d orders, total %d; want 3, 3", len(orders), total)
+	}
+	if orders[0].ID != "1060009999" || orders[0].Status != "Na ceste" || orders[0].Date != "2026-03-01" {
+		t.Errorf("orders[0] = %+v, want the active order first", orders[0])
+	}
+	if orders[1].ID != "1059887711" || len(orders[1].Items) != 1 {
+		t.Errorf("orders[1] = %+v, want newest archived order with items", orders[1])
+	}
+}
+
+func TestQuickBuyFlow(t *testing.T) {
+	srv := startServer(t)
+	srv.AddCoupon("ZLAVA10", 10)
+	c := newClient(t, srv)
+
+	config := client.QuickBuyConfig{
+		AlzaBoxID:  1009905,
+		DeliveryID: 2680,
+		PaymentID:  "216",
+		CardID:     "card-1",
+		VisitorID:  "visitor-1",
+		PromoCodes: []string{"zlava10"},
+	}
+	result, err := c.QuickBuy(7816725, 2, config)
+	if err != nil {
+		t.Fatalf("QuickBuy() error: %v", err)
+	}
+	if result.TotalPrice != 32.22 {
+		t.Errorf("TotalPrice = %v, want 32.22 after 10%% coupon", result.TotalPrice)
+	}
+
+	payments := srv.Payments()
+	if len(payments) != 1 || payments[0].OrderID != result.OrderID || payments[0].CardID != "card-1" {
+		t.Errorf("Payments() = %+v, want one for order %s", payments, result.OrderID)
+	}
+	var found bool
+	for _, o := range srv.Orders() {
+		if o.ID == result.OrderID {
+			found = o.Active && o.Status == "Zaplatená"
+		}
+	}
+	if !found {
+		t.Errorf("order %s not recorded as active and paid", result.OrderID)
+	}
+}
+
+func TestQuickBuyInvalidCoupon(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+
+	_, err := c.QuickBuy(7816725, 1, client.QuickBuyConfig{QuoteOnly: true, PromoCodes: []string{"NEPLATNY"}})
+	if err == nil || !strings.Contains(err.Error(), "Kupón NEPLATNY nie je platný") {
+		t.Fatalf("QuickBuy() error = %v, want coupon rejection", err)
+	}
+	if len(srv.Orders()) != 2 {
+		t.Error("a rejected quote must not create an order")
+	}
+}
+
+func TestFaultUnauthorized(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+	srv.InjectFault(alzatest.Unauthorized("/orders/"))
+
+	_, _, err := c.GetOrders(5)
+	if !errors.Is(err, client.ErrAuthRequired) {
+		t.Fatalf("GetOrders() error = %v, want ErrAuthRequired", err)
+	}
+
+	srv.ClearFaults()
+	if _, _, err := c.GetOrders(5); err != nil {
+		t.Fatalf("GetOrders() after ClearFaults error: %v", err)
+	}
+}
+
+func TestFaultRateLimitedIsRetried(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv, client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}))
+	srv.InjectFault(alzatest.Fault{Path: "/reviewStats", Status: 429, RetryAfter: "0", Times: 2})
+	srv.ResetRequests()
+
+	stats, err := c.GetReviewStats(7816725)
+	if err != nil {
+		t.Fatalf("GetReviewStats() error: %v", err)
+	}
+	if stats.ReviewCount != 2 {
+		t.Errorf("ReviewCount = %d, want 2", stats.ReviewCount)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("server saw %d requests, want 3 (two 429s and a success)", n)
+	}
+}
+
+func TestFaultCloudflareChallenge(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+	srv.InjectFault(alzatest.CloudflareChallenge(""))
+
+	_, err := c.GetLists()
+	if !errors.Is(err, client.ErrChallenge) {
+		t.Fatalf("GetLists() error = %v, want ErrChallenge", err)
+	}
+	var ch *client.ChallengeError
+	if !errors.As(err, &ch) || ch.RayID == "" {
+		t.Errorf("error %v does not carry the ray id", err)
+	}
+}
+
+func TestSetTokenExpiresClient(t *testing.T) {
+	srv := startServer(t)
+	c := newClient(t, srv)
+	srv.SetToken("Bearer rotated")
+
+	status, err := c.GetUserStatus()
+	if err != nil {
+		t.Fatalf("GetUserStatus() error: %v", err)
+	}
+	if status.UserID != -1 {
+		t.Errorf("UserID = %d, want -1 for a stale token", status.UserID)
+	}
+
+	if _, err := srv.NewClient(context.Background(), client.WithToken("Bearer old")); !errors.Is(err, client.ErrTokenExpired) {
+		t.Errorf("NewClient(stale token) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestTokenRotationMidSessionIsRefreshed(t *testing.T) {
+	srv := startServer(t)
+	var refreshes int
+	refresher := client.RefreshFunc(func(ctx context.Context) (string, error) {
+		refreshes++
+		return "Bearer rotated", nil
+	})
+	c := newClient(t, srv, client.WithTokenRefresher(refresher))
+
+	srv.SetToken("Bearer rotated")
+	if _, _, err := c.GetOrders(5); err != nil {
+		t.Fatalf("GetOrders() after rotation error: %v", err)
+	}
+	if refreshes != 1 {
+		t.Errorf("refreshes = %d, want 1", refreshes)
+	}
+
+	// A stale token at startup shows up as user_id -1, not 401
+	srv.SetToken("Bearer rotated-again")
+	refresher = func(ctx context.Context) (string, error) { return "Bearer rotated-again", nil }
+	if _, err := srv.NewClient(context.Background(), client.WithToken("Bearer old"), client.WithTokenRefresher(refresher)); err != nil {
+		t.Fatalf("NewClient() with refresher error: %v", err)
+	}
+}
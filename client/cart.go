@@ -1,22 +1,23 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 )
 
 // fetchBasketID gets basket ID from user status summary
-func (c *TLSClient) fetchBasketID() error {
+func (c *TLSClient) fetchBasketID(ctx context.Context) error {
 	if c.userID == "" {
-		_, err := c.GetUserStatus()
+		_, err := c.GetUserStatusContext(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
 	endpoint := fmt.Sprintf(EndpointUserStatusSummary, c.userID)
-	data, err := c.Get(endpoint)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return err
 	}
@@ -42,7 +43,7 @@ func (c *TLSClient) fetchBasketID() error {
 		return fmt.Errorf("no basket preview action in response")
 	}
 
-	// Parse: https://www.alza.sk/api/basket/1538710316/preview
+	// Parse: https://www.alza.sk/api/basket/1538710316/preview (any storefront domain)
 	parts := strings.Split(href, "/")
 	for i, p := range parts {
 		if p == "basket" && i+1 < len(parts) {
@@ -56,15 +57,22 @@ func (c *TLSClient) fetchBasketID() error {
 	}
 
 	if c.debug {
-		fmt.Printf("[DEBUG] Basket ID: %s\n", c.basketID)
+		fmt.Fprintf(c.debugOut, "[DEBUG] Basket ID: %s\n", c.basketID)
 	}
 
 	return nil
 }
 
+// GetCart returns cart items enriched with names and prices from the basket
+// preview; an empty cart is an empty slice, not nil
 func (c *TLSClient) GetCart() ([]CartItem, error) {
+	return c.GetCartContext(context.Background())
+}
+
+// GetCartContext is like GetCart but honors ctx
+func (c *TLSClient) GetCartContext(ctx context.Context) ([]CartItem, error) {
 	if c.basketID == "" {
-		if err := c.fetchBasketID(); err != nil {
+		if err := c.fetchBasketID(ctx); err != nil {
 			return nil, err
 		}
 		// If still empty after fetch, cart is empty
@@ -74,8 +82,8 @@ func (c *TLSClient) GetCart() ([]CartItem, error) {
 	}
 
 	// Get cart items
-	endpoint := fmt.Sprintf(EndpointCartItems, c.basketID)
-	data, err := c.Get(endpoint)
+	endpoint := fmt.Sprintf(EndpointCartItems, c.basketID, c.storefront().Country)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +101,10 @@ func (c *TLSClient) GetCart() ([]CartItem, error) {
 
 	// Get basket preview for more details (names, prices)
 	previewEndpoint := fmt.Sprintf(EndpointCartPreview, c.basketID)
-	previewData, err := c.Get(previewEndpoint)
+	previewData, err := c.GetContext(ctx, previewEndpoint)
 	if err != nil {
 		// If preview fails, return basic items
-		var items []CartItem
+		items := []CartItem{}
 		for _, item := range resp.Items {
 			items = append(items, CartItem{
 				ProductID:    item.ProductID,
@@ -120,7 +128,7 @@ func (c *TLSClient) GetCart() ([]CartItem, error) {
 	}
 	if err := json.Unmarshal(previewData, &preview); err != nil {
 		// Fallback to basic items
-		var items []CartItem
+		items := []CartItem{}
 		for _, item := range resp.Items {
 			items = append(items, CartItem{
 				ProductID:    item.ProductID,
@@ -140,7 +148,7 @@ func (c *TLSClient) GetCart() ([]CartItem, error) {
 	}
 
 	// Merge data - match by extracted product ID
-	var items []CartItem
+	items := []CartItem{}
 	for _, p := range preview.Items {
 		productID := extractProductID(p.DetailAction.WebLink)
 		basketItemID := 0
@@ -161,15 +169,27 @@ func (c *TLSClient) GetCart() ([]CartItem, error) {
 	return items, nil
 }
 
+// AddToCart adds quantity pieces of a product to the cart
 func (c *TLSClient) AddToCart(productID, quantity int) error {
+	return c.AddToCartContext(context.Background(), productID, quantity)
+}
+
+// AddToCartContext is like AddToCart but honors ctx
+func (c *TLSClient) AddToCartContext(ctx context.Context, productID, quantity int) error {
 	body := fmt.Sprintf(`{"id":%d,"count":%d}`, productID, quantity)
-	_, err := c.Post(EndpointOrderCommodity, body)
+	_, err := c.PostContext(ctx, EndpointOrderCommodity, body)
 	return err
 }
 
+// ClearCart removes all items from the cart
 func (c *TLSClient) ClearCart() error {
+	return c.ClearCartContext(context.Background())
+}
+
+// ClearCartContext is like ClearCart but honors ctx
+func (c *TLSClient) ClearCartContext(ctx context.Context) error {
 	if c.basketID == "" {
-		if err := c.fetchBasketID(); err != nil {
+		if err := c.fetchBasketID(ctx); err != nil {
 			return err
 		}
 		if c.basketID == "" {
@@ -178,14 +198,20 @@ func (c *TLSClient) ClearCart() error {
 		}
 	}
 
-	endpoint := fmt.Sprintf(EndpointCartItems, c.basketID)
-	_, err := c.Delete(endpoint)
+	endpoint := fmt.Sprintf(EndpointCartItems, c.basketID, c.storefront().Country)
+	_, err := c.DeleteContext(ctx, endpoint)
 	return err
 }
 
+// RemoveFromCart removes a product from the cart
 func (c *TLSClient) RemoveFromCart(productID int) error {
+	return c.RemoveFromCartContext(context.Background(), productID)
+}
+
+// RemoveFromCartContext is like RemoveFromCart but honors ctx
+func (c *TLSClient) RemoveFromCartContext(ctx context.Context, productID int) error {
 	// Get cart items to find basketItemId
-	items, err := c.GetCart()
+	items, err := c.GetCartContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -204,6 +230,6 @@ func (c *TLSClient) RemoveFromCart(productID int) error {
 
 	// Use OrderUpdate endpoint with count=0 to remove item
 	body := fmt.Sprintf(`{"id":"%d","count":0,"addHook":null,"source":4,"accessoryvariant":null}`, basketItemID)
-	_, err = c.Post(EndpointOrderUpdate, body)
+	_, err = c.PostContext(ctx, fmt.Sprintf(EndpointOrderUpdate, c.storefront().Country), body)
 	return err
 }
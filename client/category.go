@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The category endpoints' query parameters (offset, limit) and response
+// shapes below are inferred, not taken from a captured response; see
+// docs/API-SPEC.md section 6. Fix them here and in client/alzatest once a
+// recording confirms or contradicts them.
+
+type categoryData struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	URL          string         `json:"url"`
+	ParentID     int            `json:"parentId"`
+	ProductCount int            `json:"productCount"`
+	Categories   []categoryData `json:"categories"`
+}
+
+func (d categoryData) category() Category {
+	cat := Category{
+		ID:           d.ID,
+		Name:         d.Name,
+		URL:          d.URL,
+		ParentID:     d.ParentID,
+		ProductCount: d.ProductCount,
+	}
+	for _, child := range d.Categories {
+		cat.Children = append(cat.Children, child.category())
+	}
+	return cat
+}
+
+type categoryResponse struct {
+	Data struct {
+		categoryData
+		Commodities []commodityItem `json:"commodities"`
+	} `json:"data"`
+}
+
+// categoryIDRe matches category web links (/sport/kreatin/18862660.htm) and
+// REST links (/Services/RestService.svc/v1/category/18862660)
+var categoryIDRe = regexp.MustCompile(`(?i)(?:/(\d+)\.htm|/category/(\d+))(?:[?#]|$)`)
+
+// ParseCategoryID accepts a category ID or an Alza category URL as found in
+// the address bar or whisperer results.
+func ParseCategoryID(ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.Atoi(ref); err == nil && id > 0 {
+		return id, nil
+	}
+	if m := categoryIDRe.FindStringSubmatch(ref); m != nil {
+		id, _ := strconv.Atoi(m[1] + m[2])
+		if id > 0 {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid category %q (want an ID like 18862660 or a URL like https://www.alza.sk/sport/kreatin/18862660.htm)", ref)
+}
+
+// GetCategory fetches a category with its direct subcategories
+func (c *TLSClient) GetCategory(categoryID int) (*Category, error) {
+	return c.GetCategoryContext(context.Background(), categoryID)
+}
+
+// GetCategoryContext is like GetCategory but honors ctx
+func (c *TLSClient) GetCategoryContext(ctx context.Context, categoryID int) (*Category, error) {
+	resp, err := c.getCategory(ctx, fmt.Sprintf(EndpointCategory, categoryID))
+	if err != nil {
+		return nil, err
+	}
+	cat := resp.Data.category()
+	if cat.ID == 0 {
+		cat.ID = categoryID
+	}
+	return &cat, nil
+}
+
+// GetCategoryProducts fetches one page of the products in a category and its
+// subcategories. Paging by offset and limit is unverified against the live API.
+func (c *TLSClient) GetCategoryProducts(categoryID, offset, limit int) (*CategoryProducts, error) {
+	return c.GetCategoryProductsContext(context.Background(), categoryID, offset, limit)
+}
+
+// GetCategoryProductsContext is like GetCategoryProducts but honors ctx
+func (c *TLSClient) GetCategoryProductsContext(ctx context.Context, categoryID, offset, limit int) (*CategoryProducts, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 24
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	resp, err := c.getCategory(ctx, fmt.Sprintf(EndpointCategoryProducts, categoryID, offset, limit))
+	if err != nil {
+		return nil, err
+	}
+	return &CategoryProducts{
+		CategoryID: categoryID,
+		Name:       resp.Data.Name,
+		Total:      resp.Data.ProductCount,
+		Offset:     offset,
+		Products:   commodityResults(resp.Data.Commodities),
+	}, nil
+}
+
+func (c *TLSClient) getCategory(ctx context.Context, endpoint string) (*categoryResponse, error) {
+	data, err := c.GetContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var resp categoryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse category: %w", err)
+	}
+	return &resp, nil
+}
+
+// GetTopProducers fetches the leading manufacturers of a category
+func (c *TLSClient) GetTopProducers(categoryID int) ([]Producer, error) {
+	return c.GetTopProducersContext(context.Background(), categoryID)
+}
+
+// GetTopProducersContext is like GetTopProducers but honors ctx
+func (c *TLSClient) GetTopProducersContext(ctx context.Context, categoryID int) ([]Producer, error) {
+	endpoint := fmt.Sprintf(EndpointCategoryTopProducers, categoryID, c.storefront().Country)
+	data, err := c.GetContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Items []struct {
+			ID          int    `json:"id"`
+			Name        string `json:"name"`
+			ImageURL    string `json:"imageUrl"`
+			ClickAction struct {
+				WebLink string `json:"webLink"`
+			} `json:"clickAction"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse top producers: %w", err)
+	}
+
+	producers := make([]Producer, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		producers = append(producers, Producer{
+			ID:       item.ID,
+			Name:     item.Name,
+			URL:      item.ClickAction.WebLink,
+			ImageURL: item.ImageURL,
+		})
+	}
+	return producers, nil
+}
+
+// GetPromoSections fetches the promoted product sections of a category page
+func (c *TLSClient) GetPromoSections(categoryID int) ([]PromoSection, error) {
+	return c.GetPromoSectionsContext(context.Background(), categoryID)
+}
+
+// GetPromoSectionsContext is like GetPromoSections but honors ctx
+func (c *TLSClient) GetPromoSectionsContext(ctx context.Context, categoryID int) ([]PromoSection, error) {
+	endpoint := fmt.Sprintf(EndpointCategoryPromoSections, categoryID, c.storefront().Country)
+	data, err := c.GetContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Items []struct {
+			Title       string          `json:"title"`
+			Commodities []commodityItem `json:"commodities"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse promo sections: %w", err)
+	}
+
+	sections := make([]PromoSection, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		sections = append(sections, PromoSection{
+			Title:    item.Title,
+			Products: commodityResults(item.Commodities),
+		})
+	}
+	return sections, nil
+}
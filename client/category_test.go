@@ -0,0 +1,64 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseCategoryID(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"18862660", 18862660},
+		{" 18862660 ", 18862660},
+		{"https://www.alza.sk/sport/kreatin/18862660.htm", 18862660},
+		{"https://www.alza.cz/mobily/18843445.htm?pg=2", 18843445},
+		{"/kreatin/18862660.htm", 18862660},
+		{"https://www.alza.sk/Services/RestService.svc/v1/category/18862660", 18862660},
+	}
+	for _, tt := range tests {
+		if got, err := ParseCategoryID(tt.ref); got != tt.want || err != nil {
+			t.Errorf("ParseCategoryID(%q) = %d, %v, want %d", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"", "kreatin", "0", "-5", "https://www.alza.sk/gymbeam-kreatin-d7816725.htm"} {
+		if _, err := ParseCategoryID(ref); err == nil {
+			t.Errorf("ParseCategoryID(%q) succeeded", ref)
+		}
+	}
+}
+
+func TestCategoryResponseJSON(t *testing.T) {
+	jsonData := `{
+		"data": {
+			"id": 18855843,
+			"name": "Šport a outdoor",
+			"url": "https://www.alza.sk/sport/18855843.htm",
+			"productCount": 51,
+			"categories": [
+				{"id": 18862660, "name": "Kreatín", "url": "https://www.alza.sk/sport/kreatin/18862660.htm", "parentId": 18855843, "productCount": 51}
+			],
+			"commodities": [
+				{"id": 5275186, "name": "Amix Creatine 500 g", "price": "18,90 €", "avail": "Na sklade > 10 ks"}
+			]
+		}
+	}`
+
+	var resp categoryResponse
+	if err := json.Unmarshal([]byte(jsonData), &resp); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	cat := resp.Data.category()
+	if cat.ID != 18855843 || cat.ProductCount != 51 || cat.ParentID != 0 {
+		t.Errorf("category = %+v", cat)
+	}
+	if len(cat.Children) != 1 || cat.Children[0].Name != "Kreatín" || cat.Children[0].ParentID != 18855843 {
+		t.Errorf("children = %+v", cat.Children)
+	}
+	products := commodityResults(resp.Data.Commodities)
+	if len(products) != 1 || products[0].Price != 18.90 || !InStock(products[0].Availability) {
+		t.Errorf("products = %+v", products)
+	}
+}
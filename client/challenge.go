@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	http "github.com/bogdanfinn/fhttp"
+)
+
+// ChallengeError is returned when Cloudflare answers with an interstitial
+// (JS/captcha challenge or block page) instead of the API response.
+// Retrying immediately does not help; fresh cookies or a pause usually do.
+type ChallengeError struct {
+	Status int
+	URL    string
+	RayID  string
+}
+
+func (e *ChallengeError) Error() string {
+	msg := fmt.Sprintf("Cloudflare challenge (HTTP %d)", e.Status)
+	if e.RayID != "" {
+		msg += ", ray " + e.RayID
+	}
+	return msg
+}
+
+// challengeMarkers are fragments only found on Cloudflare interstitial pages.
+// The /cdn-cgi/challenge-platform/ script isn't one: bot management injects it
+// into ordinary pages too.
+var challengeMarkers = []string{
+	"<title>just a moment...</title>",
+	"<title>attention required! | cloudflare</title>",
+	"cf_chl_opt",
+	"cf-browser-verification",
+}
+
+// detectChallenge reports a Cloudflare interstitial based on headers and body markers.
+func detectChallenge(resp *http.Response, body []byte, urlStr string) *ChallengeError {
+	challenged := strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge")
+	if !challenged && looksLikeHTML(body, resp.Header.Get("Content-Type")) {
+		low := strings.ToLower(string(body))
+		for _, m := range challengeMarkers {
+			if strings.Contains(low, m) {
+				challenged = true
+				break
+			}
+		}
+	}
+	if !challenged {
+		return nil
+	}
+	return &ChallengeError{
+		Status: resp.StatusCode,
+		URL:    urlStr,
+		RayID:  resp.Header.Get("Cf-Ray"),
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	http "github.com/bogdanfinn/fhttp"
+)
+
+func TestDetectChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		body    string
+		want    bool
+	}{
+		{
+			name:    "cf-mitigated header",
+			status:  403,
+			headers: map[string]string{"Cf-Mitigated": "challenge", "Cf-Ray": "8abc-VIE"},
+			body:    "",
+			want:    true,
+		},
+		{
+			name:    "just a moment page",
+			status:  403,
+			headers: map[string]string{"Content-Type": "text/html; charset=UTF-8"},
+			body:    "<!DOCTYPE html><html><head><title>Just a moment...</title></head></html>",
+			want:    true,
+		},
+		{
+			name:    "challenge options on 503",
+			status:  503,
+			headers: map[string]string{"Content-Type": "text/html"},
+			body:    `<html><script>window._cf_chl_opt={cvId:'3'};</script><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1"></script></html>`,
+			want:    true,
+		},
+		{
+			name:    "normal page with the injected bot management script",
+			status:  200,
+			headers: map[string]string{"Content-Type": "text/html; charset=utf-8"},
+			body:    `<html><head><title>GymBeam Kreatín | Alza.sk</title></head><body><p>Popis</p><script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script></body></html>`,
+			want:    false,
+		},
+		{
+			name:    "plain 403 json",
+			status:  403,
+			headers: map[string]string{"Content-Type": "application/json"},
+			body:    `{"message":"Forbidden"}`,
+			want:    false,
+		},
+		{
+			name:    "regular html error page",
+			status:  502,
+			headers: map[string]string{"Content-Type": "text/html"},
+			body:    "<html><title>502 Bad Gateway</title></html>",
+			want:    false,
+		},
+		{
+			name:    "marker inside json is ignored",
+			status:  200,
+			headers: map[string]string{"Content-Type": "application/json"},
+			body:    `{"name":"cf_chl_opt"}`,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			got := detectChallenge(resp, []byte(tt.body), "https://www.alza.sk/api/x")
+			if (got != nil) != tt.want {
+				t.Fatalf("detectChallenge() = %v, want challenge=%v", got, tt.want)
+			}
+			if got != nil && got.Status != tt.status {
+				t.Errorf("Status = %d, want %d", got.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestChallengeErrorMessage(t *testing.T) {
+	err := &ChallengeError{Status: 403, RayID: "8abc-VIE"}
+	msg := err.Error()
+	if !strings.Contains(msg, "403") || !strings.Contains(msg, "8abc-VIE") {
+		t.Errorf("Error() = %q, want status and ray ID", msg)
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummaryGroup is the Group of the comparison rows taken from price, rating and
+// availability rather than from the product parameters.
+const SummaryGroup = "Summary"
+
+// Comparison lines products up side by side. Every row has one value per
+// product, in the order of Products; a product without the row has "".
+type Comparison struct {
+	Products []ComparedProduct `json:"products"`
+	Rows     []ComparisonRow   `json:"rows"`
+}
+
+type ComparedProduct struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ComparisonRow struct {
+	Group   string   `json:"group"`
+	Name    string   `json:"name"`
+	Values  []string `json:"values"`
+	Differs bool     `json:"differs"` // Not every product has the same value
+}
+
+// CompareProducts aligns the products' parameters by group and parameter name
+// and puts a SummaryGroup of price, promo prices, rating, complaint rate and
+// availability first. Groups and parameters keep the order in which they first
+// appear; rows no product has a value for are left out.
+func CompareProducts(products []*ProductDetail) *Comparison {
+	cmp := &Comparison{Products: make([]ComparedProduct, len(products)), Rows: []ComparisonRow{}}
+	for i, p := range products {
+		cmp.Products[i] = ComparedProduct{ID: p.ID, Name: p.Name}
+	}
+
+	summary := []struct {
+		name  string
+		value func(*ProductDetail) string
+	}{
+		{"Price", func(p *ProductDetail) string { return p.Price }},
+		{"Discount", func(p *ProductDetail) string {
+			if p.DiscountPercent == nil {
+				return ""
+			}
+			return fmt.Sprintf("%d%%", *p.DiscountPercent)
+		}},
+		{"Promo prices", comparePromoPrices},
+		{"Rating", func(p *ProductDetail) string {
+			if p.ReviewStats == nil || p.ReviewStats.RatingCount == 0 {
+				return ""
+			}
+			return fmt.Sprintf("%.1f/5 (%d)", p.ReviewStats.RatingAverage, p.ReviewStats.RatingCount)
+		}},
+		{"Complaint rate", func(p *ProductDetail) string {
+			if p.ReviewStats == nil || p.ReviewStats.Complaint == nil {
+				return ""
+			}
+			return fmt.Sprintf("%.2f%%", p.ReviewStats.Complaint.Rate*100)
+		}},
+		{"Availability", func(p *ProductDetail) string { return p.Availability }},
+	}
+	for _, field := range summary {
+		values := make([]string, len(products))
+		for i, p := range products {
+			values[i] = field.value(p)
+		}
+		cmp.addRow(SummaryGroup, field.name, values)
+	}
+
+	// Collect group and parameter order across all products first, so that a
+	// parameter only the second product has still lands in its group
+	type key struct{ group, name string }
+	var groups []string
+	params := map[string][]string{}
+	values := map[key][]string{}
+	for i, p := range products {
+		for _, g := range p.Parameters {
+			if _, ok := params[g.Name]; !ok {
+				groups = append(groups, g.Name)
+				params[g.Name] = nil
+			}
+			for _, param := range g.Parameters {
+				k := key{g.Name, param.Name}
+				if _, ok := values[k]; !ok {
+					params[g.Name] = append(params[g.Name], param.Name)
+					values[k] = make([]string, len(products))
+				}
+				values[k][i] = strings.Join(param.Values, ", ")
+			}
+		}
+	}
+	for _, g := range groups {
+		for _, name := range params[g] {
+			cmp.addRow(g, name, values[key{g, name}])
+		}
+	}
+	return cmp
+}
+
+func (c *Comparison) addRow(group, name string, values []string) {
+	differs, empty := false, true
+	for _, v := range values {
+		if v != values[0] {
+			differs = true
+		}
+		if v != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return
+	}
+	c.Rows = append(c.Rows, ComparisonRow{Group: group, Name: name, Values: values, Differs: differs})
+}
+
+// Differing returns the comparison with only the rows whose values differ.
+func (c *Comparison) Differing() *Comparison {
+	out := &Comparison{Products: c.Products, Rows: []ComparisonRow{}}
+	for _, row := range c.Rows {
+		if row.Differs {
+			out.Rows = append(out.Rows, row)
+		}
+	}
+	return out
+}
+
+func comparePromoPrices(p *ProductDetail) string {
+	var promos []string
+	for _, promo := range p.PromoPrices {
+		s := promo.Name
+		if promo.Price != "" {
+			s += " " + promo.Price
+		}
+		if promo.Code != "" {
+			s += " (code " + promo.Code + ")"
+		}
+		promos = append(promos, s)
+	}
+	return strings.Join(promos, "; ")
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareProducts(t *testing.T) {
+	discount := 10
+	a := &ProductDetail{
+		ID:              1,
+		Name:            "Kávovar A",
+		Price:           "329,00 €",
+		DiscountPercent: &discount,
+		Availability:    "Skladom 2 ks",
+		PromoPrices:     []ProductPromoPrice{{Name: "S kupónom", Price: "299,00 €", Code: "KAVA"}},
+		ReviewStats:     &ReviewStats{RatingAverage: 4.8, RatingCount: 1875, Complaint: &ComplaintInfo{Rate: 0.0123}},
+		Parameters: []ProductParameterGroup{
+			{Name: "Základné", Parameters: []ProductParameter{
+				{Name: "Tlak", Values: []string{"15 bar"}},
+				{Name: "Farba", Values: []string{"čierna"}},
+			}},
+		},
+	}
+	b := &ProductDetail{
+		ID:           2,
+		Name:         "Kávovar B",
+		Price:        "449,00 €",
+		Availability: "Skladom 2 ks",
+		Parameters: []ProductParameterGroup{
+			{Name: "Rozmery", Parameters: []ProductParameter{{Name: "Hmotnosť", Values: []string{"9 kg"}}}},
+			{Name: "Základné", Parameters: []ProductParameter{
+				{Name: "Tlak", Values: []string{"15 bar"}},
+				{Name: "Mlynček", Values: []string{"keramický", "nastaviteľný"}},
+			}},
+		},
+	}
+
+	cmp := CompareProducts([]*ProductDetail{a, b})
+	if len(cmp.Products) != 2 || cmp.Products[1] != (ComparedProduct{ID: 2, Name: "Kávovar B"}) {
+		t.Errorf("Products = %+v", cmp.Products)
+	}
+
+	type row struct {
+		group, name, a, b string
+		differs           bool
+	}
+	want := []row{
+		{SummaryGroup, "Price", "329,00 €", "449,00 €", true},
+		{SummaryGroup, "Discount", "10%", "", true},
+		{SummaryGroup, "Promo prices", "S kupónom 299,00 € (code KAVA)", "", true},
+		{SummaryGroup, "Rating", "4.8/5 (1875)", "", true},
+		{SummaryGroup, "Complaint rate", "1.23%", "", true},
+		{SummaryGroup, "Availability", "Skladom 2 ks", "Skladom 2 ks", false},
+		{"Základné", "Tlak", "15 bar", "15 bar", false},
+		{"Základné", "Farba", "čierna", "", true},
+		{"Základné", "Mlynček", "", "keramický, nastaviteľný", true},
+		{"Rozmery", "Hmotnosť", "", "9 kg", true},
+	}
+	var got []row
+	for _, r := range cmp.Rows {
+		got = append(got, row{r.Group, r.Name, r.Values[0], r.Values[1], r.Differs})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Rows =\n%+v\nwant\n%+v", got, want)
+	}
+
+	diff := cmp.Differing()
+	if len(diff.Rows) != 8 || slices.ContainsFunc(diff.Rows, func(r ComparisonRow) bool { return !r.Differs }) {
+		t.Errorf("Differing() = %+v", diff.Rows)
+	}
+	if len(cmp.Rows) != 10 {
+		t.Error("Differing() modified the comparison")
+	}
+}
@@ -26,6 +26,15 @@ func TokenPath() (string, error) {
 	return filepath.Join(dir, "auth_token.txt"), nil
 }
 
+// EncryptedTokenPath returns the path to auth_token.enc (EncryptedFileTokenStore).
+func EncryptedTokenPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "auth_token.enc"), nil
+}
+
 // QuickbuyEnvPath returns the path to quickbuy.env.
 func QuickbuyEnvPath() (string, error) {
 	dir, err := ConfigDir()
@@ -35,6 +44,49 @@ func QuickbuyEnvPath() (string, error) {
 	return filepath.Join(dir, "quickbuy.env"), nil
 }
 
+// CookieJarPath returns the path to session_cookies.txt, the cookies saved by
+// `alza token refresh --cookies-file` for later refreshes.
+func CookieJarPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "session_cookies.txt"), nil
+}
+
+// SettingsPath returns the path to config.env (general CLI settings).
+func SettingsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.env"), nil
+}
+
+// CountryFromEnvFile reads ALZA_COUNTRY from config.env. Missing file or key returns "".
+func CountryFromEnvFile(path string) (string, error) {
+	if path == "" {
+		var err error
+		path, err = SettingsPath()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data, err := readEnvFile(path)
+	if err != nil {
+		return "", err
+	}
+	country := strings.TrimSpace(data["ALZA_COUNTRY"])
+	if country == "" {
+		return "", nil
+	}
+	if _, err := StorefrontFor(country); err != nil {
+		return "", errorForEnv(path, "ALZA_COUNTRY")
+	}
+	return strings.ToUpper(country), nil
+}
+
 // QuickbuyConfigFromEnvFile loads quickbuy.env. Missing file returns empty config.
 func QuickbuyConfigFromEnvFile(path string) (QuickBuyConfig, error) {
 	if path == "" {
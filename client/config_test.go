@@ -48,6 +48,17 @@ func TestQuickbuyEnvPath(t *testing.T) {
 	}
 }
 
+func TestCookieJarPath(t *testing.T) {
+	path, err := CookieJarPath()
+	if err != nil {
+		t.Fatalf("CookieJarPath() error: %v", err)
+	}
+
+	if filepath.Base(path) != "session_cookies.txt" {
+		t.Errorf("CookieJarPath() = %q, expected session_cookies.txt", path)
+	}
+}
+
 func TestReadEnvFile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -398,3 +409,50 @@ func TestReadEnvFilePermissionError(t *testing.T) {
 	// Restore permissions for cleanup
 	os.Chmod(tmpFile, 0644)
 }
+
+func TestSettingsPath(t *testing.T) {
+	path, err := SettingsPath()
+	if err != nil {
+		t.Fatalf("SettingsPath() error: %v", err)
+	}
+
+	if filepath.Base(path) != "config.env" {
+		t.Errorf("SettingsPath() = %q, expected config.env", path)
+	}
+}
+
+func TestCountryFromEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+	if err := os.WriteFile(path, []byte("# storefront\nALZA_COUNTRY=cz\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	country, err := CountryFromEnvFile(path)
+	if err != nil {
+		t.Fatalf("CountryFromEnvFile() error: %v", err)
+	}
+	if country != "CZ" {
+		t.Errorf("country = %q, want CZ", country)
+	}
+}
+
+func TestCountryFromEnvFileMissing(t *testing.T) {
+	country, err := CountryFromEnvFile("/nonexistent/path/config.env")
+	if err != nil {
+		t.Fatalf("CountryFromEnvFile() error for missing file: %v", err)
+	}
+	if country != "" {
+		t.Errorf("country = %q, want empty", country)
+	}
+}
+
+func TestCountryFromEnvFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+	if err := os.WriteFile(path, []byte("ALZA_COUNTRY=XX\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := CountryFromEnvFile(path); err == nil {
+		t.Error("CountryFromEnvFile() expected error for unsupported country")
+	}
+}
@@ -1,5 +1,9 @@
 package client
 
+// WebAPIURL is the shared CZ/SK API host used by every storefront.
+const WebAPIURL = "https://webapi.alza.cz"
+
+// Endpoints with a country=%s parameter expect Storefront.Country in that position.
 const (
 	EndpointAccessTokenPath        = "/api/identity/v1/accesstoken"
 	EndpointCommodityLists         = "/services/restservice.svc/v1/getCommodityLists"
@@ -11,28 +15,33 @@ const (
 
 	EndpointUserStatusSummary = "/api/users/%s/statusSummary"
 
-	EndpointCartItems   = "/api/v1/anonymous/baskets/%s/checkout/cart/items?country=SK"
+	EndpointCartItems   = "/api/v1/anonymous/baskets/%s/checkout/cart/items?country=%s"
 	EndpointCartPreview = "/api/basket/%s/preview"
 
 	EndpointOrderCommodity = "/Services/EShopService.svc/OrderCommodity"
-	EndpointOrderUpdate    = "/Services/EShopService.svc/OrderUpdate?country=SK"
+	EndpointOrderUpdate    = "/Services/EShopService.svc/OrderUpdate?country=%s"
 
 	EndpointSearchService = "/Services/RestService.svc/v5/search"
-	EndpointWhisperAnon   = "https://webapi.alza.cz/api/anonymous/search/whisperer/v1/whisper"
-	EndpointWhisperUser   = "https://webapi.alza.cz/api/users/%s/search/whisperer/v1/whisper"
+	EndpointWhisperAnon   = WebAPIURL + "/api/anonymous/search/whisperer/v1/whisper"
+	EndpointWhisperUser   = WebAPIURL + "/api/users/%s/search/whisperer/v1/whisper"
+
+	EndpointCategory              = "/Services/RestService.svc/v1/category/%d?T=CATEGORY"
+	EndpointCategoryProducts      = "/Services/RestService.svc/v1/category/%d?T=CATEGORY&offset=%d&limit=%d" // offset/limit unverified
+	EndpointCategoryTopProducers  = WebAPIURL + "/api/category/v1/categories/%d/topProducers?country=%s"
+	EndpointCategoryPromoSections = "/api/catalog/v1/homePage/categories/%d/promoSections?country=%s"
 
 	EndpointOrdersArchive = "/api/users/%s/v1/orders/archive?offset=%d&limit=%d&hideCancelledOrders=false"
 	EndpointOrdersActive  = "/api/users/%s/v1/orders/active"
 
-	EndpointProductDetail           = "/api/router/legacy/catalog/product/%d?country=SK&electronicContentOnly=False"
-	EndpointProductAvailabilityUser = "/api/productAvailability/v1/users/%s/products/%d?country=SK"
-	EndpointProductAvailabilityAnon = "/api/productAvailability/v1/anonymous/products/%d?country=SK"
+	EndpointProductDetail           = "/api/router/legacy/catalog/product/%d?country=%s&electronicContentOnly=False"
+	EndpointProductAvailabilityUser = "/api/productAvailability/v1/users/%s/products/%d?country=%s"
+	EndpointProductAvailabilityAnon = "/api/productAvailability/v1/anonymous/products/%d?country=%s"
 
 	EndpointFastOrderSave = "/Services/EShopService.svc/FastOrderSave"
 	EndpointFastOrderSend = "/Services/EShopService.svc/FastOrderSend"
 	EndpointPaymentRepeat = "/api/payment/v3/recurrent"
 
 	// Review endpoints (webapi.alza.cz)
-	EndpointReviewStats = "https://webapi.alza.cz/api/catalog/v2/commodities/%d/reviewStats?country=SK&ucik=x&pgrik=x"
-	EndpointReviews     = "https://webapi.alza.cz/api/catalog/v2/commodities/%d/reviews?country=SK&offset=%d&limit=%d"
+	EndpointReviewStats = WebAPIURL + "/api/catalog/v2/commodities/%d/reviewStats?country=%s&ucik=x&pgrik=x"
+	EndpointReviews     = WebAPIURL + "/api/catalog/v2/commodities/%d/reviews?country=%s&offset=%d&limit=%d"
 )
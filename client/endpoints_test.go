@@ -23,6 +23,10 @@ func TestEndpointConstants(t *testing.T) {
 		"EndpointSearchService":           EndpointSearchService,
 		"EndpointWhisperAnon":             EndpointWhisperAnon,
 		"EndpointWhisperUser":             EndpointWhisperUser,
+		"EndpointCategory":                EndpointCategory,
+		"EndpointCategoryProducts":        EndpointCategoryProducts,
+		"EndpointCategoryTopProducers":    EndpointCategoryTopProducers,
+		"EndpointCategoryPromoSections":   EndpointCategoryPromoSections,
 		"EndpointOrdersArchive":           EndpointOrdersArchive,
 		"EndpointOrdersActive":            EndpointOrdersActive,
 		"EndpointProductDetail":           EndpointProductDetail,
@@ -68,7 +72,7 @@ func TestEndpointFormatStrings(t *testing.T) {
 		{
 			name:     "CartItems with basket ID",
 			endpoint: EndpointCartItems,
-			args:     []interface{}{"basket123"},
+			args:     []interface{}{"basket123", "SK"},
 			wantOK:   true,
 		},
 		{
@@ -98,19 +102,61 @@ func TestEndpointFormatStrings(t *testing.T) {
 		{
 			name:     "ProductDetail with product ID",
 			endpoint: EndpointProductDetail,
-			args:     []interface{}{12345},
+			args:     []interface{}{12345, "SK"},
 			wantOK:   true,
 		},
 		{
 			name:     "ProductAvailabilityUser with user and product ID",
 			endpoint: EndpointProductAvailabilityUser,
-			args:     []interface{}{"user123", 12345},
+			args:     []interface{}{"user123", 12345, "SK"},
 			wantOK:   true,
 		},
 		{
 			name:     "ProductAvailabilityAnon with product ID",
 			endpoint: EndpointProductAvailabilityAnon,
-			args:     []interface{}{12345},
+			args:     []interface{}{12345, "SK"},
+			wantOK:   true,
+		},
+		{
+			name:     "Category with category ID",
+			endpoint: EndpointCategory,
+			args:     []interface{}{18862660},
+			wantOK:   true,
+		},
+		{
+			name:     "CategoryProducts with category ID, offset and limit",
+			endpoint: EndpointCategoryProducts,
+			args:     []interface{}{18862660, 24, 24},
+			wantOK:   true,
+		},
+		{
+			name:     "CategoryTopProducers with category ID and country",
+			endpoint: EndpointCategoryTopProducers,
+			args:     []interface{}{18862660, "SK"},
+			wantOK:   true,
+		},
+		{
+			name:     "CategoryPromoSections with category ID and country",
+			endpoint: EndpointCategoryPromoSections,
+			args:     []interface{}{18862660, "CZ"},
+			wantOK:   true,
+		},
+		{
+			name:     "OrderUpdate with country",
+			endpoint: EndpointOrderUpdate,
+			args:     []interface{}{"CZ"},
+			wantOK:   true,
+		},
+		{
+			name:     "ReviewStats with product ID and country",
+			endpoint: EndpointReviewStats,
+			args:     []interface{}{12345, "HU"},
+			wantOK:   true,
+		},
+		{
+			name:     "Reviews with product ID, country, offset and limit",
+			endpoint: EndpointReviews,
+			args:     []interface{}{12345, "SK", 0, 10},
 			wantOK:   true,
 		},
 	}
@@ -122,6 +168,10 @@ func TestEndpointFormatStrings(t *testing.T) {
 			if strings.Contains(result, "%s") || strings.Contains(result, "%d") {
 				t.Errorf("fmt.Sprintf(%q, %v) = %q, still contains format verbs", tt.endpoint, tt.args, result)
 			}
+			// Should not have missing or extra arguments
+			if strings.Contains(result, "%!") {
+				t.Errorf("fmt.Sprintf(%q, %v) = %q, argument count mismatch", tt.endpoint, tt.args, result)
+			}
 		})
 	}
 }
@@ -169,3 +219,24 @@ func TestEndpointURLPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestEndpointsHaveNoHardcodedCountry(t *testing.T) {
+	endpoints := []string{
+		EndpointCartItems,
+		EndpointOrderUpdate,
+		EndpointProductDetail,
+		EndpointProductAvailabilityUser,
+		EndpointProductAvailabilityAnon,
+		EndpointReviewStats,
+		EndpointReviews,
+	}
+
+	for _, endpoint := range endpoints {
+		if strings.Contains(endpoint, "country=SK") {
+			t.Errorf("endpoint %q hardcodes country=SK", endpoint)
+		}
+		if !strings.Contains(endpoint, "country=%s") {
+			t.Errorf("endpoint %q should take country as a format argument", endpoint)
+		}
+	}
+}
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrAuthRequired = errors.New("auth required")
 	ErrTokenExpired = errors.New("auth token expired or invalid")
+	ErrChallenge    = errors.New("blocked by Cloudflare challenge")
 )
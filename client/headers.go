@@ -12,12 +12,16 @@ const (
 )
 
 func baseHeaders() http.Header {
+	return storefrontHeaders(DefaultStorefront())
+}
+
+func storefrontHeaders(store Storefront) http.Header {
 	return http.Header{
 		"User-Agent":         {userAgent()},
 		"Accept":             {acceptHeader},
-		"Accept-Language":    {"sk-SK"},
-		"Referer":            {"https://www.alza.sk/"},
-		"Origin":             {"https://www.alza.sk"},
+		"Accept-Language":    {store.Language},
+		"Referer":            {store.HomeURL()},
+		"Origin":             {store.BaseURL()},
 		"Sec-Fetch-Dest":     {"empty"},
 		"Sec-Fetch-Mode":     {"cors"},
 		"Sec-Fetch-Site":     {"same-origin"},
@@ -106,3 +106,21 @@ func TestSecCHUA(t *testing.T) {
 		t.Errorf("secCHUA = %q, expected Chrome 120 value", secCHUA)
 	}
 }
+
+func TestStorefrontHeaders(t *testing.T) {
+	cz, err := StorefrontFor("CZ")
+	if err != nil {
+		t.Fatalf("StorefrontFor(CZ) error: %v", err)
+	}
+	headers := storefrontHeaders(cz)
+
+	if got := headers.Get("Accept-Language"); got != "cs-CZ" {
+		t.Errorf("Accept-Language = %q, want cs-CZ", got)
+	}
+	if got := headers.Get("Referer"); got != "https://www.alza.cz/" {
+		t.Errorf("Referer = %q, want https://www.alza.cz/", got)
+	}
+	if got := headers.Get("Origin"); got != "https://www.alza.cz" {
+		t.Errorf("Origin = %q, want https://www.alza.cz", got)
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotJWT is returned by ParseTokenClaims for tokens that aren't three-part JWTs.
+var ErrNotJWT = errors.New("auth token is not a JWT")
+
+// TokenClaims are the registered and Alza-specific claims of an auth token.
+// The signature is not verified: the claims are only used to tell how long the
+// token will keep working, the API remains the authority.
+type TokenClaims struct {
+	Subject   string    `json:"subject,omitempty"`
+	UserID    string    `json:"userId,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	ClientID  string    `json:"clientId,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	IssuedAt  time.Time `json:"issuedAt,omitzero"`
+	NotBefore time.Time `json:"notBefore,omitzero"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// rawClaims mirrors the JWT payload; numeric dates are seconds since the epoch.
+type rawClaims struct {
+	Sub         string          `json:"sub"`
+	Iss         string          `json:"iss"`
+	ClientID    string          `json:"client_id"`
+	Scope       json.RawMessage `json:"scope"`
+	Scp         json.RawMessage `json:"scp"`
+	Iat         json.Number     `json:"iat"`
+	Nbf         json.Number     `json:"nbf"`
+	Exp         json.Number     `json:"exp"`
+	UserIDSnake json.RawMessage `json:"user_id"`
+	UserIDCamel json.RawMessage `json:"userId"`
+	UID         json.RawMessage `json:"uid"`
+}
+
+// ParseTokenClaims decodes the payload of a bearer token ("Bearer eyJ..." or the bare JWT).
+func ParseTokenClaims(token string) (*TokenClaims, error) {
+	token = strings.TrimSpace(token)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrNotJWT
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrNotJWT, err)
+	}
+
+	var raw rawClaims
+	dec := json.NewDecoder(strings.NewReader(string(payload)))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrNotJWT, err)
+	}
+
+	claims := &TokenClaims{
+		Subject:  raw.Sub,
+		Issuer:   raw.Iss,
+		ClientID: raw.ClientID,
+		Scopes:   parseScopes(raw.Scope),
+	}
+	if claims.Scopes == nil {
+		claims.Scopes = parseScopes(raw.Scp)
+	}
+	for _, v := range []json.RawMessage{raw.UserIDSnake, raw.UserIDCamel, raw.UID} {
+		if id := stringOrNumber(v); id != "" {
+			claims.UserID = id
+			break
+		}
+	}
+	if claims.UserID == "" {
+		if _, err := strconv.Atoi(claims.Subject); err == nil {
+			claims.UserID = claims.Subject
+		}
+	}
+	if claims.IssuedAt, err = unixTime(raw.Iat); err != nil {
+		return nil, fmt.Errorf("%w: iat: %v", ErrNotJWT, err)
+	}
+	if claims.NotBefore, err = unixTime(raw.Nbf); err != nil {
+		return nil, fmt.Errorf("%w: nbf: %v", ErrNotJWT, err)
+	}
+	if claims.ExpiresAt, err = unixTime(raw.Exp); err != nil {
+		return nil, fmt.Errorf("%w: exp: %v", ErrNotJWT, err)
+	}
+	return claims, nil
+}
+
+// Remaining returns the lifetime left at now; negative once expired, 0 without exp.
+func (c *TokenClaims) Remaining(now time.Time) time.Duration {
+	if c.ExpiresAt.IsZero() {
+		return 0
+	}
+	return c.ExpiresAt.Sub(now)
+}
+
+// Expired reports whether the token is past its exp claim at now.
+func (c *TokenClaims) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// parseScopes accepts both the space-separated string and the array form.
+func parseScopes(v json.RawMessage) []string {
+	if len(v) == 0 {
+		return nil
+	}
+	var list []string
+	if json.Unmarshal(v, &list) == nil {
+		return list
+	}
+	var s string
+	if json.Unmarshal(v, &s) == nil && s != "" {
+		return strings.Fields(s)
+	}
+	return nil
+}
+
+func stringOrNumber(v json.RawMessage) string {
+	if len(v) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	var n json.Number
+	if json.Unmarshal(v, &n) == nil {
+		return n.String()
+	}
+	return ""
+}
+
+func unixTime(n json.Number) (time.Time, error) {
+	if n == "" {
+		return time.Time{}, nil
+	}
+	secs, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(secs), 0).UTC(), nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testJWT builds an unsigned "Bearer <jwt>" with the given payload claims
+func testJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	enc := base64.RawURLEncoding
+	return "Bearer " + enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(payload) + ".c2ln"
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	token := testJWT(t, map[string]any{
+		"iss":       "https://identity.alza.cz",
+		"sub":       "1234567",
+		"client_id": "alza-web",
+		"scope":     []string{"openid", "profile", "alza_api"},
+		"iat":       1760600000,
+		"nbf":       1760600000,
+		"exp":       1760605400,
+	})
+
+	claims, err := ParseTokenClaims(token)
+	if err != nil {
+		t.Fatalf("ParseTokenClaims() error: %v", err)
+	}
+	if claims.Subject != "1234567" || claims.UserID != "1234567" {
+		t.Errorf("Subject/UserID = %q/%q, want numeric sub as user id", claims.Subject, claims.UserID)
+	}
+	if claims.Issuer != "https://identity.alza.cz" || claims.ClientID != "alza-web" {
+		t.Errorf("Issuer/ClientID = %q/%q", claims.Issuer, claims.ClientID)
+	}
+	if len(claims.Scopes) != 3 || claims.Scopes[2] != "alza_api" {
+		t.Errorf("Scopes = %v", claims.Scopes)
+	}
+	if !claims.IssuedAt.Equal(time.Unix(1760600000, 0)) || !claims.ExpiresAt.Equal(time.Unix(1760605400, 0)) {
+		t.Errorf("IssuedAt/ExpiresAt = %v/%v", claims.IssuedAt, claims.ExpiresAt)
+	}
+	if got := claims.ExpiresAt.Sub(claims.IssuedAt); got != 90*time.Minute {
+		t.Errorf("lifetime = %s, want 90m", got)
+	}
+}
+
+func TestParseTokenClaimsVariants(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     map[string]any
+		wantUserID string
+		wantScopes int
+	}{
+		{"space separated scope", map[string]any{"sub": "abc", "scope": "openid profile"}, "", 2},
+		{"scp array", map[string]any{"scp": []string{"a"}}, "", 1},
+		{"numeric user_id claim", map[string]any{"sub": "guid", "user_id": 42}, "42", 0},
+		{"string userId claim", map[string]any{"userId": "43"}, "43", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseTokenClaims(testJWT(t, tt.claims))
+			if err != nil {
+				t.Fatalf("ParseTokenClaims() error: %v", err)
+			}
+			if claims.UserID != tt.wantUserID || len(claims.Scopes) != tt.wantScopes {
+				t.Errorf("UserID = %q, Scopes = %v", claims.UserID, claims.Scopes)
+			}
+			if !claims.ExpiresAt.IsZero() || claims.Remaining(time.Now()) != 0 || claims.Expired(time.Now()) {
+				t.Error("a token without exp must not look expired")
+			}
+		})
+	}
+}
+
+func TestParseTokenClaimsRejectsNonJWT(t *testing.T) {
+	for _, token := range []string{"", "Bearer test", "Bearer a.b", "Bearer a.!!!.c", "Bearer a." + base64.RawURLEncoding.EncodeToString([]byte("[1]")) + ".c"} {
+		if _, err := ParseTokenClaims(token); !errors.Is(err, ErrNotJWT) {
+			t.Errorf("ParseTokenClaims(%q) error = %v, want ErrNotJWT", token, err)
+		}
+	}
+}
+
+func TestTokenClaimsExpiry(t *testing.T) {
+	exp := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	claims := &TokenClaims{ExpiresAt: exp}
+
+	if got := claims.Remaining(exp.Add(-10 * time.Minute)); got != 10*time.Minute {
+		t.Errorf("Remaining() = %s, want 10m", got)
+	}
+	if claims.Expired(exp.Add(-time.Second)) {
+		t.Error("Expired() before exp = true")
+	}
+	if !claims.Expired(exp) {
+		t.Error("Expired() at exp = false")
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -27,8 +28,14 @@ type ListItemsResponse struct {
 	} `json:"data"`
 }
 
+// GetLists returns all commodity lists of the user
 func (c *TLSClient) GetLists() ([]CommodityList, error) {
-	data, err := c.Get(EndpointCommodityLists)
+	return c.GetListsContext(context.Background())
+}
+
+// GetListsContext is like GetLists but honors ctx
+func (c *TLSClient) GetListsContext(ctx context.Context) ([]CommodityList, error) {
+	data, err := c.GetContext(ctx, EndpointCommodityLists)
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +52,15 @@ func (c *TLSClient) GetLists() ([]CommodityList, error) {
 	return resp.Data, nil
 }
 
+// GetListItems returns items of a commodity list, an empty slice when it has none
 func (c *TLSClient) GetListItems(listID int) ([]ListItem, error) {
+	return c.GetListItemsContext(context.Background(), listID)
+}
+
+// GetListItemsContext is like GetListItems but honors ctx
+func (c *TLSClient) GetListItemsContext(ctx context.Context, listID int) ([]ListItem, error) {
 	endpoint := fmt.Sprintf(EndpointCommodityListItems, listID)
-	data, err := c.Get(endpoint)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +74,7 @@ func (c *TLSClient) GetListItems(listID int) ([]ListItem, error) {
 		return nil, fmt.Errorf("list not found")
 	}
 
-	var items []ListItem
+	items := []ListItem{}
 	for _, item := range resp.Data[0].Items {
 		items = append(items, ListItem{
 			NavigationURL: item.NavigationURL,
@@ -74,8 +87,13 @@ func (c *TLSClient) GetListItems(listID int) ([]ListItem, error) {
 
 // CreateList creates a new commodity list
 func (c *TLSClient) CreateList(name string) (*CommodityList, error) {
+	return c.CreateListContext(context.Background(), name)
+}
+
+// CreateListContext is like CreateList but honors ctx
+func (c *TLSClient) CreateListContext(ctx context.Context, name string) (*CommodityList, error) {
 	body := fmt.Sprintf(`{"name":"%s","type":0}`, name)
-	data, err := c.Post(EndpointCommodityListCreate, body)
+	data, err := c.PostContext(ctx, EndpointCommodityListCreate, body)
 	if err != nil {
 		return nil, err
 	}
@@ -94,23 +112,34 @@ func (c *TLSClient) CreateList(name string) (*CommodityList, error) {
 
 // AddToList adds a product to a specific list
 func (c *TLSClient) AddToList(listID int, productID int) error {
+	return c.AddToListContext(context.Background(), listID, productID)
+}
+
+// AddToListContext is like AddToList but honors ctx
+func (c *TLSClient) AddToListContext(ctx context.Context, listID int, productID int) error {
 	body := fmt.Sprintf(`{"listID":%d,"cId":%d,"path":"","pageType":0}`, listID, productID)
-	_, err := c.Post(EndpointCommodityListAddItem, body)
+	_, err := c.PostContext(ctx, EndpointCommodityListAddItem, body)
 	return err
 }
 
+// AddToFavorites adds a product to the built-in favorites list (type 1)
 func (c *TLSClient) AddToFavorites(productID int) error {
+	return c.AddToFavoritesContext(context.Background(), productID)
+}
+
+// AddToFavoritesContext is like AddToFavorites but honors ctx
+func (c *TLSClient) AddToFavoritesContext(ctx context.Context, productID int) error {
 	if c.userID == "" {
-		_, err := c.GetUserStatus()
+		_, err := c.GetUserStatusContext(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
 	endpoint := fmt.Sprintf(EndpointUserCommodityListItems, c.userID)
-	body := fmt.Sprintf(`{"items":{"%d":1},"listType":1,"country":"SK"}`, productID)
+	body := fmt.Sprintf(`{"items":{"%d":1},"listType":1,"country":"%s"}`, productID, c.storefront().Country)
 
-	data, err := c.Post(endpoint, body)
+	data, err := c.PostContext(ctx, endpoint, body)
 	if err != nil {
 		return err
 	}
@@ -127,8 +156,14 @@ func (c *TLSClient) AddToFavorites(productID int) error {
 	return nil
 }
 
+// RemoveFromList removes a product from a commodity list
 func (c *TLSClient) RemoveFromList(listID int, productID int) error {
+	return c.RemoveFromListContext(context.Background(), listID, productID)
+}
+
+// RemoveFromListContext is like RemoveFromList but honors ctx
+func (c *TLSClient) RemoveFromListContext(ctx context.Context, listID int, productID int) error {
 	body := fmt.Sprintf(`{"id":%d,"productId":%d}`, listID, productID)
-	_, err := c.Post(EndpointCommodityListDelete, body)
+	_, err := c.PostContext(ctx, EndpointCommodityListDelete, body)
 	return err
 }
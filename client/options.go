@@ -0,0 +1,129 @@
+package client
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	http "github.com/bogdanfinn/fhttp"
+)
+
+// Doer sends HTTP requests. tls_client.HttpClient satisfies it; tests can plug
+// in anything that talks to an httptest.Server.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures a TLSClient created by New.
+type Option func(*options)
+
+type options struct {
+	store          Storefront
+	baseURL        string
+	webAPIURL      string
+	doer           Doer
+	tokens         TokenSource
+	refresher      TokenRefresher
+	refreshMargin  time.Duration
+	skipValidation bool
+	retry          RetryPolicy
+	rateLimit      RateLimit
+	recorder       *Recorder
+	replayer       *Replayer
+	debug          bool
+	debugOut       io.Writer
+}
+
+func defaultOptions() options {
+	return options{
+		store:         DefaultStorefront(),
+		retry:         DefaultRetryPolicy(),
+		rateLimit:     DefaultRateLimit(),
+		refreshMargin: DefaultRefreshMargin,
+		debugOut:      os.Stdout,
+	}
+}
+
+// WithStorefront selects the national e-shop (default SK).
+func WithStorefront(store Storefront) Option {
+	return func(o *options) { o.store = store }
+}
+
+// WithBaseURL sends e-shop requests to baseURL (e.g. an httptest.Server URL)
+// instead of the storefront origin. Headers still describe the storefront.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) { o.baseURL = strings.TrimRight(baseURL, "/") }
+}
+
+// WithWebAPIURL redirects calls to webapi.alza.cz (whisper, reviews) to url.
+func WithWebAPIURL(url string) Option {
+	return func(o *options) { o.webAPIURL = strings.TrimRight(url, "/") }
+}
+
+// WithDoer replaces the Chrome-fingerprinted tls-client transport.
+func WithDoer(d Doer) Option {
+	return func(o *options) { o.doer = d }
+}
+
+// WithTokenSource sets where the bearer token comes from (default
+// DefaultTokenStore). Any TokenStore works.
+func WithTokenSource(ts TokenSource) Option {
+	return func(o *options) { o.tokens = ts }
+}
+
+// WithToken is shorthand for WithTokenSource(StaticToken(token)).
+func WithToken(token string) Option {
+	return WithTokenSource(StaticToken(token))
+}
+
+// WithTokenRefresher makes the client fetch a new token from r when a request
+// fails with 401/403 (or validation reports an expired token) and then repeat
+// that request once. If the token source also implements TokenRefresher it is
+// used automatically.
+func WithTokenRefresher(r TokenRefresher) Option {
+	return func(o *options) { o.refresher = r }
+}
+
+// WithRefreshMargin refreshes the token before a request once its JWT exp claim
+// is less than d away, instead of waiting for a 401. d <= 0 disables this.
+func WithRefreshMargin(d time.Duration) Option {
+	return func(o *options) { o.refreshMargin = d }
+}
+
+// WithoutValidation skips the user status round-trip New does by default.
+// User and basket IDs are then fetched lazily by the calls that need them.
+func WithoutValidation() Option {
+	return func(o *options) { o.skipValidation = true }
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) { o.retry = p }
+}
+
+// WithRateLimit overrides DefaultRateLimit; PerSecond <= 0 disables throttling.
+func WithRateLimit(r RateLimit) Option {
+	return func(o *options) { o.rateLimit = r }
+}
+
+// WithRecorder writes every exchange, including token validation, to rec.
+func WithRecorder(rec *Recorder) Option {
+	return func(o *options) { o.recorder = rec }
+}
+
+// WithReplay answers every request from rep; no token or network is needed.
+func WithReplay(rep *Replayer) Option {
+	return func(o *options) { o.replayer = rep }
+}
+
+// WithDebug prints requests and responses to stdout.
+func WithDebug(debug bool) Option {
+	return func(o *options) { o.debug = debug }
+}
+
+// WithDebugOutput sends the WithDebug output to w instead of stdout, e.g. when
+// stdout carries a protocol.
+func WithDebugOutput(w io.Writer) Option {
+	return func(o *options) { o.debugOut = w }
+}
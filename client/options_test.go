@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	fhttp "github.com/bogdanfinn/fhttp"
+)
+
+// newFakeAlza serves the validation endpoints plus a review stats endpoint.
+func newFakeAlza(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			atomic.AddInt32(hits, 1)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer fake" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == EndpointCommodityLists:
+			_, _ = io.WriteString(w, `{"user_id":7,"user_name":"Fake","basket_cnt":0}`)
+		case r.URL.Path == "/api/users/7/statusSummary":
+			_, _ = io.WriteString(w, `{"basketProductsCount":1,"basketPreviewAction":{"href":"https://www.alza.sk/api/basket/55/preview"}}`)
+		case strings.HasSuffix(r.URL.Path, "/reviewStats"):
+			_, _ = io.WriteString(w, `{"ratingAverage":4.2,"ratingCount":10}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewAgainstFakeServer(t *testing.T) {
+	server := newFakeAlza(t, nil)
+
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken("Bearer fake"),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if c.GetUserID() != "7" {
+		t.Errorf("GetUserID() = %q, want 7 from validation", c.GetUserID())
+	}
+
+	stats, err := c.GetReviewStatsContext(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("GetReviewStatsContext() error: %v", err)
+	}
+	if stats.RatingAverage != 4.2 {
+		t.Errorf("RatingAverage = %v, want 4.2", stats.RatingAverage)
+	}
+}
+
+func TestNewValidationFailsWithWrongToken(t *testing.T) {
+	server := newFakeAlza(t, nil)
+
+	_, err := New(context.Background(), WithBaseURL(server.URL), WithToken("Bearer wrong"))
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("New() error = %v, want ErrAuthRequired", err)
+	}
+}
+
+func TestNewWithoutValidation(t *testing.T) {
+	var hits int32
+	server := newFakeAlza(t, &hits)
+
+	c, err := New(context.Background(), WithBaseURL(server.URL), WithToken("Bearer fake"), WithoutValidation())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 0 {
+		t.Errorf("server got %d requests during New, want 0", got)
+	}
+	if c.GetUserID() != "" {
+		t.Errorf("GetUserID() = %q, want empty before first call", c.GetUserID())
+	}
+}
+
+func TestNewReadsTokenFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	withoutKeyring(t)
+
+	_, err := New(context.Background(), WithoutValidation())
+	if err == nil || !strings.Contains(err.Error(), "failed to read auth token") {
+		t.Fatalf("New() without token file error = %v", err)
+	}
+
+	if err := SaveToken("Bearer from-file\n"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(context.Background(), WithoutValidation())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if c.authToken != "Bearer from-file" {
+		t.Errorf("authToken = %q, want trimmed file content", c.authToken)
+	}
+}
+
+func TestFileTokenSourceExplicitPath(t *testing.T) {
+	_, err := FileTokenSource{Path: filepath.Join(t.TempDir(), "missing.txt")}.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("Token() error = %v, want path in message", err)
+	}
+}
+
+func TestStaticTokenEmpty(t *testing.T) {
+	if _, err := StaticToken("").Token(context.Background()); err == nil {
+		t.Error("StaticToken(\"\").Token() expected error")
+	}
+}
+
+type fakeDoer struct {
+	requests []*fhttp.Request
+}
+
+func (d *fakeDoer) Do(req *fhttp.Request) (*fhttp.Response, error) {
+	d.requests = append(d.requests, req)
+	return &fhttp.Response{
+		StatusCode: 200,
+		Header:     fhttp.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"ok":true}`)),
+	}, nil
+}
+
+func TestNewWithDoer(t *testing.T) {
+	doer := &fakeDoer{}
+	store, _ := StorefrontFor("CZ")
+
+	c, err := New(context.Background(), WithDoer(doer), WithToken("Bearer x"), WithStorefront(store), WithoutValidation())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if _, err := c.GetContext(context.Background(), "/api/ping"); err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+
+	if len(doer.requests) != 1 {
+		t.Fatalf("doer got %d requests, want 1", len(doer.requests))
+	}
+	req := doer.requests[0]
+	if req.URL.String() != "https://www.alza.cz/api/ping" {
+		t.Errorf("URL = %q, want CZ storefront origin", req.URL.String())
+	}
+	if req.Header.Get("Authorization") != "Bearer x" {
+		t.Errorf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+}
+
+func TestClientResolveURLOverrides(t *testing.T) {
+	c := &TLSClient{baseURL: "http://127.0.0.1:9000", webAPIURL: "http://127.0.0.1:9001"}
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"/api/x", "http://127.0.0.1:9000/api/x"},
+		{BaseURL + "/api/basket/1/preview", "http://127.0.0.1:9000/api/basket/1/preview"},
+		{WebAPIURL + "/api/catalog", "http://127.0.0.1:9001/api/catalog"},
+		{"https://cdn.alza.cz/desc.htm", "https://cdn.alza.cz/desc.htm"},
+	}
+	for _, tt := range tests {
+		got, err := c.resolveURL(tt.endpoint)
+		if err != nil {
+			t.Fatalf("resolveURL(%q) error: %v", tt.endpoint, err)
+		}
+		if got != tt.want {
+			t.Errorf("resolveURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+
+	plain := &TLSClient{}
+	if got, _ := plain.resolveURL(WebAPIURL + "/api/catalog"); got != WebAPIURL+"/api/catalog" {
+		t.Errorf("resolveURL without overrides = %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -32,9 +33,15 @@ type ordersActiveResponse struct {
 	} `json:"groups"`
 }
 
+// GetOrders returns active orders followed by the most recent archived ones, plus the total count
 func (c *TLSClient) GetOrders(limit int) ([]Order, int, error) {
+	return c.GetOrdersContext(context.Background(), limit)
+}
+
+// GetOrdersContext is like GetOrders but honors ctx
+func (c *TLSClient) GetOrdersContext(ctx context.Context, limit int) ([]Order, int, error) {
 	if c.userID == "" {
-		if _, err := c.GetUserStatus(); err != nil {
+		if _, err := c.GetUserStatusContext(ctx); err != nil {
 			return nil, 0, err
 		}
 	}
@@ -43,7 +50,7 @@ func (c *TLSClient) GetOrders(limit int) ([]Order, int, error) {
 		limit = 10
 	}
 
-	activeOrders, err := c.getActiveOrders()
+	activeOrders, err := c.getActiveOrders(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -58,7 +65,7 @@ func (c *TLSClient) GetOrders(limit int) ([]Order, int, error) {
 		archiveLimit = 1
 	}
 
-	archiveOrders, archiveTotal, err := c.getArchiveOrdersPage(0, archiveLimit)
+	archiveOrders, archiveTotal, err := c.getArchiveOrdersPage(ctx, 0, archiveLimit)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -76,18 +83,24 @@ func (c *TLSClient) GetOrders(limit int) ([]Order, int, error) {
 	return orders, total, nil
 }
 
+// GetArchiveOrdersPage returns one page of archived orders and the archive size
 func (c *TLSClient) GetArchiveOrdersPage(offset, limit int) ([]Order, int, error) {
+	return c.GetArchiveOrdersPageContext(context.Background(), offset, limit)
+}
+
+// GetArchiveOrdersPageContext is like GetArchiveOrdersPage but honors ctx
+func (c *TLSClient) GetArchiveOrdersPageContext(ctx context.Context, offset, limit int) ([]Order, int, error) {
 	if c.userID == "" {
-		if _, err := c.GetUserStatus(); err != nil {
+		if _, err := c.GetUserStatusContext(ctx); err != nil {
 			return nil, 0, err
 		}
 	}
-	return c.getArchiveOrdersPage(offset, limit)
+	return c.getArchiveOrdersPage(ctx, offset, limit)
 }
 
-func (c *TLSClient) getArchiveOrdersPage(offset, limit int) ([]Order, int, error) {
+func (c *TLSClient) getArchiveOrdersPage(ctx context.Context, offset, limit int) ([]Order, int, error) {
 	endpoint := fmt.Sprintf(EndpointOrdersArchive, c.userID, offset, limit)
-	data, err := c.Get(endpoint)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -111,9 +124,9 @@ func (c *TLSClient) getArchiveOrdersPage(offset, limit int) ([]Order, int, error
 	return orders, resp.Paging.Size, nil
 }
 
-func (c *TLSClient) getActiveOrders() ([]Order, error) {
+func (c *TLSClient) getActiveOrders(ctx context.Context) ([]Order, error) {
 	endpoint := fmt.Sprintf(EndpointOrdersActive, c.userID)
-	data, err := c.Get(endpoint)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,12 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html"
 	"strings"
+	"sync"
 
 	xhtml "golang.org/x/net/html"
 )
@@ -132,10 +134,138 @@ type productAvailabilityResponse struct {
 	ExpectedStockDate string `json:"expectedStockDate"`
 }
 
+// ProductOptions selects the sub-requests made for each product on top of the
+// detail call, and how many products GetProducts fetches at once. The zero
+// value fetches the detail only, with DefaultProductWorkers.
+type ProductOptions struct {
+	Availability bool // Availability, AvailabilityDetail, ExpectedStockDate
+	Description  bool // Description, from the description page
+	Reviews      bool // ReviewStats
+	Workers      int  // Products fetched concurrently, DefaultProductWorkers when 0, at most MaxProductWorkers
+}
+
+const (
+	DefaultProductWorkers = 4
+	MaxProductWorkers     = 16
+)
+
+// DefaultProductOptions fetches everything GetProduct does.
+func DefaultProductOptions() ProductOptions {
+	return ProductOptions{Availability: true, Description: true, Reviews: true, Workers: DefaultProductWorkers}
+}
+
+func (o ProductOptions) workers(n int) int {
+	w := o.Workers
+	if w <= 0 {
+		w = DefaultProductWorkers
+	}
+	return min(w, MaxProductWorkers, n)
+}
+
+// ProductResult is one product of a GetProducts or GetProductRefs batch.
+// Either Product or Err is set; ID is 0 when Ref couldn't be resolved.
+type ProductResult struct {
+	Ref     ProductRef
+	ID      int
+	Product *ProductDetail
+	Err     error
+}
+
+// MarshalJSON renders Ref as a string and Err as its message:
+// {"ref":..,"id":..,"product":..} or {"ref":..,"id":..,"error":".."}, without
+// the id when Ref couldn't be resolved
+func (r ProductResult) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Ref     string         `json:"ref"`
+		ID      int            `json:"id,omitempty"`
+		Product *ProductDetail `json:"product,omitempty"`
+		Error   string         `json:"error,omitempty"`
+	}{Ref: r.Ref.String(), ID: r.ID, Product: r.Product}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
 // GetProduct returns rich product info for a commodity ID.
 func (c *TLSClient) GetProduct(productID int) (*ProductDetail, error) {
-	endpoint := fmt.Sprintf(EndpointProductDetail, productID)
-	data, err := c.Get(endpoint)
+	return c.GetProductContext(context.Background(), productID)
+}
+
+// GetProductContext is like GetProduct but honors ctx
+func (c *TLSClient) GetProductContext(ctx context.Context, productID int) (*ProductDetail, error) {
+	return c.getProduct(ctx, productID, DefaultProductOptions(), true)
+}
+
+// GetProducts fetches many products with a bounded worker pool. Results come
+// back in the order of ids; a product that fails carries its error in Err and
+// doesn't stop the others. Every request still waits for the client's rate
+// limiter, so Workers bounds the requests in flight, not the request rate.
+func (c *TLSClient) GetProducts(ids []int, opts ProductOptions) []ProductResult {
+	return c.GetProductsContext(context.Background(), ids, opts)
+}
+
+// GetProductsContext is like GetProducts but honors ctx; products not fetched
+// before ctx is done fail with ctx's error.
+func (c *TLSClient) GetProductsContext(ctx context.Context, ids []int, opts ProductOptions) []ProductResult {
+	refs := make([]ProductRef, len(ids))
+	for i, id := range ids {
+		refs[i] = ProductRef{ID: id}
+	}
+	return c.GetProductRefsContext(ctx, refs, opts)
+}
+
+// GetProductRefs is like GetProducts for product references: each worker
+// resolves its reference (see ResolveProduct) before fetching the product, so
+// the code and barcode searches run in the pool too. A reference that can't be
+// resolved fails on its own, with ID 0.
+func (c *TLSClient) GetProductRefs(refs []ProductRef, opts ProductOptions) []ProductResult {
+	return c.GetProductRefsContext(context.Background(), refs, opts)
+}
+
+// GetProductRefsContext is like GetProductRefs but honors ctx
+func (c *TLSClient) GetProductRefsContext(ctx context.Context, refs []ProductRef, opts ProductOptions) []ProductResult {
+	results := make([]ProductResult, len(refs))
+	if len(refs) == 0 {
+		return results
+	}
+	// Availability needs the user ID; look it up once here instead of racing
+	// on it in every worker
+	if opts.Availability && c.userID == "" {
+		_, _ = c.GetUserStatusContext(ctx)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range opts.workers(len(refs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = ProductResult{Ref: refs[i]}
+				id, err := c.ResolveProductContext(ctx, refs[i])
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].ID = id
+				results[i].Product, results[i].Err = c.getProduct(ctx, id, opts, false)
+			}
+		}()
+	}
+	for i := range refs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// getProduct fetches the detail plus the sub-requests opts asks for. lookupUser
+// lets the availability call resolve the user ID on demand.
+func (c *TLSClient) getProduct(ctx context.Context, productID int, opts ProductOptions, lookupUser bool) (*ProductDetail, error) {
+	endpoint := fmt.Sprintf(EndpointProductDetail, productID, c.storefront().Country)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -160,40 +290,67 @@ func (c *TLSClient) GetProduct(productID int) (*ProductDetail, error) {
 		PromoPrices:         pickPromoPrices(resp.Data),
 	}
 
-	if resp.Data.DescPageURL != "" {
+	if opts.Description && resp.Data.DescPageURL != "" {
 		descURL := normalizeExternalURL(resp.Data.DescPageURL)
-		if descHTML, err := c.Get(descURL); err == nil {
+		if descHTML, err := c.GetContext(ctx, descURL); err == nil {
 			detail.Description = extractDescriptionFromHTML(string(descHTML))
 		}
 	}
 
-	if availability, err := c.getProductAvailability(productID); err == nil {
-		detail.Availability = availability.Title
-		detail.AvailabilityDetail = availability.Description
-		detail.ExpectedStockDate = availability.ExpectedStockDate
+	if opts.Availability {
+		if availability, err := c.getProductAvailability(ctx, productID, lookupUser); err == nil {
+			detail.Availability = availability.Title
+			detail.AvailabilityDetail = availability.Description
+			detail.ExpectedStockDate = availability.ExpectedStockDate
+		}
 	}
 
 	// Fetch review stats (non-blocking, ignore errors)
-	if reviewStats, err := c.GetReviewStats(productID); err == nil {
-		detail.ReviewStats = reviewStats
+	if opts.Reviews {
+		if reviewStats, err := c.GetReviewStatsContext(ctx, productID); err == nil {
+			detail.ReviewStats = reviewStats
+		}
+	}
+
+	// Sub-fetches above are best-effort; don't hand back a half-filled detail after cancellation
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	return &detail, nil
 }
 
-func (c *TLSClient) getProductAvailability(productID int) (*productAvailabilityResponse, error) {
-	if c.userID == "" {
-		_, _ = c.GetUserStatus()
+// inStockPrefixes start the availability titles of products that ship from
+// stock, per storefront language ("Skladom 2 ks", "Skladem > 5 ks", ...).
+// Search results word it as "Na sklade > 10 ks".
+var inStockPrefixes = []string{"skladom", "skladem", "na sklade", "na skladě", "raktáron", "auf lager", "lagernd"}
+
+// InStock reports whether an availability title (ProductDetail.Availability)
+// means the product can be bought now. "Na objednávku", "Vypredané" and an
+// empty title (availability unknown) are not in stock.
+func InStock(availability string) bool {
+	title := strings.ToLower(strings.TrimSpace(availability))
+	for _, prefix := range inStockPrefixes {
+		if strings.HasPrefix(title, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *TLSClient) getProductAvailability(ctx context.Context, productID int, lookupUser bool) (*productAvailabilityResponse, error) {
+	if lookupUser && c.userID == "" {
+		_, _ = c.GetUserStatusContext(ctx)
 	}
 
 	var endpoint string
 	if c.userID != "" {
-		endpoint = fmt.Sprintf(EndpointProductAvailabilityUser, c.userID, productID)
+		endpoint = fmt.Sprintf(EndpointProductAvailabilityUser, c.userID, productID, c.storefront().Country)
 	} else {
-		endpoint = fmt.Sprintf(EndpointProductAvailabilityAnon, productID)
+		endpoint = fmt.Sprintf(EndpointProductAvailabilityAnon, productID, c.storefront().Country)
 	}
 
-	data, err := c.Get(endpoint)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
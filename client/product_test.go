@@ -523,3 +523,27 @@ func TestExtractDescriptionFromHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestInStock(t *testing.T) {
+	tests := []struct {
+		availability string
+		want         bool
+	}{
+		{"Skladom > 5 ks", true},
+		{"Skladom u dodávateľa", true},
+		{"Skladem 2 ks", true},
+		{"Raktáron > 5 db", true},
+		{"Auf Lager", true},
+		{"Na sklade > 10 ks", true},
+		{"  skladom 1 ks", true},
+		{"Na objednávku", false},
+		{"Vypredané", false},
+		{"Očakávame 12.3.", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := InStock(tt.availability); got != tt.want {
+			t.Errorf("InStock(%q) = %v, want %v", tt.availability, got, tt.want)
+		}
+	}
+}
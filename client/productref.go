@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProductRef identifies a product the way users come across one: by Alza
+// product ID, product page URL, Alza item code ("GYMB0105") or EAN/GTIN
+// barcode. IDs and URLs carry the ID; codes and barcodes are looked up with
+// ResolveProduct. ProductRef implements encoding.TextUnmarshaler, so it can
+// be used directly as a kong argument.
+type ProductRef struct {
+	ID   int    // Known product ID
+	Code string // Alza item code, resolved via search
+	GTIN string // EAN-8/UPC-A/EAN-13/GTIN-14 barcode, resolved via search
+}
+
+var productCodeRe = regexp.MustCompile(`^[A-Za-z0-9]{4,20}$`)
+
+// ParseProductRef accepts a product ID ("7816725"), an Alza product URL
+// ("https://www.alza.sk/gymbeam-kreatin-d7816725.htm"), an Alza item code
+// ("GYMB0105") or a barcode ("8586022211126"). Numbers of up to 11 digits are
+// IDs and longer ones barcodes; "ean:", "code:" and "id:" prefixes force the
+// kind, e.g. ean:96385074 for an EAN-8.
+func ParseProductRef(s string) (ProductRef, error) {
+	ref := strings.TrimSpace(s)
+	if ref == "" {
+		return ProductRef{}, errors.New("empty product reference")
+	}
+	if kind, value, ok := strings.Cut(ref, ":"); ok && !strings.Contains(value, "//") {
+		switch strings.ToLower(kind) {
+		case "id":
+			return parseProductID(value)
+		case "ean", "gtin", "upc":
+			return parseGTIN(value)
+		case "code":
+			if !productCodeRe.MatchString(value) {
+				return ProductRef{}, fmt.Errorf("invalid Alza code %q", value)
+			}
+			return ProductRef{Code: value}, nil
+		}
+	}
+
+	switch {
+	case isDigits(ref) && len(ref) >= 12:
+		return parseGTIN(ref)
+	case isDigits(ref):
+		return parseProductID(ref)
+	case strings.Contains(ref, "/") || strings.Contains(ref, "."):
+		id := extractProductID(ref)
+		if id == 0 {
+			return ProductRef{}, fmt.Errorf("no product ID in %q (want a product page URL ending in -d<ID>.htm)", ref)
+		}
+		return ProductRef{ID: id}, nil
+	case productCodeRe.MatchString(ref) && !isDigits(ref):
+		return ProductRef{Code: ref}, nil
+	}
+	return ProductRef{}, fmt.Errorf("invalid product %q (want an ID, product URL, Alza code or EAN)", ref)
+}
+
+func parseProductID(s string) (ProductRef, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return ProductRef{}, fmt.Errorf("invalid product ID %q", s)
+	}
+	return ProductRef{ID: id}, nil
+}
+
+func parseGTIN(s string) (ProductRef, error) {
+	switch {
+	case !isDigits(s) || (len(s) != 8 && len(s) != 12 && len(s) != 13 && len(s) != 14):
+		return ProductRef{}, fmt.Errorf("invalid barcode %q (want 8, 12, 13 or 14 digits)", s)
+	case !validGTIN(s):
+		return ProductRef{}, fmt.Errorf("invalid barcode %q: wrong check digit", s)
+	}
+	return ProductRef{GTIN: s}, nil
+}
+
+// validGTIN checks the GS1 mod-10 check digit: from the right, the digits
+// before it are weighted 3, 1, 3, ...
+func validGTIN(s string) bool {
+	sum := 0
+	for i := len(s) - 2; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if (len(s)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(s[len(s)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with ParseProductRef.
+func (r *ProductRef) UnmarshalText(text []byte) error {
+	ref, err := ParseProductRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+func (r ProductRef) String() string {
+	switch {
+	case r.ID > 0:
+		return strconv.Itoa(r.ID)
+	case r.Code != "":
+		return "code " + r.Code
+	case r.GTIN != "":
+		return "EAN " + r.GTIN
+	}
+	return ""
+}
+
+// ResolveProduct returns the product ID ref stands for, searching for codes and barcodes
+func (c *TLSClient) ResolveProduct(ref ProductRef) (int, error) {
+	return c.ResolveProductContext(context.Background(), ref)
+}
+
+// ResolveProductContext is like ResolveProduct but honors ctx
+func (c *TLSClient) ResolveProductContext(ctx context.Context, ref ProductRef) (int, error) {
+	switch {
+	case ref.ID > 0:
+		return ref.ID, nil
+	case ref.Code != "":
+		results, err := c.SearchContext(ctx, ref.Code, 50)
+		if err != nil {
+			return 0, fmt.Errorf("look up Alza code %s: %w", ref.Code, err)
+		}
+		for _, r := range results {
+			if strings.EqualFold(r.Code, ref.Code) && r.ID > 0 {
+				return r.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("no product with Alza code %s", ref.Code)
+	case ref.GTIN != "":
+		results, err := c.SearchContext(ctx, ref.GTIN, 5)
+		if err != nil {
+			return 0, fmt.Errorf("look up EAN %s: %w", ref.GTIN, err)
+		}
+		switch len(results) {
+		case 0:
+			return 0, fmt.Errorf("no product with EAN %s", ref.GTIN)
+		case 1:
+			if results[0].ID > 0 {
+				return results[0].ID, nil
+			}
+			return 0, fmt.Errorf("no product with EAN %s", ref.GTIN)
+		}
+		names := make([]string, 0, len(results))
+		for _, r := range results {
+			names = append(names, fmt.Sprintf("[%d] %s", r.ID, r.Name))
+		}
+		return 0, fmt.Errorf("EAN %s matches %d products: %s", ref.GTIN, len(results), strings.Join(names, "; "))
+	}
+	return 0, errors.New("empty product reference")
+}
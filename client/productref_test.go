@@ -0,0 +1,73 @@
+package client
+
+import "testing"
+
+func TestParseProductRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ProductRef
+	}{
+		{"7816725", ProductRef{ID: 7816725}},
+		{" 12345678 ", ProductRef{ID: 12345678}},
+		{"https://www.alza.sk/gymbeam-kreatin-monohydrat-500-g-d7816725.htm", ProductRef{ID: 7816725}},
+		{"https://www.alza.cz/sport/amix-creatine-d5275186.htm?o=2#reviews", ProductRef{ID: 5275186}},
+		{"www.alza.sk/iphone-15-d8123456.htm", ProductRef{ID: 8123456}},
+		{"GYMB0105", ProductRef{Code: "GYMB0105"}},
+		{"SPTgym363", ProductRef{Code: "SPTgym363"}},
+		{"8586022211126", ProductRef{GTIN: "8586022211126"}},
+		{"0194253780465", ProductRef{GTIN: "0194253780465"}},
+		{"194253780465", ProductRef{GTIN: "194253780465"}},
+		{"ean:96385074", ProductRef{GTIN: "96385074"}},
+		{"code:1234567", ProductRef{Code: "1234567"}},
+		{"id:8586022211126", ProductRef{ID: 8586022211126}},
+	}
+	for _, tt := range tests {
+		got, err := ParseProductRef(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseProductRef(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"0",
+		"8586022211127", // Wrong check digit
+		"ean:123",
+		"https://www.alza.sk/sport/kreatin/18862660.htm", // Category, not a product
+		"kreatín 500 g",
+		"abc",
+	} {
+		if got, err := ParseProductRef(in); err == nil {
+			t.Errorf("ParseProductRef(%q) = %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestProductRefUnmarshalTextAndString(t *testing.T) {
+	var ref ProductRef
+	if err := ref.UnmarshalText([]byte("GYMB0105")); err != nil || ref.String() != "code GYMB0105" {
+		t.Errorf("UnmarshalText(code) = %+v, %v", ref, err)
+	}
+	if err := ref.UnmarshalText([]byte("nope nope")); err == nil || ref.Code != "GYMB0105" {
+		t.Errorf("failed UnmarshalText() = %v and changed the ref to %+v", err, ref)
+	}
+	if s := (ProductRef{ID: 7816725}).String(); s != "7816725" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := (ProductRef{GTIN: "8586022211126"}).String(); s != "EAN 8586022211126" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestValidGTIN(t *testing.T) {
+	for _, s := range []string{"96385074", "036000291452", "4006381333931", "10614141000415"} {
+		if !validGTIN(s) {
+			t.Errorf("validGTIN(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"96385075", "036000291453", "4006381333932"} {
+		if validGTIN(s) {
+			t.Errorf("validGTIN(%q) = true", s)
+		}
+	}
+}
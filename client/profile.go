@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfileName is the profile that lives directly in ConfigDir, where
+// single-account setups keep their files.
+const DefaultProfileName = "default"
+
+// Profile is one Alza account. Its directory has the same layout as ConfigDir
+// (auth token, quickbuy.env, config.env, session cookies); named profiles live
+// in ConfigDir/profiles/<name>.
+type Profile struct {
+	Name string
+	Dir  string
+}
+
+// ProfileSettings are the per-account keys of a profile's config.env.
+type ProfileSettings struct {
+	Country       string `json:"country,omitempty"`       // ALZA_COUNTRY
+	FavoritesList string `json:"favoritesList,omitempty"` // ALZA_FAVORITES_LIST
+	ChromeProfile string `json:"chromeProfile,omitempty"` // ALZA_CHROME_PROFILE, browser profile for token refresh
+}
+
+// ErrProfileNotFound is returned for a named profile without a directory.
+var ErrProfileNotFound = errors.New("profile not found")
+
+var profileNameRE = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+// ProfilesDir returns ~/.config/alza/profiles.
+func ProfilesDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles"), nil
+}
+
+// currentProfilePath returns the file `alza profile use` writes.
+func currentProfilePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "current_profile"), nil
+}
+
+// ValidateProfileName rejects names that can't be a directory name.
+func ValidateProfileName(name string) error {
+	if !profileNameRE.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// LoadProfile returns the named profile; "" means the current one
+// (CurrentProfileName). Named profiles must exist.
+func LoadProfile(name string) (Profile, error) {
+	if name == "" {
+		var err error
+		if name, err = CurrentProfileName(); err != nil {
+			return Profile{}, err
+		}
+	}
+	if name == DefaultProfileName {
+		dir, err := ConfigDir()
+		if err != nil {
+			return Profile{}, err
+		}
+		return Profile{Name: name, Dir: dir}, nil
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return Profile{}, err
+	}
+
+	root, err := ProfilesDir()
+	if err != nil {
+		return Profile{}, err
+	}
+	p := Profile{Name: name, Dir: filepath.Join(root, name)}
+	if info, err := os.Stat(p.Dir); err != nil || !info.IsDir() {
+		return Profile{}, fmt.Errorf("%w: %s (create it with `alza profile add %s`)", ErrProfileNotFound, name, name)
+	}
+	return p, nil
+}
+
+// CurrentProfileName returns the profile selected by `alza profile use`, or
+// DefaultProfileName.
+func CurrentProfileName() (string, error) {
+	path, err := currentProfilePath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultProfileName, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if name := strings.TrimSpace(string(data)); name != "" {
+		return name, nil
+	}
+	return DefaultProfileName, nil
+}
+
+// SetCurrentProfile makes name the profile used without --profile.
+func SetCurrentProfile(name string) error {
+	if _, err := LoadProfile(name); err != nil {
+		return err
+	}
+	path, err := currentProfilePath()
+	if err != nil {
+		return err
+	}
+	if name == DefaultProfileName {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return WritePrivateFile(path, []byte(name+"\n"))
+}
+
+// ListProfiles returns the default profile followed by the named ones, sorted.
+func ListProfiles() ([]Profile, error) {
+	def, err := LoadProfile(DefaultProfileName)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []Profile{def}
+
+	root, err := ProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && ValidateProfileName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profiles = append(profiles, Profile{Name: name, Dir: filepath.Join(root, name)})
+	}
+	return profiles, nil
+}
+
+// CreateProfile makes a new named profile with settings in its config.env.
+func CreateProfile(name string, settings ProfileSettings) (Profile, error) {
+	if name == DefaultProfileName {
+		return Profile{}, fmt.Errorf("profile %q always exists", name)
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return Profile{}, err
+	}
+	if settings.Country != "" {
+		if _, err := StorefrontFor(settings.Country); err != nil {
+			return Profile{}, err
+		}
+		settings.Country = strings.ToUpper(settings.Country)
+	}
+
+	root, err := ProfilesDir()
+	if err != nil {
+		return Profile{}, err
+	}
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return Profile{}, err
+	}
+	p := Profile{Name: name, Dir: filepath.Join(root, name)}
+	if err := os.Mkdir(p.Dir, 0o700); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return Profile{}, fmt.Errorf("profile %q already exists", name)
+		}
+		return Profile{}, err
+	}
+
+	var b strings.Builder
+	for _, kv := range [][2]string{
+		{"ALZA_COUNTRY", settings.Country},
+		{"ALZA_FAVORITES_LIST", settings.FavoritesList},
+		{"ALZA_CHROME_PROFILE", settings.ChromeProfile},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+		}
+	}
+	if b.Len() > 0 {
+		if err := WritePrivateFile(p.SettingsPath(), []byte(b.String())); err != nil {
+			return Profile{}, err
+		}
+	}
+	return p, nil
+}
+
+// RemoveProfile deletes a named profile with its token (including the keyring
+// entry) and falls back to the default profile if it was the current one.
+func RemoveProfile(ctx context.Context, name string) error {
+	if name == DefaultProfileName {
+		return fmt.Errorf("profile %q can't be removed", name)
+	}
+	p, err := LoadProfile(name)
+	if err != nil {
+		return err
+	}
+	if secretServiceAvailable() {
+		if err := p.keyring().Delete(ctx); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(p.Dir); err != nil {
+		return err
+	}
+	if current, err := CurrentProfileName(); err == nil && current == name {
+		return SetCurrentProfile(DefaultProfileName)
+	}
+	return nil
+}
+
+// TokenPath returns the profile's auth_token.txt.
+func (p Profile) TokenPath() string { return filepath.Join(p.Dir, "auth_token.txt") }
+
+// EncryptedTokenPath returns the profile's auth_token.enc.
+func (p Profile) EncryptedTokenPath() string { return filepath.Join(p.Dir, "auth_token.enc") }
+
+// QuickbuyEnvPath returns the profile's quickbuy.env.
+func (p Profile) QuickbuyEnvPath() string { return filepath.Join(p.Dir, "quickbuy.env") }
+
+// SettingsPath returns the profile's config.env.
+func (p Profile) SettingsPath() string { return filepath.Join(p.Dir, "config.env") }
+
+// CookieJarPath returns the profile's session_cookies.txt.
+func (p Profile) CookieJarPath() string { return filepath.Join(p.Dir, "session_cookies.txt") }
+
+// WatchesPath returns the profile's watches.json (alza watch).
+func (p Profile) WatchesPath() string { return filepath.Join(p.Dir, "watches.json") }
+
+// PriceHistoryPath returns the profile's price_history.jsonl (alza watch run).
+func (p Profile) PriceHistoryPath() string { return filepath.Join(p.Dir, "price_history.jsonl") }
+
+// NotifyConfigPath returns the profile's notify.json (notification sinks and routes).
+func (p Profile) NotifyConfigPath() string { return filepath.Join(p.Dir, "notify.json") }
+
+// OrderStatusPath returns the profile's order_status.json (alza orders --track).
+func (p Profile) OrderStatusPath() string { return filepath.Join(p.Dir, "order_status.json") }
+
+// Settings reads the profile's config.env. A missing file means no settings.
+func (p Profile) Settings() (ProfileSettings, error) {
+	data, err := readEnvFile(p.SettingsPath())
+	if err != nil {
+		return ProfileSettings{}, err
+	}
+	return ProfileSettings{
+		Country:       strings.ToUpper(strings.TrimSpace(data["ALZA_COUNTRY"])),
+		FavoritesList: strings.TrimSpace(data["ALZA_FAVORITES_LIST"]),
+		ChromeProfile: strings.TrimSpace(data["ALZA_CHROME_PROFILE"]),
+	}, nil
+}
+
+// TokenStore returns the profile's token store of the given kind. Each
+// profile has its own files and keyring entry.
+func (p Profile) TokenStore(kind TokenStoreKind) (TokenStore, error) {
+	return openTokenStore(kind,
+		p.keyring(),
+		EncryptedFileTokenStore{Path: p.EncryptedTokenPath()},
+		PlaintextTokenStore{Path: p.TokenPath()},
+	)
+}
+
+func (p Profile) keyring() KeyringTokenStore {
+	if p.Name == DefaultProfileName || p.Name == "" {
+		return KeyringTokenStore{}
+	}
+	return KeyringTokenStore{Account: "auth-token@" + p.Name}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProfileDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	p, err := LoadProfile("")
+	if err != nil {
+		t.Fatalf("LoadProfile(\"\") error: %v", err)
+	}
+	if p.Name != DefaultProfileName || p.Dir != filepath.Join(home, ".config", "alza") {
+		t.Errorf("LoadProfile(\"\") = %+v", p)
+	}
+	// The default profile keeps the single-account layout
+	if want, _ := TokenPath(); p.TokenPath() != want {
+		t.Errorf("TokenPath() = %q, want %q", p.TokenPath(), want)
+	}
+	if want, _ := QuickbuyEnvPath(); p.QuickbuyEnvPath() != want {
+		t.Errorf("QuickbuyEnvPath() = %q, want %q", p.QuickbuyEnvPath(), want)
+	}
+
+	if _, err := LoadProfile("work"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("LoadProfile(work) error = %v, want ErrProfileNotFound", err)
+	}
+	for _, bad := range []string{"../etc", ".hidden", "a/b", "with space"} {
+		if _, err := LoadProfile(bad); err == nil || errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("LoadProfile(%q) error = %v, want invalid name", bad, err)
+		}
+	}
+}
+
+func TestProfileLifecycle(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	withoutKeyring(t)
+
+	p, err := CreateProfile("firma", ProfileSettings{Country: "cz", FavoritesList: "FIRMA", ChromeProfile: "Profile 2"})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if p.Dir != filepath.Join(home, ".config", "alza", "profiles", "firma") {
+		t.Errorf("Dir = %q", p.Dir)
+	}
+	if info, err := os.Stat(p.Dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("profile dir = %v, %v; want 0700", info, err)
+	}
+	settings, err := p.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings != (ProfileSettings{Country: "CZ", FavoritesList: "FIRMA", ChromeProfile: "Profile 2"}) {
+		t.Errorf("Settings() = %+v", settings)
+	}
+	if country, err := CountryFromEnvFile(p.SettingsPath()); err != nil || country != "CZ" {
+		t.Errorf("CountryFromEnvFile() = %q, %v", country, err)
+	}
+
+	if _, err := CreateProfile("firma", ProfileSettings{}); err == nil {
+		t.Error("CreateProfile() twice expected error")
+	}
+	if _, err := CreateProfile("default", ProfileSettings{}); err == nil {
+		t.Error("CreateProfile(default) expected error")
+	}
+	if _, err := CreateProfile("bad", ProfileSettings{Country: "PL"}); err == nil {
+		t.Error("CreateProfile() with unknown country expected error")
+	}
+	if _, err := CreateProfile("osobny", ProfileSettings{}); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != "default" || names[1] != "firma" || names[2] != "osobny" {
+		t.Errorf("ListProfiles() = %v", names)
+	}
+
+	if err := SetCurrentProfile("firma"); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := LoadProfile(""); current.Name != "firma" {
+		t.Errorf("current profile = %q, want firma", current.Name)
+	}
+	if err := SetCurrentProfile("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("SetCurrentProfile(missing) error = %v", err)
+	}
+
+	if err := RemoveProfile(context.Background(), "firma"); err != nil {
+		t.Fatalf("RemoveProfile() error: %v", err)
+	}
+	if _, err := os.Stat(p.Dir); err == nil {
+		t.Error("profile dir still exists")
+	}
+	// Removing the current profile switches back to the default one
+	if name, _ := CurrentProfileName(); name != DefaultProfileName {
+		t.Errorf("CurrentProfileName() = %q after removing it", name)
+	}
+	if err := RemoveProfile(context.Background(), "default"); err == nil {
+		t.Error("RemoveProfile(default) expected error")
+	}
+}
+
+func TestProfileTokenStoresAreSeparate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secrets := fakeKeyring(t)
+
+	work, err := CreateProfile("work", ProfileSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, _ := LoadProfile(DefaultProfileName)
+
+	for _, tc := range []struct {
+		p     Profile
+		token string
+	}{{def, "Bearer personal"}, {work, "Bearer company"}} {
+		store, err := tc.p.TokenStore(TokenStoreAuto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Save(context.Background(), tc.token); err != nil {
+			t.Fatalf("%s: Save() error: %v", tc.p.Name, err)
+		}
+	}
+	if secrets["service alza-cli account auth-token"] != "Bearer personal" || secrets["service alza-cli account auth-token@work"] != "Bearer company" {
+		t.Errorf("keyring = %v", secrets)
+	}
+
+	plain, _ := work.TokenStore(TokenStorePlaintext)
+	if plain.String() != filepath.Join(work.Dir, "auth_token.txt") {
+		t.Errorf("plaintext store = %s", plain)
+	}
+
+	if err := RemoveProfile(context.Background(), "work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := secrets["service alza-cli account auth-token@work"]; ok {
+		t.Error("keyring entry of the removed profile is still there")
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -144,6 +145,12 @@ func (c QuickBuyConfig) WithDefaults(defaults QuickBuyConfig) QuickBuyConfig {
 
 // QuickBuy performs fast order for a single product
 func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig) (*QuickBuyResult, error) {
+	return c.QuickBuyContext(context.Background(), productID, quantity, config)
+}
+
+// QuickBuyContext is like QuickBuy but honors ctx. Once FastOrderSend succeeded the
+// payment step runs detached from ctx so a late cancel can't leave an unpaid order.
+func (c *TLSClient) QuickBuyContext(ctx context.Context, productID int, quantity int, config QuickBuyConfig) (*QuickBuyResult, error) {
 	// Dry-run mode - simulate without actually ordering
 	if config.DryRun {
 		return &QuickBuyResult{
@@ -192,9 +199,9 @@ func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig)
 
 	// Step 1: FastOrderSave
 	if c.debug {
-		fmt.Println("[DEBUG] Step 1: FastOrderSave")
+		fmt.Fprintln(c.debugOut, "[DEBUG] Step 1: FastOrderSave")
 	}
-	saveResp, err := c.Post(EndpointFastOrderSave, string(bodyJSON))
+	saveResp, err := c.PostContext(ctx, EndpointFastOrderSave, string(bodyJSON))
 	if err != nil {
 		return nil, fmt.Errorf("FastOrderSave failed: %w", err)
 	}
@@ -244,9 +251,9 @@ func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig)
 
 	// Step 2: FastOrderSend
 	if c.debug {
-		fmt.Println("[DEBUG] Step 2: FastOrderSend")
+		fmt.Fprintln(c.debugOut, "[DEBUG] Step 2: FastOrderSend")
 	}
-	sendResp, err := c.Post(EndpointFastOrderSend, string(bodyJSON))
+	sendResp, err := c.PostContext(ctx, EndpointFastOrderSend, string(bodyJSON))
 	if err != nil {
 		return nil, fmt.Errorf("FastOrderSend failed: %w", err)
 	}
@@ -278,7 +285,7 @@ func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig)
 
 	// Step 3: Process payment (Adyen recurrent)
 	if c.debug {
-		fmt.Println("[DEBUG] Step 3: Payment processing")
+		fmt.Fprintln(c.debugOut, "[DEBUG] Step 3: Payment processing")
 	}
 	paymentBody := paymentRequest{
 		Browser: paymentBrowserInfo{
@@ -287,7 +294,7 @@ func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig)
 			ColorDepth:        30,
 			UserAgent:         userAgent(),
 			TimeZoneOffset:    -60,
-			Language:          "sk-SK",
+			Language:          c.storefront().Language,
 			JavaEnabled:       false,
 			DeviceFingerprint: config.VisitorID,
 		},
@@ -299,11 +306,11 @@ func (c *TLSClient) QuickBuy(productID int, quantity int, config QuickBuyConfig)
 	}
 	paymentJSON, _ := json.Marshal(paymentBody)
 
-	_, err = c.Post(EndpointPaymentRepeat, string(paymentJSON))
+	_, err = c.PostContext(context.WithoutCancel(ctx), EndpointPaymentRepeat, string(paymentJSON))
 	if err != nil {
 		// Payment might still succeed, check order status
 		if c.debug {
-			fmt.Printf("[DEBUG] Payment request returned error (may still succeed): %v\n", err)
+			fmt.Fprintf(c.debugOut, "[DEBUG] Payment request returned error (may still succeed): %v\n", err)
 		}
 	}
 
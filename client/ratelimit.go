@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit configures the client-side token bucket applied to every request.
+// Each host (www.alza.sk, webapi.alza.cz, ...) gets its own bucket.
+type RateLimit struct {
+	PerSecond float64 // Sustained requests per second per host; <= 0 disables limiting
+	Burst     int     // Requests allowed back-to-back before throttling kicks in (min 1)
+	StateDir  string  // If set, buckets live in files under this dir, shared by all alza processes
+}
+
+// DefaultRateLimit follows the 1-2 req/s recommendation from docs/API-SPEC.md.
+func DefaultRateLimit() RateLimit {
+	return RateLimit{PerSecond: 2, Burst: 4}
+}
+
+// RateLimitDir returns ~/.config/alza/ratelimit, the default shared state dir.
+func RateLimitDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ratelimit"), nil
+}
+
+func (r RateLimit) burst() float64 {
+	if r.Burst < 1 {
+		return 1
+	}
+	return float64(r.Burst)
+}
+
+// bucket is the persisted token bucket state for one host.
+type bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// reserve takes one token and returns how long the caller must wait before sending.
+// Tokens may go negative so concurrent callers queue up instead of all waking at once.
+func (b *bucket) reserve(now time.Time, cfg RateLimit) time.Duration {
+	burst := cfg.burst()
+	if b.Updated.IsZero() {
+		b.Tokens = burst
+		b.Updated = now
+	} else if now.After(b.Updated) {
+		b.Tokens = min(burst, b.Tokens+now.Sub(b.Updated).Seconds()*cfg.PerSecond)
+		b.Updated = now
+	}
+
+	b.Tokens--
+	if b.Tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.Tokens / cfg.PerSecond * float64(time.Second))
+}
+
+type rateLimiter struct {
+	cfg     RateLimit
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newRateLimiter(cfg RateLimit) *rateLimiter {
+	if cfg.PerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Wait blocks until a request to host may be sent or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, l.reserve(host))
+}
+
+func (l *rateLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.cfg.StateDir != "" {
+		wait, err := reserveShared(l.cfg.StateDir, host, now, l.cfg)
+		if err == nil {
+			return wait
+		}
+		// Shared state is best effort; fall back to the in-process bucket
+	}
+
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{}
+		l.buckets[host] = b
+	}
+	return b.reserve(now, l.cfg)
+}
+
+var errLockUnsupported = errors.New("file locking not supported on this platform")
+
+// reserveShared updates the host's bucket file under an exclusive lock so that
+// concurrent alza invocations draw from one budget.
+func reserveShared(dir, host string, now time.Time, cfg RateLimit) (time.Duration, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, bucketFileName(host)), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return 0, err
+	}
+	defer unlockFile(f)
+
+	var b bucket
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > 0 && json.Unmarshal(data, &b) != nil {
+		b = bucket{} // corrupt state, start over with a full bucket
+	}
+
+	wait := b.reserve(now, cfg)
+
+	data, err = json.Marshal(b)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return 0, err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return 0, err
+	}
+	return wait, nil
+}
+
+func bucketFileName(host string) string {
+	r := strings.NewReplacer(":", "_", "/", "_", "\\", "_")
+	return r.Replace(strings.ToLower(host)) + ".json"
+}
+
+func hostOf(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package client
+
+import "os"
+
+func lockFile(f *os.File) error {
+	return errLockUnsupported
+}
+
+func unlockFile(f *os.File) {}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	cfg := RateLimit{PerSecond: 2, Burst: 2}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var b bucket
+
+	// Full bucket: burst requests go through immediately
+	for i := 0; i < 2; i++ {
+		if wait := b.reserve(start, cfg); wait != 0 {
+			t.Fatalf("reserve #%d wait = %s, want 0", i+1, wait)
+		}
+	}
+	// Empty bucket: next caller waits one interval, the one after two
+	if wait := b.reserve(start, cfg); wait != 500*time.Millisecond {
+		t.Errorf("reserve #3 wait = %s, want 500ms", wait)
+	}
+	if wait := b.reserve(start, cfg); wait != time.Second {
+		t.Errorf("reserve #4 wait = %s, want 1s", wait)
+	}
+
+	// Refill never exceeds burst
+	later := start.Add(time.Hour)
+	b.reserve(later, cfg)
+	if b.Tokens != 1 {
+		t.Errorf("tokens after long idle = %v, want burst-1 = 1", b.Tokens)
+	}
+}
+
+func TestBucketReserveClockSkew(t *testing.T) {
+	cfg := RateLimit{PerSecond: 1, Burst: 1}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := bucket{Tokens: 0, Updated: now}
+
+	if wait := b.reserve(now.Add(-time.Minute), cfg); wait != time.Second {
+		t.Errorf("wait with clock going backwards = %s, want 1s", wait)
+	}
+}
+
+func TestRateLimiterPerHost(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimit{PerSecond: 1, Burst: 1})
+	l.now = func() time.Time { return now }
+
+	if wait := l.reserve("www.alza.sk"); wait != 0 {
+		t.Errorf("first alza.sk wait = %s, want 0", wait)
+	}
+	if wait := l.reserve("webapi.alza.cz"); wait != 0 {
+		t.Errorf("first webapi wait = %s, want 0 (separate bucket)", wait)
+	}
+	if wait := l.reserve("www.alza.sk"); wait != time.Second {
+		t.Errorf("second alza.sk wait = %s, want 1s", wait)
+	}
+}
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	if l := newRateLimiter(RateLimit{}); l != nil {
+		t.Error("newRateLimiter with PerSecond 0 should return nil")
+	}
+	var l *rateLimiter
+	if err := l.Wait(context.Background(), "www.alza.sk"); err != nil {
+		t.Errorf("nil limiter Wait() = %v", err)
+	}
+}
+
+func TestRateLimiterSharedAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cfg := RateLimit{PerSecond: 1, Burst: 1, StateDir: dir}
+
+	// Two limiters stand in for two alza processes
+	a := newRateLimiter(cfg)
+	a.now = func() time.Time { return now }
+	b := newRateLimiter(cfg)
+	b.now = func() time.Time { return now }
+
+	if wait := a.reserve("www.alza.sk"); wait != 0 {
+		t.Fatalf("process A wait = %s, want 0", wait)
+	}
+	if wait := b.reserve("www.alza.sk"); wait != time.Second {
+		t.Errorf("process B wait = %s, want 1s (shared budget)", wait)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "www.alza.sk.json")); err != nil {
+		t.Errorf("state file not written: %v", err)
+	}
+}
+
+func TestRateLimiterSharedCorruptState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "www.alza.sk.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newRateLimiter(RateLimit{PerSecond: 1, Burst: 1, StateDir: dir})
+	if wait := l.reserve("www.alza.sk"); wait != 0 {
+		t.Errorf("wait with corrupt state = %s, want 0 (reset to full bucket)", wait)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := newRateLimiter(RateLimit{PerSecond: 0.01, Burst: 1})
+	l.reserve("www.alza.sk")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "www.alza.sk"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBucketFileName(t *testing.T) {
+	tests := map[string]string{
+		"www.alza.sk":     "www.alza.sk.json",
+		"WebAPI.alza.cz":  "webapi.alza.cz.json",
+		"127.0.0.1:54321": "127.0.0.1_54321.json",
+	}
+	for host, want := range tests {
+		if got := bucketFileName(host); got != want {
+			t.Errorf("bucketFileName(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestDoRequestRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRateLimit(RateLimit{PerSecond: 20, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetContext(context.Background(), server.URL+"/api/x"); err != nil {
+			t.Fatalf("GetContext() error: %v", err)
+		}
+	}
+	// 1 immediate + 2 x 50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20 req/s took %s, want >= ~100ms", elapsed)
+	}
+}
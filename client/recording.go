@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	http "github.com/bogdanfinn/fhttp"
+)
+
+// ErrNoRecording is returned in replay mode when no recorded exchange matches a request.
+var ErrNoRecording = errors.New("no recorded response")
+
+// redactedValue replaces secrets in recorded headers.
+const redactedValue = "REDACTED"
+
+// Exchange is one request/response pair, stored as a single JSONL line.
+type Exchange struct {
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"requestHeaders,omitempty"`
+	RequestBody     string            `json:"requestBody,omitempty"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	ResponseBody    string            `json:"responseBody"`
+}
+
+// Recorder appends every exchange made by a TLSClient to a JSONL file.
+// Authorization and cookie headers are redacted; response bodies are kept as-is
+// and may contain personal data (orders, addresses).
+type Recorder struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewRecorder opens (or creates) path for appending.
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording %s: %w", path, err)
+	}
+	return &Recorder{f: f}, nil
+}
+
+// Record writes one exchange.
+func (r *Recorder) Record(ex Exchange) error {
+	data, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying file.
+func (r *Recorder) Close() error {
+	return r.f.Close()
+}
+
+// Replayer serves responses from a recording instead of the network.
+// Exchanges with the same method and URL are returned in recorded order;
+// the last one keeps being served once the others are used up.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	used      []bool
+}
+
+// LoadReplay reads a recording written by Recorder.
+func LoadReplay(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var exchanges []Exchange
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 32*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid exchange: %w", path, line, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", path, err)
+	}
+	return NewReplayer(exchanges), nil
+}
+
+// NewReplayer serves the given exchanges.
+func NewReplayer(exchanges []Exchange) *Replayer {
+	return &Replayer{
+		exchanges: exchanges,
+		used:      make([]bool, len(exchanges)),
+	}
+}
+
+// Match finds the response for a request. An exchange with the same body wins
+// over one that only matches method and URL.
+func (r *Replayer) Match(method, urlStr, body string) (Exchange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sameBody := range []bool{true, false} {
+		last := -1
+		for i, ex := range r.exchanges {
+			if ex.Method != method || ex.URL != urlStr || (sameBody && ex.RequestBody != body) {
+				continue
+			}
+			if !r.used[i] {
+				r.used[i] = true
+				return ex, nil
+			}
+			last = i
+		}
+		if last >= 0 {
+			return r.exchanges[last], nil
+		}
+	}
+	return Exchange{}, fmt.Errorf("%w for %s %s", ErrNoRecording, method, urlStr)
+}
+
+func (ex Exchange) response() *http.Response {
+	header := http.Header{}
+	for k, v := range ex.ResponseHeaders {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode: ex.Status,
+		Header:     header,
+	}
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if k == http.HeaderOrderKey || k == http.PHeaderOrderKey {
+			continue
+		}
+		if isSecretHeader(k) {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+func isSecretHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "authorization", "cookie", "set-cookie", "proxy-authorization":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readExchanges(t *testing.T, path string) []Exchange {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open recording: %v", err)
+	}
+	defer f.Close()
+
+	var out []Exchange
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ex Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			t.Fatalf("invalid JSONL line %q: %v", scanner.Text(), err)
+		}
+		out = append(out, ex)
+	}
+	return out
+}
+
+func TestRecorderWritesRedactedExchanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "POST" {
+			_, _ = io.WriteString(w, `{"posted":true}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+
+	c := newTestTLSClient(t)
+	c.recorder = rec
+	if _, err := c.GetContext(context.Background(), server.URL+"/api/get"); err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+	if _, err := c.PostContext(context.Background(), server.URL+"/api/post", `{"id":1}`); err != nil {
+		t.Fatalf("PostContext() error: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "Bearer test") || strings.Contains(string(raw), "session=secret") {
+		t.Fatalf("recording leaks secrets:\n%s", raw)
+	}
+
+	exchanges := readExchanges(t, path)
+	if len(exchanges) != 2 {
+		t.Fatalf("recorded %d exchanges, want 2", len(exchanges))
+	}
+
+	get := exchanges[0]
+	if get.Method != "GET" || get.URL != server.URL+"/api/get" || get.Status != 200 {
+		t.Errorf("GET exchange = %+v", get)
+	}
+	if get.RequestHeaders["Authorization"] != redactedValue {
+		t.Errorf("Authorization = %q, want redacted", get.RequestHeaders["Authorization"])
+	}
+	if get.ResponseHeaders["Set-Cookie"] != redactedValue {
+		t.Errorf("Set-Cookie = %q, want redacted", get.ResponseHeaders["Set-Cookie"])
+	}
+	if get.ResponseBody != `{"ok":true}` {
+		t.Errorf("ResponseBody = %q", get.ResponseBody)
+	}
+
+	post := exchanges[1]
+	if post.RequestBody != `{"id":1}` || post.ResponseBody != `{"posted":true}` {
+		t.Errorf("POST exchange = %+v", post)
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+	}))
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestTLSClient(t)
+	c.recorder = rec
+	want, err := c.GetContext(context.Background(), server.URL+"/api/a")
+	if err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+	rec.Close()
+	server.Close()
+
+	rep, err := LoadReplay(path)
+	if err != nil {
+		t.Fatalf("LoadReplay() error: %v", err)
+	}
+	replay := &TLSClient{replayer: rep}
+	got, err := replay.GetContext(context.Background(), server.URL+"/api/a")
+	if err != nil {
+		t.Fatalf("replayed GetContext() error: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("replayed body = %q, want %q", got, want)
+	}
+}
+
+func TestReplayerMatch(t *testing.T) {
+	rep := NewReplayer([]Exchange{
+		{Method: "GET", URL: "https://x/a", Status: 200, ResponseBody: "first"},
+		{Method: "GET", URL: "https://x/a", Status: 200, ResponseBody: "second"},
+		{Method: "POST", URL: "https://x/s", RequestBody: `{"q":"a"}`, Status: 200, ResponseBody: "qa"},
+		{Method: "POST", URL: "https://x/s", RequestBody: `{"q":"b"}`, Status: 200, ResponseBody: "qb"},
+	})
+
+	steps := []struct {
+		method, url, body string
+		want              string
+	}{
+		{"GET", "https://x/a", "", "first"},
+		{"GET", "https://x/a", "", "second"},
+		{"GET", "https://x/a", "", "second"}, // last one is sticky
+		{"POST", "https://x/s", `{"q":"b"}`, "qb"},
+		{"POST", "https://x/s", `{"q":"a"}`, "qa"},
+		{"POST", "https://x/s", `{"q":"c"}`, "qb"}, // unknown body falls back to method+URL
+	}
+	for i, s := range steps {
+		ex, err := rep.Match(s.method, s.url, s.body)
+		if err != nil {
+			t.Fatalf("step %d: Match() error: %v", i, err)
+		}
+		if ex.ResponseBody != s.want {
+			t.Errorf("step %d: got %q, want %q", i, ex.ResponseBody, s.want)
+		}
+	}
+
+	if _, err := rep.Match("GET", "https://x/missing", ""); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Match() unknown URL error = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestReplayServesErrorStatus(t *testing.T) {
+	rep := NewReplayer([]Exchange{
+		{Method: "GET", URL: "https://x/gone", Status: 404, ResponseBody: `{"error":"gone"}`},
+	})
+	c := &TLSClient{replayer: rep, retry: DefaultRetryPolicy()}
+
+	_, err := c.GetContext(context.Background(), "https://x/gone")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != 404 {
+		t.Errorf("GetContext() error = %v, want HTTP 404", err)
+	}
+
+	_, err = c.GetContext(context.Background(), "https://x/other")
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("GetContext() error = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestLoadReplayInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.jsonl")
+	if err := os.WriteFile(path, []byte("{\"method\":\"GET\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadReplay(path)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("LoadReplay() error = %v, want line 2 reported", err)
+	}
+}
+
+func newSessionReplayClient(t *testing.T) *TLSClient {
+	t.Helper()
+
+	rep, err := LoadReplay(filepath.Join("testdata", "replay_session.jsonl"))
+	if err != nil {
+		t.Fatalf("LoadReplay() error: %v", err)
+	}
+	c, err := NewReplayTLSClient(context.Background(), DefaultStorefront(), rep, false)
+	if err != nil {
+		t.Fatalf("NewReplayTLSClient() error: %v", err)
+	}
+	return c
+}
+
+func TestReplayGetCart(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // replay must not need a saved token
+	c := newSessionReplayClient(t)
+
+	if c.GetUserID() != "42" {
+		t.Errorf("GetUserID() = %q, want 42", c.GetUserID())
+	}
+
+	items, err := c.GetCartContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetCartContext() error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("items len = %d, want 1", len(items))
+	}
+	item := items[0]
+	if item.ProductID != 12345 || item.Count != 2 || item.BasketItemID != 9001 {
+		t.Errorf("item = %+v", item)
+	}
+	if item.Name != "Test Kávovar" || item.Price != "399,98 €" {
+		t.Errorf("item name/price = %q / %q", item.Name, item.Price)
+	}
+}
+
+func TestReplayGetProduct(t *testing.T) {
+	c := newSessionReplayClient(t)
+
+	p, err := c.GetProductContext(context.Background(), 12345)
+	if err != nil {
+		t.Fatalf("GetProductContext() error: %v", err)
+	}
+	if p.Name != "Test Kávovar" || p.Price != "199,99 €" {
+		t.Errorf("name/price = %q / %q", p.Name, p.Price)
+	}
+	if p.Availability != "Skladom > 5 ks" {
+		t.Errorf("Availability = %q", p.Availability)
+	}
+	if p.ReviewStats == nil || p.ReviewStats.RatingAverage != 4.6 {
+		t.Errorf("ReviewStats = %+v", p.ReviewStats)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := map[string][]string{
+		"Authorization":   {"Bearer x"},
+		"Cookie":          {"a=b"},
+		"Accept-Language": {"sk-SK"},
+	}
+	got := redactHeaders(h)
+	if got["Authorization"] != redactedValue || got["Cookie"] != redactedValue {
+		t.Errorf("secrets not redacted: %v", got)
+	}
+	if got["Accept-Language"] != "sk-SK" {
+		t.Errorf("Accept-Language = %q", got["Accept-Language"])
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	http "github.com/bogdanfinn/fhttp"
@@ -13,14 +14,19 @@ import (
 	"github.com/bogdanfinn/tls-client/profiles"
 )
 
-const tokenEndpoint = BaseURL + EndpointAccessTokenPath
-
 // RefreshTokenWithCookies fetches a new Bearer token using Chrome cookies.
 func RefreshTokenWithCookies(ctx context.Context, cookieHeader string, debug bool) (string, error) {
-	return refreshTokenWithCookies(ctx, cookieHeader, debug, tokenEndpoint)
+	return RefreshTokenForStorefront(ctx, DefaultStorefront(), cookieHeader, debug)
+}
+
+// RefreshTokenForStorefront fetches a new Bearer token from the given storefront's identity endpoint.
+// debug prints the request to stderr: refreshes also run mid-command, where
+// stdout may carry a protocol (alza mcp).
+func RefreshTokenForStorefront(ctx context.Context, store Storefront, cookieHeader string, debug bool) (string, error) {
+	return refreshTokenWithCookies(ctx, store, cookieHeader, debug, store.BaseURL()+EndpointAccessTokenPath)
 }
 
-func refreshTokenWithCookies(ctx context.Context, cookieHeader string, debug bool, endpoint string) (string, error) {
+func refreshTokenWithCookies(ctx context.Context, store Storefront, cookieHeader string, debug bool, endpoint string) (string, error) {
 	cookieHeader = strings.TrimSpace(cookieHeader)
 	if cookieHeader == "" {
 		return "", errors.New("cookie header missing (are you logged in in Chrome?)")
@@ -43,10 +49,10 @@ func refreshTokenWithCookies(ctx context.Context, cookieHeader string, debug boo
 	if err != nil {
 		return "", err
 	}
-	setTokenHeaders(req, cookieHeader)
+	setTokenHeaders(req, store, cookieHeader)
 
 	if debug {
-		fmt.Printf("[DEBUG] GET %s\n", endpoint)
+		fmt.Fprintf(os.Stderr, "[DEBUG] GET %s\n", endpoint)
 	}
 
 	resp, err := httpClient.Do(req)
@@ -62,7 +68,7 @@ func refreshTokenWithCookies(ctx context.Context, cookieHeader string, debug boo
 
 	if debug {
 		contentType := resp.Header.Get("Content-Type")
-		fmt.Printf("[DEBUG] Token response: %d %s\n", resp.StatusCode, contentType)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Token response: %d %s\n", resp.StatusCode, contentType)
 	}
 
 	if resp.StatusCode >= 400 {
@@ -91,8 +97,8 @@ func refreshTokenWithCookies(ctx context.Context, cookieHeader string, debug boo
 	return "Bearer " + accessToken, nil
 }
 
-func setTokenHeaders(req *http.Request, cookieHeader string) {
-	req.Header = baseHeaders()
+func setTokenHeaders(req *http.Request, store Storefront, cookieHeader string) {
+	req.Header = storefrontHeaders(store)
 	req.Header.Set("Cookie", cookieHeader)
 }
 
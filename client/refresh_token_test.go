@@ -25,7 +25,7 @@ func TestRefreshTokenWithCookiesSuccess(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	token, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	token, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -46,7 +46,7 @@ func TestRefreshTokenWithCookiesHTMLResponse(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "html") {
 		t.Fatalf("expected HTML error, got: %v", err)
 	}
@@ -64,7 +64,7 @@ func TestRefreshTokenWithCookiesLogout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err == nil || !strings.Contains(err.Error(), "logOut") {
 		t.Fatalf("expected logOut error, got: %v", err)
 	}
@@ -76,7 +76,7 @@ func TestRefreshTokenWithCookiesMissingHeader(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "", false, "http://example.test")
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "", false, "http://example.test")
 	if err == nil {
 		t.Fatal("expected error for missing cookie header")
 	}
@@ -88,7 +88,7 @@ func TestRefreshTokenWithCookiesWhitespaceHeader(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "   ", false, "http://example.test")
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "   ", false, "http://example.test")
 	if err == nil {
 		t.Fatal("expected error for whitespace-only cookie header")
 	}
@@ -106,7 +106,7 @@ func TestRefreshTokenWithCookiesMissingAccessToken(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err == nil || !strings.Contains(err.Error(), "accessToken") {
 		t.Fatalf("expected missing accessToken error, got: %v", err)
 	}
@@ -124,7 +124,7 @@ func TestRefreshTokenWithCookiesInvalidJSON(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err == nil || !strings.Contains(err.Error(), "decode") {
 		t.Fatalf("expected decode error, got: %v", err)
 	}
@@ -142,7 +142,7 @@ func TestRefreshTokenWithCookiesHTTP400(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	_, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	_, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err == nil || !strings.Contains(err.Error(), "400") {
 		t.Fatalf("expected HTTP 400 error, got: %v", err)
 	}
@@ -160,7 +160,7 @@ func TestRefreshTokenWithCookiesUppercaseAccessToken(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	token, err := refreshTokenWithCookies(ctx, "cf=1", false, server.URL)
+	token, err := refreshTokenWithCookies(ctx, DefaultStorefront(), "cf=1", false, server.URL)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -333,3 +333,31 @@ func TestLooksLikeHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshTokenWithCookiesUsesStorefrontHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Language"); got != "hu-HU" {
+			t.Errorf("Accept-Language = %q, want hu-HU", got)
+		}
+		if got := r.Header.Get("Origin"); got != "https://www.alza.hu" {
+			t.Errorf("Origin = %q, want https://www.alza.hu", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"accessToken":"hu","logOut":false}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	hu, _ := StorefrontFor("HU")
+	token, err := refreshTokenWithCookies(ctx, hu, "cf=1", false, server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "Bearer hu" {
+		t.Fatalf("unexpected token: %s", token)
+	}
+}
@@ -1,11 +1,13 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
+	"time"
 
 	http "github.com/bogdanfinn/fhttp"
 )
@@ -23,41 +25,89 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.Status, e.Body)
 }
 
-func (c *TLSClient) doRequest(method, endpoint string, body io.Reader, contentType, debugBody string) ([]byte, error) {
-	urlStr, err := resolveURL(endpoint)
+// doRequest sends one API call. Every attempt waits for the host's rate limiter first.
+// Idempotent GETs are retried on transport errors and 429/502/503/504 according
+// to c.retry; everything else is sent once. A 401/403 triggers one token refresh
+// (when a refresher is configured) and the request is repeated with the new token;
+// the server rejected the first attempt, so this is safe for POSTs too.
+func (c *TLSClient) doRequest(ctx context.Context, method, endpoint, body, contentType string) ([]byte, error) {
+	urlStr, err := c.resolveURL(endpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(method, urlStr, body)
+	attempts := 1
+	if canRetry(method, endpoint) {
+		attempts = c.retry.attempts()
+	}
+
+	// Best effort: on failure the request still goes out and a 401 refreshes reactively
+	_ = c.refreshIfDue(ctx)
+
+	host := hostOf(urlStr)
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx, host); err != nil {
+			return nil, err
+		}
+		token := c.token()
+		data, retryAfter, err := c.doAttempt(ctx, method, urlStr, token, body, contentType)
+		if c.canRefresh(err) && !refreshed {
+			refreshed = true
+			if refreshErr := c.refreshToken(ctx, token); refreshErr != nil {
+				return nil, fmt.Errorf("%w (token refresh failed: %w)", err, refreshErr)
+			}
+			attempt-- // The rejected attempt doesn't count against the retry budget
+			continue
+		}
+		if err == nil || attempt >= attempts || !isRetryable(ctx, err) {
+			return data, err
+		}
+
+		wait := c.retry.delay(attempt, retryAfter, time.Now())
+		if c.debug {
+			fmt.Fprintf(c.debugOut, "[DEBUG] Retrying in %s (attempt %d/%d): %v\n", wait, attempt+1, attempts, err)
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doAttempt performs a single round trip. retryAfter carries the Retry-After header of failed responses.
+func (c *TLSClient) doAttempt(ctx context.Context, method, urlStr, token, body, contentType string) (data []byte, retryAfter string, err error) {
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	c.setHeaders(req)
+	c.setHeaders(req, token)
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
 
 	if c.debug {
-		fmt.Printf("[DEBUG] %s %s\n", method, urlStr)
-		if debugBody != "" {
-			fmt.Printf("[DEBUG] Body: %s\n", snippet([]byte(debugBody), 500))
+		fmt.Fprintf(c.debugOut, "[DEBUG] %s %s\n", method, urlStr)
+		if body != "" {
+			fmt.Fprintf(c.debugOut, "[DEBUG] Body: %s\n", snippet([]byte(body), 500))
 		}
 	}
 
-	resp, err := c.client.Do(req)
+	resp, bodyBytes, err := c.roundTrip(ctx, req, body)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, "", err
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	if c.debug {
+		fmt.Fprintf(c.debugOut, "[DEBUG] Response: %d %s\n", resp.StatusCode, snippet(bodyBytes, 500))
 	}
 
-	if c.debug {
-		fmt.Printf("[DEBUG] Response: %d %s\n", resp.StatusCode, snippet(bodyBytes, 500))
+	// Checked before the status so a 403 challenge is not mistaken for an expired token
+	if challengeErr := detectChallenge(resp, bodyBytes, urlStr); challengeErr != nil {
+		return nil, "", errors.Join(ErrChallenge, challengeErr)
 	}
 
 	if resp.StatusCode >= 400 {
@@ -67,18 +117,103 @@ func (c *TLSClient) doRequest(method, endpoint string, body io.Reader, contentTy
 			Body:   snippet(bodyBytes, 500),
 		}
 		if resp.StatusCode == 401 || resp.StatusCode == 403 {
-			return nil, errors.Join(ErrAuthRequired, httpErr)
+			return nil, "", errors.Join(ErrAuthRequired, httpErr)
+		}
+		return nil, resp.Header.Get("Retry-After"), httpErr
+	}
+
+	return bodyBytes, "", nil
+}
+
+// roundTrip sends req over the network, or answers it from the replay recording.
+// Network exchanges are written to the recorder when one is attached.
+func (c *TLSClient) roundTrip(ctx context.Context, req *http.Request, body string) (*http.Response, []byte, error) {
+	urlStr := req.URL.String()
+	if c.replayer != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		ex, err := c.replayer.Match(req.Method, urlStr, body)
+		if err != nil {
+			return nil, nil, err
 		}
-		return nil, httpErr
+		return ex.response(), []byte(ex.ResponseBody), nil
 	}
 
-	return bodyBytes, nil
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Surface cancellation as-is so callers can match context.Canceled / DeadlineExceeded
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if c.recorder != nil {
+		err := c.recorder.Record(Exchange{
+			Time:            time.Now().UTC(),
+			Method:          req.Method,
+			URL:             urlStr,
+			RequestHeaders:  redactHeaders(req.Header),
+			RequestBody:     body,
+			Status:          resp.StatusCode,
+			ResponseHeaders: redactHeaders(resp.Header),
+			ResponseBody:    string(bodyBytes),
+		})
+		if err != nil && c.debug {
+			fmt.Fprintf(c.debugOut, "[DEBUG] Failed to record exchange: %v\n", err)
+		}
+	}
+
+	return resp, bodyBytes, nil
+}
+
+// canRefresh reports whether err is an auth rejection a new token could fix.
+func (c *TLSClient) canRefresh(err error) bool {
+	return c.refresher != nil && c.replayer == nil && errors.Is(err, ErrAuthRequired)
+}
+
+// isRetryable reports whether a failed attempt is worth repeating.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrChallenge) || errors.Is(err, ErrAuthRequired) || errors.Is(err, ErrNoRecording) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return isRetryableStatus(httpErr.Status)
+	}
+	// Transport failure (connection reset, timeout, ...)
+	return true
+}
+
+// resolveURL makes endpoint absolute, applying WithBaseURL / WithWebAPIURL overrides
+// to both relative paths and absolute URLs on the overridden origins.
+func (c *TLSClient) resolveURL(endpoint string) (string, error) {
+	base := c.storefront().BaseURL()
+	if c.baseURL != "" {
+		if rest, ok := strings.CutPrefix(endpoint, base); ok {
+			endpoint = c.baseURL + rest
+		}
+		base = c.baseURL
+	}
+	if c.webAPIURL != "" {
+		if rest, ok := strings.CutPrefix(endpoint, WebAPIURL); ok {
+			endpoint = c.webAPIURL + rest
+		}
+	}
+	return resolveURL(base, endpoint)
 }
 
-func resolveURL(endpoint string) (string, error) {
+func resolveURL(baseURL, endpoint string) (string, error) {
 	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
 		_, err := url.Parse(endpoint)
 		return endpoint, err
 	}
-	return BaseURL + endpoint, nil
+	return baseURL + endpoint, nil
 }
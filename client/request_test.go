@@ -1,9 +1,29 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	tls_client "github.com/bogdanfinn/tls-client"
 )
 
+// newTestTLSClient returns a TLSClient with a real tls-client transport, suitable for httptest servers.
+func newTestTLSClient(t *testing.T) *TLSClient {
+	t.Helper()
+
+	httpClient, err := tls_client.NewHttpClient(tls_client.NewNoopLogger(), tls_client.WithTimeoutSeconds(5))
+	if err != nil {
+		t.Fatalf("failed to create tls client: %v", err)
+	}
+	return &TLSClient{client: httpClient, authToken: "Bearer test"}
+}
+
 func TestResolveURL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -45,7 +65,7 @@ func TestResolveURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := resolveURL(tt.endpoint)
+			got, err := resolveURL(BaseURL, tt.endpoint)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("resolveURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -107,3 +127,280 @@ func TestBaseURLConstant(t *testing.T) {
 		t.Errorf("BaseURL = %q, want https://www.alza.sk", BaseURL)
 	}
 }
+
+func TestResolveURLUsesStorefrontBase(t *testing.T) {
+	got, err := resolveURL("https://www.alza.cz", "/api/v1/users")
+	if err != nil {
+		t.Fatalf("resolveURL() error: %v", err)
+	}
+	if got != "https://www.alza.cz/api/v1/users" {
+		t.Errorf("resolveURL() = %q, want https://www.alza.cz/api/v1/users", got)
+	}
+}
+
+func TestDoRequestCanceledContext(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetContext(ctx, server.URL+"/api/test")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetContext() error = %v, want context.Canceled", err)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Errorf("server got %d requests, want 0", hits)
+	}
+}
+
+func TestDoRequestDeadlineExceeded(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := newTestTLSClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetContext(ctx, server.URL+"/api/slow")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetContext() took %s, expected to abort near the deadline", elapsed)
+	}
+}
+
+func TestDoRequestSendsAuthAndStorefrontHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test" {
+			t.Errorf("Authorization = %q, want Bearer test", got)
+		}
+		if got := r.Header.Get("Accept-Language"); got != "cs-CZ" {
+			t.Errorf("Accept-Language = %q, want cs-CZ", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.store, _ = StorefrontFor("CZ")
+
+	body, err := c.PostContext(context.Background(), server.URL+"/api/post", `{"a":1}`)
+	if err != nil {
+		t.Fatalf("PostContext() error: %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSearchContextCanceledSkipsWhisperFallback(t *testing.T) {
+	c := newTestTLSClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.SearchContext(ctx, "kreatin", 5)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SearchContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func fastRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+func TestDoRequestRetriesTransientGET(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	body, err := c.GetContext(context.Background(), server.URL+"/api/flaky")
+	if err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %q", body)
+	}
+	if got := atomic.LoadInt32(&hits); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
+
+func TestDoRequestGivesUpAfterMaxAttempts(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	_, err := c.GetContext(context.Background(), server.URL+"/api/down")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusBadGateway {
+		t.Fatalf("GetContext() error = %v, want HTTP 502", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
+
+func TestDoRequestDoesNotRetryClientErrors(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	if _, err := c.GetContext(context.Background(), server.URL+"/api/missing"); err == nil {
+		t.Fatal("GetContext() expected error")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+}
+
+func TestDoRequestNeverRetriesOrderOrPayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+	}{
+		{"fast order send", EndpointFastOrderSend},
+		{"fast order save", EndpointFastOrderSave},
+		{"payment repeat", EndpointPaymentRepeat},
+		{"plain post", "/api/post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			c := newTestTLSClient(t)
+			c.SetRetryPolicy(fastRetryPolicy())
+
+			if _, err := c.PostContext(context.Background(), server.URL+tt.endpoint, `{}`); err == nil {
+				t.Fatal("PostContext() expected error")
+			}
+			if got := atomic.LoadInt32(&hits); got != 1 {
+				t.Errorf("server got %d requests, want exactly 1", got)
+			}
+		})
+	}
+}
+
+func TestDoRequestHonorsRetryAfter(t *testing.T) {
+	var hits int32
+	var first time.Time
+	var gap time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		gap = time.Since(first)
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second})
+
+	if _, err := c.GetContext(context.Background(), server.URL+"/api/limited"); err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+	if gap < 900*time.Millisecond {
+		t.Errorf("second attempt after %s, want >= ~1s (Retry-After)", gap)
+	}
+}
+
+func TestDoRequestCancelDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetContext(ctx, server.URL+"/api/busy")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("GetContext() took %s, backoff should stop on cancellation", elapsed)
+	}
+}
+
+func TestDoRequestCloudflareChallenge(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Header().Set("Cf-Ray", "8abc-VIE")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `<!DOCTYPE html><html><head><title>Just a moment...</title></head></html>`)
+	}))
+	defer server.Close()
+
+	c := newTestTLSClient(t)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	_, err := c.GetContext(context.Background(), server.URL+"/api/guarded")
+	if !errors.Is(err, ErrChallenge) {
+		t.Fatalf("GetContext() error = %v, want ErrChallenge", err)
+	}
+	if errors.Is(err, ErrAuthRequired) {
+		t.Error("challenge must not be reported as ErrAuthRequired")
+	}
+	var challengeErr *ChallengeError
+	if !errors.As(err, &challengeErr) || challengeErr.RayID != "8abc-VIE" {
+		t.Errorf("errors.As ChallengeError = %+v", challengeErr)
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("server got %d requests, want 1 (challenges are not retried)", got)
+	}
+}
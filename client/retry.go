@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls automatic retries of idempotent (GET) requests.
+// Non-idempotent calls such as FastOrderSend or the payment step are never retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first one; <= 1 disables retries
+	BaseDelay   time.Duration // Backoff before the 2nd attempt, doubled for every next one
+	MaxDelay    time.Duration // Upper bound for backoff and Retry-After waits
+}
+
+// DefaultRetryPolicy returns the policy used by NewTLSClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+// NoRetry returns a policy that makes exactly one attempt.
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// neverRetryEndpoints lists endpoints that place orders or charge cards.
+// They are POSTs today; the list guards against that ever changing.
+var neverRetryEndpoints = []string{
+	EndpointFastOrderSave,
+	EndpointFastOrderSend,
+	EndpointPaymentRepeat,
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// canRetry reports whether a request may be sent more than once.
+func canRetry(method, endpoint string) bool {
+	if method != "GET" {
+		return false
+	}
+	for _, e := range neverRetryEndpoints {
+		if strings.Contains(endpoint, e) {
+			return false
+		}
+	}
+	return true
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case 429, 502, 503, 504:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the wait before attempt+1 (attempt is 1-based), with jitter in [d/2, d].
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// delay picks the wait before the next attempt, preferring the server's Retry-After hint.
+func (p RetryPolicy) delay(attempt int, retryAfter string, now time.Time) time.Duration {
+	d, ok := parseRetryAfter(retryAfter, now)
+	if !ok {
+		d = p.backoff(attempt)
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// parseRetryAfter understands both delta-seconds and HTTP-date forms.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := time.Parse(time.RFC1123, value); err == nil {
+		d := at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOK: true},
+		{name: "zero", value: "0", want: 0, wantOK: true},
+		{name: "http date", value: "Wed, 01 May 2024 12:00:05 GMT", want: 5 * time.Second, wantOK: true},
+		{name: "date in past", value: "Wed, 01 May 2024 11:00:00 GMT", want: 0, wantOK: true},
+		{name: "empty", value: "", wantOK: false},
+		{name: "negative", value: "-1", wantOK: false},
+		{name: "garbage", value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRetryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 150 * time.Millisecond, max: 300 * time.Millisecond},  // capped
+		{attempt: 40, min: 150 * time.Millisecond, max: 300 * time.Millisecond}, // shift overflow
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			got := p.backoff(tt.attempt)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("backoff(%d) = %s, want in [%s, %s]", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyDelayPrefersRetryAfter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}
+	now := time.Now()
+
+	if got := p.delay(1, "2", now); got != 2*time.Second {
+		t.Errorf("delay with Retry-After 2 = %s, want 2s", got)
+	}
+	if got := p.delay(1, "120", now); got != 5*time.Second {
+		t.Errorf("delay with Retry-After 120 = %s, want capped 5s", got)
+	}
+	if got := p.delay(1, "", now); got > time.Millisecond {
+		t.Errorf("delay without Retry-After = %s, want backoff <= 1ms", got)
+	}
+}
+
+func TestRetryPolicyAttempts(t *testing.T) {
+	if got := (RetryPolicy{}).attempts(); got != 1 {
+		t.Errorf("zero policy attempts = %d, want 1", got)
+	}
+	if got := NoRetry().attempts(); got != 1 {
+		t.Errorf("NoRetry attempts = %d, want 1", got)
+	}
+	if got := DefaultRetryPolicy().attempts(); got != 3 {
+		t.Errorf("DefaultRetryPolicy attempts = %d, want 3", got)
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	tests := []struct {
+		method   string
+		endpoint string
+		want     bool
+	}{
+		{"GET", "/api/users/1/v1/orders/active", true},
+		{"GET", BaseURL + EndpointSearchService, true},
+		{"POST", EndpointSearchService, false},
+		{"DELETE", "/api/x", false},
+		{"GET", EndpointFastOrderSend, false},
+		{"GET", BaseURL + EndpointFastOrderSave, false},
+		{"GET", EndpointPaymentRepeat, false},
+	}
+
+	for _, tt := range tests {
+		if got := canRetry(tt.method, tt.endpoint); got != tt.want {
+			t.Errorf("canRetry(%s, %s) = %v, want %v", tt.method, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestSleepContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := sleepContext(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Fatalf("sleepContext() error = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("sleepContext() did not return on cancellation")
+	}
+}
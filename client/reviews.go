@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -91,8 +92,13 @@ type reviewAPIItem struct {
 
 // GetReviewStats fetches aggregate review statistics for a product
 func (c *TLSClient) GetReviewStats(productID int) (*ReviewStats, error) {
-	endpoint := fmt.Sprintf(EndpointReviewStats, productID)
-	data, err := c.Get(endpoint)
+	return c.GetReviewStatsContext(context.Background(), productID)
+}
+
+// GetReviewStatsContext is like GetReviewStats but honors ctx
+func (c *TLSClient) GetReviewStatsContext(ctx context.Context, productID int) (*ReviewStats, error) {
+	endpoint := fmt.Sprintf(EndpointReviewStats, productID, c.storefront().Country)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +121,11 @@ func (c *TLSClient) GetReviewStats(productID int) (*ReviewStats, error) {
 
 // GetReviews fetches individual reviews for a product with pagination
 func (c *TLSClient) GetReviews(productID, offset, limit int) (*ReviewsResponse, error) {
+	return c.GetReviewsContext(context.Background(), productID, offset, limit)
+}
+
+// GetReviewsContext is like GetReviews but honors ctx
+func (c *TLSClient) GetReviewsContext(ctx context.Context, productID, offset, limit int) (*ReviewsResponse, error) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -125,8 +136,8 @@ func (c *TLSClient) GetReviews(productID, offset, limit int) (*ReviewsResponse,
 		limit = 50
 	}
 
-	endpoint := fmt.Sprintf(EndpointReviews, productID, offset, limit)
-	data, err := c.Get(endpoint)
+	endpoint := fmt.Sprintf(EndpointReviews, productID, c.storefront().Country, offset, limit)
+	data, err := c.GetContext(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
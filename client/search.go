@@ -1,29 +1,251 @@
 package client
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SearchSort orders search results
+type SearchSort string
 
-	http "github.com/bogdanfinn/fhttp"
+const (
+	SortRelevance   SearchSort = "relevance"   // Alza's own order
+	SortPriceAsc    SearchSort = "price-asc"   // Cheapest first
+	SortPriceDesc   SearchSort = "price-desc"  // Most expensive first
+	SortRating      SearchSort = "rating"      // Best average rating first
+	SortBestselling SearchSort = "bestselling" // Most ratings first, the closest to sales figures Alza exposes
 )
 
+// MaxRatedSearchResults caps the review stats lookups of the rating and
+// bestselling sorts: they rank only this many of the matching results, in
+// Alza's order.
+const MaxRatedSearchResults = 50
+
+// ErrSearchPageOutOfRange means a page or offset starts past the results of
+// the one response the v5 search endpoint gives per term. It takes no paging
+// parameters, so there is no way to ask Alza for the results after it.
+var ErrSearchPageOutOfRange = errors.New("search page out of range")
+
+// SearchOptions narrows, orders and pages a search. The v5 endpoint takes
+// nothing but the search term, so all of it is applied client-side to the
+// single batch of results Alza returns for the term; paging past that batch
+// fails with ErrSearchPageOutOfRange. The whisperer fallback has no prices or
+// availability, so price and stock filters drop its results.
+type SearchOptions struct {
+	Limit  int // Max results, 10 when 0
+	Offset int // Skip this many matching results
+	Page   int // 1-based page of Limit results; overrides Offset when set
+
+	Sort SearchSort // SortRelevance when empty
+
+	MinPrice     float64 // 0 = no lower bound
+	MaxPrice     float64 // 0 = no upper bound
+	InStockOnly  bool    // Only products InStock
+	Manufacturer string  // Name starts with it; Alza names lead with the brand
+	Category     string  // Category slug in the product URL, e.g. "sport" in /sport/...-d123.htm
+}
+
+// Search returns up to limit products, falling back to the whisperer when v5
+// search is empty. A limit of 0 returns up to 10 like SearchOptions.Limit; a
+// negative one is an error.
 func (c *TLSClient) Search(query string, limit int) ([]SearchResult, error) {
-	results, err := c.searchV5(query, limit)
+	return c.SearchContext(context.Background(), query, limit)
+}
+
+// SearchContext is like Search but honors ctx
+func (c *TLSClient) SearchContext(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	return c.SearchWithOptionsContext(ctx, query, SearchOptions{Limit: limit})
+}
+
+// SearchWithOptions is Search with filters, sorting and paging
+func (c *TLSClient) SearchWithOptions(query string, opts SearchOptions) ([]SearchResult, error) {
+	return c.SearchWithOptionsContext(context.Background(), query, opts)
+}
+
+// SearchWithOptionsContext is like SearchWithOptions but honors ctx
+func (c *TLSClient) SearchWithOptionsContext(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	results, err := c.search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	results = opts.filter(results)
+	if offset := opts.offset(); offset > 0 && offset >= len(results) {
+		return nil, fmt.Errorf("%w: it starts at result %d, but Alza's search response has only %d matching results (the endpoint doesn't page; narrow the query instead)", ErrSearchPageOutOfRange, offset+1, len(results))
+	}
+	if opts.Sort == SortRating || opts.Sort == SortBestselling {
+		results = results[:min(len(results), MaxRatedSearchResults)]
+		if err := c.fetchRatings(ctx, results); err != nil {
+			return nil, err
+		}
+	}
+	opts.sort(results)
+	return opts.page(results), nil
+}
+
+// fetchRatings fills in Rating and RatingCount, which search results lack, with
+// one review stats call per result on DefaultProductWorkers workers
+func (c *TLSClient) fetchRatings(ctx context.Context, results []SearchResult) error {
+	errs := make([]error, len(results))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(DefaultProductWorkers, len(results)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stats, err := c.GetReviewStatsContext(ctx, results[i].ID)
+				if err != nil {
+					errs[i] = fmt.Errorf("rating of %d: %w", results[i].ID, err)
+					continue
+				}
+				results[i].Rating, results[i].RatingCount = stats.RatingAverage, stats.RatingCount
+			}
+		}()
+	}
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o SearchOptions) validate() error {
+	switch o.Sort {
+	case "", SortRelevance, SortPriceAsc, SortPriceDesc, SortRating, SortBestselling:
+	default:
+		return fmt.Errorf("unknown search sort %q", o.Sort)
+	}
+	switch {
+	case o.Limit < 0 || o.Offset < 0 || o.Page < 0:
+		return errors.New("search limit, offset and page can't be negative")
+	case o.MinPrice < 0 || o.MaxPrice < 0:
+		return errors.New("search price bounds can't be negative")
+	case o.MaxPrice > 0 && o.MinPrice > o.MaxPrice:
+		return fmt.Errorf("search min price %.2f is above max price %.2f", o.MinPrice, o.MaxPrice)
+	}
+	return nil
+}
+
+// filter keeps the results matching every set filter
+func (o SearchOptions) filter(results []SearchResult) []SearchResult {
+	return slices.DeleteFunc(results, func(r SearchResult) bool {
+		switch {
+		case o.MinPrice > 0 && r.Price < o.MinPrice,
+			o.MaxPrice > 0 && (r.Price == 0 || r.Price > o.MaxPrice),
+			o.InStockOnly && !InStock(r.Availability),
+			o.Manufacturer != "" && !hasManufacturer(r.Name, o.Manufacturer),
+			o.Category != "" && !inCategory(r.URL, o.Category):
+			return true
+		}
+		return false
+	})
+}
+
+// sort orders results in place; equal ones keep Alza's order
+func (o SearchOptions) sort(results []SearchResult) {
+	var less func(a, b SearchResult) int
+	switch o.Sort {
+	case SortPriceAsc:
+		less = func(a, b SearchResult) int { return cmp.Compare(a.Price, b.Price) }
+	case SortPriceDesc:
+		less = func(a, b SearchResult) int { return cmp.Compare(b.Price, a.Price) }
+	case SortRating:
+		less = func(a, b SearchResult) int {
+			return cmp.Or(cmp.Compare(b.Rating, a.Rating), cmp.Compare(b.RatingCount, a.RatingCount))
+		}
+	case SortBestselling:
+		less = func(a, b SearchResult) int { return cmp.Compare(b.RatingCount, a.RatingCount) }
+	default:
+		return
+	}
+	slices.SortStableFunc(results, less)
+}
+
+func (o SearchOptions) limit() int {
+	if o.Limit == 0 {
+		return 10
+	}
+	return o.Limit
+}
+
+// offset is the index of the first result to return
+func (o SearchOptions) offset() int {
+	if o.Page > 0 {
+		return (o.Page - 1) * o.limit()
+	}
+	return o.Offset
+}
+
+// page cuts the requested window out of results
+func (o SearchOptions) page(results []SearchResult) []SearchResult {
+	limit, offset := o.limit(), o.offset()
+	if offset >= len(results) {
+		return []SearchResult{}
+	}
+	return results[offset:min(offset+limit, len(results))]
+}
+
+// hasManufacturer reports whether a product name starts with the manufacturer
+// as a whole word ("Apple iPhone 15" is Apple, "Applewood ..." isn't)
+func hasManufacturer(name, manufacturer string) bool {
+	name, manufacturer = strings.ToLower(name), strings.ToLower(strings.TrimSpace(manufacturer))
+	rest, ok := strings.CutPrefix(name, manufacturer)
+	return ok && (rest == "" || strings.HasPrefix(rest, " "))
+}
+
+// inCategory reports whether a product URL lies under the category slug, e.g.
+// https://www.alza.sk/sport/amix-...-d5275186.htm is in "sport"
+func inCategory(rawURL, category string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.EqualFold(segment, strings.Trim(category, "/ ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// search returns every result Alza has for query, from v5 search or, when
+// that fails or comes back empty, the whisperer
+func (c *TLSClient) search(ctx context.Context, query string) ([]SearchResult, error) {
+	results, err := c.searchV5(ctx, query)
 	if err == nil && len(results) > 0 {
 		return results, nil
 	}
 
 	if c.debug {
 		if err != nil {
-			fmt.Printf("[DEBUG] Search v5 failed, falling back to whisperer: %v\n", err)
+			fmt.Fprintf(c.debugOut, "[DEBUG] Search v5 failed, falling back to whisperer: %v\n", err)
 		} else {
-			fmt.Printf("[DEBUG] Search v5 returned no items, falling back to whisperer\n")
+			fmt.Fprintf(c.debugOut, "[DEBUG] Search v5 returned no items, falling back to whisperer\n")
 		}
 	}
 
-	fallback, fallbackErr := c.searchWhisper(query, limit)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	fallback, fallbackErr := c.searchWhisper(ctx, query)
 	if fallbackErr != nil {
 		if err != nil {
 			return nil, fmt.Errorf("search v5 failed: %w; whisperer failed: %v", err, fallbackErr)
@@ -39,7 +261,7 @@ func (c *TLSClient) Search(query string, limit int) ([]SearchResult, error) {
 	return fallback, nil
 }
 
-func (c *TLSClient) searchV5(query string, limit int) ([]SearchResult, error) {
+func (c *TLSClient) searchV5(ctx context.Context, query string) ([]SearchResult, error) {
 	payload := map[string]string{
 		"searchTerm": query,
 	}
@@ -48,30 +270,37 @@ func (c *TLSClient) searchV5(query string, limit int) ([]SearchResult, error) {
 		return nil, fmt.Errorf("failed to build search payload: %w", err)
 	}
 
-	data, err := c.Post(EndpointSearchService, string(body))
+	data, err := c.PostContext(ctx, EndpointSearchService, string(body))
 	if err != nil {
 		return nil, err
 	}
 
 	var searchResp struct {
-		Data2 []struct {
-			ID              int     `json:"id"`
-			Name            string  `json:"name"`
-			Code            string  `json:"code"`
-			Price           string  `json:"price"`
-			PriceNoCurrency float64 `json:"priceNoCurrency"`
-			Avail           string  `json:"avail"`
-			Img             string  `json:"img"`
-			URL             string  `json:"url"`
-		} `json:"data2"`
+		Data2 []commodityItem `json:"data2"`
 	}
 
 	if err := json.Unmarshal(data, &searchResp); err != nil {
 		return nil, fmt.Errorf("failed to parse search: %w", err)
 	}
 
-	results := make([]SearchResult, 0, min(len(searchResp.Data2), limit))
-	for _, item := range searchResp.Data2 {
+	return commodityResults(searchResp.Data2), nil
+}
+
+// commodityItem is a product in v5 search results and category listings
+type commodityItem struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Code            string  `json:"code"`
+	Price           string  `json:"price"`
+	PriceNoCurrency float64 `json:"priceNoCurrency"`
+	Avail           string  `json:"avail"`
+	Img             string  `json:"img"`
+	URL             string  `json:"url"`
+}
+
+func commodityResults(items []commodityItem) []SearchResult {
+	results := make([]SearchResult, 0, len(items))
+	for _, item := range items {
 		price := item.PriceNoCurrency
 		if price == 0 {
 			price = parsePrice(item.Price)
@@ -86,54 +315,28 @@ func (c *TLSClient) searchV5(query string, limit int) ([]SearchResult, error) {
 			ImageURL:     item.Img,
 			URL:          item.URL,
 		})
-		if len(results) >= limit {
-			break
-		}
 	}
-	return results, nil
+	return results
 }
 
-func (c *TLSClient) searchWhisper(query string, limit int) ([]SearchResult, error) {
+func (c *TLSClient) searchWhisper(ctx context.Context, query string) ([]SearchResult, error) {
 	endpoint := EndpointWhisperAnon
 	if c.userID != "" {
 		endpoint = fmt.Sprintf(EndpointWhisperUser, c.userID)
 	}
 
 	params := url.Values{}
-	params.Set("country", "SK")
-	params.Set("eshopUrl", "https://www.alza.sk/")
+	store := c.storefront()
+	params.Set("country", store.Country)
+	params.Set("eshopUrl", store.HomeURL())
 	params.Set("searchTerm", query)
 	params.Set("visitor", "00000000-0000-0000-0000-000000000000")
 	searchURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+	data, err := c.GetContext(ctx, searchURL)
 	if err != nil {
 		return nil, err
 	}
-	c.setHeaders(req)
-
-	if c.debug {
-		fmt.Printf("[DEBUG] GET %s\n", searchURL)
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if c.debug {
-		fmt.Printf("[DEBUG] Search whisper response: %d %s\n", resp.StatusCode, string(data)[:min(300, len(data))])
-	}
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data)[:min(500, len(data))])
-	}
 
 	var searchResp struct {
 		Commodities []struct {
@@ -150,7 +353,7 @@ func (c *TLSClient) searchWhisper(query string, limit int) ([]SearchResult, erro
 		return nil, fmt.Errorf("failed to parse whisper search: %w", err)
 	}
 
-	results := make([]SearchResult, 0, min(len(searchResp.Commodities), limit))
+	results := make([]SearchResult, 0, len(searchResp.Commodities))
 	for _, item := range searchResp.Commodities {
 		link := item.ClickAction.WebLink
 		if link == "" {
@@ -163,9 +366,6 @@ func (c *TLSClient) searchWhisper(query string, limit int) ([]SearchResult, erro
 			ImageURL: item.ImageURL,
 			URL:      link,
 		})
-		if len(results) >= limit {
-			break
-		}
 	}
 	return results, nil
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 )
 
@@ -267,3 +268,89 @@ func TestParsePriceInSearch(t *testing.T) {
 		t.Errorf("price = %f, want 1299.99", price)
 	}
 }
+
+func TestSearchOptionsFilter(t *testing.T) {
+	results := []SearchResult{
+		{ID: 1, Name: "Apple iPhone 15 128GB", Price: 899, Availability: "Skladom > 5 ks", URL: "https://www.alza.sk/mobily/apple-iphone-15-d1.htm"},
+		{ID: 2, Name: "Applewood Case", Price: 19.90, Availability: "Na sklade > 10 ks", URL: "https://www.alza.sk/puzdra/applewood-case-d2.htm"},
+		{ID: 3, Name: "Apple iPhone 15 Pro", Price: 1099, Availability: "Na objednávku", URL: "https://www.alza.sk/mobily/apple-iphone-15-pro-d3.htm"},
+		{ID: 4, Name: "Apple iPhone 14", URL: "/apple-iphone-14-d4.htm"}, // From the whisperer, no price or availability
+	}
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []int
+	}{
+		{"no filters", SearchOptions{}, []int{1, 2, 3, 4}},
+		{"min price", SearchOptions{MinPrice: 100}, []int{1, 3}},
+		{"max price", SearchOptions{MaxPrice: 900}, []int{1, 2}},
+		{"price range", SearchOptions{MinPrice: 100, MaxPrice: 1000}, []int{1}},
+		{"in stock", SearchOptions{InStockOnly: true}, []int{1, 2}},
+		{"manufacturer is a whole word", SearchOptions{Manufacturer: "apple"}, []int{1, 3, 4}},
+		{"category slug", SearchOptions{Category: "Mobily"}, []int{1, 3}},
+		{"combined", SearchOptions{Manufacturer: "Apple", Category: "mobily", InStockOnly: true}, []int{1}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, r := range tt.opts.filter(slices.Clone(results)) {
+			got = append(got, r.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: filter() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchOptionsSortAndPage(t *testing.T) {
+	results := []SearchResult{
+		{ID: 1, Price: 30, Rating: 4.5, RatingCount: 10},
+		{ID: 2, Price: 10, Rating: 4.9, RatingCount: 3},
+		{ID: 3, Price: 20, Rating: 4.5, RatingCount: 80},
+		{ID: 4, Price: 10, Rating: 3.0, RatingCount: 200},
+	}
+	ids := func(rs []SearchResult) []int {
+		out := []int{}
+		for _, r := range rs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		opts SearchOptions
+		want []int
+	}{
+		{SearchOptions{}, []int{1, 2, 3, 4}},
+		{SearchOptions{Sort: SortPriceAsc}, []int{2, 4, 3, 1}}, // Equal prices keep Alza's order
+		{SearchOptions{Sort: SortPriceDesc}, []int{1, 3, 2, 4}},
+		{SearchOptions{Sort: SortRating}, []int{2, 3, 1, 4}},
+		{SearchOptions{Sort: SortBestselling}, []int{4, 3, 1, 2}},
+		{SearchOptions{Sort: SortPriceAsc, Limit: 2}, []int{2, 4}},
+		{SearchOptions{Sort: SortPriceAsc, Limit: 2, Page: 2}, []int{3, 1}},
+		{SearchOptions{Sort: SortPriceAsc, Limit: 2, Offset: 3}, []int{1}},
+		{SearchOptions{Limit: 2, Page: 3}, []int{}},
+	}
+	for _, tt := range tests {
+		rs := slices.Clone(results)
+		tt.opts.sort(rs)
+		if got := ids(tt.opts.page(rs)); !slices.Equal(got, tt.want) {
+			t.Errorf("%+v: %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSearchOptionsValidate(t *testing.T) {
+	for _, opts := range []SearchOptions{
+		{Sort: "cheapest"},
+		{Limit: -1},
+		{Page: -1},
+		{MinPrice: -5},
+		{MinPrice: 50, MaxPrice: 20},
+	} {
+		if err := opts.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", opts)
+		}
+	}
+	if err := (SearchOptions{Sort: SortRating, MinPrice: 50}).validate(); err != nil {
+		t.Errorf("validate() of valid options = %v", err)
+	}
+}
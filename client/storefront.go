@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Storefront describes one national Alza e-shop (domain, locale, currency).
+type Storefront struct {
+	Country        string `json:"country"`        // Country code used in API query params (SK, CZ, ...)
+	Domain         string `json:"domain"`         // E-shop host, e.g. www.alza.sk
+	Language       string `json:"language"`       // Browser locale, e.g. sk-SK
+	Currency       string `json:"currency"`       // ISO 4217 code, e.g. EUR
+	CurrencySymbol string `json:"currencySymbol"` // Symbol used in formatted prices, e.g. €
+}
+
+// DefaultCountry is the storefront used when nothing else is configured.
+const DefaultCountry = "SK"
+
+var storefronts = []Storefront{
+	{Country: "SK", Domain: "www.alza.sk", Language: "sk-SK", Currency: "EUR", CurrencySymbol: "€"},
+	{Country: "CZ", Domain: "www.alza.cz", Language: "cs-CZ", Currency: "CZK", CurrencySymbol: "Kč"},
+	{Country: "HU", Domain: "www.alza.hu", Language: "hu-HU", Currency: "HUF", CurrencySymbol: "Ft"},
+	{Country: "AT", Domain: "www.alza.at", Language: "de-AT", Currency: "EUR", CurrencySymbol: "€"},
+	{Country: "DE", Domain: "www.alza.de", Language: "de-DE", Currency: "EUR", CurrencySymbol: "€"},
+}
+
+// DefaultStorefront returns the alza.sk storefront.
+func DefaultStorefront() Storefront {
+	s, _ := StorefrontFor(DefaultCountry)
+	return s
+}
+
+// Storefronts returns all supported storefronts.
+func Storefronts() []Storefront {
+	out := make([]Storefront, len(storefronts))
+	copy(out, storefronts)
+	return out
+}
+
+// StorefrontFor looks up a storefront by country code (case-insensitive).
+func StorefrontFor(country string) (Storefront, error) {
+	code := strings.ToUpper(strings.TrimSpace(country))
+	for _, s := range storefronts {
+		if s.Country == code {
+			return s, nil
+		}
+	}
+	return Storefront{}, fmt.Errorf("unsupported country %q (supported: %s)", country, strings.Join(storefrontCountries(), ", "))
+}
+
+// BaseURL returns the e-shop origin without trailing slash, e.g. https://www.alza.sk.
+func (s Storefront) BaseURL() string {
+	return "https://" + s.Domain
+}
+
+// HomeURL returns the e-shop home page with trailing slash, e.g. https://www.alza.sk/.
+func (s Storefront) HomeURL() string {
+	return s.BaseURL() + "/"
+}
+
+func storefrontCountries() []string {
+	codes := make([]string, 0, len(storefronts))
+	for _, s := range storefronts {
+		codes = append(codes, s.Country)
+	}
+	return codes
+}
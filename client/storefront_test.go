@@ -0,0 +1,102 @@
+package client
+
+import "testing"
+
+func TestStorefrontFor(t *testing.T) {
+	tests := []struct {
+		country      string
+		wantDomain   string
+		wantLanguage string
+		wantCurrency string
+	}{
+		{"SK", "www.alza.sk", "sk-SK", "EUR"},
+		{"cz", "www.alza.cz", "cs-CZ", "CZK"},
+		{" HU ", "www.alza.hu", "hu-HU", "HUF"},
+		{"AT", "www.alza.at", "de-AT", "EUR"},
+		{"DE", "www.alza.de", "de-DE", "EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			s, err := StorefrontFor(tt.country)
+			if err != nil {
+				t.Fatalf("StorefrontFor(%q) error: %v", tt.country, err)
+			}
+			if s.Domain != tt.wantDomain {
+				t.Errorf("Domain = %q, want %q", s.Domain, tt.wantDomain)
+			}
+			if s.Language != tt.wantLanguage {
+				t.Errorf("Language = %q, want %q", s.Language, tt.wantLanguage)
+			}
+			if s.Currency != tt.wantCurrency {
+				t.Errorf("Currency = %q, want %q", s.Currency, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestStorefrontForUnknown(t *testing.T) {
+	if _, err := StorefrontFor("PL"); err == nil {
+		t.Fatal("expected error for unsupported country")
+	}
+	if _, err := StorefrontFor(""); err == nil {
+		t.Fatal("expected error for empty country")
+	}
+}
+
+func TestDefaultStorefrontMatchesBaseURL(t *testing.T) {
+	s := DefaultStorefront()
+	if s.Country != DefaultCountry {
+		t.Errorf("Country = %q, want %q", s.Country, DefaultCountry)
+	}
+	if s.BaseURL() != BaseURL {
+		t.Errorf("BaseURL() = %q, want %q", s.BaseURL(), BaseURL)
+	}
+	if s.HomeURL() != BaseURL+"/" {
+		t.Errorf("HomeURL() = %q, want %q", s.HomeURL(), BaseURL+"/")
+	}
+}
+
+func TestStorefrontsReturnsCopy(t *testing.T) {
+	list := Storefronts()
+	if len(list) != 5 {
+		t.Fatalf("len(Storefronts()) = %d, want 5", len(list))
+	}
+	list[0].Domain = "evil.example"
+	if DefaultStorefront().Domain != "www.alza.sk" {
+		t.Error("mutating Storefronts() result changed the registry")
+	}
+}
+
+func TestTLSClientStorefrontDefaultsToSK(t *testing.T) {
+	c := &TLSClient{}
+	if got := c.Storefront().Country; got != "SK" {
+		t.Errorf("Storefront().Country = %q, want SK", got)
+	}
+
+	cz, _ := StorefrontFor("CZ")
+	c2 := &TLSClient{store: cz}
+	if got := c2.Storefront().Domain; got != "www.alza.cz" {
+		t.Errorf("Storefront().Domain = %q, want www.alza.cz", got)
+	}
+}
+
+func TestExtractBasketIDAnyStorefront(t *testing.T) {
+	tests := []struct {
+		href string
+		want int
+	}{
+		{"https://www.alza.sk/api/basket/1538710316/preview", 1538710316},
+		{"https://www.alza.cz/api/basket/42/preview", 42},
+		{"https://www.alza.hu/api/basket/7/preview", 7},
+		{"/api/basket/123456/preview", 123456},
+		{"", 0},
+		{"https://www.alza.sk/api/other/1/preview", 0},
+	}
+
+	for _, tt := range tests {
+		if got := extractBasketID(tt.href); got != tt.want {
+			t.Errorf("extractBasketID(%q) = %d, want %d", tt.href, got, tt.want)
+		}
+	}
+}
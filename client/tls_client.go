@@ -1,41 +1,161 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"sync"
+	"time"
 
 	http "github.com/bogdanfinn/fhttp"
 	tls_client "github.com/bogdanfinn/tls-client"
 	"github.com/bogdanfinn/tls-client/profiles"
 )
 
+// BaseURL is the origin of the default (SK) storefront.
 const BaseURL = "https://www.alza.sk"
 
+// DefaultRefreshMargin is how long before expiry a token is refreshed proactively.
+const DefaultRefreshMargin = 5 * time.Minute
+
 // TLSClient uses tls-client library to bypass Cloudflare
 // It stores auth/session context for all API calls.
 type TLSClient struct {
-	client    tls_client.HttpClient
+	client    Doer
+	tokenMu   sync.RWMutex
 	authToken string
+	expiresAt time.Time // exp claim of authToken, zero if unknown
+	noEarly   string    // token whose proactive refresh failed; wait for a 401 instead
+	refresher TokenRefresher
+	margin    time.Duration
+	refreshMu sync.Mutex // one refresh at a time; see refreshToken
 	userID    string
 	basketID  string
+	store     Storefront
+	baseURL   string // overrides store.BaseURL() for requests (tests, proxies)
+	webAPIURL string // overrides WebAPIURL for requests
+	retry     RetryPolicy
+	limiter   *rateLimiter
+	recorder  *Recorder
+	replayer  *Replayer
 	debug     bool
+	debugOut  io.Writer
 }
 
-// NewTLSClient creates a client with Chrome TLS fingerprint
-func NewTLSClient(debug bool) (*TLSClient, error) {
-	authTokenPath, err := TokenPath()
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve token path: %w", err)
+// New creates a client configured by opts. Without options it behaves like
+// NewTLSClient: SK storefront, token from DefaultTokenStore, Chrome TLS fingerprint
+// and a validation call; ctx bounds that call.
+func New(ctx context.Context, opts ...Option) (*TLSClient, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
 	}
-	tokenData, err := os.ReadFile(authTokenPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read auth token from %s: %w\nRun token refresh or `alza token pull --from <ssh-host>` first", authTokenPath, err)
+
+	c := &TLSClient{
+		client:    o.doer,
+		store:     o.store,
+		baseURL:   o.baseURL,
+		webAPIURL: o.webAPIURL,
+		retry:     o.retry,
+		limiter:   newRateLimiter(o.rateLimit),
+		recorder:  o.recorder,
+		debug:     o.debug,
+		debugOut:  o.debugOut,
+	}
+
+	if o.replayer != nil {
+		// Recordings carry redacted auth and were already throttled when captured
+		c.replayer = o.replayer
+		c.authToken = redactedValue
+		c.retry = NoRetry()
+		c.limiter = nil
+	} else {
+		tokens := o.tokens
+		if tokens == nil {
+			store, err := DefaultTokenStore()
+			if err != nil {
+				return nil, err
+			}
+			tokens = store
+		}
+		authToken, err := tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.setToken(authToken)
+
+		c.refresher = o.refresher
+		c.margin = o.refreshMargin
+		if c.refresher == nil {
+			c.refresher, _ = tokens.(TokenRefresher)
+		}
+
+		if c.client == nil {
+			httpClient, err := newChromeHTTPClient()
+			if err != nil {
+				return nil, err
+			}
+			c.client = httpClient
+		}
 	}
-	authToken := strings.TrimSpace(string(tokenData))
 
-	// Create TLS client with Chrome 120 profile
+	// A token the JWT claims already show as (nearly) expired is refreshed up
+	// front instead of spending a validation call to find out
+	refreshErr := c.refreshIfDue(ctx)
+
+	if !o.skipValidation {
+		// Validate token by checking user status. An expired token is reported
+		// as user_id -1 rather than 401, so refresh here explicitly.
+		err := c.validateToken(ctx)
+		if errors.Is(err, ErrTokenExpired) && c.refresher != nil {
+			if refreshErr == nil {
+				if refreshErr = c.refreshToken(ctx, c.token()); refreshErr == nil {
+					err = c.validateToken(ctx)
+				}
+			}
+			if refreshErr != nil {
+				return nil, fmt.Errorf("%w\n\nAuto-refresh zlyhal: %w", err, refreshErr)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// NewTLSClient creates a client with Chrome TLS fingerprint for the default storefront
+func NewTLSClient(debug bool) (*TLSClient, error) {
+	return NewTLSClientForStorefront(DefaultStorefront(), debug)
+}
+
+// NewTLSClientForStorefront creates a client bound to a specific national e-shop
+func NewTLSClientForStorefront(store Storefront, debug bool) (*TLSClient, error) {
+	return NewTLSClientContext(context.Background(), store, debug)
+}
+
+// NewTLSClientContext is like NewTLSClientForStorefront; ctx bounds the token validation call
+func NewTLSClientContext(ctx context.Context, store Storefront, debug bool) (*TLSClient, error) {
+	return New(ctx, WithStorefront(store), WithDebug(debug))
+}
+
+// NewRecordingTLSClient is like NewTLSClientContext and writes every exchange,
+// including the token validation calls, to rec
+func NewRecordingTLSClient(ctx context.Context, store Storefront, rec *Recorder, debug bool) (*TLSClient, error) {
+	return New(ctx, WithStorefront(store), WithRecorder(rec), WithDebug(debug))
+}
+
+// NewReplayTLSClient answers every request from a recording.
+// It needs neither a saved token nor network access.
+func NewReplayTLSClient(ctx context.Context, store Storefront, rep *Replayer, debug bool) (*TLSClient, error) {
+	return New(ctx, WithStorefront(store), WithReplay(rep), WithDebug(debug))
+}
+
+// newChromeHTTPClient creates the tls-client transport with Chrome 120 profile
+func newChromeHTTPClient() (tls_client.HttpClient, error) {
 	jar := tls_client.NewCookieJar()
 	options := []tls_client.HttpClientOption{
 		tls_client.WithTimeoutSeconds(30),
@@ -48,23 +168,11 @@ func NewTLSClient(debug bool) (*TLSClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TLS client: %w", err)
 	}
-
-	c := &TLSClient{
-		client:    client,
-		authToken: authToken,
-		debug:     debug,
-	}
-
-	// Validate token by checking user status
-	if err := c.validateToken(); err != nil {
-		return nil, err
-	}
-
-	return c, nil
+	return client, nil
 }
 
-func (c *TLSClient) validateToken() error {
-	status, err := c.GetUserStatus()
+func (c *TLSClient) validateToken(ctx context.Context) error {
+	status, err := c.GetUserStatusContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to validate token: %w", err)
 	}
@@ -87,24 +195,116 @@ func (c *TLSClient) validateToken() error {
 	return nil
 }
 
-func (c *TLSClient) setHeaders(req *http.Request) {
-	req.Header = baseHeaders()
-	req.Header.Set("Authorization", c.authToken)
+func (c *TLSClient) setHeaders(req *http.Request, token string) {
+	req.Header = storefrontHeaders(c.storefront())
+	req.Header.Set("Authorization", token)
+}
+
+// token returns the current Authorization value
+func (c *TLSClient) token() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.authToken
+}
+
+// setToken swaps the Authorization value and remembers its JWT expiry
+func (c *TLSClient) setToken(token string) {
+	var expiresAt time.Time
+	if claims, err := ParseTokenClaims(token); err == nil {
+		expiresAt = claims.ExpiresAt
+	}
+	c.tokenMu.Lock()
+	c.authToken = token
+	c.expiresAt = expiresAt
+	c.tokenMu.Unlock()
+}
+
+// TokenClaims decodes the current token without a network call.
+func (c *TLSClient) TokenClaims() (*TokenClaims, error) {
+	return ParseTokenClaims(c.token())
+}
+
+// refreshIfDue refreshes a token that expires within the margin. A failure is
+// remembered so later requests don't retry it; they fall back to the 401 path.
+func (c *TLSClient) refreshIfDue(ctx context.Context) error {
+	if c.refresher == nil || c.replayer != nil || c.margin <= 0 {
+		return nil
+	}
+	c.tokenMu.RLock()
+	token, expiresAt, skip := c.authToken, c.expiresAt, c.noEarly
+	c.tokenMu.RUnlock()
+	if expiresAt.IsZero() || token == skip || time.Until(expiresAt) > c.margin {
+		return nil
+	}
+
+	if c.debug {
+		fmt.Fprintf(c.debugOut, "[DEBUG] Auth token expires at %s, refreshing early\n", expiresAt.Format(time.RFC3339))
+	}
+	err := c.refreshToken(ctx, token)
+	if err != nil {
+		c.tokenMu.Lock()
+		c.noEarly = token
+		c.tokenMu.Unlock()
+		if c.debug {
+			fmt.Fprintf(c.debugOut, "[DEBUG] Early token refresh failed: %v\n", err)
+		}
+	}
+	return err
+}
+
+// refreshToken replaces stale, the token a request was rejected with. Callers that
+// lost the race find the token already swapped and return without refreshing again.
+func (c *TLSClient) refreshToken(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if c.token() != stale {
+		return nil
+	}
+	if c.debug {
+		fmt.Fprintln(c.debugOut, "[DEBUG] Auth token rejected, refreshing")
+	}
+	token, err := c.refresher.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("token refresher returned an empty token")
+	}
+
+	c.setToken(token)
+	return nil
 }
 
 // Get performs a GET request
 func (c *TLSClient) Get(endpoint string) ([]byte, error) {
-	return c.doRequest("GET", endpoint, nil, "", "")
+	return c.GetContext(context.Background(), endpoint)
+}
+
+// GetContext performs a GET request bound to ctx
+func (c *TLSClient) GetContext(ctx context.Context, endpoint string) ([]byte, error) {
+	return c.doRequest(ctx, "GET", endpoint, "", "")
 }
 
 // Post performs a POST request
 func (c *TLSClient) Post(endpoint string, bodyStr string) ([]byte, error) {
-	return c.doRequest("POST", endpoint, strings.NewReader(bodyStr), "application/json", bodyStr)
+	return c.PostContext(context.Background(), endpoint, bodyStr)
+}
+
+// PostContext performs a POST request bound to ctx
+func (c *TLSClient) PostContext(ctx context.Context, endpoint string, bodyStr string) ([]byte, error) {
+	return c.doRequest(ctx, "POST", endpoint, bodyStr, "application/json")
 }
 
 // Delete performs a DELETE request
 func (c *TLSClient) Delete(endpoint string) ([]byte, error) {
-	return c.doRequest("DELETE", endpoint, nil, "", "")
+	return c.DeleteContext(context.Background(), endpoint)
+}
+
+// DeleteContext performs a DELETE request bound to ctx
+func (c *TLSClient) DeleteContext(ctx context.Context, endpoint string) ([]byte, error) {
+	return c.doRequest(ctx, "DELETE", endpoint, "", "")
 }
 
 // SetUserID sets the user ID
@@ -126,3 +326,29 @@ func (c *TLSClient) GetUserID() string {
 func (c *TLSClient) GetBasketID() string {
 	return c.basketID
 }
+
+// SetRetryPolicy replaces the retry policy used for GET requests
+func (c *TLSClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// SetRateLimit replaces the per-host request throttling; PerSecond <= 0 disables it.
+// Replay clients never throttle.
+func (c *TLSClient) SetRateLimit(r RateLimit) {
+	if c.replayer != nil {
+		return
+	}
+	c.limiter = newRateLimiter(r)
+}
+
+// Storefront returns the national e-shop this client talks to
+func (c *TLSClient) Storefront() Storefront {
+	return c.storefront()
+}
+
+func (c *TLSClient) storefront() Storefront {
+	if c.store.Domain == "" {
+		return DefaultStorefront()
+	}
+	return c.store
+}
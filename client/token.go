@@ -1,20 +1,67 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 )
 
-// SaveToken saves the token to config file.
-func SaveToken(token string) error {
-	path, err := TokenPath()
-	if err != nil {
-		return err
+// TokenSource supplies the Authorization header value (e.g. "Bearer eyJ...").
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+// Token implements TokenSource.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	if t == "" {
+		return "", errors.New("empty auth token")
 	}
+	return string(t), nil
+}
+
+// FileTokenSource reads the token from a file such as auth_token.txt.
+type FileTokenSource struct {
+	Path string // Empty means TokenPath()
+}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
-		return err
+// Token implements TokenSource.
+func (s FileTokenSource) Token(ctx context.Context) (string, error) {
+	path := s.Path
+	if path == "" {
+		var err error
+		if path, err = TokenPath(); err != nil {
+			return "", fmt.Errorf("failed to resolve token path: %w", err)
+		}
 	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth token from %s: %w\nRun token refresh or `alza token pull --from <ssh-host>` first", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// TokenRefresher obtains a new token after the API rejected the current one
+// (e.g. from Chrome cookies or another host). The client calls it at most once
+// per request and serializes concurrent calls.
+type TokenRefresher interface {
+	Refresh(ctx context.Context) (string, error)
+}
 
-	return os.WriteFile(path, []byte(token), 0600)
+// RefreshFunc adapts a plain function to TokenRefresher.
+type RefreshFunc func(ctx context.Context) (string, error)
+
+// Refresh implements TokenRefresher.
+func (f RefreshFunc) Refresh(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// SaveToken saves the token in plaintext to auth_token.txt, like
+// PlaintextTokenStore. Use a TokenStore from OpenTokenStore to keep it secret.
+func SaveToken(token string) error {
+	return PlaintextTokenStore{}.Save(context.Background(), token)
 }
@@ -1,9 +1,17 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestSaveToken(t *testing.T) {
@@ -111,3 +119,300 @@ func TestSaveTokenOverwrites(t *testing.T) {
 		t.Errorf("saved token = %q, want token_v2", string(data))
 	}
 }
+
+// countingRefresher hands out token and counts how often it was asked
+type countingRefresher struct {
+	token string
+	err   error
+	delay time.Duration
+	calls int32
+}
+
+func (r *countingRefresher) Refresh(ctx context.Context) (string, error) {
+	atomic.AddInt32(&r.calls, 1)
+	time.Sleep(r.delay)
+	return r.token, r.err
+}
+
+func newRefreshingClient(t *testing.T, refresher TokenRefresher, hits *int32) *TLSClient {
+	t.Helper()
+	server := newFakeAlza(t, hits)
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken("Bearer stale"),
+		WithTokenRefresher(refresher),
+		WithoutValidation(),
+		WithRateLimit(RateLimit{}),
+		WithRetryPolicy(NoRetry()),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return c
+}
+
+func TestRefreshOn401ReplaysRequest(t *testing.T) {
+	var hits int32
+	refresher := &countingRefresher{token: "Bearer fake"}
+	c := newRefreshingClient(t, refresher, &hits)
+
+	stats, err := c.GetReviewStatsContext(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetReviewStats() error: %v", err)
+	}
+	if stats.RatingCount != 10 {
+		t.Errorf("RatingCount = %d, want 10", stats.RatingCount)
+	}
+	if refresher.calls != 1 || hits != 2 {
+		t.Errorf("refresh calls = %d, hits = %d; want 1 refresh and 2 requests", refresher.calls, hits)
+	}
+	if c.token() != "Bearer fake" {
+		t.Errorf("token() = %q, want the refreshed token kept for later calls", c.token())
+	}
+
+	if _, err := c.GetReviewStatsContext(context.Background(), 1); err != nil {
+		t.Fatalf("second GetReviewStats() error: %v", err)
+	}
+	if refresher.calls != 1 {
+		t.Errorf("refresh calls = %d after a successful request, want 1", refresher.calls)
+	}
+}
+
+func TestRefreshOnlyOncePerRequest(t *testing.T) {
+	var hits int32
+	refresher := &countingRefresher{token: "Bearer still-wrong"}
+	c := newRefreshingClient(t, refresher, &hits)
+
+	_, err := c.GetReviewStatsContext(context.Background(), 1)
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("error = %v, want ErrAuthRequired", err)
+	}
+	if refresher.calls != 1 || hits != 2 {
+		t.Errorf("refresh calls = %d, hits = %d; want 1 and 2", refresher.calls, hits)
+	}
+}
+
+func TestRefreshErrorIsReported(t *testing.T) {
+	refresher := &countingRefresher{err: errors.New("no cookies")}
+	c := newRefreshingClient(t, refresher, nil)
+
+	_, err := c.GetReviewStatsContext(context.Background(), 1)
+	if !errors.Is(err, ErrAuthRequired) || !errors.Is(err, refresher.err) {
+		t.Fatalf("error = %v, want ErrAuthRequired joined with the refresh error", err)
+	}
+}
+
+func TestRefreshEmptyTokenIsRejected(t *testing.T) {
+	refresher := &countingRefresher{token: "  "}
+	c := newRefreshingClient(t, refresher, nil)
+
+	if _, err := c.GetReviewStatsContext(context.Background(), 1); err == nil {
+		t.Fatal("expected an error for an empty refreshed token")
+	}
+	if c.token() != "Bearer stale" {
+		t.Errorf("token() = %q, an empty refresh must not replace the token", c.token())
+	}
+}
+
+func TestConcurrentRequestsRefreshOnce(t *testing.T) {
+	refresher := &countingRefresher{token: "Bearer fake", delay: 20 * time.Millisecond}
+	c := newRefreshingClient(t, refresher, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetReviewStatsContext(context.Background(), 1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetReviewStats() error: %v", err)
+		}
+	}
+	if refresher.calls != 1 {
+		t.Errorf("refresh calls = %d, want 1 for concurrent 401s", refresher.calls)
+	}
+}
+
+func TestNewRefreshesDuringValidation(t *testing.T) {
+	server := newFakeAlza(t, nil)
+	refresher := &countingRefresher{token: "Bearer fake"}
+
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken("Bearer stale"),
+		WithTokenRefresher(refresher),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if c.GetUserID() != "7" || refresher.calls != 1 {
+		t.Errorf("userID = %q, refresh calls = %d; want 7 and 1", c.GetUserID(), refresher.calls)
+	}
+}
+
+// refreshableSource is a TokenSource that can also refresh itself
+type refreshableSource struct{ StaticToken }
+
+func (refreshableSource) Refresh(ctx context.Context) (string, error) {
+	return "Bearer fake", nil
+}
+
+func TestTokenSourceRefresherIsUsed(t *testing.T) {
+	server := newFakeAlza(t, nil)
+
+	_, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithTokenSource(refreshableSource{"Bearer stale"}),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+}
+
+func TestReplayNeverRefreshes(t *testing.T) {
+	rep := NewReplayer([]Exchange{{Method: "GET", URL: "https://www.alza.sk/x", Status: 401}})
+	refresher := &countingRefresher{token: "Bearer fake"}
+	c, err := New(context.Background(), WithReplay(rep), WithTokenRefresher(refresher), WithoutValidation())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if _, err := c.GetContext(context.Background(), "/x"); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("error = %v, want recorded 401 as ErrAuthRequired", err)
+	}
+	if refresher.calls != 0 {
+		t.Errorf("refresh calls = %d in replay mode, want 0", refresher.calls)
+	}
+}
+
+// newJWTServer accepts only the token currently stored in accepted
+func newJWTServer(t *testing.T, accepted *atomic.Value, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get("Authorization") != accepted.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ratingAverage":4.2,"ratingCount":10}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProactiveRefreshBeforeExpiry(t *testing.T) {
+	expiring := testJWT(t, map[string]any{"sub": "7", "exp": time.Now().Add(time.Minute).Unix()})
+	fresh := testJWT(t, map[string]any{"sub": "7", "exp": time.Now().Add(90 * time.Minute).Unix()})
+
+	var accepted atomic.Value
+	accepted.Store(fresh)
+	var hits int32
+	server := newJWTServer(t, &accepted, &hits)
+	refresher := &countingRefresher{token: fresh}
+
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken(expiring),
+		WithTokenRefresher(refresher),
+		WithRefreshMargin(5*time.Minute),
+		WithoutValidation(),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if refresher.calls != 1 {
+		t.Fatalf("refresh calls = %d, want 1 in New for a token 1m from expiry", refresher.calls)
+	}
+
+	if _, err := c.GetReviewStatsContext(context.Background(), 1); err != nil {
+		t.Fatalf("GetReviewStats() error: %v", err)
+	}
+	if hits != 1 || refresher.calls != 1 {
+		t.Errorf("hits = %d, refresh calls = %d; want no 401 round-trip and no second refresh", hits, refresher.calls)
+	}
+}
+
+func TestProactiveRefreshDisabledByMargin(t *testing.T) {
+	expiring := testJWT(t, map[string]any{"exp": time.Now().Add(time.Minute).Unix()})
+	var accepted atomic.Value
+	accepted.Store(expiring)
+	var hits int32
+	server := newJWTServer(t, &accepted, &hits)
+	refresher := &countingRefresher{token: "Bearer other"}
+
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken(expiring),
+		WithTokenRefresher(refresher),
+		WithRefreshMargin(0),
+		WithoutValidation(),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if _, err := c.GetReviewStatsContext(context.Background(), 1); err != nil {
+		t.Fatalf("GetReviewStats() error: %v", err)
+	}
+	if refresher.calls != 0 {
+		t.Errorf("refresh calls = %d with margin 0, want 0", refresher.calls)
+	}
+}
+
+func TestProactiveRefreshFailureIsNotRepeated(t *testing.T) {
+	expiring := testJWT(t, map[string]any{"exp": time.Now().Add(time.Minute).Unix()})
+	var accepted atomic.Value
+	accepted.Store(expiring) // still valid for a minute
+	var hits int32
+	server := newJWTServer(t, &accepted, &hits)
+	refresher := &countingRefresher{err: errors.New("chrome not running")}
+
+	c, err := New(context.Background(),
+		WithBaseURL(server.URL),
+		WithWebAPIURL(server.URL),
+		WithToken(expiring),
+		WithTokenRefresher(refresher),
+		WithoutValidation(),
+		WithRateLimit(RateLimit{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	for range 3 {
+		if _, err := c.GetReviewStatsContext(context.Background(), 1); err != nil {
+			t.Fatalf("GetReviewStats() error: %v", err)
+		}
+	}
+	if refresher.calls != 1 {
+		t.Errorf("refresh calls = %d, want 1 (failed early refresh is not retried per request)", refresher.calls)
+	}
+}
+
+func TestClientTokenClaims(t *testing.T) {
+	c := &TLSClient{}
+	c.setToken(testJWT(t, map[string]any{"sub": "99", "exp": 2000000000}))
+
+	claims, err := c.TokenClaims()
+	if err != nil {
+		t.Fatalf("TokenClaims() error: %v", err)
+	}
+	if claims.UserID != "99" || !c.expiresAt.Equal(time.Unix(2000000000, 0)) {
+		t.Errorf("claims = %+v, expiresAt = %v", claims, c.expiresAt)
+	}
+}
@@ -0,0 +1,488 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// TokenStore keeps the bearer token between runs. The token lets anyone order
+// with a saved card, so the default store never writes it in plaintext.
+type TokenStore interface {
+	TokenSource
+	Save(ctx context.Context, token string) error
+	String() string // Where the token lives, for messages
+}
+
+// TokenStoreKind selects a TokenStore backend (ALZA_TOKEN_STORE).
+type TokenStoreKind string
+
+const (
+	// TokenStoreAuto reads the keyring, then auth_token.enc, then a legacy
+	// auth_token.txt, and saves to the keyring or, without one, to the
+	// encrypted file when a passphrase is configured.
+	TokenStoreAuto TokenStoreKind = "auto"
+	// TokenStoreKeyring uses the Secret Service (GNOME Keyring, KWallet) via secret-tool.
+	TokenStoreKeyring TokenStoreKind = "keyring"
+	// TokenStoreEncrypted uses auth_token.enc, encrypted with ALZA_TOKEN_PASSPHRASE.
+	TokenStoreEncrypted TokenStoreKind = "encrypted"
+	// TokenStorePlaintext uses auth_token.txt as before; explicit opt-in only.
+	TokenStorePlaintext TokenStoreKind = "plaintext"
+)
+
+// ErrNoPassphrase means the encrypted token store has no passphrase configured.
+var ErrNoPassphrase = errors.New("no token passphrase (set ALZA_TOKEN_PASSPHRASE or ALZA_TOKEN_PASSPHRASE_FILE)")
+
+// ErrNoSecureStore means auto found neither a keyring nor a passphrase to save the token with.
+var ErrNoSecureStore = errors.New("no secure token store available")
+
+// OpenTokenStore returns the store for kind of the default profile; empty
+// means TokenStoreAuto.
+func OpenTokenStore(kind TokenStoreKind) (TokenStore, error) {
+	return openTokenStore(kind, KeyringTokenStore{}, EncryptedFileTokenStore{}, PlaintextTokenStore{})
+}
+
+func openTokenStore(kind TokenStoreKind, keyring KeyringTokenStore, enc EncryptedFileTokenStore, plain PlaintextTokenStore) (TokenStore, error) {
+	switch kind {
+	case TokenStoreAuto, "":
+		return &autoTokenStore{keyring: keyring, enc: enc, plain: plain}, nil
+	case TokenStoreKeyring:
+		return keyring, nil
+	case TokenStoreEncrypted:
+		return enc, nil
+	case TokenStorePlaintext:
+		return plain, nil
+	}
+	return nil, fmt.Errorf("unknown token store %q (auto, keyring, encrypted, plaintext)", kind)
+}
+
+// DefaultTokenStore is the store named by ALZA_TOKEN_STORE, auto when unset.
+func DefaultTokenStore() (TokenStore, error) {
+	return OpenTokenStore(TokenStoreKind(strings.ToLower(strings.TrimSpace(os.Getenv("ALZA_TOKEN_STORE")))))
+}
+
+// === PLAINTEXT ===
+
+// PlaintextTokenStore is auth_token.txt readable only by the current user.
+type PlaintextTokenStore struct {
+	Path string // Empty means TokenPath()
+}
+
+// Token implements TokenSource.
+func (s PlaintextTokenStore) Token(ctx context.Context) (string, error) {
+	return FileTokenSource(s).Token(ctx)
+}
+
+// Save implements TokenStore.
+func (s PlaintextTokenStore) Save(ctx context.Context, token string) error {
+	path, err := s.path()
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(path, []byte(token))
+}
+
+func (s PlaintextTokenStore) String() string {
+	path, _ := s.path()
+	return path
+}
+
+func (s PlaintextTokenStore) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+	return TokenPath()
+}
+
+// === KEYRING ===
+
+// KeyringTokenStore keeps the token in the Secret Service over D-Bus, using
+// secret-tool (libsecret) so no D-Bus client is linked in.
+type KeyringTokenStore struct {
+	Service string // Empty means "alza-cli"
+	Account string // Empty means "auth-token"
+}
+
+// Token implements TokenSource.
+func (s KeyringTokenStore) Token(ctx context.Context) (string, error) {
+	if !secretServiceAvailable() {
+		return "", errNoSecretService
+	}
+	out, err := secretTool(ctx, "", "lookup", "service", s.service(), "account", s.account())
+	if err != nil && !errors.Is(err, errSecretNotFound) {
+		return "", fmt.Errorf("read auth token from %s: %w", s, err)
+	}
+	token := strings.TrimSpace(out)
+	if token == "" {
+		return "", fmt.Errorf("no auth token in %s\nRun token refresh or `alza token pull --from <ssh-host>` first", s)
+	}
+	return token, nil
+}
+
+// Save implements TokenStore.
+func (s KeyringTokenStore) Save(ctx context.Context, token string) error {
+	if !secretServiceAvailable() {
+		return errNoSecretService
+	}
+	if _, err := secretTool(ctx, token, "store", "--label", "alza-cli auth token", "service", s.service(), "account", s.account()); err != nil {
+		return fmt.Errorf("save auth token to %s: %w", s, err)
+	}
+	return nil
+}
+
+// Delete removes the token from the keyring; a missing entry is not an error.
+func (s KeyringTokenStore) Delete(ctx context.Context) error {
+	if !secretServiceAvailable() {
+		return errNoSecretService
+	}
+	if _, err := secretTool(ctx, "", "clear", "service", s.service(), "account", s.account()); err != nil && !errors.Is(err, errSecretNotFound) {
+		return fmt.Errorf("delete auth token from %s: %w", s, err)
+	}
+	return nil
+}
+
+func (s KeyringTokenStore) String() string {
+	return fmt.Sprintf("keyring (service=%s account=%s)", s.service(), s.account())
+}
+
+func (s KeyringTokenStore) service() string {
+	if s.Service != "" {
+		return s.Service
+	}
+	return "alza-cli"
+}
+
+func (s KeyringTokenStore) account() string {
+	if s.Account != "" {
+		return s.Account
+	}
+	return "auth-token"
+}
+
+var (
+	errNoSecretService = errors.New("no Secret Service keyring (needs secret-tool and a D-Bus session)")
+	errSecretNotFound  = errors.New("secret-tool: not found")
+)
+
+// secretServiceAvailable reports whether secret-tool can reach a keyring.
+// Without a session bus (SSH, systemd services, containers) there is none.
+var secretServiceAvailable = func() bool {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return false
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return true
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(runtimeDir, "bus"))
+	return err == nil
+}
+
+// secretTool runs secret-tool with stdin and returns its stdout. A silent exit
+// status 1 is how lookup reports that nothing is stored.
+var secretTool = func(ctx context.Context, stdin string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("secret-tool: %s", msg)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && ctx.Err() == nil {
+			return stdout.String(), errSecretNotFound
+		}
+		return stdout.String(), fmt.Errorf("secret-tool: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// === ENCRYPTED FILE ===
+
+// EncryptedFileTokenStore keeps the token in auth_token.enc, sealed with
+// XChaCha20-Poly1305 under a key derived from a passphrase with scrypt
+// (N=2^logN, r=8, p=1). The file format is alza-cli's own (see
+// encryptedTokenMagic); age and other tools can't read it.
+type EncryptedFileTokenStore struct {
+	Path       string                 // Empty means EncryptedTokenPath()
+	Passphrase func() (string, error) // Nil means PassphraseFromEnv
+}
+
+// encryptedTokenMagic starts the single line of auth_token.enc:
+// "alza-token-v1 scrypt <logN> <salt> <nonce||ciphertext>", base64 without padding.
+const encryptedTokenMagic = "alza-token-v1"
+
+// scryptLogN is the work factor of new files (2^15, ~50 ms); files with more
+// than maxScryptLogN are rejected rather than hanging on a hostile header.
+const (
+	scryptLogN    = 15
+	maxScryptLogN = 22
+)
+
+// Token implements TokenSource.
+func (s EncryptedFileTokenStore) Token(ctx context.Context) (string, error) {
+	path, err := s.path()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth token from %s: %w\nRun token refresh or `alza token pull --from <ssh-host>` first", path, err)
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return "", err
+	}
+	token, err := openToken(strings.TrimSpace(string(data)), passphrase)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return token, nil
+}
+
+// Save implements TokenStore.
+func (s EncryptedFileTokenStore) Save(ctx context.Context, token string) error {
+	path, err := s.path()
+	if err != nil {
+		return err
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealToken(token, passphrase, scryptLogN)
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(path, []byte(sealed+"\n"))
+}
+
+func (s EncryptedFileTokenStore) String() string {
+	path, _ := s.path()
+	return path + " (encrypted)"
+}
+
+func (s EncryptedFileTokenStore) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+	return EncryptedTokenPath()
+}
+
+func (s EncryptedFileTokenStore) passphrase() (string, error) {
+	if s.Passphrase != nil {
+		return s.Passphrase()
+	}
+	return PassphraseFromEnv()
+}
+
+// PassphraseFromEnv returns ALZA_TOKEN_PASSPHRASE, or the first line of the
+// file named by ALZA_TOKEN_PASSPHRASE_FILE.
+func PassphraseFromEnv() (string, error) {
+	if p := os.Getenv("ALZA_TOKEN_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	file := os.Getenv("ALZA_TOKEN_PASSPHRASE_FILE")
+	if file == "" {
+		return "", ErrNoPassphrase
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read ALZA_TOKEN_PASSPHRASE_FILE: %w", err)
+	}
+	p, _, _ := strings.Cut(string(data), "\n")
+	p = strings.TrimRight(p, "\r")
+	if p == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNoPassphrase, file)
+	}
+	return p, nil
+}
+
+func sealToken(token, passphrase string, logN int) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	header := fmt.Sprintf("%s scrypt %d %s", encryptedTokenMagic, logN, b64.EncodeToString(salt))
+	aead, err := tokenAEAD(passphrase, salt, logN)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(token)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	// The header is authenticated too, so the work factor can't be swapped
+	sealed := aead.Seal(nonce, nonce, []byte(token), []byte(header))
+	return header + " " + b64.EncodeToString(sealed), nil
+}
+
+func openToken(line, passphrase string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 5 || fields[0] != encryptedTokenMagic || fields[1] != "scrypt" {
+		return "", errors.New("not an encrypted alza token file")
+	}
+	logN, err := strconv.Atoi(fields[2])
+	if err != nil || logN < 1 || logN > maxScryptLogN {
+		return "", fmt.Errorf("bad scrypt work factor %q", fields[2])
+	}
+	salt, err := b64.DecodeString(fields[3])
+	if err != nil {
+		return "", errors.New("bad salt")
+	}
+	sealed, err := b64.DecodeString(fields[4])
+	if err != nil {
+		return "", errors.New("bad ciphertext")
+	}
+
+	aead, err := tokenAEAD(passphrase, salt, logN)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(strings.Join(fields[:4], " ")))
+	if err != nil {
+		return "", errors.New("wrong passphrase or corrupted file")
+	}
+	return string(plain), nil
+}
+
+func tokenAEAD(passphrase string, salt []byte, logN int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<logN, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+var b64 = base64.RawStdEncoding
+
+// === AUTO ===
+
+// autoTokenStore picks the most secure backend that works on this machine.
+type autoTokenStore struct {
+	keyring KeyringTokenStore
+	enc     EncryptedFileTokenStore
+	plain   PlaintextTokenStore
+	last    TokenStore // Backend of the last successful Token or Save, for String
+}
+
+// Token implements TokenSource.
+func (s *autoTokenStore) Token(ctx context.Context) (string, error) {
+	var keyringErr error
+	if secretServiceAvailable() {
+		token, err := s.keyring.Token(ctx)
+		if err == nil {
+			s.last = s.keyring
+			return token, nil
+		}
+		keyringErr = err
+	}
+
+	if path, err := s.enc.path(); err == nil && fileExists(path) {
+		s.last = s.enc
+		return s.enc.Token(ctx)
+	}
+
+	// Tokens saved before the secure stores existed
+	token, err := s.plain.Token(ctx)
+	if err != nil {
+		if keyringErr != nil && errors.Is(err, fs.ErrNotExist) {
+			return "", keyringErr
+		}
+		return "", err
+	}
+	s.last = s.plain
+	return token, nil
+}
+
+// Save implements TokenStore. A legacy auth_token.txt is removed once the
+// token is stored securely.
+func (s *autoTokenStore) Save(ctx context.Context, token string) error {
+	var store TokenStore
+	switch {
+	case secretServiceAvailable():
+		store = s.keyring
+	case passphraseConfigured():
+		store = s.enc
+	default:
+		return fmt.Errorf("%w: unlock a Secret Service keyring (secret-tool), set ALZA_TOKEN_PASSPHRASE for an encrypted file, or opt in to plaintext with --token-store plaintext (ALZA_TOKEN_STORE=plaintext)", ErrNoSecureStore)
+	}
+	if err := store.Save(ctx, token); err != nil {
+		return err
+	}
+	s.last = store
+
+	if path, err := s.plain.path(); err == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("token saved to %s, but removing the plaintext %s failed: %w", store, path, err)
+		}
+	}
+	return nil
+}
+
+func (s *autoTokenStore) String() string {
+	if s.last != nil {
+		return s.last.String()
+	}
+	return "auto (keyring, encrypted file, plaintext file)"
+}
+
+func passphraseConfigured() bool {
+	return os.Getenv("ALZA_TOKEN_PASSPHRASE") != "" || os.Getenv("ALZA_TOKEN_PASSPHRASE_FILE") != ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// WritePrivateFile replaces path atomically with a file only the current user
+// can read, creating its directory if needed.
+func WritePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring replaces secret-tool with an in-memory Secret Service.
+func fakeKeyring(t *testing.T) map[string]string {
+	t.Helper()
+	secrets := map[string]string{}
+	oldAvailable, oldTool := secretServiceAvailable, secretTool
+	t.Cleanup(func() { secretServiceAvailable, secretTool = oldAvailable, oldTool })
+
+	secretServiceAvailable = func() bool { return true }
+	secretTool = func(ctx context.Context, stdin string, args ...string) (string, error) {
+		switch args[0] {
+		case "lookup":
+			key := strings.Join(args[1:], " ")
+			if v, ok := secrets[key]; ok {
+				return v, nil
+			}
+			return "", errSecretNotFound
+		case "store":
+			secrets[strings.Join(args[3:], " ")] = stdin
+			return "", nil
+		case "clear":
+			delete(secrets, strings.Join(args[1:], " "))
+			return "", nil
+		}
+		t.Fatalf("unexpected secret-tool %v", args)
+		return "", nil
+	}
+	return secrets
+}
+
+// withoutKeyring makes auto behave like a headless machine.
+func withoutKeyring(t *testing.T) {
+	t.Helper()
+	old := secretServiceAvailable
+	t.Cleanup(func() { secretServiceAvailable = old })
+	secretServiceAvailable = func() bool { return false }
+}
+
+func TestEncryptedFileTokenStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alza", "auth_token.enc")
+	store := EncryptedFileTokenStore{Path: path, Passphrase: func() (string, error) { return "correct horse", nil }}
+
+	if err := store.Save(context.Background(), "Bearer secret-token"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") || !strings.HasPrefix(string(data), "alza-token-v1 scrypt 15 ") {
+		t.Errorf("file content = %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	token, err := store.Token(context.Background())
+	if err != nil || token != "Bearer secret-token" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+
+	wrong := EncryptedFileTokenStore{Path: path, Passphrase: func() (string, error) { return "battery staple", nil }}
+	if _, err := wrong.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Token() with wrong passphrase error = %v", err)
+	}
+}
+
+func TestOpenTokenRejectsTampering(t *testing.T) {
+	sealed, err := sealToken("Bearer x", "pw", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := openToken(sealed, "pw"); err != nil || token != "Bearer x" {
+		t.Fatalf("openToken() = %q, %v", token, err)
+	}
+
+	for name, line := range map[string]string{
+		"work factor":  strings.Replace(sealed, " scrypt 10 ", " scrypt 11 ", 1),
+		"huge factor":  strings.Replace(sealed, " scrypt 10 ", " scrypt 40 ", 1),
+		"other format": strings.Replace(sealed, "alza-token-v1", "age-encryption.org/v1", 1),
+		"truncated":    sealed[:len(sealed)-10],
+		"empty":        "",
+	} {
+		if _, err := openToken(line, "pw"); err == nil {
+			t.Errorf("%s: openToken() expected error", name)
+		}
+	}
+}
+
+func TestPassphraseFromEnv(t *testing.T) {
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "")
+	t.Setenv("ALZA_TOKEN_PASSPHRASE_FILE", "")
+	if _, err := PassphraseFromEnv(); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("PassphraseFromEnv() error = %v, want ErrNoPassphrase", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(file, []byte("from file\r\nignored\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ALZA_TOKEN_PASSPHRASE_FILE", file)
+	if p, err := PassphraseFromEnv(); err != nil || p != "from file" {
+		t.Errorf("PassphraseFromEnv() = %q, %v", p, err)
+	}
+
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "from env")
+	if p, err := PassphraseFromEnv(); err != nil || p != "from env" {
+		t.Errorf("PassphraseFromEnv() = %q, %v, env should win", p, err)
+	}
+}
+
+func TestKeyringTokenStore(t *testing.T) {
+	secrets := fakeKeyring(t)
+	store := KeyringTokenStore{}
+
+	_, err := store.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no auth token in keyring") {
+		t.Errorf("Token() on empty keyring error = %v", err)
+	}
+
+	if err := store.Save(context.Background(), "Bearer kr"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if got := secrets["service alza-cli account auth-token"]; got != "Bearer kr" {
+		t.Errorf("stored secret = %q, secrets = %v", got, secrets)
+	}
+	if token, err := store.Token(context.Background()); err != nil || token != "Bearer kr" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+}
+
+func TestKeyringTokenStoreUnavailable(t *testing.T) {
+	withoutKeyring(t)
+	if err := (KeyringTokenStore{}).Save(context.Background(), "Bearer x"); !errors.Is(err, errNoSecretService) {
+		t.Errorf("Save() error = %v, want errNoSecretService", err)
+	}
+}
+
+func TestAutoTokenStoreHeadless(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "")
+	t.Setenv("ALZA_TOKEN_PASSPHRASE_FILE", "")
+	withoutKeyring(t)
+	store, _ := OpenTokenStore(TokenStoreAuto)
+
+	// Plaintext is never written without opting in
+	if err := store.Save(context.Background(), "Bearer new"); !errors.Is(err, ErrNoSecureStore) {
+		t.Fatalf("Save() error = %v, want ErrNoSecureStore", err)
+	}
+	plainPath, _ := TokenPath()
+	if _, err := os.Stat(plainPath); err == nil {
+		t.Fatal("auth_token.txt written without opt-in")
+	}
+
+	// ...but a token saved by older versions is still read
+	if err := SaveToken("Bearer legacy"); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := store.Token(context.Background()); err != nil || token != "Bearer legacy" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+	if store.String() != plainPath {
+		t.Errorf("String() = %q, want %q", store.String(), plainPath)
+	}
+
+	// With a passphrase the token moves into auth_token.enc
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "pw")
+	if err := store.Save(context.Background(), "Bearer encrypted"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := os.Stat(plainPath); err == nil {
+		t.Error("legacy auth_token.txt not removed")
+	}
+	fresh, _ := OpenTokenStore(TokenStoreAuto)
+	if token, err := fresh.Token(context.Background()); err != nil || token != "Bearer encrypted" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+	if !strings.HasSuffix(fresh.String(), "auth_token.enc (encrypted)") {
+		t.Errorf("String() = %q", fresh.String())
+	}
+}
+
+func TestAutoTokenStorePrefersKeyring(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "pw")
+	fakeKeyring(t)
+
+	if err := SaveToken("Bearer legacy"); err != nil {
+		t.Fatal(err)
+	}
+	store, _ := OpenTokenStore(TokenStoreAuto)
+	// Nothing in the keyring yet: falls back to the legacy file
+	if token, err := store.Token(context.Background()); err != nil || token != "Bearer legacy" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+
+	if err := store.Save(context.Background(), "Bearer kr"); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if !strings.HasPrefix(store.String(), "keyring") {
+		t.Errorf("String() = %q, want keyring", store.String())
+	}
+	encPath, _ := EncryptedTokenPath()
+	plainPath, _ := TokenPath()
+	for _, path := range []string{encPath, plainPath} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("%s exists, token should only be in the keyring", path)
+		}
+	}
+	if token, err := store.Token(context.Background()); err != nil || token != "Bearer kr" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+}
+
+func TestOpenTokenStore(t *testing.T) {
+	for kind, want := range map[TokenStoreKind]string{
+		"":                  "*client.autoTokenStore",
+		TokenStoreKeyring:   "client.KeyringTokenStore",
+		TokenStoreEncrypted: "client.EncryptedFileTokenStore",
+		TokenStorePlaintext: "client.PlaintextTokenStore",
+	} {
+		store, err := OpenTokenStore(kind)
+		if err != nil {
+			t.Fatalf("OpenTokenStore(%q) error: %v", kind, err)
+		}
+		if got := fmt.Sprintf("%T", store); got != want {
+			t.Errorf("OpenTokenStore(%q) = %s, want %s", kind, got, want)
+		}
+	}
+	if _, err := OpenTokenStore("vault"); err == nil {
+		t.Error("OpenTokenStore(vault) expected error")
+	}
+}
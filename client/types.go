@@ -19,6 +19,40 @@ type SearchResult struct {
 	Availability string  `json:"availability"`
 	ImageURL     string  `json:"imageUrl"`
 	URL          string  `json:"url"`
+	Rating       float64 `json:"rating,omitempty"`      // Only when sorted by rating or bestselling
+	RatingCount  int     `json:"ratingCount,omitempty"` // Only when sorted by rating or bestselling
+}
+
+// Category is a catalog category. GetCategory fills Children with the direct
+// subcategories only; their own Children stay empty.
+type Category struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	URL          string     `json:"url,omitempty"`
+	ParentID     int        `json:"parentId,omitempty"`
+	ProductCount int        `json:"productCount"`
+	Children     []Category `json:"children,omitempty"`
+}
+
+// CategoryProducts is one page of a category's products
+type CategoryProducts struct {
+	CategoryID int            `json:"categoryId"`
+	Name       string         `json:"name"`
+	Total      int            `json:"total"`
+	Offset     int            `json:"offset"`
+	Products   []SearchResult `json:"products"`
+}
+
+type Producer struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+	ImageURL string `json:"imageUrl,omitempty"`
+}
+
+type PromoSection struct {
+	Title    string         `json:"title"`
+	Products []SearchResult `json:"products"`
 }
 
 type WhisperResponse struct {
@@ -1,13 +1,21 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
+// GetUserStatus returns the logged in user's summary (UserID <= 0 means not logged in)
 func (c *TLSClient) GetUserStatus() (*UserStatusResponse, error) {
+	return c.GetUserStatusContext(context.Background())
+}
+
+// GetUserStatusContext is like GetUserStatus but honors ctx
+func (c *TLSClient) GetUserStatusContext(ctx context.Context) (*UserStatusResponse, error) {
 	// Get basic user info from lists endpoint
-	data, err := c.Get(EndpointCommodityLists)
+	data, err := c.GetContext(ctx, EndpointCommodityLists)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +41,7 @@ func (c *TLSClient) GetUserStatus() (*UserStatusResponse, error) {
 
 	// Get detailed status from statusSummary endpoint
 	statusEndpoint := fmt.Sprintf(EndpointUserStatusSummary, c.userID)
-	statusData, err := c.Get(statusEndpoint)
+	statusData, err := c.GetContext(ctx, statusEndpoint)
 	if err != nil {
 		// Fallback to basic info if statusSummary fails
 		return &UserStatusResponse{
@@ -85,10 +93,12 @@ func extractBasketID(href string) int {
 	if href == "" {
 		return 0
 	}
-	var id int
-	fmt.Sscanf(href, "https://www.alza.sk/api/basket/%d/preview", &id)
-	if id == 0 {
-		fmt.Sscanf(href, "/api/basket/%d/preview", &id)
+	// The host differs per storefront, so match from the path onwards
+	idx := strings.Index(href, "/api/basket/")
+	if idx < 0 {
+		return 0
 	}
+	var id int
+	fmt.Sscanf(href[idx:], "/api/basket/%d/preview", &id)
 	return id
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// CompareCmd lines products up side by side
+type CompareCmd struct {
+	Products []client.ProductRef `arg:"" name:"product" help:"Product IDs, URLs, Alza codes or EANs to compare (at least 2)"`
+	Output   string              `help:"Text layout: table, csv or markdown (--format json for JSON)" enum:"table,csv,markdown" default:"table" short:"o"`
+	Diff     bool                `help:"Only show rows whose values differ"`
+	Width    int                 `help:"Max width of a table column, longer values are cut" default:"32"`
+}
+
+func (c *CompareCmd) Run(g *Globals) error {
+	if len(c.Products) < 2 {
+		return errors.New("compare needs at least 2 products")
+	}
+	if c.Width < 8 {
+		return fmt.Errorf("invalid --width %d (min 8)", c.Width)
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	opts := client.DefaultProductOptions()
+	opts.Description = false
+	products := make([]*client.ProductDetail, len(c.Products))
+	for i, r := range cl.GetProductRefsContext(g.Context(), c.Products, opts) {
+		if r.Err != nil {
+			return fmt.Errorf("product %s: %w", r.Ref, r.Err)
+		}
+		products[i] = r.Product
+	}
+
+	cmp := client.CompareProducts(products)
+	if c.Diff {
+		cmp = cmp.Differing()
+	}
+
+	if g.Format == "json" {
+		outputJSON(cmp)
+		return nil
+	}
+	switch c.Output {
+	case "csv":
+		return writeComparisonCSV(os.Stdout, cmp)
+	case "markdown":
+		writeComparisonMarkdown(os.Stdout, cmp)
+	default:
+		writeComparisonTable(os.Stdout, cmp, c.Width)
+	}
+	return nil
+}
+
+func comparedProductLabel(p client.ComparedProduct) string {
+	return fmt.Sprintf("[%d] %s", p.ID, p.Name)
+}
+
+// writeComparisonTable prints one column per product, group headings between
+// the rows and ≠ in front of rows whose values differ
+func writeComparisonTable(w io.Writer, cmp *client.Comparison, width int) {
+	nameWidth := len("Product")
+	for _, row := range cmp.Rows {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(row.Name))
+	}
+	widths := make([]int, len(cmp.Products))
+	for i, p := range cmp.Products {
+		widths[i] = utf8.RuneCountInString(comparedProductLabel(p))
+		for _, row := range cmp.Rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row.Values[i]))
+		}
+		widths[i] = min(widths[i], width)
+	}
+
+	line := func(marker, name string, values []string) {
+		var b strings.Builder
+		b.WriteString(marker + " " + padRight(name, nameWidth))
+		for i, v := range values {
+			b.WriteString("  " + padRight(truncateRunes(v, widths[i]), widths[i]))
+		}
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	}
+
+	labels := make([]string, len(cmp.Products))
+	for i, p := range cmp.Products {
+		labels[i] = comparedProductLabel(p)
+	}
+	line(" ", "Product", labels)
+
+	group := ""
+	for _, row := range cmp.Rows {
+		if row.Group != group {
+			group = row.Group
+			fmt.Fprintf(w, "\n%s\n", group)
+		}
+		marker := " "
+		if row.Differs {
+			marker = "≠"
+		}
+		line(marker, row.Name, row.Values)
+	}
+	if len(cmp.Rows) == 0 {
+		fmt.Fprintln(w, "\nNo rows to compare")
+	}
+}
+
+// writeComparisonCSV writes a header of the product labels and one record per
+// row; the last column tells whether the values differ
+func writeComparisonCSV(w io.Writer, cmp *client.Comparison) error {
+	cw := csv.NewWriter(w)
+	header := []string{"group", "parameter"}
+	for _, p := range cmp.Products {
+		header = append(header, comparedProductLabel(p))
+	}
+	if err := cw.Write(append(header, "differs")); err != nil {
+		return err
+	}
+	for _, row := range cmp.Rows {
+		record := append([]string{row.Group, row.Name}, row.Values...)
+		if err := cw.Write(append(record, fmt.Sprintf("%t", row.Differs))); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeComparisonMarkdown writes a GitHub-style table with a bold row per
+// group and the values of differing rows in bold
+func writeComparisonMarkdown(w io.Writer, cmp *client.Comparison) {
+	cells := []string{"Parameter"}
+	for _, p := range cmp.Products {
+		cells = append(cells, markdownCell(comparedProductLabel(p)))
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(cells)))
+
+	group := ""
+	for _, row := range cmp.Rows {
+		if row.Group != group {
+			group = row.Group
+			fmt.Fprintf(w, "| **%s** |%s\n", markdownCell(group), strings.Repeat("  |", len(cmp.Products)))
+		}
+		cells := []string{markdownCell(row.Name)}
+		for _, v := range row.Values {
+			v = markdownCell(v)
+			if row.Differs && v != "" {
+				v = "**" + v + "**"
+			}
+			cells = append(cells, v)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func truncateRunes(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
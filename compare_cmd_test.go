@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+)
+
+func addCoffeeMachines(srv *alzatest.Server) {
+	srv.AddProduct(alzatest.Product{
+		ID: 9200001, Name: "Philips EP2231/40", Code: "PHIL0401", Price: 379, Availability: "Skladom > 5 ks",
+		PromoPrices:   []alzatest.PromoPrice{{Name: "S kupónom", Price: 349, Code: "KAVA30"}},
+		ComplaintRate: 0.0123,
+		Reviews:       []alzatest.Review{{Rating: 5, Name: "Jana"}},
+		Parameters: []alzatest.Parameter{
+			{Group: "Základné vlastnosti", Name: "Tlak", Values: []string{"15 bar"}},
+			{Group: "Základné vlastnosti", Name: "Mlynček", Values: []string{"keramický"}},
+			{Group: "Rozmery", Name: "Hmotnosť", Values: []string{"7,5 kg"}},
+		},
+	})
+	srv.AddProduct(alzatest.Product{
+		ID: 9200002, Name: "Krups EA8108 | Arabica", Code: "KRUP0108", Price: 299, Availability: "Skladom > 5 ks",
+		Parameters: []alzatest.Parameter{
+			{Group: "Základné vlastnosti", Name: "Tlak", Values: []string{"15 bar"}},
+			{Group: "Základné vlastnosti", Name: "Mlynček", Values: []string{"kovový"}},
+		},
+	})
+}
+
+func TestCLICompareTable(t *testing.T) {
+	srv := startFakeAlza(t)
+	addCoffeeMachines(srv)
+
+	out := mustRunCLI(t, "compare", "9200001", "KRUP0108")
+	for _, want := range []string{
+		"[9200001] Philips EP2231/40",
+		"\nSummary\n",
+		"≠ Price ",
+		"S kupónom 349,00 € (code KAVA30)",
+		"5.0/5 (1)",
+		"1.23%",
+		"  Availability    Skladom > 5 ks",
+		"\nZákladné vlastnosti\n",
+		"  Tlak ",
+		"≠ Mlynček ",
+		"≠ Hmotnosť        7,5 kg",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("compare output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = mustRunCLI(t, "compare", "9200001", "9200002", "--diff", "--width", "10")
+	if strings.Contains(out, "Tlak") || strings.Contains(out, "Availability") || !strings.Contains(out, "[9200001]…") {
+		t.Errorf("compare --diff --width 10 output:\n%s", out)
+	}
+
+	if _, err := runCLI(t, "compare", "9200001"); err == nil {
+		t.Error("compare with one product succeeded")
+	}
+	if _, err := runCLI(t, "compare", "9200001", "404"); err == nil || !strings.Contains(err.Error(), "product 404") {
+		t.Errorf("compare with an unknown product error = %v", err)
+	}
+	if _, err := runCLI(t, "compare", "9200001", "NOSUCH99"); err == nil || !strings.Contains(err.Error(), "product code NOSUCH99: no product with Alza code NOSUCH99") {
+		t.Errorf("compare with an unknown code error = %v", err)
+	}
+}
+
+func TestCLICompareFormats(t *testing.T) {
+	srv := startFakeAlza(t)
+	addCoffeeMachines(srv)
+
+	var cmp client.Comparison
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format", "json", "compare", "9200001", "9200002")), &cmp); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmp.Products) != 2 || cmp.Rows[0].Name != "Price" || !cmp.Rows[0].Differs || cmp.Rows[0].Values[1] != "299,00 €" {
+		t.Errorf("compare JSON = %+v", cmp)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(mustRunCLI(t, "compare", "9200001", "9200002", "-o", "csv"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(records[0], ","); got != "group,parameter,[9200001] Philips EP2231/40,[9200002] Krups EA8108 | Arabica,differs" {
+		t.Errorf("CSV header = %q", got)
+	}
+	last := records[len(records)-1]
+	if strings.Join(last, ",") != "Rozmery,Hmotnosť,7,5 kg,,true" {
+		t.Errorf("CSV last record = %q", last)
+	}
+
+	out := mustRunCLI(t, "compare", "9200001", "9200002", "-o", "markdown")
+	for _, want := range []string{
+		"| Parameter | [9200001] Philips EP2231/40 | [9200002] Krups EA8108 \\| Arabica |\n| --- | --- | --- |\n",
+		"| **Summary** |  |  |\n",
+		"| Price | **379,00 €** | **299,00 €** |",
+		"| Tlak | 15 bar | 15 bar |",
+		"| Hmotnosť | **7,5 kg** |  |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("compare markdown missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/alecthomas/kong v1.13.0
 	github.com/bogdanfinn/fhttp v0.6.4
 	github.com/bogdanfinn/tls-client v1.12.0
+	golang.org/x/crypto v0.46.0
 	golang.org/x/net v0.48.0
 )
 
@@ -18,7 +19,6 @@ require (
 	github.com/klauspost/compress v1.18.2 // indirect
 	github.com/quic-go/qpack v0.6.0 // indirect
 	github.com/tam7t/hpkp v0.0.0-20160821193359-2b70b4024ed5 // indirect
-	golang.org/x/crypto v0.46.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 )
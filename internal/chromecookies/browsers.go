@@ -0,0 +1,203 @@
+package chromecookies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Browser names a browser whose cookie store can be read.
+type Browser string
+
+const (
+	BrowserChrome   Browser = "chrome"
+	BrowserChromium Browser = "chromium"
+	BrowserBrave    Browser = "brave"
+	BrowserVivaldi  Browser = "vivaldi"
+	BrowserEdge     Browser = "edge"
+	BrowserFirefox  Browser = "firefox"
+)
+
+// Browsers lists the supported browsers, the default first.
+func Browsers() []Browser {
+	return []Browser{BrowserChrome, BrowserChromium, BrowserBrave, BrowserVivaldi, BrowserEdge, BrowserFirefox}
+}
+
+// Name returns the product name for messages ("Brave", "Firefox", ...).
+func (b Browser) Name() string {
+	switch b {
+	case BrowserChromium:
+		return "Chromium"
+	case BrowserBrave:
+		return "Brave"
+	case BrowserVivaldi:
+		return "Vivaldi"
+	case BrowserEdge:
+		return "Edge"
+	case BrowserFirefox:
+		return "Firefox"
+	default:
+		return "Chrome"
+	}
+}
+
+// Source reads the cookies one browser would send to Options.TargetURL.
+type Source interface {
+	Browser() Browser
+	Load(ctx context.Context, opts Options) (Result, error)
+}
+
+// SourceFor returns the cookie source of b; empty means Chrome.
+func SourceFor(b Browser) (Source, error) {
+	if b == "" {
+		b = BrowserChrome
+	}
+	if b == BrowserFirefox {
+		return firefoxSource{}, nil
+	}
+	for _, s := range chromiumSources {
+		if s.browser == b {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("chromecookies: unsupported browser %q", b)
+}
+
+// chromiumSource reads a Chromium-based browser. They all share Chrome's cookie
+// DB format and encryption and differ only in where the profiles live and which
+// keyring entry holds the v11 password.
+type chromiumSource struct {
+	browser Browser
+	roots   []string   // Linux user data directories relative to $HOME, first existing wins
+	keyring keyringApp // Zero means keyringAppFor(cookie file)
+}
+
+// chromiumSources follow the directories and keyring names the browsers use on
+// Linux. Edge and Vivaldi store their v11 password under the Chromium and Chrome
+// names respectively.
+var chromiumSources = []chromiumSource{
+	{
+		browser: BrowserChrome,
+		// Historically "chrome" also picked up Edge and Chromium profiles, kept for compatibility
+		roots: []string{
+			".config/google-chrome",
+			".config/microsoft-edge",
+			".config/chromium",
+			"snap/chromium/common/chromium",
+			"snap/chromium/current/chromium",
+		},
+	},
+	{
+		browser: BrowserChromium,
+		roots: []string{
+			".config/chromium",
+			"snap/chromium/common/chromium",
+			"snap/chromium/current/chromium",
+			".var/app/org.chromium.Chromium/config/chromium",
+		},
+		keyring: chromiumKeyring,
+	},
+	{
+		browser: BrowserBrave,
+		roots: []string{
+			".config/BraveSoftware/Brave-Browser",
+			".var/app/com.brave.Browser/config/BraveSoftware/Brave-Browser",
+			"snap/brave/current/.config/BraveSoftware/Brave-Browser",
+		},
+		keyring: keyringApp{secretApp: "brave", kwalletFolder: "Brave Keys", kwalletKey: "Brave Safe Storage"},
+	},
+	{
+		browser: BrowserVivaldi,
+		roots: []string{
+			".config/vivaldi",
+			".var/app/com.vivaldi.Vivaldi/config/vivaldi",
+		},
+		keyring: chromeKeyring,
+	},
+	{
+		browser: BrowserEdge,
+		roots: []string{
+			".config/microsoft-edge",
+			".var/app/com.microsoft.Edge/config/microsoft-edge",
+		},
+		keyring: chromiumKeyring,
+	},
+}
+
+func (s chromiumSource) Browser() Browser { return s.browser }
+
+// Load reads the profile in Go on Linux. Only Chrome can fall back to (or be
+// forced onto) the Node reader, chrome-cookies-secure knows no other browser.
+func (s chromiumSource) Load(ctx context.Context, opts Options) (Result, error) {
+	switch opts.Backend {
+	case BackendNative:
+		if runtime.GOOS != "linux" {
+			return Result{}, errNativeUnsupported
+		}
+		return s.loadNative(ctx, opts)
+	case BackendNode:
+		if s.browser != BrowserChrome {
+			return Result{}, fmt.Errorf("chromecookies: node backend reads Chrome only, not %s", s.browser.Name())
+		}
+		return loadNode(ctx, opts)
+	case BackendAuto, "":
+	default:
+		return Result{}, fmt.Errorf("chromecookies: unknown backend %q", opts.Backend)
+	}
+
+	if runtime.GOOS != "linux" {
+		if s.browser != BrowserChrome {
+			return Result{}, errNativeUnsupported
+		}
+		return loadNode(ctx, opts)
+	}
+	res, err := s.loadNative(ctx, opts)
+	if err == nil || errors.Is(err, errNoCookieDB) || ctx.Err() != nil || s.browser != BrowserChrome || !nodeAvailable() {
+		return res, err
+	}
+	if opts.LogWriter != nil {
+		fmt.Fprintf(opts.LogWriter, "chromecookies: native reader failed, falling back to node: %v\n", err)
+	}
+	res, nodeErr := loadNode(ctx, opts)
+	if nodeErr != nil {
+		return Result{}, errors.Join(err, nodeErr)
+	}
+	return res, nil
+}
+
+// profileRoot returns the first existing user data directory of the browser.
+func (s chromiumSource) profileRoot() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return firstExisting(home, s.roots), nil
+}
+
+func (s chromiumSource) keyringFor(cookieFile string) keyringApp {
+	if s.keyring != (keyringApp{}) {
+		return s.keyring
+	}
+	return keyringAppFor(cookieFile)
+}
+
+// firstExisting joins each candidate to home and returns the first that exists,
+// or the first candidate so error messages point at the usual location.
+func firstExisting(home string, candidates []string) string {
+	for _, rel := range candidates {
+		candidate := filepath.Join(home, filepath.FromSlash(rel))
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return filepath.Join(home, filepath.FromSlash(candidates[0]))
+}
+
+// looksLikePath tells a profile directory from a profile name, like load.mjs does.
+func looksLikePath(profile string) bool {
+	return strings.ContainsAny(profile, `/\`)
+}
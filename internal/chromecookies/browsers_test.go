@@ -0,0 +1,68 @@
+package chromecookies
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceFor(t *testing.T) {
+	for _, b := range Browsers() {
+		src, err := SourceFor(b)
+		if err != nil || src.Browser() != b {
+			t.Errorf("SourceFor(%s) = %v, %v", b, src, err)
+		}
+	}
+	if src, err := SourceFor(""); err != nil || src.Browser() != BrowserChrome {
+		t.Errorf("SourceFor(\"\") = %v, %v; want Chrome", src, err)
+	}
+	if _, err := SourceFor("netscape"); err == nil {
+		t.Error("SourceFor(netscape) succeeded")
+	}
+}
+
+func TestBraveProfileAndKeyring(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Chromium forks are read natively on Linux only")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	copyTree(t, "testdata", filepath.Join(home, ".config", "BraveSoftware", "Brave-Browser", "Default"))
+
+	var asked keyringApp
+	orig := lookupKeyring
+	lookupKeyring = func(ctx context.Context, app keyringApp, timeout time.Duration, logWriter io.Writer) []string {
+		asked = app
+		return []string{"alzatest-keyring"}
+	}
+	t.Cleanup(func() { lookupKeyring = orig })
+
+	res, err := LoadCookieHeader(context.Background(), Options{
+		TargetURL:   "https://www.alza.sk/",
+		Browser:     BrowserBrave,
+		FilterNames: []string{"SESSION"},
+	})
+	if err != nil || res.CookieHeader != "SESSION=session-v11-value" {
+		t.Fatalf("LoadCookieHeader = %+v, %v", res, err)
+	}
+	if asked.kwalletKey != "Brave Safe Storage" || asked.secretApp != "brave" {
+		t.Errorf("keyring entry = %+v, want Brave's", asked)
+	}
+
+	// Chrome's directories don't include Brave's
+	_, err = LoadCookieHeader(context.Background(), Options{TargetURL: "https://www.alza.sk/", Browser: BrowserChrome, Backend: BackendNative})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(home, ".config", "google-chrome")) {
+		t.Errorf("chrome error = %v, want the google-chrome path", err)
+	}
+}
+
+func TestForksRejectNodeBackend(t *testing.T) {
+	_, err := LoadCookieHeader(context.Background(), Options{TargetURL: "https://www.alza.sk/", Browser: BrowserVivaldi, Backend: BackendNode})
+	if err == nil || !strings.Contains(err.Error(), "Chrome only, not Vivaldi") {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -17,32 +17,63 @@ import (
 //go:embed load.mjs
 var loadScript []byte
 
+// Backend selects how the cookie DB is read.
+type Backend string
+
+const (
+	// BackendAuto reads the DB in Go on Linux and falls back to Node when that
+	// fails (or on other platforms) and node/npm are installed.
+	BackendAuto Backend = "auto"
+	// BackendNative only uses the Go reader (Linux v10/v11 cookies; Firefox always).
+	BackendNative Backend = "native"
+	// BackendNode only uses chrome-cookies-secure via node (Chrome only).
+	BackendNode Backend = "node"
+)
+
 type Options struct {
 	TargetURL          string
-	ChromeProfile      string
+	Browser            Browser // Empty means BrowserChrome
+	ChromeProfile      string  // Profile name or directory of the selected browser
 	ExplicitCookiePath string
 	FilterNames        []string
 	Timeout            time.Duration
-	CacheDir           string
+	CacheDir           string // npm project for the Node backend
 	LogWriter          io.Writer
+	Backend            Backend // Empty means BackendAuto
+	CookiesFile        string  // Netscape cookies.txt or HAR export to read instead of a browser
 }
 
 type Result struct {
 	CookieHeader string
 	CookieCount  int
+	Cookies      []Cookie // The cookies in CookieHeader (not filled by the Node backend)
 }
 
 func LoadCookieHeader(ctx context.Context, opts Options) (Result, error) {
 	if opts.TargetURL == "" {
 		return Result{}, errors.New("chromecookies: TargetURL missing")
 	}
-	if opts.CacheDir == "" {
-		return Result{}, errors.New("chromecookies: CacheDir missing")
-	}
 	if opts.Timeout <= 0 {
 		opts.Timeout = 10 * time.Second
 	}
 
+	if opts.CookiesFile != "" {
+		return loadCookieFile(opts)
+	}
+
+	src, err := SourceFor(opts.Browser)
+	if err != nil {
+		return Result{}, err
+	}
+	return src.Load(ctx, opts)
+}
+
+// loadNode reads the cookies with chrome-cookies-secure, installing it into CacheDir on first use.
+func loadNode(ctx context.Context, opts Options) (Result, error) {
+	if opts.CacheDir == "" {
+		return Result{}, errors.New("chromecookies: CacheDir missing")
+	}
+
 	if err := os.MkdirAll(opts.CacheDir, 0o755); err != nil {
 		return Result{}, err
 	}
@@ -98,6 +129,13 @@ type scriptOutput struct {
 
 var runScript = runScriptReal
 
+// nodeAvailable reports whether the Node fallback can run at all.
+var nodeAvailable = func() bool {
+	_, nodeErr := exec.LookPath("node")
+	_, npmErr := exec.LookPath("npm")
+	return nodeErr == nil && npmErr == nil
+}
+
 func ensureNpmProject(ctx context.Context, dir string, logWriter io.Writer) error {
 	nodeModules := filepath.Join(dir, "node_modules", "chrome-cookies-secure", "package.json")
 	if _, err := os.Stat(nodeModules); err == nil {
@@ -21,7 +21,7 @@ func TestLoadCookieHeaderMissingTargetURL(t *testing.T) {
 func TestLoadCookieHeaderMissingCacheDir(t *testing.T) {
 	t.Parallel()
 
-	_, err := LoadCookieHeader(context.Background(), Options{TargetURL: "https://www.alza.sk/"})
+	_, err := LoadCookieHeader(context.Background(), Options{TargetURL: "https://www.alza.sk/", Backend: BackendNode})
 	if err == nil {
 		t.Fatal("expected error for missing cache dir")
 	}
@@ -40,6 +40,7 @@ func TestLoadCookieHeaderSuccess(t *testing.T) {
 		TargetURL: "https://www.alza.sk/",
 		CacheDir:  cacheDir,
 		Timeout:   5 * time.Second,
+		Backend:   BackendNode,
 	})
 	if err != nil {
 		t.Fatalf("LoadCookieHeader error: %v", err)
@@ -61,6 +62,7 @@ func TestLoadCookieHeaderScriptError(t *testing.T) {
 	_, err := LoadCookieHeader(context.Background(), Options{
 		TargetURL: "https://www.alza.sk/",
 		CacheDir:  cacheDir,
+		Backend:   BackendNode,
 	})
 	if err == nil || err.Error() != "boom" {
 		t.Fatalf("expected script error, got: %v", err)
@@ -0,0 +1,259 @@
+package chromecookies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cookie is one cookie sent to Options.TargetURL, in the shape a cookie jar keeps it.
+type Cookie struct {
+	Domain  string // ".alza.sk" for domain cookies, "www.alza.sk" for host-only ones
+	Path    string
+	Name    string
+	Value   string
+	Secure  bool
+	Expires time.Time // Zero for session cookies
+}
+
+// loadCookieFile reads Options.CookiesFile instead of a browser profile.
+func loadCookieFile(opts Options) (Result, error) {
+	target, err := url.Parse(opts.TargetURL)
+	if err != nil || target.Hostname() == "" {
+		return Result{}, fmt.Errorf("chromecookies: invalid TargetURL %q", opts.TargetURL)
+	}
+	path, err := expandPath(opts.CookiesFile)
+	if err != nil {
+		return Result{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: read cookies file: %w", err)
+	}
+	cookies, err := parseCookieFile(data)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: %s: %w", path, err)
+	}
+	return cookieHeader(cookies, target, opts.FilterNames, nil, time.Now())
+}
+
+// parseCookieFile accepts a HAR export (JSON) or a Netscape cookies.txt.
+func parseCookieFile(data []byte) ([]cookie, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseHAR(trimmed)
+	}
+	return parseNetscape(bytes.NewReader(data))
+}
+
+// parseNetscape reads the cookies.txt format of curl, wget and browser export
+// extensions: domain, include-subdomains flag, path, secure, expiry (Unix
+// seconds, 0 for session cookies), name and value separated by tabs.
+func parseNetscape(r io.Reader) ([]cookie, error) {
+	var cookies []cookie
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimRight(sc.Text(), "\r")
+		// curl marks HttpOnly cookies with a prefix that otherwise looks like a comment
+		text = strings.TrimPrefix(text, "#HttpOnly_")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) == 6 {
+			fields = append(fields, "") // Empty value with the trailing tab trimmed
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: want 7 tab-separated fields, got %d", line, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad expiry %q", line, fields[4])
+		}
+
+		c := cookie{
+			hostKey: strings.ToLower(fields[0]),
+			path:    fields[2],
+			secure:  strings.EqualFold(fields[3], "TRUE"),
+			name:    fields[5],
+			value:   fields[6],
+		}
+		if strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(c.hostKey, ".") {
+			c.hostKey = "." + c.hostKey
+		}
+		if expiry > 0 {
+			c.expires = toChromeTime(time.Unix(expiry, 0))
+		}
+		cookies = append(cookies, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("no cookies (expected a Netscape cookies.txt or a HAR file)")
+	}
+	return cookies, nil
+}
+
+// harFile is the part of a HAR 1.2 export that carries cookies.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				URL     string      `json:"url"`
+				Cookies []harCookie `json:"cookies"`
+			} `json:"request"`
+			Response struct {
+				Cookies []harCookie `json:"cookies"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harCookie struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Path    string `json:"path"`
+	Domain  string `json:"domain"`
+	Expires string `json:"expires"`
+	Secure  bool   `json:"secure"`
+}
+
+// parseHAR replays the recorded traffic: cookies a request sent belong to its
+// host, cookies a response set follow their attributes, later entries win.
+func parseHAR(data []byte) ([]cookie, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("parse HAR: %w", err)
+	}
+
+	jar := map[harKey]cookie{}
+	var order []harKey
+	set := func(c cookie) {
+		k := harKey{c.hostKey, c.path, c.name}
+		if _, ok := jar[k]; !ok {
+			order = append(order, k)
+		}
+		c.created = int64(len(order))
+		jar[k] = c
+	}
+
+	for _, e := range har.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		for _, hc := range e.Request.Cookies {
+			if hc.Name == "" {
+				continue
+			}
+			// Requests don't say which domain a cookie was set for; keep what a
+			// response already told us and only fill in unknown ones as host-only
+			if k, ok := findHARCookie(order, host, hc.Name); ok {
+				c := jar[k]
+				c.value = hc.Value
+				jar[k] = c
+				continue
+			}
+			set(cookie{hostKey: host, name: hc.Name, value: hc.Value, path: "/"})
+		}
+		for _, hc := range e.Response.Cookies {
+			if hc.Name == "" {
+				continue
+			}
+			c := cookie{hostKey: host, name: hc.Name, value: hc.Value, path: hc.Path, secure: hc.Secure}
+			if hc.Domain != "" {
+				c.hostKey = "." + strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+			}
+			if c.path == "" {
+				c.path = "/"
+			}
+			if hc.Expires != "" {
+				if t, err := time.Parse(time.RFC3339, hc.Expires); err == nil {
+					c.expires = toChromeTime(t)
+				}
+			}
+			set(c)
+		}
+	}
+	if len(order) == 0 {
+		return nil, errors.New("no cookies in HAR (export it with cookies / \"Save all as HAR with content\")")
+	}
+
+	cookies := make([]cookie, 0, len(order))
+	for _, k := range order {
+		cookies = append(cookies, jar[k])
+	}
+	return cookies, nil
+}
+
+type harKey struct{ host, path, name string }
+
+// findHARCookie returns the first known cookie name that host would be sent.
+func findHARCookie(order []harKey, host, name string) (harKey, bool) {
+	for _, k := range order {
+		if k.name == name && domainMatches(k.host, host) {
+			return k, true
+		}
+	}
+	return harKey{}, false
+}
+
+// WriteCookieJar saves cookies as a Netscape cookies.txt readable only by the
+// current user, so later refreshes can use it as Options.CookiesFile. The file
+// is replaced atomically.
+func WriteCookieJar(path string, cookies []Cookie) error {
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n# Written by alza token refresh - contains session cookies, keep it private\n\n")
+	for _, c := range cookies {
+		flag := "FALSE"
+		if strings.HasPrefix(c.Domain, ".") {
+			flag = "TRUE"
+		}
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+		var expiry int64
+		if !c.Expires.IsZero() {
+			expiry = c.Expires.Unix()
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", c.Domain, flag, path, secure, expiry, c.Name, c.Value)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cookies-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
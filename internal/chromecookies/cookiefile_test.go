@@ -0,0 +1,103 @@
+package chromecookies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadFile(t *testing.T, target, file string) Result {
+	t.Helper()
+	res, err := LoadCookieHeader(context.Background(), Options{TargetURL: target, CookiesFile: file})
+	if err != nil {
+		t.Fatalf("LoadCookieHeader(%s) error: %v", file, err)
+	}
+	return res
+}
+
+func TestCookiesFileNetscape(t *testing.T) {
+	file := filepath.Join("testdata", "cookiefile", "cookies.txt")
+
+	res := loadFile(t, "https://www.alza.sk/", file)
+	// OLD is expired, EMPTY has no value, .alza.cz and .google.com don't match
+	if res.CookieHeader != "CCC=txt-ccc; TKNP=txt-tknp; SESSION=txt-session; CRLF=ok" {
+		t.Errorf("alza.sk header = %q", res.CookieHeader)
+	}
+	if len(res.Cookies) != 4 || res.Cookies[1].Domain != ".alza.sk" || !res.Cookies[1].Secure || !res.Cookies[2].Expires.IsZero() {
+		t.Errorf("Cookies = %+v", res.Cookies)
+	}
+
+	if res := loadFile(t, "https://www.alza.cz/", file); res.CookieHeader != "CCC=cz-ccc" {
+		t.Errorf("alza.cz header = %q", res.CookieHeader)
+	}
+	// Secure TKNP isn't sent over http
+	if res := loadFile(t, "http://www.alza.sk/", file); strings.Contains(res.CookieHeader, "TKNP") {
+		t.Errorf("http header = %q", res.CookieHeader)
+	}
+}
+
+func TestCookiesFileHAR(t *testing.T) {
+	res := loadFile(t, "https://www.alza.sk/", filepath.Join("testdata", "cookiefile", "alza.har"))
+
+	// The later request's values win; TKNP keeps the .alza.sk domain from Set-Cookie
+	if res.CookieHeader != "CCC=har-ccc; SESSION=har-session; TKNP=har-tknp-2" {
+		t.Errorf("header = %q", res.CookieHeader)
+	}
+	for _, c := range res.Cookies {
+		if c.Name == "TKNP" && (c.Domain != ".alza.sk" || !c.Expires.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))) {
+			t.Errorf("TKNP = %+v", c)
+		}
+	}
+}
+
+func TestCookiesFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"empty.txt":   "# Netscape HTTP Cookie File\n",
+		"broken.txt":  ".alza.sk\tTRUE\t/\n",
+		"expiry.txt":  ".alza.sk\tTRUE\t/\tFALSE\tnever\tCCC\tx\n",
+		"broken.har":  `{"log": {"entries": [`,
+		"nothing.har": `{"log": {"entries": []}}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCookieHeader(context.Background(), Options{TargetURL: "https://www.alza.sk/", CookiesFile: path}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriteCookieJarRoundTrip(t *testing.T) {
+	res := loadFile(t, "https://www.alza.sk/", filepath.Join("testdata", "cookiefile", "alza.har"))
+
+	jar := filepath.Join(t.TempDir(), "alza", "session_cookies.txt")
+	if err := WriteCookieJar(jar, res.Cookies); err != nil {
+		t.Fatalf("WriteCookieJar error: %v", err)
+	}
+	info, err := os.Stat(jar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("jar mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	again := loadFile(t, "https://www.alza.sk/", jar)
+	if again.CookieHeader != res.CookieHeader {
+		t.Errorf("round trip header = %q, want %q", again.CookieHeader, res.CookieHeader)
+	}
+
+	// Overwrites in place without leaving temp files behind
+	if err := WriteCookieJar(jar, res.Cookies[:1]); err != nil {
+		t.Fatalf("WriteCookieJar error: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(jar))
+	if len(entries) != 1 {
+		t.Errorf("jar dir has %d entries, want 1", len(entries))
+	}
+}
@@ -0,0 +1,201 @@
+package chromecookies
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Chrome on Linux encrypts cookie values with AES-128-CBC. The key is
+// PBKDF2-SHA1(password, "saltysalt", 1 iteration): "v10" values use the
+// hardcoded password "peanuts", "v11" values a random password Chrome keeps in
+// the desktop keyring (GNOME libsecret or KWallet).
+const (
+	v10Password = "peanuts"
+	kdfSalt     = "saltysalt"
+)
+
+// hashPrefixVersion is the cookie DB version (meta.version) from which Chrome
+// prepends SHA-256(host_key) to the plaintext before encrypting it.
+const hashPrefixVersion = 24
+
+var errDecrypt = errors.New("chromecookies: cannot decrypt cookie value")
+
+func deriveKey(password string) []byte {
+	key, err := pbkdf2.Key(sha1.New, password, []byte(kdfSalt), 1, 16)
+	if err != nil {
+		panic(err) // Only fails for invalid parameters, ours are constant
+	}
+	return key
+}
+
+// decrypter holds the candidate keys for one cookie DB. The keyring is only
+// queried when the first v11 value shows up.
+type decrypter struct {
+	dbVersion int
+	v10       [][]byte
+	v11       [][]byte
+	keyring   func() []string
+	loaded    bool
+}
+
+func newDecrypter(dbVersion int, keyring func() []string) *decrypter {
+	// Chrome falls back to an empty password when no keyring was available at
+	// the time the cookie was written
+	empty := deriveKey("")
+	return &decrypter{
+		dbVersion: dbVersion,
+		v10:       [][]byte{deriveKey(v10Password), empty},
+		v11:       [][]byte{empty},
+		keyring:   keyring,
+	}
+}
+
+// decrypt returns the plaintext of an encrypted_value blob stored for hostKey.
+func (d *decrypter) decrypt(hostKey string, enc []byte) (string, error) {
+	if len(enc) < 3 {
+		return "", errDecrypt
+	}
+	var keys [][]byte
+	switch string(enc[:3]) {
+	case "v10":
+		keys = d.v10
+	case "v11":
+		if !d.loaded {
+			d.loaded = true
+			if d.keyring != nil {
+				var fromKeyring [][]byte
+				for _, password := range d.keyring() {
+					fromKeyring = append(fromKeyring, deriveKey(password))
+				}
+				d.v11 = append(fromKeyring, d.v11...)
+			}
+		}
+		keys = d.v11
+	default:
+		return "", fmt.Errorf("%w: unsupported format %q", errDecrypt, enc[:3])
+	}
+
+	for _, key := range keys {
+		if value, ok := decryptCBC(key, enc[3:], hostKey, d.dbVersion); ok {
+			return value, nil
+		}
+	}
+	if string(enc[:3]) == "v11" {
+		return "", fmt.Errorf("%w: v11 key not available (is the desktop keyring unlocked?)", errDecrypt)
+	}
+	return "", errDecrypt
+}
+
+// decryptCBC reports ok only when the padding, the host hash prefix (DB
+// version 24+) and the UTF-8 check all pass, so a wrong key is never mistaken
+// for a right one.
+func decryptCBC(key, ciphertext []byte, hostKey string, dbVersion int) (string, bool) {
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", false
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", false
+	}
+	plain := make([]byte, len(ciphertext))
+	iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return "", false
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return "", false
+		}
+	}
+	plain = plain[:len(plain)-pad]
+
+	if dbVersion >= hashPrefixVersion {
+		sum := sha256.Sum256([]byte(hostKey))
+		if len(plain) < len(sum) || !bytes.Equal(plain[:len(sum)], sum[:]) {
+			return "", false
+		}
+		plain = plain[len(sum):]
+	}
+	if !utf8.Valid(plain) {
+		return "", false
+	}
+	return string(plain), true
+}
+
+// keyringApp names the browser's "Safe Storage" secret in libsecret and KWallet.
+type keyringApp struct {
+	secretApp     string // libsecret "application" attribute
+	kwalletFolder string
+	kwalletKey    string
+}
+
+var (
+	chromeKeyring   = keyringApp{secretApp: "chrome", kwalletFolder: "Chrome Keys", kwalletKey: "Chrome Safe Storage"}
+	chromiumKeyring = keyringApp{secretApp: "chromium", kwalletFolder: "Chromium Keys", kwalletKey: "Chromium Safe Storage"}
+)
+
+// keyringAppFor picks the keyring entry by the profile directory the cookie DB lives in.
+func keyringAppFor(cookieFile string) keyringApp {
+	if strings.Contains(strings.ToLower(filepath.ToSlash(cookieFile)), "/chromium/") {
+		return chromiumKeyring
+	}
+	return chromeKeyring
+}
+
+var lookupKeyring = lookupKeyringReal
+
+// lookupKeyringReal asks the Secret Service (secret-tool) and KWallet
+// (kwallet-query) over the D-Bus session bus for the v11 password. Without a
+// session bus (SSH, systemd services) there is no keyring to ask.
+func lookupKeyringReal(ctx context.Context, app keyringApp, timeout time.Duration, logWriter io.Writer) []string {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(runtimeDir, "bus")); err != nil {
+			return nil
+		}
+	}
+
+	commands := [][]string{
+		{"secret-tool", "lookup", "application", app.secretApp},
+		{"kwallet-query", "--read-password", app.kwalletKey, "--folder", app.kwalletFolder, "kdewallet"},
+	}
+	var passwords []string
+	for _, args := range commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+		out, err := exec.CommandContext(cmdCtx, args[0], args[1:]...).Output() //nolint:gosec
+		cancel()
+		if err != nil {
+			if logWriter != nil {
+				fmt.Fprintf(logWriter, "chromecookies: %s: %v\n", args[0], err)
+			}
+			continue
+		}
+		if password := strings.TrimRight(string(out), "\r\n"); password != "" {
+			passwords = append(passwords, password)
+		}
+	}
+	return passwords
+}
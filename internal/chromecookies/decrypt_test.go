@@ -0,0 +1,80 @@
+package chromecookies
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Ciphertexts from testdata/make_cookies.py
+const (
+	tknpV10    = "763130a09e394eb3f1cd19ec55a31432499ba2121755413ab7fea0ea1909e59d4ccef3bc29075c7361f629e0fa3eee66ab4769"
+	sessionV11 = "763131de1fd93504c592ada6022cc9328d245fbd6e76cb25e0c375ec14e7e6036dc9bee8719df7eaf215744f1a24ee42b09920a6908be30809479bacd29c86e558f268"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex: %v", err)
+	}
+	return b
+}
+
+func TestDecryptV10(t *testing.T) {
+	dec := newDecrypter(24, nil)
+	got, err := dec.decrypt(".alza.sk", mustHex(t, tknpV10))
+	if err != nil || got != "tknp-v10-value" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+
+	// The SHA-256(host_key) prefix ties the value to its host
+	if _, err := dec.decrypt(".evil.sk", mustHex(t, tknpV10)); !errors.Is(err, errDecrypt) {
+		t.Errorf("decrypt with other host error = %v, want errDecrypt", err)
+	}
+}
+
+func TestDecryptV11AsksKeyringOnce(t *testing.T) {
+	calls := 0
+	dec := newDecrypter(24, func() []string {
+		calls++
+		return []string{"alzatest-keyring"}
+	})
+
+	for range 2 {
+		got, err := dec.decrypt("www.alza.sk", mustHex(t, sessionV11))
+		if err != nil || got != "session-v11-value" {
+			t.Fatalf("decrypt = %q, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("keyring lookups = %d, want 1", calls)
+	}
+}
+
+func TestDecryptV11WithoutKeyring(t *testing.T) {
+	dec := newDecrypter(24, func() []string { return nil })
+	_, err := dec.decrypt("www.alza.sk", mustHex(t, sessionV11))
+	if !errors.Is(err, errDecrypt) || !strings.Contains(err.Error(), "keyring") {
+		t.Errorf("decrypt error = %v, want keyring hint", err)
+	}
+}
+
+func TestDecryptRejectsUnknownFormat(t *testing.T) {
+	dec := newDecrypter(24, nil)
+	for _, enc := range [][]byte{nil, []byte("v1"), []byte("v20abcdefabcdefabcdef")} {
+		if _, err := dec.decrypt(".alza.sk", enc); !errors.Is(err, errDecrypt) {
+			t.Errorf("decrypt(%q) error = %v, want errDecrypt", enc, err)
+		}
+	}
+}
+
+func TestKeyringAppFor(t *testing.T) {
+	if app := keyringAppFor("/home/u/.config/chromium/Default/Cookies"); app.secretApp != "chromium" || app.kwalletKey != "Chromium Safe Storage" {
+		t.Errorf("chromium app = %+v", app)
+	}
+	if app := keyringAppFor("/home/u/.config/google-chrome/Default/Cookies"); app.secretApp != "chrome" || app.kwalletFolder != "Chrome Keys" {
+		t.Errorf("chrome app = %+v", app)
+	}
+}
@@ -0,0 +1,245 @@
+package chromecookies
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// firefoxSource reads cookies.sqlite of a Firefox profile. Firefox doesn't
+// encrypt cookie values, so it works the same on every platform.
+type firefoxSource struct{}
+
+func (firefoxSource) Browser() Browser { return BrowserFirefox }
+
+func (firefoxSource) Load(ctx context.Context, opts Options) (Result, error) {
+	if opts.Backend == BackendNode {
+		return Result{}, errors.New("chromecookies: node backend reads Chrome only, not Firefox")
+	}
+	target, err := url.Parse(opts.TargetURL)
+	if err != nil || target.Hostname() == "" {
+		return Result{}, fmt.Errorf("chromecookies: invalid TargetURL %q", opts.TargetURL)
+	}
+
+	cookieFile, err := firefoxCookieFile(opts.ChromeProfile, opts.ExplicitCookiePath)
+	if err != nil {
+		return Result{}, err
+	}
+	db, err := openSQLite(cookieFile)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: read cookie DB: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	cookies, err := readFirefoxCookies(db)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: %s: %w", cookieFile, err)
+	}
+	return cookieHeader(cookies, target, opts.FilterNames, nil, time.Now())
+}
+
+// readFirefoxCookies maps moz_cookies rows onto Chrome's cookie shape. Cookies
+// from containers and private windows (non-empty originAttributes) are skipped.
+func readFirefoxCookies(db *sqliteDB) ([]cookie, error) {
+	rows, err := db.rows("moz_cookies")
+	if err != nil {
+		return nil, err
+	}
+	cookies := make([]cookie, 0, len(rows))
+	for _, r := range rows {
+		if asString(r["originAttributes"]) != "" {
+			continue
+		}
+		c := cookie{
+			hostKey: strings.ToLower(asString(r["host"])),
+			name:    asString(r["name"]),
+			value:   asString(r["value"]),
+			path:    asString(r["path"]),
+			secure:  asInt(r["isSecure"]) != 0,
+			created: asInt(r["creationTime"]),
+		}
+		if expiry := asInt(r["expiry"]); expiry > 0 {
+			// Seconds since the epoch; recent Firefox versions store milliseconds
+			if expiry > 1e11 {
+				c.expires = toChromeTime(time.UnixMilli(expiry))
+			} else {
+				c.expires = toChromeTime(time.Unix(expiry, 0))
+			}
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
+
+// firefoxCookieFile finds cookies.sqlite: an explicit file or profile
+// directory, or the profile named in profiles.ini (the default one when empty).
+func firefoxCookieFile(profile, explicitCookiePath string) (string, error) {
+	switch {
+	case strings.TrimSpace(explicitCookiePath) != "":
+		return firefoxCookieFileIn(explicitCookiePath)
+	case looksLikePath(profile):
+		return firefoxCookieFileIn(profile)
+	}
+
+	root, err := firefoxProfileRoot()
+	if err != nil {
+		return "", err
+	}
+	dir, err := findFirefoxProfile(root, strings.TrimSpace(profile))
+	if err != nil {
+		return "", err
+	}
+	return firefoxCookieFileIn(dir)
+}
+
+func firefoxCookieFileIn(input string) (string, error) {
+	expanded, err := expandPath(input)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(expanded)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s", errNoCookieDB, expanded)
+	}
+	if !info.IsDir() {
+		return expanded, nil
+	}
+	candidate := filepath.Join(expanded, "cookies.sqlite")
+	if _, err := os.Stat(candidate); err != nil {
+		return "", fmt.Errorf("%w under %s", errNoCookieDB, expanded)
+	}
+	return candidate, nil
+}
+
+// firefoxProfile is one [ProfileN] section of profiles.ini.
+type firefoxProfile struct {
+	name      string
+	dir       string
+	isDefault bool
+}
+
+// findFirefoxProfile picks a profile from root/profiles.ini by name or directory
+// name. Without a name it takes the profile the installation last used
+// ([Install...] Default=), then the one marked Default=1, then the first one.
+func findFirefoxProfile(root, name string) (string, error) {
+	f, err := os.Open(filepath.Join(root, "profiles.ini"))
+	if err != nil {
+		return "", fmt.Errorf("%w: no Firefox profiles.ini under %s", errNoCookieDB, root)
+	}
+	defer f.Close()
+	profiles, installDefault, err := parseProfilesINI(f, root)
+	if err != nil {
+		return "", fmt.Errorf("chromecookies: %s: %w", f.Name(), err)
+	}
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("%w: no profiles in %s", errNoCookieDB, f.Name())
+	}
+
+	if name != "" {
+		for _, p := range profiles {
+			if strings.EqualFold(p.name, name) || filepath.Base(p.dir) == name {
+				return p.dir, nil
+			}
+		}
+		return "", fmt.Errorf("%w: no Firefox profile %q in %s", errNoCookieDB, name, f.Name())
+	}
+	if installDefault != "" {
+		for _, p := range profiles {
+			if p.dir == installDefault {
+				return p.dir, nil
+			}
+		}
+	}
+	for _, p := range profiles {
+		if p.isDefault {
+			return p.dir, nil
+		}
+	}
+	return profiles[0].dir, nil
+}
+
+// parseProfilesINI returns the profiles with absolute directories and the
+// directory of the first [Install...] section's default profile.
+func parseProfilesINI(r io.Reader, root string) ([]firefoxProfile, string, error) {
+	type section struct {
+		name   string
+		values map[string]string
+	}
+	var sections []section
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			sections = append(sections, section{name: line[1 : len(line)-1], values: map[string]string{}})
+		case len(sections) > 0:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				sections[len(sections)-1].values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, "", err
+	}
+
+	resolve := func(path, relative string) string {
+		if relative == "0" {
+			return filepath.Clean(path)
+		}
+		return filepath.Join(root, filepath.FromSlash(path))
+	}
+
+	var profiles []firefoxProfile
+	installDefault := ""
+	for _, s := range sections {
+		switch {
+		case strings.HasPrefix(s.name, "Profile"):
+			if s.values["Path"] == "" {
+				continue
+			}
+			profiles = append(profiles, firefoxProfile{
+				name:      s.values["Name"],
+				dir:       resolve(s.values["Path"], s.values["IsRelative"]),
+				isDefault: s.values["Default"] == "1",
+			})
+		case strings.HasPrefix(s.name, "Install") && installDefault == "" && s.values["Default"] != "":
+			// Install sections always use paths relative to root
+			installDefault = resolve(s.values["Default"], "1")
+		}
+	}
+	return profiles, installDefault, nil
+}
+
+// firefoxProfileRoot returns the first existing directory holding profiles.ini.
+func firefoxProfileRoot() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Firefox"), nil
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			appData = filepath.Join(home, "AppData", "Roaming")
+		}
+		return filepath.Join(appData, "Mozilla", "Firefox"), nil
+	}
+	return firstExisting(home, []string{
+		".mozilla/firefox",
+		"snap/firefox/common/.mozilla/firefox",
+		".var/app/org.mozilla.firefox/.mozilla/firefox",
+	}), nil
+}
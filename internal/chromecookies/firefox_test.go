@@ -0,0 +1,110 @@
+package chromecookies
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyTree copies testdata/src into dst
+func copyTree(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o600)
+	})
+	if err != nil {
+		t.Fatalf("copy %s: %v", src, err)
+	}
+}
+
+// firefoxHome installs the Firefox fixture under a temp $HOME
+func firefoxHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	copyTree(t, filepath.Join("testdata", "firefox"), filepath.Join(home, ".mozilla", "firefox"))
+	return home
+}
+
+func loadFirefox(t *testing.T, opts Options) (Result, error) {
+	t.Helper()
+	opts.TargetURL = "https://www.alza.sk/"
+	opts.Browser = BrowserFirefox
+	return LoadCookieHeader(context.Background(), opts)
+}
+
+func TestFirefoxDefaultProfile(t *testing.T) {
+	firefoxHome(t)
+
+	// [Install...] Default wins over the Default=1 flag of the "work" profile
+	res, err := loadFirefox(t, Options{})
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	// OLD is expired, CONTAINER belongs to a container tab
+	if res.CookieHeader != "CCC=ff-ccc; TKNP=ff-tknp" || res.CookieCount != 2 {
+		t.Errorf("Result = %+v", res)
+	}
+}
+
+func TestFirefoxNamedProfile(t *testing.T) {
+	home := firefoxHome(t)
+
+	for _, profile := range []string{"work", "WORK", "xyz.work", filepath.Join(home, ".mozilla", "firefox", "Profiles", "xyz.work")} {
+		res, err := loadFirefox(t, Options{ChromeProfile: profile})
+		if err != nil || res.CookieHeader != "CCC=work-ccc" {
+			t.Errorf("profile %q: %+v, %v", profile, res, err)
+		}
+	}
+
+	_, err := loadFirefox(t, Options{ChromeProfile: "missing"})
+	if !errors.Is(err, errNoCookieDB) || !strings.Contains(err.Error(), `no Firefox profile "missing"`) {
+		t.Errorf("missing profile error = %v", err)
+	}
+}
+
+func TestFirefoxExplicitCookiePath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	res, err := loadFirefox(t, Options{ExplicitCookiePath: filepath.Join("testdata", "firefox", "Profiles", "xyz.work", "cookies.sqlite")})
+	if err != nil || res.CookieHeader != "CCC=work-ccc" {
+		t.Errorf("explicit file: %+v, %v", res, err)
+	}
+
+	// No profiles.ini at all
+	if _, err := loadFirefox(t, Options{}); !errors.Is(err, errNoCookieDB) {
+		t.Errorf("no Firefox error = %v, want errNoCookieDB", err)
+	}
+}
+
+func TestFirefoxRejectsNodeBackend(t *testing.T) {
+	if _, err := loadFirefox(t, Options{Backend: BackendNode}); err == nil || !strings.Contains(err.Error(), "Chrome only") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestParseProfilesINIAbsolutePath(t *testing.T) {
+	ini := "[Profile0]\nName=abs\nIsRelative=0\nPath=/data/firefox/abs\n\n[Profile1]\nName=no-path\n"
+	profiles, installDefault, err := parseProfilesINI(strings.NewReader(ini), "/home/u/.mozilla/firefox")
+	if err != nil {
+		t.Fatalf("parseProfilesINI error: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].dir != filepath.Clean("/data/firefox/abs") || installDefault != "" {
+		t.Errorf("profiles = %+v, installDefault = %q", profiles, installDefault)
+	}
+}
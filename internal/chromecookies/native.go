@@ -0,0 +1,279 @@
+package chromecookies
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNativeUnsupported means the Go reader can't handle this platform's
+// cookie encryption (macOS Keychain, Windows DPAPI) and only Node can.
+var errNativeUnsupported = errors.New("chromecookies: native reader supports Linux only")
+
+// errNoCookieDB keeps load.mjs's wording, the CLI shows login guidance for it.
+var errNoCookieDB = errors.New("No Cookies DB found") //nolint:staticcheck
+
+// cookie is one row of Chrome's cookies table.
+type cookie struct {
+	hostKey   string
+	name      string
+	value     string
+	encrypted []byte
+	path      string
+	secure    bool
+	expires   int64 // Microseconds since 1601-01-01 UTC, 0 for session cookies
+	created   int64
+}
+
+// loadNative reads and decrypts the cookie DB in-process.
+func (s chromiumSource) loadNative(ctx context.Context, opts Options) (Result, error) {
+	target, err := url.Parse(opts.TargetURL)
+	if err != nil || target.Hostname() == "" {
+		return Result{}, fmt.Errorf("chromecookies: invalid TargetURL %q", opts.TargetURL)
+	}
+
+	cookieFile, err := s.resolveCookieFile(opts.ChromeProfile, opts.ExplicitCookiePath)
+	if err != nil {
+		return Result{}, err
+	}
+	db, err := openSQLite(cookieFile)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: read cookie DB: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	cookies, err := readCookies(db)
+	if err != nil {
+		return Result{}, fmt.Errorf("chromecookies: %s: %w", cookieFile, err)
+	}
+
+	app := s.keyringFor(cookieFile)
+	dec := newDecrypter(metaVersion(db), func() []string {
+		return lookupKeyring(ctx, app, opts.Timeout, opts.LogWriter)
+	})
+	return cookieHeader(cookies, target, opts.FilterNames, dec, time.Now())
+}
+
+// cookieHeader builds the Cookie header for target like a browser would: only
+// matching, unexpired cookies, the most specific path first, each name once.
+func cookieHeader(cookies []cookie, target *url.URL, filterNames []string, dec *decrypter, now time.Time) (Result, error) {
+	host := strings.ToLower(target.Hostname())
+	reqPath := target.EscapedPath()
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	nowChrome := toChromeTime(now)
+
+	var matched []cookie
+	for _, c := range cookies {
+		if !domainMatches(c.hostKey, host) || !pathMatches(c.path, reqPath) {
+			continue
+		}
+		if c.secure && target.Scheme != "https" {
+			continue
+		}
+		if c.expires != 0 && c.expires < nowChrome {
+			continue
+		}
+		if len(filterNames) > 0 && !slices.Contains(filterNames, c.name) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	slices.SortStableFunc(matched, func(a, b cookie) int {
+		if n := cmp.Compare(len(b.path), len(a.path)); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.created, b.created)
+	})
+
+	var pairs []string
+	var jar []Cookie
+	seen := map[string]bool{}
+	for _, c := range matched {
+		if seen[c.name] {
+			continue
+		}
+		value := c.value
+		if value == "" && len(c.encrypted) > 0 {
+			var err error
+			if value, err = dec.decrypt(c.hostKey, c.encrypted); err != nil {
+				return Result{}, fmt.Errorf("%w (%s on %s)", err, c.name, c.hostKey)
+			}
+		}
+		if value == "" {
+			continue
+		}
+		seen[c.name] = true
+		pairs = append(pairs, c.name+"="+value)
+		jc := Cookie{Domain: c.hostKey, Path: c.path, Name: c.name, Value: value, Secure: c.secure}
+		if c.expires != 0 {
+			jc.Expires = fromChromeTime(c.expires)
+		}
+		jar = append(jar, jc)
+	}
+	return Result{CookieHeader: strings.Join(pairs, "; "), CookieCount: len(pairs), Cookies: jar}, nil
+}
+
+func readCookies(db *sqliteDB) ([]cookie, error) {
+	rows, err := db.rows("cookies")
+	if err != nil {
+		return nil, err
+	}
+	cookies := make([]cookie, 0, len(rows))
+	for _, r := range rows {
+		c := cookie{
+			hostKey: strings.ToLower(asString(r["host_key"])),
+			name:    asString(r["name"]),
+			value:   asString(r["value"]),
+			path:    asString(r["path"]),
+			secure:  asInt(r["is_secure"]) != 0,
+			expires: asInt(r["expires_utc"]),
+			created: asInt(r["creation_utc"]),
+		}
+		if b, ok := r["encrypted_value"].([]byte); ok {
+			c.encrypted = b
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
+
+// metaVersion is the schema version Chrome records in the meta table (0 if unknown).
+func metaVersion(db *sqliteDB) int {
+	rows, err := db.rows("meta")
+	if err != nil {
+		return 0
+	}
+	for _, r := range rows {
+		if asString(r["key"]) == "version" {
+			v, _ := strconv.Atoi(asString(r["value"]))
+			return v
+		}
+	}
+	return 0
+}
+
+func asString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
+
+func asInt(v any) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// chromeEpochOffset is the number of seconds between 1601-01-01 and 1970-01-01.
+const chromeEpochOffset = 11644473600
+
+func toChromeTime(t time.Time) int64 {
+	return (t.Unix()+chromeEpochOffset)*1_000_000 + int64(t.Nanosecond()/1000)
+}
+
+func fromChromeTime(us int64) time.Time {
+	return time.UnixMicro(us - chromeEpochOffset*1_000_000)
+}
+
+// domainMatches implements RFC 6265 domain matching for Chrome's host_key,
+// where a leading dot marks a domain cookie.
+func domainMatches(hostKey, host string) bool {
+	if domain, ok := strings.CutPrefix(hostKey, "."); ok {
+		return host == domain || strings.HasSuffix(host, hostKey)
+	}
+	return host == hostKey
+}
+
+// pathMatches implements RFC 6265 path matching.
+func pathMatches(cookiePath, reqPath string) bool {
+	if cookiePath == "" || cookiePath == "/" || cookiePath == reqPath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+// resolveCookieFile finds the Cookies DB the same way load.mjs does: an explicit
+// file or directory, a profile path, or a profile name under the browser's
+// user data directory.
+func (s chromiumSource) resolveCookieFile(profile, explicitCookiePath string) (string, error) {
+	if strings.TrimSpace(explicitCookiePath) != "" {
+		return ensureCookieFile(explicitCookiePath)
+	}
+	if looksLikePath(profile) {
+		return ensureCookieFile(profile)
+	}
+	name := strings.TrimSpace(profile)
+	if name == "" {
+		name = "Default"
+	}
+	root, err := s.profileRoot()
+	if err != nil {
+		return "", err
+	}
+	return ensureCookieFile(filepath.Join(root, name))
+}
+
+func ensureCookieFile(input string) (string, error) {
+	expanded, err := expandPath(input)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(expanded)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s", errNoCookieDB, expanded)
+	}
+	if !info.IsDir() {
+		return expanded, nil
+	}
+	for _, rel := range []string{
+		"Cookies",
+		filepath.Join("Network", "Cookies"),
+		filepath.Join("Default", "Cookies"),
+		filepath.Join("Default", "Network", "Cookies"),
+	} {
+		candidate := filepath.Join(expanded, rel)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w under %s", errNoCookieDB, expanded)
+}
+
+func expandPath(input string) (string, error) {
+	if rest, ok := strings.CutPrefix(input, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	return filepath.Abs(input)
+}
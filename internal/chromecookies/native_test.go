@@ -0,0 +1,229 @@
+package chromecookies
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubKeyring(t *testing.T, passwords ...string) {
+	t.Helper()
+	orig := lookupKeyring
+	lookupKeyring = func(ctx context.Context, app keyringApp, timeout time.Duration, logWriter io.Writer) []string {
+		return passwords
+	}
+	t.Cleanup(func() { lookupKeyring = orig })
+}
+
+func mustSource(t *testing.T, b Browser) chromiumSource {
+	t.Helper()
+	src, err := SourceFor(b)
+	if err != nil {
+		t.Fatalf("SourceFor(%s) error: %v", b, err)
+	}
+	return src.(chromiumSource)
+}
+
+func TestLoadNativeBuildsCookieHeader(t *testing.T) {
+	stubKeyring(t, "alzatest-keyring")
+
+	res, err := mustSource(t, BrowserChrome).loadNative(context.Background(), Options{
+		TargetURL:          "https://www.alza.sk/",
+		ExplicitCookiePath: filepath.Join("testdata", "Cookies"),
+	})
+	if err != nil {
+		t.Fatalf("loadNative error: %v", err)
+	}
+
+	want := map[string]string{
+		"CCC":     "plain-ccc",
+		"TKNP":    "tknp-v10-value",
+		"SESSION": "session-v11-value",
+		"CART":    "root",
+		"BIG":     strings.Repeat("B", 3000),
+	}
+	got := map[string]string{}
+	for _, pair := range strings.Split(res.CookieHeader, "; ") {
+		name, value, _ := strings.Cut(pair, "=")
+		got[name] = value
+	}
+	if len(got) != len(want) || res.CookieCount != len(want) {
+		t.Errorf("cookies = %v (count %d), want %v", keys(got), res.CookieCount, keys(want))
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %.20q, want %.20q", name, got[name], value)
+		}
+	}
+}
+
+func TestLoadNativeMatchesLikeABrowser(t *testing.T) {
+	stubKeyring(t)
+
+	res, err := mustSource(t, BrowserChrome).loadNative(context.Background(), Options{
+		TargetURL:          "http://www.alza.sk/kosik/detail",
+		ExplicitCookiePath: filepath.Join("testdata", "Cookies"),
+		FilterNames:        []string{"CART", "SESSION", "OLD"},
+	})
+	if err != nil {
+		t.Fatalf("loadNative error: %v", err)
+	}
+	// Longest path wins, the secure SESSION cookie isn't sent over http, OLD is expired
+	if res.CookieHeader != "CART=kosik" {
+		t.Errorf("CookieHeader = %q, want CART=kosik", res.CookieHeader)
+	}
+}
+
+func TestLoadNativeReadsWAL(t *testing.T) {
+	res, err := mustSource(t, BrowserChrome).loadNative(context.Background(), Options{
+		TargetURL:          "https://www.alza.sk/",
+		ExplicitCookiePath: filepath.Join("testdata", "wal"),
+	})
+	if err != nil {
+		t.Fatalf("loadNative error: %v", err)
+	}
+	if res.CookieHeader != "CCC=from-wal; NEW=only-in-wal" {
+		t.Errorf("CookieHeader = %q", res.CookieHeader)
+	}
+}
+
+func TestLoadNativeMissingDB(t *testing.T) {
+	dir := t.TempDir()
+	_, err := mustSource(t, BrowserChrome).loadNative(context.Background(), Options{TargetURL: "https://www.alza.sk/", ChromeProfile: dir})
+	if !errors.Is(err, errNoCookieDB) || !strings.Contains(err.Error(), "No Cookies DB found under "+dir) {
+		t.Errorf("loadNative error = %v", err)
+	}
+}
+
+func TestResolveCookieFileLayouts(t *testing.T) {
+	dir := t.TempDir()
+	network := filepath.Join(dir, "Default", "Network")
+	if err := os.MkdirAll(network, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(network, "Cookies"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mustSource(t, BrowserChrome).resolveCookieFile(dir, "")
+	if err != nil || got != filepath.Join(network, "Cookies") {
+		t.Errorf("resolveCookieFile = %q, %v", got, err)
+	}
+
+	t.Setenv("HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, ".config", "chromium", "Profile 1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".config", "chromium", "Profile 1", "Cookies"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = mustSource(t, BrowserChrome).resolveCookieFile("Profile 1", "")
+	if err != nil || got != filepath.Join(dir, ".config", "chromium", "Profile 1", "Cookies") {
+		t.Errorf("mustSource(t, BrowserChrome).resolveCookieFile(Profile 1) = %q, %v", got, err)
+	}
+}
+
+func TestLoadCookieHeaderAutoFallsBackToNode(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("native reader is Linux only")
+	}
+	cacheDir := setupCacheDir(t)
+	notSQLite := filepath.Join(t.TempDir(), "Cookies")
+	if err := os.WriteFile(notSQLite, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	origNode, origScript := nodeAvailable, runScript
+	nodeAvailable = func() bool { return true }
+	runScript = func(ctx context.Context, cacheDir, scriptPath, outPath string, input []byte, logWriter io.Writer, timeout time.Duration) (scriptOutput, error) {
+		return scriptOutput{CookieHeader: "from=node", CookieCount: 1}, nil
+	}
+	t.Cleanup(func() { nodeAvailable, runScript = origNode, origScript })
+
+	var log strings.Builder
+	res, err := LoadCookieHeader(context.Background(), Options{
+		TargetURL:          "https://www.alza.sk/",
+		ExplicitCookiePath: notSQLite,
+		CacheDir:           cacheDir,
+		LogWriter:          &log,
+	})
+	if err != nil || res.CookieHeader != "from=node" {
+		t.Fatalf("LoadCookieHeader = %+v, %v", res, err)
+	}
+	if !strings.Contains(log.String(), "falling back to node") {
+		t.Errorf("log = %q", log.String())
+	}
+
+	// A missing DB is missing for Node too: no fallback
+	_, err = LoadCookieHeader(context.Background(), Options{
+		TargetURL:          "https://www.alza.sk/",
+		ExplicitCookiePath: filepath.Join(t.TempDir(), "nope"),
+		CacheDir:           cacheDir,
+	})
+	if !errors.Is(err, errNoCookieDB) {
+		t.Errorf("missing DB error = %v, want errNoCookieDB", err)
+	}
+}
+
+func TestLoadCookieHeaderNativeBackend(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("native reader is Linux only")
+	}
+	stubKeyring(t, "alzatest-keyring")
+
+	// No CacheDir: the Go reader needs neither npm nor a cache
+	res, err := LoadCookieHeader(context.Background(), Options{
+		TargetURL:          "https://www.alza.sk/",
+		ExplicitCookiePath: filepath.Join("testdata", "Cookies"),
+		FilterNames:        []string{"TKNP"},
+		Backend:            BackendNative,
+	})
+	if err != nil || res.CookieHeader != "TKNP=tknp-v10-value" {
+		t.Fatalf("LoadCookieHeader = %+v, %v", res, err)
+	}
+}
+
+func TestPathMatches(t *testing.T) {
+	tests := []struct {
+		cookie, req string
+		want        bool
+	}{
+		{"/", "/anything", true},
+		{"/kosik", "/kosik", true},
+		{"/kosik", "/kosik/detail", true},
+		{"/kosik/", "/kosik/detail", true},
+		{"/kosik", "/kosikovy", false},
+		{"/kosik", "/", false},
+	}
+	for _, tt := range tests {
+		if got := pathMatches(tt.cookie, tt.req); got != tt.want {
+			t.Errorf("pathMatches(%q, %q) = %v", tt.cookie, tt.req, got)
+		}
+	}
+}
+
+func TestDomainMatches(t *testing.T) {
+	target, _ := url.Parse("https://www.alza.sk/")
+	host := target.Hostname()
+	if !domainMatches(".alza.sk", host) || !domainMatches("www.alza.sk", host) || !domainMatches(".alza.sk", "alza.sk") {
+		t.Error("expected alza.sk cookies to match")
+	}
+	if domainMatches(".balza.sk", host) || domainMatches("alza.sk", host) || domainMatches(".sk.alza.sk", host) {
+		t.Error("unexpected match")
+	}
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,486 @@
+package chromecookies
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// sqliteDB is a read-only view of an SQLite database file and its write-ahead log.
+// It implements just enough of the file format to scan whole tables: no SQL, no
+// indexes, UTF-8 text only. That is all a cookie DB needs and keeps cgo and
+// third-party drivers out of the binary.
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int               // pageSize minus the reserved bytes at the end of each page
+	pages    uint32            // Database size in pages
+	wal      map[uint32][]byte // Committed pages from the WAL, newer than data
+}
+
+const sqliteMagic = "SQLite format 3\x00"
+
+// errNotSQLite is returned for files that don't carry the SQLite header.
+var errNotSQLite = errors.New("not an SQLite database")
+
+// openSQLite reads path (and path-wal when Chrome left one behind) into memory.
+// Working on a snapshot means the file can be read while the browser holds it open.
+func openSQLite(path string) (*sqliteDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wal, err := os.ReadFile(path + "-wal")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	db, err := parseSQLite(data, wal)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+func parseSQLite(data, wal []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteMagic {
+		return nil, errNotSQLite
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("sqlite: invalid page size %d", pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, fmt.Errorf("sqlite: unsupported text encoding %d (only UTF-8)", enc)
+	}
+
+	db := &sqliteDB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+		pages:    uint32(len(data) / pageSize),
+	}
+	if len(wal) > 0 {
+		if err := db.applyWAL(wal); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// applyWAL loads the committed frames of the write-ahead log. Frames after the
+// last commit, with stale salts or a broken checksum chain are ignored, the same
+// way SQLite itself recovers a WAL.
+func (db *sqliteDB) applyWAL(wal []byte) error {
+	if len(wal) < 32 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch binary.BigEndian.Uint32(wal[:4]) {
+	case 0x377f0682:
+		order = binary.LittleEndian
+	case 0x377f0683:
+		order = binary.BigEndian
+	default:
+		return errors.New("sqlite: invalid WAL header")
+	}
+	if int(binary.BigEndian.Uint32(wal[8:12])) != db.pageSize {
+		return nil // WAL of a different database generation
+	}
+
+	s0, s1 := walChecksum(order, wal[:24], 0, 0)
+	if s0 != binary.BigEndian.Uint32(wal[24:28]) || s1 != binary.BigEndian.Uint32(wal[28:32]) {
+		return nil
+	}
+	salt := wal[16:24]
+
+	committed := map[uint32][]byte{}
+	pending := map[uint32][]byte{}
+	frameSize := 24 + db.pageSize
+	for off := 32; off+frameSize <= len(wal); off += frameSize {
+		frame := wal[off : off+frameSize]
+		if !bytes.Equal(frame[8:16], salt) {
+			break
+		}
+		s0, s1 = walChecksum(order, frame[:8], s0, s1)
+		s0, s1 = walChecksum(order, frame[24:], s0, s1)
+		if s0 != binary.BigEndian.Uint32(frame[16:20]) || s1 != binary.BigEndian.Uint32(frame[20:24]) {
+			break
+		}
+
+		pending[binary.BigEndian.Uint32(frame[:4])] = frame[24:]
+		if commit := binary.BigEndian.Uint32(frame[4:8]); commit != 0 {
+			for n, p := range pending {
+				committed[n] = p
+			}
+			clear(pending)
+			db.pages = commit
+		}
+	}
+	if len(committed) > 0 {
+		db.wal = committed
+	}
+	return nil
+}
+
+func walChecksum(order binary.ByteOrder, b []byte, s0, s1 uint32) (uint32, uint32) {
+	for i := 0; i+8 <= len(b); i += 8 {
+		s0 += order.Uint32(b[i:]) + s1
+		s1 += order.Uint32(b[i+4:]) + s0
+	}
+	return s0, s1
+}
+
+// page returns page n (1-based), preferring the WAL copy.
+func (db *sqliteDB) page(n uint32) ([]byte, error) {
+	if n == 0 || n > db.pages {
+		return nil, fmt.Errorf("sqlite: page %d out of range", n)
+	}
+	if p, ok := db.wal[n]; ok {
+		return p, nil
+	}
+	off := int(n-1) * db.pageSize
+	if off+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("sqlite: page %d beyond end of file", n)
+	}
+	return db.data[off : off+db.pageSize], nil
+}
+
+// sqliteTable is one table from sqlite_schema.
+type sqliteTable struct {
+	root    uint32
+	columns []string
+	rowid   int // Index of the INTEGER PRIMARY KEY column aliasing the rowid, or -1
+}
+
+// table looks name up in the schema table on page 1.
+func (db *sqliteDB) table(name string) (*sqliteTable, error) {
+	var found *sqliteTable
+	err := db.scan(1, func(rowid int64, rec []any) error {
+		if found != nil || len(rec) < 5 {
+			return nil
+		}
+		typ, _ := rec[0].(string)
+		tblName, _ := rec[1].(string)
+		root, _ := rec[3].(int64)
+		sql, _ := rec[4].(string)
+		if typ != "table" || !strings.EqualFold(tblName, name) {
+			return nil
+		}
+		columns, rowidCol := parseColumns(sql)
+		found = &sqliteTable{root: uint32(root), columns: columns, rowid: rowidCol}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("sqlite: no such table: %s", name)
+	}
+	return found, nil
+}
+
+// rows returns every row of the table keyed by column name. Columns added by
+// ALTER TABLE after a row was written are missing from its record and read as nil.
+func (db *sqliteDB) rows(name string) ([]map[string]any, error) {
+	t, err := db.table(name)
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]any
+	err = db.scan(t.root, func(rowid int64, rec []any) error {
+		row := make(map[string]any, len(t.columns))
+		for i, col := range t.columns {
+			switch {
+			case i == t.rowid:
+				row[col] = rowid
+			case i < len(rec):
+				row[col] = rec[i]
+			default:
+				row[col] = nil
+			}
+		}
+		out = append(out, row)
+		return nil
+	})
+	return out, err
+}
+
+// scan walks the table b-tree rooted at root in rowid order.
+func (db *sqliteDB) scan(root uint32, fn func(rowid int64, rec []any) error) error {
+	return db.scanPage(root, 0, fn)
+}
+
+const maxTreeDepth = 32
+
+func (db *sqliteDB) scanPage(n uint32, depth int, fn func(rowid int64, rec []any) error) error {
+	if depth > maxTreeDepth {
+		return errors.New("sqlite: b-tree too deep (corrupt database?)")
+	}
+	p, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	if len(p) < hdr+12 {
+		return fmt.Errorf("sqlite: page %d truncated", n)
+	}
+
+	kind := p[hdr]
+	cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	ptrs := hdr + 8
+	if kind == 0x05 {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(p) {
+		return fmt.Errorf("sqlite: page %d: bad cell count", n)
+	}
+
+	for i := range cells {
+		off := int(binary.BigEndian.Uint16(p[ptrs+2*i:]))
+		if off >= len(p) {
+			return fmt.Errorf("sqlite: page %d: bad cell offset", n)
+		}
+		switch kind {
+		case 0x05: // Interior table page: 4-byte left child, then the rowid key
+			if off+4 > len(p) {
+				return fmt.Errorf("sqlite: page %d: truncated cell", n)
+			}
+			if err := db.scanPage(binary.BigEndian.Uint32(p[off:]), depth+1, fn); err != nil {
+				return err
+			}
+		case 0x0d: // Leaf table page
+			rowid, payload, err := db.leafCell(p, off)
+			if err != nil {
+				return fmt.Errorf("sqlite: page %d: %w", n, err)
+			}
+			rec, err := decodeRecord(payload)
+			if err != nil {
+				return fmt.Errorf("sqlite: page %d: %w", n, err)
+			}
+			if err := fn(rowid, rec); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("sqlite: page %d is not a table b-tree page (type %#x)", n, kind)
+		}
+	}
+	if kind == 0x05 {
+		return db.scanPage(binary.BigEndian.Uint32(p[hdr+8:]), depth+1, fn)
+	}
+	return nil
+}
+
+// leafCell returns the rowid and the full payload of a table leaf cell,
+// following the overflow chain for payloads that don't fit on the page.
+func (db *sqliteDB) leafCell(p []byte, off int) (int64, []byte, error) {
+	size, n := readVarint(p[off:])
+	if n == 0 {
+		return 0, nil, errors.New("bad payload size")
+	}
+	off += n
+	rowid, n := readVarint(p[off:])
+	if n == 0 {
+		return 0, nil, errors.New("bad rowid")
+	}
+	off += n
+
+	total := int(size)
+	local := db.localPayload(total)
+	if off+local > len(p) {
+		return 0, nil, errors.New("payload overruns page")
+	}
+	if local == total {
+		return int64(rowid), p[off : off+local], nil
+	}
+
+	payload := make([]byte, 0, total)
+	payload = append(payload, p[off:off+local]...)
+	if off+local+4 > len(p) {
+		return 0, nil, errors.New("missing overflow pointer")
+	}
+	next := binary.BigEndian.Uint32(p[off+local:])
+	for hops := 0; len(payload) < total; hops++ {
+		if next == 0 || hops > int(db.pages) {
+			return 0, nil, errors.New("broken overflow chain")
+		}
+		op, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		chunk := min(total-len(payload), db.usable-4)
+		payload = append(payload, op[4:4+chunk]...)
+		next = binary.BigEndian.Uint32(op)
+	}
+	return int64(rowid), payload, nil
+}
+
+// localPayload is how many payload bytes a table leaf cell stores on its own page.
+func (db *sqliteDB) localPayload(total int) int {
+	u := db.usable
+	maxLocal := u - 35
+	if total <= maxLocal {
+		return total
+	}
+	minLocal := (u-12)*32/255 - 23
+	k := minLocal + (total-minLocal)%(u-4)
+	if k <= maxLocal {
+		return k
+	}
+	return minLocal
+}
+
+// decodeRecord turns a record into nil, int64, float64, string or []byte values.
+func decodeRecord(b []byte) ([]any, error) {
+	hdrSize, n := readVarint(b)
+	if n == 0 || int(hdrSize) > len(b) || int(hdrSize) < n {
+		return nil, errors.New("bad record header")
+	}
+	var types []uint64
+	for off := n; off < int(hdrSize); {
+		t, n := readVarint(b[off:hdrSize])
+		if n == 0 {
+			return nil, errors.New("bad record header")
+		}
+		types = append(types, t)
+		off += n
+	}
+
+	values := make([]any, len(types))
+	body := b[hdrSize:]
+	for i, t := range types {
+		size := serialSize(t)
+		if size > len(body) {
+			return nil, errors.New("record overruns payload")
+		}
+		v := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t <= 6:
+			values[i] = readInt(v)
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(v))
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t >= 12 && t%2 == 0:
+			values[i] = v
+		case t >= 13:
+			values[i] = string(v)
+		default:
+			return nil, fmt.Errorf("reserved serial type %d", t)
+		}
+	}
+	return values, nil
+}
+
+func serialSize(t uint64) int {
+	switch {
+	case t <= 4:
+		return int(t)
+	case t == 5:
+		return 6
+	case t == 6 || t == 7:
+		return 8
+	case t >= 12:
+		return int((t - 12) / 2)
+	default:
+		return 0
+	}
+}
+
+// readInt decodes a big-endian two's-complement integer of 1-8 bytes.
+func readInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// readVarint decodes an SQLite varint; n is 0 when b is too short.
+func readVarint(b []byte) (v uint64, n int) {
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
+
+// parseColumns extracts the column names from a CREATE TABLE statement and the
+// index of the INTEGER PRIMARY KEY column, which SQLite stores as the rowid.
+func parseColumns(sql string) ([]string, int) {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil, -1
+	}
+
+	var defs []string
+	depth, last := 0, start+1
+	var quote byte
+	for i := start + 1; i < end; i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			defs = append(defs, sql[last:i])
+			last = i + 1
+		}
+	}
+	defs = append(defs, sql[last:end])
+
+	columns := make([]string, 0, len(defs))
+	rowid := -1
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		upper := strings.ToUpper(def)
+		if len(fields) > 1 && strings.ToUpper(fields[1]) == "INTEGER" && strings.Contains(upper, "PRIMARY KEY") && !strings.Contains(upper, "DESC") {
+			rowid = len(columns)
+		}
+		columns = append(columns, strings.Trim(fields[0], "\"'`[]"))
+	}
+	return columns, rowid
+}
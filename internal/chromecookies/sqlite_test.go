@@ -0,0 +1,121 @@
+package chromecookies
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteReadsMultiLevelTable(t *testing.T) {
+	db, err := openSQLite(filepath.Join("testdata", "Cookies"))
+	if err != nil {
+		t.Fatalf("openSQLite error: %v", err)
+	}
+	if db.pageSize != 1024 {
+		t.Fatalf("pageSize = %d, want 1024", db.pageSize)
+	}
+
+	rows, err := db.rows("cookies")
+	if err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	if len(rows) != 309 {
+		t.Fatalf("got %d rows, want 309", len(rows))
+	}
+
+	byName := map[string]map[string]any{}
+	for _, r := range rows {
+		byName[asString(r["name"])] = r
+	}
+	if got := asString(byName["BIG"]["value"]); got != strings.Repeat("B", 3000) {
+		t.Errorf("BIG overflow value has %d bytes, want 3000", len(got))
+	}
+	if got := asInt(byName["CCC"]["expires_utc"]); got != 13537929600000000 {
+		t.Errorf("CCC expires_utc = %d", got)
+	}
+	if enc, _ := byName["TKNP"]["encrypted_value"].([]byte); !strings.HasPrefix(string(enc), "v10") {
+		t.Errorf("TKNP encrypted_value = %q", enc)
+	}
+	if got := metaVersion(db); got != 24 {
+		t.Errorf("metaVersion = %d, want 24", got)
+	}
+}
+
+func TestSQLiteAppliesCommittedWAL(t *testing.T) {
+	db, err := openSQLite(filepath.Join("testdata", "wal", "Cookies"))
+	if err != nil {
+		t.Fatalf("openSQLite error: %v", err)
+	}
+	rows, err := db.rows("cookies")
+	if err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	values := map[string]string{}
+	for _, r := range rows {
+		values[asString(r["name"])] = asString(r["value"])
+	}
+	if values["CCC"] != "from-wal" || values["NEW"] != "only-in-wal" {
+		t.Errorf("values = %v, want the WAL versions", values)
+	}
+}
+
+func TestSQLiteIgnoresWALWithBrokenChecksum(t *testing.T) {
+	dir := t.TempDir()
+	data, _ := os.ReadFile(filepath.Join("testdata", "wal", "Cookies"))
+	wal, _ := os.ReadFile(filepath.Join("testdata", "wal", "Cookies-wal"))
+	wal[len(wal)-1] ^= 0xff // Corrupt the last (commit) frame
+	_ = os.WriteFile(filepath.Join(dir, "Cookies"), data, 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "Cookies-wal"), wal, 0o600)
+
+	db, err := openSQLite(filepath.Join(dir, "Cookies"))
+	if err != nil {
+		t.Fatalf("openSQLite error: %v", err)
+	}
+	rows, err := db.rows("cookies")
+	if err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	if len(rows) != 1 || asString(rows[0]["value"]) != "before-wal" {
+		t.Errorf("rows = %v, want only the checkpointed state", rows)
+	}
+}
+
+func TestSQLiteRejectsOtherFiles(t *testing.T) {
+	if _, err := parseSQLite([]byte("not a database"), nil); err != errNotSQLite {
+		t.Errorf("parseSQLite error = %v, want errNotSQLite", err)
+	}
+
+	db, err := openSQLite(filepath.Join("testdata", "Cookies"))
+	if err != nil {
+		t.Fatalf("openSQLite error: %v", err)
+	}
+	if _, err := db.rows("passwords"); err == nil || !strings.Contains(err.Error(), "no such table") {
+		t.Errorf("rows(passwords) error = %v", err)
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<64 - 1, 9},
+		{[]byte{0x81}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got, n := readVarint(tt.in); got != tt.want || n != tt.n {
+			t.Errorf("readVarint(%x) = %d, %d; want %d, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, rowid := parseColumns(`CREATE TABLE t(id INTEGER PRIMARY KEY, "name" TEXT NOT NULL, price DECIMAL(10, 2), UNIQUE (name))`)
+	if strings.Join(columns, ",") != "id,name,price" || rowid != 0 {
+		t.Errorf("parseColumns = %v, %d", columns, rowid)
+	}
+}
@@ -0,0 +1,147 @@
+#!/usr/bin/env python3
+"""Regenerates the Chrome cookie DB fixtures used by the native reader tests.
+
+    cd internal/chromecookies/testdata && python3 make_cookies.py
+
+Cookies      schema version 24 DB with 1 KiB pages, so the cookies table spans
+             interior pages and BIG needs overflow pages.
+wal/Cookies  DB in WAL mode whose last changes exist only in wal/Cookies-wal.
+firefox/     Firefox profile root: profiles.ini with two profiles, each with
+             a cookies.sqlite (moz_cookies, plaintext values).
+
+The encrypted values are AES-128-CBC as Chrome writes them on Linux
+(PBKDF2-SHA1 key, salt "saltysalt", IV of 16 spaces, SHA-256(host_key) prefix):
+TKNP uses the v10 password "peanuts", SESSION the v11 keyring password
+"alzatest-keyring".
+"""
+import os
+import shutil
+import sqlite3
+
+SCHEMA = """
+CREATE TABLE meta(key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR);
+CREATE TABLE cookies(creation_utc INTEGER NOT NULL,host_key TEXT NOT NULL,top_frame_site_key TEXT NOT NULL,name TEXT NOT NULL,value TEXT NOT NULL,encrypted_value BLOB NOT NULL,path TEXT NOT NULL,expires_utc INTEGER NOT NULL,is_secure INTEGER NOT NULL,is_httponly INTEGER NOT NULL,last_access_utc INTEGER NOT NULL,has_expires INTEGER NOT NULL,is_persistent INTEGER NOT NULL,priority INTEGER NOT NULL,samesite INTEGER NOT NULL,source_scheme INTEGER NOT NULL,source_port INTEGER NOT NULL,last_update_utc INTEGER NOT NULL,source_type INTEGER NOT NULL,has_cross_site_ancestor INTEGER NOT NULL);
+CREATE UNIQUE INDEX cookies_unique_index ON cookies(host_key, top_frame_site_key, has_cross_site_ancestor, name, path, source_scheme, source_port);
+INSERT INTO meta VALUES ('version', '24'), ('last_compatible_version', '24');
+"""
+
+TKNP_V10 = bytes.fromhex("763130a09e394eb3f1cd19ec55a31432499ba2121755413ab7fea0ea1909e59d4ccef3bc29075c7361f629e0fa3eee66ab4769")
+SESSION_V11 = bytes.fromhex("763131de1fd93504c592ada6022cc9328d245fbd6e76cb25e0c375ec14e7e6036dc9bee8719df7eaf215744f1a24ee42b09920a6908be30809479bacd29c86e558f268")
+
+Y2030 = 13537929600000000  # Chrome time: microseconds since 1601-01-01
+Y2012 = 12969964800000000
+
+
+def cookie(db, created, host, name, value="", encrypted=b"", path="/", expires=Y2030, secure=0):
+    db.execute(
+        "INSERT INTO cookies VALUES (?,?,'',?,?,?,?,?,?,0,?,?,?,1,0,2,443,?,0,0)",
+        (created, host, name, value, encrypted, path, expires, secure, created, int(expires != 0), int(expires != 0), created),
+    )
+
+
+def fill(db):
+    cookie(db, 1, ".alza.sk", "CCC", "plain-ccc")
+    cookie(db, 2, ".alza.sk", "TKNP", encrypted=TKNP_V10)
+    cookie(db, 3, "www.alza.sk", "SESSION", encrypted=SESSION_V11, expires=0, secure=1)
+    cookie(db, 4, ".alza.sk", "OLD", "expired", expires=Y2012)
+    cookie(db, 5, ".alza.sk", "CART", "root")
+    cookie(db, 6, "www.alza.sk", "CART", "kosik", path="/kosik")
+    cookie(db, 7, ".example.com", "OTHER", "x")
+    cookie(db, 8, ".alza.sk", "BIG", "B" * 3000)
+    cookie(db, 9, ".alza.sk", "EMPTY")
+    for i in range(300):
+        cookie(db, 100 + i, f"filler{i}.test", "f", "v" * 20)
+
+
+FIREFOX_SCHEMA = """
+CREATE TABLE moz_cookies (id INTEGER PRIMARY KEY, originAttributes TEXT NOT NULL DEFAULT '', name TEXT, value TEXT, host TEXT, path TEXT, expiry INTEGER, lastAccessed INTEGER, creationTime INTEGER, isSecure INTEGER, isHttpOnly INTEGER, inBrowserElement INTEGER DEFAULT 0, sameSite INTEGER DEFAULT 0, rawSameSite INTEGER DEFAULT 0, schemeMap INTEGER DEFAULT 0, isPartitionedAttributeSet INTEGER DEFAULT 0, CONSTRAINT moz_uniqueid UNIQUE (name, host, path, originAttributes));
+"""
+
+PROFILES_INI = """[Profile1]
+Name=work
+IsRelative=1
+Path=Profiles/xyz.work
+Default=1
+
+[Profile0]
+Name=default-release
+IsRelative=1
+Path=Profiles/abc.default-release
+
+[General]
+StartWithLastProfile=1
+Version=2
+
+[Install4F96D1932A9F858E]
+Default=Profiles/abc.default-release
+Locked=1
+"""
+
+
+def firefox_cookie(db, host, name, value, expiry=1893456000, origin="", secure=0):
+    db.execute(
+        "INSERT INTO moz_cookies (originAttributes, name, value, host, path, expiry, lastAccessed, creationTime, isSecure, isHttpOnly) VALUES (?,?,?,?,'/',?,0,0,?,0)",
+        (origin, name, value, host, expiry, secure),
+    )
+
+
+def firefox():
+    for profile, rows in {
+        "abc.default-release": [
+            (".alza.sk", "CCC", "ff-ccc", 1893456000),  # expiry in seconds
+            ("www.alza.sk", "TKNP", "ff-tknp", 1893456000000),  # milliseconds (Firefox 13x)
+            (".alza.sk", "OLD", "expired", 1300000000),
+            (".alza.sk", "CONTAINER", "x", 1893456000, "^userContextId=1"),
+        ],
+        "xyz.work": [(".alza.sk", "CCC", "work-ccc", 1893456000)],
+    }.items():
+        path = f"firefox/Profiles/{profile}/cookies.sqlite"
+        os.makedirs(os.path.dirname(path), exist_ok=True)
+        if os.path.exists(path):
+            os.remove(path)
+        db = sqlite3.connect(path)
+        db.executescript(FIREFOX_SCHEMA)
+        for row in rows:
+            firefox_cookie(db, *row)
+        db.commit()
+        db.close()
+    with open("firefox/profiles.ini", "w") as f:
+        f.write(PROFILES_INI)
+
+
+def main():
+    for path in ["Cookies", "wal/Cookies", "wal/Cookies-wal"]:
+        if os.path.exists(path):
+            os.remove(path)
+
+    db = sqlite3.connect("Cookies")
+    db.execute("PRAGMA page_size = 1024")
+    db.executescript(SCHEMA)
+    fill(db)
+    db.commit()
+    db.close()
+
+    # The WAL copy is taken while the writer is still open, before any checkpoint
+    os.makedirs("wal", exist_ok=True)
+    db = sqlite3.connect("wal/tmp")
+    db.execute("PRAGMA journal_mode = WAL")
+    db.executescript(SCHEMA)
+    cookie(db, 1, ".alza.sk", "CCC", "before-wal")
+    db.commit()
+    db.execute("PRAGMA wal_checkpoint(TRUNCATE)")
+    db.execute("PRAGMA wal_autocheckpoint = 0")
+    db.execute("UPDATE cookies SET value = 'from-wal' WHERE name = 'CCC'")
+    cookie(db, 2, ".alza.sk", "NEW", "only-in-wal")
+    db.commit()
+    shutil.copy("wal/tmp", "wal/Cookies")
+    shutil.copy("wal/tmp-wal", "wal/Cookies-wal")
+    db.close()
+    for path in ["wal/tmp", "wal/tmp-wal", "wal/tmp-shm"]:
+        if os.path.exists(path):
+            os.remove(path)
+
+    firefox()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON Schema subset used for tool input and output schemas.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // *Schema or false
+}
+
+// SchemaFor derives a schema from T the way encoding/json marshals it: json
+// tag names, fields without omitempty/omitzero are required, embedded structs
+// are inlined. The `jsonschema:"..."` tag is the field description and
+// `enum:"a,b"` lists the allowed string values.
+func SchemaFor[T any]() *Schema {
+	return schemaFor(reflect.TypeFor[T](), map[reflect.Type]bool{})
+}
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	rawMsgType = reflect.TypeFor[json.RawMessage]()
+)
+
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMsgType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), seen)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("mcp: map key %s is not a string", t.Key()))
+		}
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"} // Recursive type
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addFields(s, t, seen)
+		return s
+	}
+	panic(fmt.Sprintf("mcp: no JSON schema for %s", t))
+}
+
+func addFields(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaFor(f.Type, seen)
+		if desc := f.Tag.Get("jsonschema"); desc != "" {
+			prop.Description = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		s.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
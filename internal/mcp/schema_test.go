@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaBase struct {
+	ID int `json:"id"`
+}
+
+type schemaSample struct {
+	schemaBase
+	Name     string            `json:"name" jsonschema:"Display name"`
+	Price    float64           `json:"price,omitempty"`
+	Tags     []string          `json:"tags,omitzero"`
+	Discount *int              `json:"discount,omitempty"`
+	Sort     string            `json:"sort,omitempty" enum:"price,rating"`
+	Seen     time.Time         `json:"seen"`
+	Extra    map[string]int    `json:"extra,omitempty"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Children []*schemaSample   `json:"children,omitempty"`
+	Hidden   string            `json:"-"`
+	NoTag    bool              // No json tag: Go field name
+	internal string            // Unexported: skipped
+	Any      map[string]any    `json:"any,omitempty"`
+	Nested   struct{ A int }   `json:"nested"`
+	Bytes    []byte            `json:"bytes,omitempty"`
+	Groups   [][]schemaBase    `json:"groups,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor[schemaSample]()
+	if s.Type != "object" {
+		t.Fatalf("Type = %q, want object", s.Type)
+	}
+
+	wantRequired := []string{"id", "name", "seen", "NoTag", "nested"}
+	if !reflect.DeepEqual(s.Required, wantRequired) {
+		t.Errorf("Required = %v, want %v", s.Required, wantRequired)
+	}
+	for _, skipped := range []string{"Hidden", "-", "internal", "schemaBase"} {
+		if _, ok := s.Properties[skipped]; ok {
+			t.Errorf("property %q should be skipped", skipped)
+		}
+	}
+
+	tests := []struct {
+		prop string
+		want Schema
+	}{
+		{"id", Schema{Type: "integer"}},
+		{"name", Schema{Type: "string", Description: "Display name"}},
+		{"price", Schema{Type: "number"}},
+		{"discount", Schema{Type: "integer"}},
+		{"sort", Schema{Type: "string", Enum: []string{"price", "rating"}}},
+		{"seen", Schema{Type: "string", Format: "date-time"}},
+		{"raw", Schema{}},
+		{"bytes", Schema{Type: "string", Format: "byte"}},
+		{"NoTag", Schema{Type: "boolean"}},
+	}
+	for _, tt := range tests {
+		got := s.Properties[tt.prop]
+		if got == nil || !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Properties[%q] = %+v, want %+v", tt.prop, got, tt.want)
+		}
+	}
+
+	if tags := s.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Errorf("tags = %+v, want array of string", tags)
+	}
+	if extra := s.Properties["extra"]; extra.Type != "object" || extra.AdditionalProperties.(*Schema).Type != "integer" {
+		t.Errorf("extra = %+v, want object of integer", extra)
+	}
+	if children := s.Properties["children"]; children.Items.Type != "object" || children.Items.Properties != nil {
+		t.Errorf("children = %+v, want recursive type cut to a bare object", children.Items)
+	}
+	if groups := s.Properties["groups"]; groups.Items.Items.Properties["id"] == nil {
+		t.Errorf("groups = %+v, want nested arrays of objects", groups)
+	}
+	if nested := s.Properties["nested"]; nested.Properties["A"] == nil {
+		t.Errorf("nested = %+v, want anonymous struct fields", nested)
+	}
+}
+
+func TestSchemaForNonStruct(t *testing.T) {
+	if s := SchemaFor[[]int](); s.Type != "array" || s.Items.Type != "integer" {
+		t.Errorf("SchemaFor[[]int]() = %+v", s)
+	}
+	if s := SchemaFor[*schemaBase](); s.Type != "object" || s.Properties["id"] == nil {
+		t.Errorf("SchemaFor[*schemaBase]() = %+v", s)
+	}
+}
+
+func TestSchemaForPanicsOnUnsupportedType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("SchemaFor[chan int]() didn't panic")
+		}
+	}()
+	SchemaFor[chan int]()
+}
@@ -0,0 +1,373 @@
+// Package mcp is a minimal Model Context Protocol server: JSON-RPC 2.0 over
+// newline-delimited stdio with the tools capability (initialize, ping,
+// tools/list, tools/call and request cancellation).
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// LatestProtocolVersion is answered to clients asking for a version this server doesn't know.
+const LatestProtocolVersion = "2025-06-18"
+
+var supportedProtocolVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Tool is one callable tool. Handler gets the raw "arguments" object; its
+// result is sent as structuredContent (when OutputSchema is set) and as JSON
+// text content. A Handler error is reported to the model as a tool error,
+// except *InvalidParamsError, which is a protocol error.
+type Tool struct {
+	Name         string           `json:"name"`
+	Title        string           `json:"title,omitempty"`
+	Description  string           `json:"description,omitempty"`
+	InputSchema  *Schema          `json:"inputSchema"`
+	OutputSchema *Schema          `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
+
+	Handler func(ctx context.Context, args json.RawMessage) (any, error) `json:"-"`
+}
+
+// ToolAnnotations are hints for the client (e.g. to ask before destructive calls).
+type ToolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+// InvalidParamsError is returned for arguments that don't match the input schema.
+type InvalidParamsError struct {
+	Err error
+}
+
+func (e *InvalidParamsError) Error() string { return "invalid arguments: " + e.Err.Error() }
+func (e *InvalidParamsError) Unwrap() error { return e.Err }
+
+// NewTool builds a Tool whose schemas are derived from In and Out (see
+// SchemaFor). Arguments are decoded strictly: unknown and missing required
+// properties are invalid params. Out gets an output schema only if it is a struct.
+func NewTool[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) Tool {
+	input := SchemaFor[In]()
+	input.AdditionalProperties = false
+	var output *Schema
+	if out := SchemaFor[Out](); out.Type == "object" && out.AdditionalProperties == nil {
+		output = out
+	}
+	return Tool{
+		Name:         name,
+		Description:  description,
+		InputSchema:  input,
+		OutputSchema: output,
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in In
+			if err := DecodeArgs(args, input, &in); err != nil {
+				return nil, &InvalidParamsError{Err: err}
+			}
+			return fn(ctx, in)
+		},
+	}
+}
+
+// DecodeArgs decodes the JSON object args into v, rejecting properties schema
+// doesn't know and missing required ones. Empty args decode as {}.
+func DecodeArgs(args json.RawMessage, schema *Schema, v any) error {
+	if len(bytes.TrimSpace(args)) == 0 || bytes.Equal(bytes.TrimSpace(args), []byte("null")) {
+		args = json.RawMessage("{}")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return fmt.Errorf("arguments must be an object")
+	}
+	for _, name := range schema.Required {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing required property %q", name)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Server answers MCP requests for a fixed set of tools.
+type Server struct {
+	name         string
+	version      string
+	instructions string
+	tools        []Tool
+}
+
+// NewServer returns a server announcing itself as name/version; instructions
+// are passed to the model on initialize.
+func NewServer(name, version, instructions string) *Server {
+	return &Server{name: name, version: version, instructions: instructions}
+}
+
+// AddTool registers t. Names must be unique.
+func (s *Server) AddTool(t Tool) {
+	if s.tool(t.Name) != nil {
+		panic("mcp: duplicate tool " + t.Name)
+	}
+	s.tools = append(s.tools, t)
+}
+
+// Tools returns the registered tools in registration order.
+func (s *Server) Tools() []Tool { return slices.Clone(s.tools) }
+
+func (s *Server) tool(name string) *Tool {
+	for i := range s.tools {
+		if s.tools[i].Name == name {
+			return &s.tools[i]
+		}
+	}
+	return nil
+}
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Content is one item of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallToolResult is the result of tools/call.
+type CallToolResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w, one JSON message per
+// line, until r is exhausted or ctx is done. tools/call requests run
+// concurrently and can be cancelled with notifications/cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sess := &session{server: s, w: w, inflight: map[string]context.CancelFunc{}}
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 16<<20)
+		for sc.Scan() {
+			select {
+			case lines <- bytes.Clone(sc.Bytes()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- sc.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			sess.wg.Wait()
+			return ctx.Err()
+		case err := <-readErr:
+			sess.wg.Wait()
+			return err
+		case line := <-lines:
+			if len(bytes.TrimSpace(line)) > 0 {
+				sess.dispatch(ctx, line)
+			}
+		}
+	}
+}
+
+type session struct {
+	server *Server
+	wg     sync.WaitGroup
+
+	writeMu sync.Mutex
+	w       io.Writer
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc // tools/call by request ID
+}
+
+func (sess *session) dispatch(ctx context.Context, line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		code := CodeParseError
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("[")) {
+			code = CodeInvalidRequest // Batches were removed from MCP
+		}
+		sess.reply(nil, nil, &rpcError{Code: code, Message: err.Error()})
+		return
+	}
+	if msg.Method == "" {
+		return // A response; this server sends no requests
+	}
+	if msg.ID == nil {
+		sess.notification(msg)
+		return
+	}
+
+	if msg.Method != "tools/call" {
+		result, rpcErr := sess.server.handle(msg)
+		sess.reply(msg.ID, result, rpcErr)
+		return
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	key := string(msg.ID)
+	sess.mu.Lock()
+	sess.inflight[key] = cancel
+	sess.mu.Unlock()
+	sess.wg.Add(1)
+	go func() {
+		defer sess.wg.Done()
+		defer func() {
+			sess.mu.Lock()
+			delete(sess.inflight, key)
+			sess.mu.Unlock()
+			cancel()
+		}()
+		result, rpcErr := sess.server.callTool(callCtx, msg.Params)
+		if callCtx.Err() != nil && ctx.Err() == nil {
+			return // Cancelled by the client, which expects no response
+		}
+		sess.reply(msg.ID, result, rpcErr)
+	}()
+}
+
+func (sess *session) notification(msg message) {
+	if msg.Method != "notifications/cancelled" {
+		return // notifications/initialized and others need no action
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(msg.Params, &params) != nil {
+		return
+	}
+	sess.mu.Lock()
+	cancel := sess.inflight[string(params.RequestID)]
+	sess.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (sess *session) reply(id json.RawMessage, result any, rpcErr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	resp := response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}
+	if rpcErr == nil && result == nil {
+		resp.Result = struct{}{}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: CodeInternalError, Message: err.Error()}})
+	}
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+	_, _ = sess.w.Write(append(data, '\n'))
+}
+
+func (s *Server) handle(msg message) (any, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		version := LatestProtocolVersion
+		if slices.Contains(supportedProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+			"instructions":    s.instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		tools := s.tools
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]any{"tools": tools}, nil
+	}
+	return nil, &rpcError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (result any, rpcErr *rpcError) {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	t := s.tool(params.Name)
+	if t == nil {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			result, rpcErr = toolError(fmt.Errorf("%s: panic: %v", t.Name, p)), nil
+		}
+	}()
+	out, err := t.Handler(ctx, params.Arguments)
+	if invalid := (*InvalidParamsError)(nil); errors.As(err, &invalid) {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: invalid.Error()}
+	}
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	text, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return toolError(err), nil
+	}
+	res := CallToolResult{Content: []Content{{Type: "text", Text: string(text)}}}
+	if t.OutputSchema != nil {
+		res.StructuredContent = out
+	}
+	return res, nil
+}
+
+func toolError(err error) CallToolResult {
+	return CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+}
@@ -0,0 +1,236 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoArgs struct {
+	Text  string `json:"text" jsonschema:"Text to echo"`
+	Times int    `json:"times,omitempty"`
+}
+
+type echoResult struct {
+	Text string `json:"text"`
+}
+
+func newTestServer() *Server {
+	s := NewServer("test", "1.0", "Use echo.")
+	s.AddTool(NewTool("echo", "Echo text", func(ctx context.Context, in echoArgs) (echoResult, error) {
+		if in.Text == "fail" {
+			return echoResult{}, errors.New("echo failed")
+		}
+		if in.Text == "panic" {
+			panic("boom")
+		}
+		return echoResult{Text: strings.Repeat(in.Text, max(in.Times, 1))}, nil
+	}))
+	s.AddTool(NewTool("wait", "Block until cancelled", func(ctx context.Context, in struct{}) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	return s
+}
+
+// rpcClient drives Server.Serve over pipes
+type rpcClient struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *bufio.Scanner
+	done chan error
+}
+
+func startServer(t *testing.T, s *Server) *rpcClient {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &rpcClient{t: t, in: inW, out: bufio.NewScanner(outR), done: make(chan error, 1)}
+	go func() {
+		c.done <- s.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+func (c *rpcClient) send(msg string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, msg+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func (c *rpcClient) recv() testResponse {
+	c.t.Helper()
+	if !c.out.Scan() {
+		c.t.Fatalf("no response: %v", c.out.Err())
+	}
+	var resp testResponse
+	if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+		c.t.Fatalf("bad response %s: %v", c.out.Bytes(), err)
+	}
+	return resp
+}
+
+func (c *rpcClient) call(msg string) testResponse {
+	c.t.Helper()
+	c.send(msg)
+	return c.recv()
+}
+
+func TestServeInitialize(t *testing.T) {
+	c := startServer(t, newTestServer())
+
+	resp := c.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`)
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Tools *struct{} `json:"tools"`
+		} `json:"capabilities"`
+		ServerInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Instructions string `json:"instructions"`
+	}
+	if err := json.Unmarshal(resp.Result, &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.ProtocolVersion != "2025-03-26" || init.Capabilities.Tools == nil || init.ServerInfo.Name != "test" || init.Instructions != "Use echo." {
+		t.Errorf("initialize = %s", resp.Result)
+	}
+
+	resp = c.call(`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	if !strings.Contains(string(resp.Result), `"protocolVersion":"`+LatestProtocolVersion+`"`) {
+		t.Errorf("initialize with unknown version = %s, want %s", resp.Result, LatestProtocolVersion)
+	}
+
+	// Notifications get no response; the next line answers the ping
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp := c.call(`{"jsonrpc":"2.0","id":"p","method":"ping"}`); string(resp.ID) != `"p"` || string(resp.Result) != "{}" {
+		t.Errorf("ping = %+v", resp)
+	}
+}
+
+func TestServeToolsList(t *testing.T) {
+	c := startServer(t, newTestServer())
+	resp := c.call(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	var list struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(resp.Result, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Tools) != 2 || list.Tools[0].Name != "echo" {
+		t.Fatalf("tools = %s", resp.Result)
+	}
+	echo := list.Tools[0]
+	if echo.InputSchema.Properties["text"].Description != "Text to echo" || len(echo.InputSchema.Required) != 1 || echo.InputSchema.AdditionalProperties != false {
+		t.Errorf("echo inputSchema = %+v", echo.InputSchema)
+	}
+	if echo.OutputSchema == nil || echo.OutputSchema.Properties["text"] == nil {
+		t.Errorf("echo outputSchema = %+v", echo.OutputSchema)
+	}
+	if list.Tools[1].OutputSchema != nil {
+		t.Errorf("wait returns an array, outputSchema = %+v, want none", list.Tools[1].OutputSchema)
+	}
+}
+
+func TestServeToolsCall(t *testing.T) {
+	c := startServer(t, newTestServer())
+
+	resp := c.call(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"ab","times":2}}}`)
+	var res CallToolResult
+	if err := json.Unmarshal(resp.Result, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError || !strings.Contains(res.Content[0].Text, `"abab"`) {
+		t.Errorf("echo = %s", resp.Result)
+	}
+	if sc, _ := res.StructuredContent.(map[string]any); sc["text"] != "abab" {
+		t.Errorf("structuredContent = %v", res.StructuredContent)
+	}
+
+	for _, tt := range []struct{ args, want string }{
+		{`{"text":"fail"}`, "echo failed"},
+		{`{"text":"panic"}`, "panic: boom"},
+	} {
+		resp := c.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":` + tt.args + `}}`)
+		var res CallToolResult
+		_ = json.Unmarshal(resp.Result, &res)
+		if !res.IsError || !strings.Contains(res.Content[0].Text, tt.want) {
+			t.Errorf("echo %s = %s, want tool error %q", tt.args, resp.Result, tt.want)
+		}
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	c := startServer(t, newTestServer())
+	tests := []struct {
+		name string
+		msg  string
+		code int
+	}{
+		{"parse error", `{not json`, CodeParseError},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, CodeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, CodeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, CodeInvalidParams},
+		{"missing required", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`, CodeInvalidParams},
+		{"unknown property", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"a","loud":true}}}`, CodeInvalidParams},
+		{"wrong type", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":1}}}`, CodeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := c.call(tt.msg)
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("response = %+v, want error %d", resp, tt.code)
+			}
+		})
+	}
+}
+
+func TestServeCancel(t *testing.T) {
+	c := startServer(t, newTestServer())
+	c.send(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait"}}`)
+	c.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+
+	// The cancelled call sends nothing, so the next response is the ping
+	if resp := c.call(`{"jsonrpc":"2.0","id":8,"method":"ping"}`); string(resp.ID) != "8" {
+		t.Errorf("response after cancel = %+v, want the ping", resp)
+	}
+}
+
+func TestServeReturnsOnEOF(t *testing.T) {
+	c := startServer(t, newTestServer())
+	c.in.Close()
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Errorf("Serve() = %v, want nil on EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() didn't return on EOF")
+	}
+}
+
+func TestAddToolDuplicatePanics(t *testing.T) {
+	s := newTestServer()
+	defer func() {
+		if recover() == nil {
+			t.Error("AddTool() with a duplicate name didn't panic")
+		}
+	}()
+	s.AddTool(Tool{Name: "echo"})
+}
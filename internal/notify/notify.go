@@ -0,0 +1,297 @@
+// Package notify delivers events the CLI raises while nobody is watching
+// (price and stock alerts, order status changes, quickbuy results) to the
+// sinks configured in a profile's notify.json: JSON webhooks, ntfy-style
+// push, SMTP email and commands reading the event on stdin.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Event types published by the CLI. Watch alerts are "watch." + the alert
+// kind (watch.below, watch.drop, watch.in_stock, watch.stock_date, watch.carted).
+const (
+	EventWatchPrefix     = "watch."
+	EventOrderStatus     = "order.status"
+	EventQuickbuyOrdered = "quickbuy.ordered"
+	EventQuickbuyFailed  = "quickbuy.failed"
+	EventTest            = "test" // alza notify test
+)
+
+// Event is one thing to tell the user about.
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile,omitempty"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Data    any       `json:"data,omitempty"` // The alert, order change, ... as JSON
+}
+
+// Message is an event rendered by a route's templates, ready for a sink.
+type Message struct {
+	Event Event
+	Title string
+	Body  string
+}
+
+// Sink delivers messages somewhere.
+type Sink interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Config is the content of notify.json.
+type Config struct {
+	Sinks  map[string]SinkConfig `json:"sinks"`
+	Routes []Route               `json:"routes"`
+}
+
+// Route sends the events it matches to its sinks. Title and Message are
+// text/template templates over the event, with Data as decoded JSON (so
+// {{.Data.observation.price}}); they default to the event's own title and
+// message.
+type Route struct {
+	Events  []string `json:"events,omitempty"` // Event types; "watch.*" matches a prefix, none or "*" everything
+	Sinks   []string `json:"sinks"`
+	Title   string   `json:"title,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// Matches reports whether the route takes events of type typ.
+func (r Route) Matches(typ string) bool {
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, pattern := range r.Events {
+		if pattern == "*" || pattern == typ {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(typ, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTimeout bounds each delivery, so a dead sink can't hang a cron run
+const sendTimeout = 30 * time.Second
+
+// Notifier routes events to sinks. The zero value and nil drop every event.
+type Notifier struct {
+	sinks  map[string]Sink
+	routes []route
+}
+
+type route struct {
+	Route
+	title   *template.Template
+	message *template.Template
+}
+
+// ReadConfig reads notify.json at path; a missing file is an empty config.
+func ReadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Load reads the config at path and builds its notifier. A missing file gives
+// a notifier without routes.
+func Load(path string) (*Notifier, error) {
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// New builds the sinks and parses the route templates of cfg.
+func New(cfg Config) (*Notifier, error) {
+	n := &Notifier{sinks: map[string]Sink{}}
+	for name, sc := range cfg.Sinks {
+		sink, err := NewSink(sc)
+		if err != nil {
+			return nil, fmt.Errorf("sink %q: %w", name, err)
+		}
+		n.sinks[name] = sink
+	}
+	for i, r := range cfg.Routes {
+		if len(r.Sinks) == 0 {
+			return nil, fmt.Errorf("route %d: no sinks", i+1)
+		}
+		for _, name := range r.Sinks {
+			if _, ok := n.sinks[name]; !ok {
+				return nil, fmt.Errorf("route %d: unknown sink %q", i+1, name)
+			}
+		}
+		title, err := parseTemplate(r.Title, "{{.Title}}")
+		if err != nil {
+			return nil, fmt.Errorf("route %d title: %w", i+1, err)
+		}
+		message, err := parseTemplate(r.Message, "{{.Message}}")
+		if err != nil {
+			return nil, fmt.Errorf("route %d message: %w", i+1, err)
+		}
+		n.routes = append(n.routes, route{Route: r, title: title, message: message})
+	}
+	return n, nil
+}
+
+func parseTemplate(text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	return template.New("").Option("missingkey=zero").Parse(text)
+}
+
+// Routes returns the configured routes.
+func (n *Notifier) Routes() []Route {
+	if n == nil {
+		return nil
+	}
+	routes := make([]Route, len(n.routes))
+	for i, r := range n.routes {
+		routes[i] = r.Route
+	}
+	return routes
+}
+
+// Publish sends ev through every route matching its type. All sinks are
+// tried; the error joins the failed deliveries.
+func (n *Notifier) Publish(ctx context.Context, ev Event) error {
+	if n == nil || len(n.routes) == 0 {
+		return nil
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	// Round-trip Data through JSON, so templates and sinks see the same field names
+	if ev.Data != nil {
+		raw, err := json.Marshal(ev.Data)
+		if err != nil {
+			return fmt.Errorf("notify %s: %w", ev.Type, err)
+		}
+		var data any
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("notify %s: %w", ev.Type, err)
+		}
+		ev.Data = data
+	}
+
+	var errs []error
+	for _, r := range n.routes {
+		if !r.Matches(ev.Type) {
+			continue
+		}
+		m, err := r.render(ev)
+		if err != nil {
+			// Still deliver, with the event's own text; a broken template shouldn't lose the alert
+			errs = append(errs, fmt.Errorf("notify %s: %w", ev.Type, err))
+			m = Message{Event: ev, Title: ev.Title, Body: ev.Message}
+		}
+		for _, name := range r.Sinks {
+			sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+			err := n.sinks[name].Send(sendCtx, m)
+			cancel()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("notify %s via %s: %w", ev.Type, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r route) render(ev Event) (Message, error) {
+	var title, body strings.Builder
+	if err := r.title.Execute(&title, ev); err != nil {
+		return Message{}, fmt.Errorf("title template: %w", err)
+	}
+	if err := r.message.Execute(&body, ev); err != nil {
+		return Message{}, fmt.Errorf("message template: %w", err)
+	}
+	return Message{Event: ev, Title: strings.TrimSpace(title.String()), Body: strings.TrimSpace(body.String())}, nil
+}
+
+// SinkTypes lists the sink types NewSink knows.
+var SinkTypes = []string{"webhook", "ntfy", "smtp", "exec"}
+
+// NewSink builds the sink described by sc.
+func NewSink(sc SinkConfig) (Sink, error) {
+	switch sc.Type {
+	case "webhook":
+		return newWebhookSink(sc)
+	case "ntfy":
+		return newNtfySink(sc)
+	case "smtp":
+		return newSMTPSink(sc)
+	case "exec":
+		return newExecSink(sc)
+	}
+	return nil, fmt.Errorf("unknown type %q (want one of %s)", sc.Type, strings.Join(SinkTypes, ", "))
+}
+
+// readSecret returns value, or the first line of file when value is empty
+func readSecret(value, file, what string) (string, error) {
+	if value != "" || file == "" {
+		return value, nil
+	}
+	if rest, ok := strings.CutPrefix(file, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		file = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", what, err)
+	}
+	secret, _, _ := strings.Cut(string(data), "\n")
+	if secret = strings.TrimSpace(secret); secret == "" {
+		return "", fmt.Errorf("%s file %s is empty", what, file)
+	}
+	return secret, nil
+}
+
+// payload is the JSON webhook and exec sinks deliver: the event with the
+// rendered title and message
+func payload(m Message) ([]byte, error) {
+	ev := m.Event
+	ev.Title, ev.Message = m.Title, m.Body
+	return json.Marshal(ev)
+}
+
+// validateHeaders rejects header names the HTTP sinks would mangle
+func validateHeaders(headers map[string]string) error {
+	for name := range headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordSink keeps the messages it was sent
+type recordSink struct {
+	messages []Message
+	err      error
+}
+
+func (s *recordSink) Send(_ context.Context, m Message) error {
+	s.messages = append(s.messages, m)
+	return s.err
+}
+
+func TestRouteMatches(t *testing.T) {
+	tests := []struct {
+		events []string
+		typ    string
+		want   bool
+	}{
+		{nil, "watch.below", true},
+		{[]string{"*"}, "order.status", true},
+		{[]string{"watch.*"}, "watch.in_stock", true},
+		{[]string{"watch.*"}, "order.status", false},
+		{[]string{"quickbuy.failed", "order.status"}, "order.status", true},
+		{[]string{"quickbuy.failed"}, "quickbuy.ordered", false},
+	}
+	for _, tt := range tests {
+		if got := (Route{Events: tt.events}).Matches(tt.typ); got != tt.want {
+			t.Errorf("Route{%v}.Matches(%q) = %v, want %v", tt.events, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	hook := map[string]SinkConfig{"hook": {Type: "webhook", URL: "http://127.0.0.1:1/"}}
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"unknown sink type", Config{Sinks: map[string]SinkConfig{"x": {Type: "pager"}}}, `sink "x": unknown type "pager"`},
+		{"bad webhook url", Config{Sinks: map[string]SinkConfig{"x": {Type: "webhook", URL: "ftp://x"}}}, "invalid url"},
+		{"smtp without to", Config{Sinks: map[string]SinkConfig{"x": {Type: "smtp", Addr: "localhost:25", From: "a@b"}}}, "from and to are required"},
+		{"exec without command", Config{Sinks: map[string]SinkConfig{"x": {Type: "exec"}}}, "command is required"},
+		{"route without sinks", Config{Sinks: hook, Routes: []Route{{Events: []string{"*"}}}}, "route 1: no sinks"},
+		{"route to unknown sink", Config{Sinks: hook, Routes: []Route{{Sinks: []string{"mail"}}}}, `route 1: unknown sink "mail"`},
+		{"bad template", Config{Sinks: hook, Routes: []Route{{Sinks: []string{"hook"}, Title: "{{.Title"}}}, "route 1 title"},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: New() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := New(Config{Sinks: hook, Routes: []Route{{Sinks: []string{"hook"}}}}); err != nil {
+		t.Errorf("New() of a valid config error = %v", err)
+	}
+}
+
+func TestPublishRoutes(t *testing.T) {
+	phone, mail := &recordSink{}, &recordSink{}
+	n := &Notifier{sinks: map[string]Sink{"phone": phone, "mail": mail}}
+	for _, r := range []Route{
+		{Events: []string{"watch.*"}, Sinks: []string{"phone"}, Title: "🔔 {{.Title}}", Message: "{{.Message}} ({{.Data.observation.price}})"},
+		{Events: []string{"watch.in_stock", "order.status"}, Sinks: []string{"mail"}},
+	} {
+		title, _ := parseTemplate(r.Title, "{{.Title}}")
+		message, _ := parseTemplate(r.Message, "{{.Message}}")
+		n.routes = append(n.routes, route{Route: r, title: title, message: message})
+	}
+
+	type observation struct {
+		Price float64 `json:"price"`
+	}
+	ev := Event{
+		Type: "watch.below", Title: "[1] Kávovar", Message: "299.00 € is at or below 300.00 €",
+		Data: map[string]any{"observation": observation{Price: 299}},
+	}
+	if err := n.Publish(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	if len(phone.messages) != 1 || len(mail.messages) != 0 {
+		t.Fatalf("watch.below delivered %d to phone, %d to mail", len(phone.messages), len(mail.messages))
+	}
+	m := phone.messages[0]
+	if m.Title != "🔔 [1] Kávovar" || m.Body != "299.00 € is at or below 300.00 € (299)" {
+		t.Errorf("rendered = %q / %q", m.Title, m.Body)
+	}
+	if m.Event.Time.IsZero() {
+		t.Error("Publish() didn't stamp the event time")
+	}
+
+	// The phone route's template needs Data; without it the event's own text goes out
+	err := n.Publish(context.Background(), Event{Type: "watch.in_stock", Title: "t", Message: "m"})
+	if err == nil || !strings.Contains(err.Error(), "message template") {
+		t.Errorf("Publish() with a failing template = %v", err)
+	}
+	if len(phone.messages) != 2 || phone.messages[1].Body != "m" || len(mail.messages) != 1 || mail.messages[0].Title != "t" {
+		t.Errorf("watch.in_stock delivered %+v to phone, %+v to mail", phone.messages, mail.messages)
+	}
+
+	// A failing sink doesn't stop the others
+	phone.err = errors.New("offline")
+	err = n.Publish(context.Background(), Event{Type: "watch.in_stock", Data: map[string]any{"observation": observation{}}})
+	if err == nil || !strings.Contains(err.Error(), "notify watch.in_stock via phone: offline") || len(mail.messages) != 2 {
+		t.Errorf("Publish() with a failing sink = %v, mail got %d", err, len(mail.messages))
+	}
+
+	if err := n.Publish(context.Background(), Event{Type: "quickbuy.ordered"}); err != nil || len(mail.messages) != 2 {
+		t.Errorf("unrouted event = %v", err)
+	}
+}
+
+func TestPublishWithoutConfig(t *testing.T) {
+	var nilNotifier *Notifier
+	for _, n := range []*Notifier{nil, nilNotifier, {}} {
+		if err := n.Publish(context.Background(), Event{Type: EventTest}); err != nil {
+			t.Errorf("Publish() = %v", err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	n, err := Load(filepath.Join(dir, "notify.json"))
+	if err != nil || len(n.Routes()) != 0 {
+		t.Fatalf("Load() of a missing file = %+v, %v", n, err)
+	}
+
+	path := filepath.Join(dir, "notify.json")
+	writeConfig := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`{
+		"sinks": {"script": {"type": "exec", "command": ["true"]}},
+		"routes": [{"events": ["order.status"], "sinks": ["script"]}]
+	}`)
+	n, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Route{{Events: []string{"order.status"}, Sinks: []string{"script"}}}; !reflect.DeepEqual(n.Routes(), want) {
+		t.Errorf("Routes() = %+v", n.Routes())
+	}
+
+	writeConfig(`{"sinks": {}, "rutes": []}`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "rutes") {
+		t.Errorf("Load() with a misspelled key error = %v", err)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("  tk_123 \nignored\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readSecret("", path, "token"); got != "tk_123" || err != nil {
+		t.Errorf("readSecret(file) = %q, %v", got, err)
+	}
+	if got, _ := readSecret("inline", path, "token"); got != "inline" {
+		t.Errorf("readSecret(inline) = %q", got)
+	}
+	if _, err := readSecret("", filepath.Join(t.TempDir(), "missing"), "token"); err == nil {
+		t.Error("readSecret() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,236 @@
+// Package notifytest runs local stand-ins for notification endpoints, for
+// testing package notify sinks and notify.json routes offline: an HTTP server
+// for webhook and ntfy sinks and an SMTP server for the smtp sink. Both record
+// what they receive.
+//
+//	hook := notifytest.NewHTTPServer()
+//	defer hook.Close()
+//	mail := notifytest.NewSMTPServer()
+//	defer mail.Close()
+//	// {"type": "webhook", "url": hook.URL}, {"type": "smtp", "addr": mail.Addr, ...}
+package notifytest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// HTTPRequest is a request the HTTP server received.
+type HTTPRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+// HTTPServer records every request and answers with a configurable status.
+type HTTPServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []HTTPRequest
+}
+
+// NewHTTPServer starts a server answering 200.
+func NewHTTPServer() *HTTPServer {
+	s := &HTTPServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, HTTPRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: string(body)})
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		if status >= 300 {
+			fmt.Fprintln(w, http.StatusText(status))
+		}
+	}))
+	return s
+}
+
+// SetStatus changes the status of the following answers.
+func (s *HTTPServer) SetStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// Requests returns a copy of the requests received so far.
+func (s *HTTPServer) Requests() []HTTPRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Mail is a message the SMTP server accepted.
+type Mail struct {
+	From     string
+	To       []string
+	Data     string // Headers and body as sent, CRLF line endings, dot-stuffing removed
+	Username string // From AUTH PLAIN, empty without auth
+	Password string
+}
+
+// SMTPServer speaks just enough SMTP (EHLO, AUTH PLAIN, MAIL, RCPT, DATA)
+// for net/smtp clients, without TLS.
+type SMTPServer struct {
+	Addr string // host:port to dial
+
+	ln     net.Listener
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	mails  []Mail
+	reject string // RCPT reply when set
+}
+
+// NewSMTPServer starts a server on a loopback port. It panics if it can't
+// listen, like httptest.NewServer.
+func NewSMTPServer() *SMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("notifytest: listen: %v", err))
+	}
+	s := &SMTPServer{Addr: ln.Addr().String(), ln: ln, conns: map[net.Conn]struct{}{}}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+		}
+	}()
+	return s
+}
+
+// Close stops the server and ends open sessions.
+func (s *SMTPServer) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// RejectRecipients makes RCPT TO fail with reply, e.g. "550 no such user";
+// "" accepts again.
+func (s *SMTPServer) RejectRecipients(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = reply
+}
+
+// Mails returns a copy of the messages accepted so far.
+func (s *SMTPServer) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.mails)
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			_, _ = io.WriteString(conn, line+"\r\n")
+		}
+	}
+
+	reply("220 notifytest ESMTP")
+	var mail Mail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-notifytest", "250-8BITMIME", "250 AUTH PLAIN")
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			raw, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(raw), "\x00")
+			if !strings.EqualFold(mech, "PLAIN") || err != nil || len(parts) != 3 {
+				reply("504 only AUTH PLAIN with an initial response")
+				continue
+			}
+			mail.Username, mail.Password = parts[1], parts[2]
+			reply("235 Authenticated")
+		case "MAIL":
+			mail.From, mail.To = address(arg), nil
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			reject := s.reject
+			s.mu.Unlock()
+			if reject != "" {
+				reply(reject)
+				continue
+			}
+			mail.To = append(mail.To, address(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			mail.Data = data.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			mail = Mail{Username: mail.Username, Password: mail.Password}
+			reply("250 OK queued")
+		case "RSET":
+			mail = Mail{Username: mail.Username, Password: mail.Password}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// address extracts the mailbox from "FROM:<a@b>" / "TO:<a@b>"
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SinkConfig describes one sink in notify.json. Which fields apply depends on
+// Type; secrets can be given inline or as a file holding them on its first line.
+type SinkConfig struct {
+	Type string `json:"type"` // webhook, ntfy, smtp or exec
+
+	// webhook, ntfy
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// ntfy
+	Token     string   `json:"token,omitempty"`
+	TokenFile string   `json:"tokenFile,omitempty"`
+	Priority  string   `json:"priority,omitempty"` // min, low, default, high, urgent (or 1-5)
+	Tags      []string `json:"tags,omitempty"`
+
+	// smtp
+	Addr         string   `json:"addr,omitempty"` // host:port
+	TLS          bool     `json:"tls,omitempty"`  // Implicit TLS (port 465); otherwise STARTTLS when the server offers it
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	PasswordFile string   `json:"passwordFile,omitempty"`
+	From         string   `json:"from,omitempty"`
+	To           []string `json:"to,omitempty"`
+
+	// exec
+	Command []string `json:"command,omitempty"` // argv, no shell
+}
+
+// Redacted returns sc without its inline secrets, for display.
+func (sc SinkConfig) Redacted() SinkConfig {
+	if sc.Token != "" {
+		sc.Token = "***"
+	}
+	if sc.Password != "" {
+		sc.Password = "***"
+	}
+	if len(sc.Headers) > 0 {
+		headers := make(map[string]string, len(sc.Headers))
+		for name := range sc.Headers {
+			headers[name] = "***"
+		}
+		sc.Headers = headers
+	}
+	if u, err := url.Parse(sc.URL); err == nil && u.User != nil {
+		u.User = url.User("***")
+		sc.URL = u.String()
+	}
+	return sc
+}
+
+func parseHTTPURL(raw string) (string, error) {
+	if raw == "" {
+		return "", errors.New("url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid url %q (want http:// or https://)", raw)
+	}
+	return raw, nil
+}
+
+// postHTTP sends body to url and fails on a non-2xx answer
+func postHTTP(ctx context.Context, rawURL string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// webhookSink POSTs the event as JSON
+type webhookSink struct {
+	url     string
+	headers map[string]string
+}
+
+func newWebhookSink(sc SinkConfig) (*webhookSink, error) {
+	u, err := parseHTTPURL(sc.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHeaders(sc.Headers); err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	for name, value := range sc.Headers {
+		headers[name] = value
+	}
+	return &webhookSink{url: u, headers: headers}, nil
+}
+
+func (s *webhookSink) Send(ctx context.Context, m Message) error {
+	body, err := payload(m)
+	if err != nil {
+		return err
+	}
+	return postHTTP(ctx, s.url, s.headers, body)
+}
+
+// ntfySink publishes the message as a plain-text body to an ntfy topic URL,
+// with the title, priority and tags in headers (https://docs.ntfy.sh/publish/)
+type ntfySink struct {
+	url     string
+	headers map[string]string
+}
+
+func newNtfySink(sc SinkConfig) (*ntfySink, error) {
+	u, err := parseHTTPURL(sc.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHeaders(sc.Headers); err != nil {
+		return nil, err
+	}
+	token, err := readSecret(sc.Token, sc.TokenFile, "ntfy token")
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Content-Type": "text/plain; charset=utf-8"}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	if sc.Priority != "" {
+		headers["Priority"] = sc.Priority
+	}
+	if len(sc.Tags) > 0 {
+		headers["Tags"] = strings.Join(sc.Tags, ",")
+	}
+	for name, value := range sc.Headers {
+		headers[name] = value
+	}
+	return &ntfySink{url: u, headers: headers}, nil
+}
+
+func (s *ntfySink) Send(ctx context.Context, m Message) error {
+	headers := make(map[string]string, len(s.headers)+1)
+	for name, value := range s.headers {
+		headers[name] = value
+	}
+	if m.Title != "" {
+		// Header values are ASCII; ntfy decodes RFC 2047 for the rest
+		headers["Title"] = mime.QEncoding.Encode("utf-8", m.Title)
+	}
+	return postHTTP(ctx, s.url, headers, []byte(m.Body))
+}
+
+// smtpSink mails the message as text/plain
+type smtpSink struct {
+	addr     string
+	host     string
+	tls      bool
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newSMTPSink(sc SinkConfig) (*smtpSink, error) {
+	host, _, err := net.SplitHostPort(sc.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid addr %q (want host:port)", sc.Addr)
+	}
+	if sc.From == "" || len(sc.To) == 0 {
+		return nil, errors.New("from and to are required")
+	}
+	password, err := readSecret(sc.Password, sc.PasswordFile, "SMTP password")
+	if err != nil {
+		return nil, err
+	}
+	if sc.Username != "" && password == "" {
+		return nil, errors.New("username without password or passwordFile")
+	}
+	return &smtpSink{addr: sc.Addr, host: host, tls: sc.TLS, username: sc.Username, password: password, from: sc.From, to: sc.To}, nil
+}
+
+func (s *smtpSink) Send(ctx context.Context, m Message) error {
+	tlsConfig := &tls.Config{ServerName: s.host}
+	var conn net.Conn
+	var err error
+	if s.tls {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", s.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return err
+	}
+	// net/smtp has no context; closing the connection unblocks it
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+	if !s.tls {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send the password unencrypted except to localhost
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.mail(m)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *smtpSink) mail(m Message) []byte {
+	subject := m.Title
+	if subject == "" {
+		subject = "alza: " + m.Event.Type
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", m.Event.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	fmt.Fprintf(&b, "X-Alza-Event: %s\r\n\r\n", m.Event.Type)
+	qp := quotedprintable.NewWriter(&b)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n")))
+	_ = qp.Close()
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// execSink runs a command with the event JSON on stdin
+type execSink struct {
+	command []string
+}
+
+func newExecSink(sc SinkConfig) (*execSink, error) {
+	if len(sc.Command) == 0 || sc.Command[0] == "" {
+		return nil, errors.New("command is required")
+	}
+	return &execSink{command: sc.Command}, nil
+}
+
+func (s *execSink) Send(ctx context.Context, m Message) error {
+	body, err := payload(m)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(body, '\n'))
+	cmd.Env = append(os.Environ(),
+		"ALZA_EVENT_TYPE="+m.Event.Type,
+		"ALZA_EVENT_TITLE="+m.Title,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if text := strings.TrimSpace(string(out)); text != "" {
+			return fmt.Errorf("%s: %w: %s", s.command[0], err, text)
+		}
+		return fmt.Errorf("%s: %w", s.command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/internal/notify/notifytest"
+)
+
+func testMessage() Message {
+	return Message{
+		Event: Event{
+			Type: "watch.in_stock", Time: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Profile: "default",
+			Title: "raw title", Message: "raw message", Data: map[string]any{"productId": 8123456.0},
+		},
+		Title: "Späť na sklade: iPhone",
+		Body:  "Skladom 2 ks\nza 1099.00 €",
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	srv := notifytest.NewHTTPServer()
+	defer srv.Close()
+	sink, err := NewSink(SinkConfig{Type: "webhook", URL: srv.URL + "/alza", Headers: map[string]string{"X-Key": "s3cret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Method != "POST" || reqs[0].Path != "/alza" || reqs[0].Header.Get("X-Key") != "s3cret" || reqs[0].Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(reqs[0].Body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "watch.in_stock" || got.Title != "Späť na sklade: iPhone" || got.Message != "Skladom 2 ks\nza 1099.00 €" || got.Profile != "default" {
+		t.Errorf("webhook body = %+v", got)
+	}
+
+	srv.SetStatus(503)
+	if err := sink.Send(context.Background(), testMessage()); err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+		t.Errorf("Send() to a failing webhook error = %v", err)
+	}
+}
+
+func TestNtfySink(t *testing.T) {
+	srv := notifytest.NewHTTPServer()
+	defer srv.Close()
+	tokenFile := filepath.Join(t.TempDir(), "ntfy-token")
+	if err := os.WriteFile(tokenFile, []byte("tk_abc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewSink(SinkConfig{Type: "ntfy", URL: srv.URL + "/alza-alerts", TokenFile: tokenFile, Priority: "high", Tags: []string{"shopping_cart", "bell"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Path != "/alza-alerts" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	h := reqs[0].Header
+	if h.Get("Authorization") != "Bearer tk_abc" || h.Get("Priority") != "high" || h.Get("Tags") != "shopping_cart,bell" {
+		t.Errorf("headers = %v", h)
+	}
+	if h.Get("Title") != "=?utf-8?q?Sp=C3=A4=C5=A5_na_sklade:_iPhone?=" {
+		t.Errorf("Title = %q, want RFC 2047", h.Get("Title"))
+	}
+	if reqs[0].Body != "Skladom 2 ks\nza 1099.00 €" {
+		t.Errorf("body = %q", reqs[0].Body)
+	}
+}
+
+func TestSMTPSink(t *testing.T) {
+	srv := notifytest.NewSMTPServer()
+	defer srv.Close()
+	sink, err := NewSink(SinkConfig{
+		Type: "smtp", Addr: srv.Addr, Username: "alza", Password: "pw",
+		From: "alza@example.com", To: []string{"me@example.com", "spouse@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	mails := srv.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("mails = %+v", mails)
+	}
+	m := mails[0]
+	if m.From != "alza@example.com" || strings.Join(m.To, ",") != "me@example.com,spouse@example.com" || m.Username != "alza" || m.Password != "pw" {
+		t.Errorf("envelope = %+v", m)
+	}
+	for _, want := range []string{
+		"Subject: =?utf-8?q?Sp=C3=A4=C5=A5_na_sklade:_iPhone?=\r\n",
+		"To: me@example.com, spouse@example.com\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"X-Alza-Event: watch.in_stock\r\n",
+		"\r\n\r\nSkladom 2 ks\r\nza 1099.00 =E2=82=AC\r\n",
+	} {
+		if !strings.Contains(m.Data, want) {
+			t.Errorf("mail data missing %q:\n%s", want, m.Data)
+		}
+	}
+
+	srv.RejectRecipients("550 no such user")
+	if err := sink.Send(context.Background(), testMessage()); err == nil || !strings.Contains(err.Error(), "no such user") {
+		t.Errorf("Send() to a rejected recipient error = %v", err)
+	}
+}
+
+func TestSMTPSinkCancelled(t *testing.T) {
+	srv := notifytest.NewSMTPServer()
+	defer srv.Close()
+	sink, err := NewSink(SinkConfig{Type: "smtp", Addr: srv.Addr, From: "a@example.com", To: []string{"b@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sink.Send(ctx, testMessage()); err == nil {
+		t.Error("Send() with a cancelled context succeeded")
+	}
+}
+
+func TestExecSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "event.json")
+	sink, err := NewSink(SinkConfig{Type: "exec", Command: []string{"sh", "-c", `cat > "$1"; echo "$ALZA_EVENT_TYPE|$ALZA_EVENT_TITLE" >> "$1"`, "hook", out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, env, _ := strings.Cut(string(data), "\n")
+	var got Event
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("stdin = %q: %v", body, err)
+	}
+	if got.Title != "Späť na sklade: iPhone" || strings.TrimSpace(env) != "watch.in_stock|Späť na sklade: iPhone" {
+		t.Errorf("exec got %+v, env %q", got, env)
+	}
+
+	failing, _ := NewSink(SinkConfig{Type: "exec", Command: []string{"sh", "-c", "echo boom >&2; exit 3"}})
+	if err := failing.Send(context.Background(), testMessage()); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Send() of a failing command error = %v", err)
+	}
+}
+
+func TestSinkConfigRedacted(t *testing.T) {
+	sc := SinkConfig{Type: "ntfy", URL: "https://user:pw@ntfy.example.com/topic", Token: "tk", Password: "pw", Headers: map[string]string{"X-Key": "k"}}
+	got := sc.Redacted()
+	if got.Token != "***" || got.Password != "***" || got.Headers["X-Key"] != "***" || strings.Contains(got.URL, "pw") {
+		t.Errorf("Redacted() = %+v", got)
+	}
+	if sc.Headers["X-Key"] != "k" {
+		t.Error("Redacted() changed the original headers")
+	}
+}
@@ -0,0 +1,305 @@
+// Package pricewatch keeps a profile's watched products and the prices and
+// availability observed for them: watches in a JSON file, observations
+// appended to a JSONL history so every `alza watch run` adds to the series.
+package pricewatch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// Watch is one watched product on one storefront. Prices are in that
+// storefront's currency.
+type Watch struct {
+	ProductID   int       `json:"productId"`
+	Name        string    `json:"name,omitempty"`
+	Country     string    `json:"country"`
+	Below       float64   `json:"below,omitempty"`       // Alert when the price drops to or below this
+	DropPercent float64   `json:"dropPercent,omitempty"` // Alert when the price is this many percent under BasePrice
+	BasePrice   float64   `json:"basePrice,omitempty"`   // Price when the watch was added
+	Stock       bool      `json:"stock,omitempty"`       // Alert when the product comes back in stock or its expected stock date changes
+	AutoCart    int       `json:"autoCart,omitempty"`    // Quantity to add to the cart once the product is in stock
+	CartedAt    time.Time `json:"cartedAt,omitzero"`     // When AutoCart was done; it is done only once
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+// CartDue reports whether the product should be added to the cart now: the
+// watch asks for it, it wasn't done yet and cur is in stock.
+func (w Watch) CartDue(cur Observation) bool {
+	return w.AutoCart > 0 && w.CartedAt.IsZero() && cur.InStock
+}
+
+// Observation is the price of a product at one point in time.
+type Observation struct {
+	Time              time.Time `json:"time"`
+	ProductID         int       `json:"productId"`
+	Country           string    `json:"country"`
+	Price             float64   `json:"price"`                // 0 when the product has no price (sold out, delisted)
+	PriceText         string    `json:"priceText,omitempty"`  // As formatted by the storefront
+	PromoPrice        float64   `json:"promoPrice,omitempty"` // Lowest promo or coupon price, if any
+	DiscountPercent   *int      `json:"discountPercent,omitempty"`
+	Availability      string    `json:"availability,omitempty"` // Empty when it couldn't be fetched
+	InStock           bool      `json:"inStock"`
+	ExpectedStockDate string    `json:"expectedStockDate,omitempty"`
+}
+
+// ObservationOf records the prices of p as seen on country's storefront at t.
+func ObservationOf(p *client.ProductDetail, country string, t time.Time) Observation {
+	o := Observation{
+		Time:              t,
+		ProductID:         p.ID,
+		Country:           country,
+		Price:             p.PriceNoCurrency,
+		PriceText:         p.Price,
+		DiscountPercent:   p.DiscountPercent,
+		Availability:      p.Availability,
+		InStock:           client.InStock(p.Availability),
+		ExpectedStockDate: p.ExpectedStockDate,
+	}
+	for _, promo := range p.PromoPrices {
+		if promo.UnformattedPrice > 0 && (o.PromoPrice == 0 || promo.UnformattedPrice < o.PromoPrice) {
+			o.PromoPrice = promo.UnformattedPrice
+		}
+	}
+	return o
+}
+
+// Alert kinds.
+const (
+	AlertBelow     = "below"
+	AlertDrop      = "drop"
+	AlertInStock   = "in_stock"   // Back in stock
+	AlertStockDate = "stock_date" // Expected stock date changed while not in stock
+	AlertCarted    = "carted"     // Added to the cart by Watch.AutoCart
+)
+
+// Alert reports that an observation crossed one of the watch's thresholds.
+type Alert struct {
+	Kind        string      `json:"kind"`
+	Watch       Watch       `json:"watch"`
+	Observation Observation `json:"observation"`
+	Message     string      `json:"message"`
+}
+
+// Check returns the thresholds cur crossed. A threshold alerts once, when it
+// is first met; it alerts again only after a price above it was seen. prev is
+// the previous observation of the product, nil for the first one.
+func Check(w Watch, prev *Observation, cur Observation) []Alert {
+	var alerts []Alert
+	crossed := func(met func(price float64) bool) bool {
+		return cur.Price > 0 && met(cur.Price) && (prev == nil || prev.Price <= 0 || !met(prev.Price))
+	}
+	if w.Below > 0 && crossed(func(price float64) bool { return price <= w.Below }) {
+		alerts = append(alerts, Alert{
+			Kind: AlertBelow, Watch: w, Observation: cur,
+			Message: fmt.Sprintf("price %.2f is at or below %.2f", cur.Price, w.Below),
+		})
+	}
+	if w.DropPercent > 0 && w.BasePrice > 0 && crossed(func(price float64) bool { return DropPercent(w.BasePrice, price) >= w.DropPercent }) {
+		alerts = append(alerts, Alert{
+			Kind: AlertDrop, Watch: w, Observation: cur,
+			Message: fmt.Sprintf("price %.2f is %.1f%% under %.2f", cur.Price, DropPercent(w.BasePrice, cur.Price), w.BasePrice),
+		})
+	}
+	return alerts
+}
+
+// LastKnownAvailability returns the newest observation in history whose
+// availability was fetched, nil if there is none. Stock changes are compared
+// against it, so a run that couldn't fetch the availability doesn't look like
+// the product sold out.
+func LastKnownAvailability(history []Observation) *Observation {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Availability != "" {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// CheckStock returns the availability changes from prev to cur for a watch
+// with Stock set. prev is the last observation with a known availability (see
+// LastKnownAvailability); without one there is no change to report.
+func CheckStock(w Watch, prev *Observation, cur Observation) []Alert {
+	if !w.Stock || prev == nil || cur.Availability == "" {
+		return nil
+	}
+	switch {
+	case cur.InStock && !prev.InStock:
+		return []Alert{{
+			Kind: AlertInStock, Watch: w, Observation: cur,
+			Message: fmt.Sprintf("back in stock: %s (was %s)", cur.Availability, prev.Availability),
+		}}
+	case !cur.InStock && !prev.InStock && cur.ExpectedStockDate != "" && cur.ExpectedStockDate != prev.ExpectedStockDate:
+		msg := "expected in stock " + cur.ExpectedStockDate
+		if prev.ExpectedStockDate != "" {
+			msg += " (was " + prev.ExpectedStockDate + ")"
+		}
+		return []Alert{{Kind: AlertStockDate, Watch: w, Observation: cur, Message: msg}}
+	}
+	return nil
+}
+
+// DropPercent is how many percent price is under base (negative when above).
+func DropPercent(base, price float64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	return (base - price) / base * 100
+}
+
+// Store reads and writes the watch files in a profile directory.
+type Store struct {
+	watchesPath string
+	historyPath string
+}
+
+// NewStore uses watchesPath and historyPath (see client.Profile.WatchesPath
+// and PriceHistoryPath). The files are created on the first write.
+func NewStore(watchesPath, historyPath string) *Store {
+	return &Store{watchesPath: watchesPath, historyPath: historyPath}
+}
+
+// Watches returns all watches, on every storefront; none if the file doesn't exist yet.
+func (s *Store) Watches() ([]Watch, error) {
+	data, err := os.ReadFile(s.watchesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var watches []Watch
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.watchesPath, err)
+	}
+	return watches, nil
+}
+
+// Add stores w, replacing an existing watch of the same product and country.
+func (s *Store) Add(w Watch) error {
+	watches, err := s.Watches()
+	if err != nil {
+		return err
+	}
+	w.Country = strings.ToUpper(w.Country)
+	if i := slices.IndexFunc(watches, func(x Watch) bool { return x.ProductID == w.ProductID && x.Country == w.Country }); i >= 0 {
+		watches[i] = w
+	} else {
+		watches = append(watches, w)
+	}
+	return s.saveWatches(watches)
+}
+
+// Remove deletes the watch of productID on country; false if there was none.
+// Its price history is kept.
+func (s *Store) Remove(productID int, country string) (bool, error) {
+	watches, err := s.Watches()
+	if err != nil {
+		return false, err
+	}
+	country = strings.ToUpper(country)
+	kept := slices.DeleteFunc(slices.Clone(watches), func(w Watch) bool { return w.ProductID == productID && w.Country == country })
+	if len(kept) == len(watches) {
+		return false, nil
+	}
+	return true, s.saveWatches(kept)
+}
+
+func (s *Store) saveWatches(watches []Watch) error {
+	if watches == nil {
+		watches = []Watch{}
+	}
+	data, err := json.MarshalIndent(watches, "", "  ")
+	if err != nil {
+		return err
+	}
+	return client.WritePrivateFile(s.watchesPath, append(data, '\n'))
+}
+
+// Append adds observations to the end of the history.
+func (s *Store) Append(obs ...Observation) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, o := range obs {
+		line, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(s.historyPath), 0o700); err != nil {
+		return err
+	}
+	// One write per call, so concurrent runs don't interleave within a line
+	f, err := os.OpenFile(s.historyPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HistoryKey identifies the observations of one product on one storefront.
+type HistoryKey struct {
+	ProductID int
+	Country   string // Upper case
+}
+
+// History returns the observations of productID on country, oldest first.
+func (s *Store) History(productID int, country string) ([]Observation, error) {
+	histories, err := s.Histories()
+	if err != nil {
+		return nil, err
+	}
+	return histories[HistoryKey{productID, strings.ToUpper(country)}], nil
+}
+
+// Histories reads the whole history once and returns the observations of
+// every product and country, oldest first; nil if there is no history yet.
+// Lines that don't parse (a run killed mid-write) are skipped.
+func (s *Store) Histories() (map[HistoryKey][]Observation, error) {
+	f, err := os.Open(s.historyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	histories := map[HistoryKey][]Observation{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var o Observation
+		if json.Unmarshal(sc.Bytes(), &o) != nil {
+			continue
+		}
+		k := HistoryKey{o.ProductID, o.Country}
+		histories[k] = append(histories[k], o)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, history := range histories {
+		slices.SortStableFunc(history, func(a, b Observation) int { return a.Time.Compare(b.Time) })
+	}
+	return histories, nil
+}
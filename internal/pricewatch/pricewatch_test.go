@@ -0,0 +1,235 @@
+package pricewatch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+func newTestStore(t *testing.T) *Store {
+	dir := t.TempDir()
+	return NewStore(filepath.Join(dir, "watches.json"), filepath.Join(dir, "price_history.jsonl"))
+}
+
+func TestObservationOf(t *testing.T) {
+	discount := 10
+	p := &client.ProductDetail{
+		ID: 1, Price: "29,90 €", PriceNoCurrency: 29.90, DiscountPercent: &discount, Availability: "Skladom",
+		PromoPrices: []client.ProductPromoPrice{{Name: "A", UnformattedPrice: 27}, {Name: "No price"}, {Name: "B", UnformattedPrice: 25.5}},
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := Observation{Time: at, ProductID: 1, Country: "SK", Price: 29.90, PriceText: "29,90 €", PromoPrice: 25.5, DiscountPercent: &discount, Availability: "Skladom", InStock: true}
+	if got := ObservationOf(p, "SK", at); !reflect.DeepEqual(got, want) {
+		t.Errorf("ObservationOf() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	w := Watch{ProductID: 1, Below: 25, DropPercent: 10, BasePrice: 30}
+	obs := func(price float64) *Observation { return &Observation{ProductID: 1, Price: price} }
+	tests := []struct {
+		name string
+		prev *Observation
+		cur  float64
+		want []string
+	}{
+		{"above both", obs(30), 28, nil},
+		{"drop crossed", obs(28), 27, []string{AlertDrop}},
+		{"both crossed", obs(30), 24, []string{AlertBelow, AlertDrop}},
+		{"first observation under both", nil, 24, []string{AlertBelow, AlertDrop}},
+		{"still under both", obs(24), 23, nil},
+		{"below crossed after drop", obs(26), 25, []string{AlertBelow}},
+		{"back in stock under both", obs(0), 24, []string{AlertBelow, AlertDrop}},
+		{"no price", obs(30), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, a := range Check(w, tt.prev, *obs(tt.cur)) {
+				kinds = append(kinds, a.Kind)
+				if a.Message == "" || a.Watch.ProductID != 1 {
+					t.Errorf("alert = %+v", a)
+				}
+			}
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("Check() = %v, want %v", kinds, tt.want)
+			}
+		})
+	}
+
+	if alerts := Check(Watch{DropPercent: 10}, nil, *obs(1)); alerts != nil {
+		t.Errorf("Check() without a base price = %+v, want none", alerts)
+	}
+}
+
+func TestCheckStock(t *testing.T) {
+	w := Watch{ProductID: 1, Stock: true}
+	out := func(date string) *Observation {
+		return &Observation{ProductID: 1, Availability: "Na objednávku", ExpectedStockDate: date}
+	}
+	in := &Observation{ProductID: 1, Availability: "Skladom 2 ks", InStock: true}
+	unknown := &Observation{ProductID: 1}
+	tests := []struct {
+		name string
+		w    Watch
+		prev *Observation
+		cur  *Observation
+		want []string
+	}{
+		{"back in stock", w, out(""), in, []string{AlertInStock}},
+		{"still in stock", w, in, in, nil},
+		{"sold out", w, in, out(""), nil},
+		{"still out", w, out("2026-03-10"), out("2026-03-10"), nil},
+		{"date set", w, out(""), out("2026-03-10"), []string{AlertStockDate}},
+		{"date moved", w, out("2026-03-10"), out("2026-03-20"), []string{AlertStockDate}},
+		{"date dropped", w, out("2026-03-10"), out(""), nil},
+		{"availability unknown", w, out(""), unknown, nil},
+		{"first observation", w, nil, in, nil},
+		{"not a stock watch", Watch{ProductID: 1, Below: 10}, out(""), in, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, a := range CheckStock(tt.w, tt.prev, *tt.cur) {
+				kinds = append(kinds, a.Kind)
+				if a.Message == "" {
+					t.Errorf("alert = %+v", a)
+				}
+			}
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("CheckStock() = %v, want %v", kinds, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastKnownAvailability(t *testing.T) {
+	history := []Observation{{Price: 1, Availability: "Na objednávku"}, {Price: 2}}
+	if got := LastKnownAvailability(history); got == nil || got.Price != 1 {
+		t.Errorf("LastKnownAvailability() = %+v, want the first observation", got)
+	}
+	if got := LastKnownAvailability(history[1:]); got != nil {
+		t.Errorf("LastKnownAvailability() without availability = %+v", got)
+	}
+}
+
+func TestWatchCartDue(t *testing.T) {
+	in := Observation{InStock: true}
+	tests := []struct {
+		name string
+		w    Watch
+		cur  Observation
+		want bool
+	}{
+		{"due", Watch{AutoCart: 1}, in, true},
+		{"not in stock", Watch{AutoCart: 1}, Observation{}, false},
+		{"already carted", Watch{AutoCart: 1, CartedAt: time.Now()}, in, false},
+		{"no auto-cart", Watch{Stock: true}, in, false},
+	}
+	for _, tt := range tests {
+		if got := tt.w.CartDue(tt.cur); got != tt.want {
+			t.Errorf("%s: CartDue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStoreWatches(t *testing.T) {
+	s := newTestStore(t)
+	if watches, err := s.Watches(); err != nil || watches != nil {
+		t.Fatalf("Watches() on a new store = %v, %v", watches, err)
+	}
+
+	for _, w := range []Watch{
+		{ProductID: 1, Country: "sk", Below: 10},
+		{ProductID: 1, Country: "CZ", Below: 250},
+		{ProductID: 2, Country: "SK"},
+		{ProductID: 1, Country: "SK", Below: 9}, // Replaces the first
+	} {
+		if err := s.Add(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	watches, err := s.Watches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watches) != 3 || watches[0].Below != 9 || watches[0].Country != "SK" {
+		t.Errorf("Watches() = %+v", watches)
+	}
+	if info, err := os.Stat(s.watchesPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("watches file mode = %v, %v", info.Mode(), err)
+	}
+
+	if removed, err := s.Remove(1, "sk"); !removed || err != nil {
+		t.Errorf("Remove(1, sk) = %v, %v", removed, err)
+	}
+	if removed, _ := s.Remove(1, "SK"); removed {
+		t.Error("Remove() of a removed watch = true")
+	}
+	if watches, _ := s.Watches(); len(watches) != 2 || watches[0].Country != "CZ" {
+		t.Errorf("Watches() after Remove = %+v", watches)
+	}
+}
+
+func TestStoreHistory(t *testing.T) {
+	s := newTestStore(t)
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := s.Append(
+		Observation{Time: t0.Add(time.Hour), ProductID: 1, Country: "SK", Price: 20},
+		Observation{Time: t0, ProductID: 1, Country: "SK", Price: 21},
+		Observation{Time: t0, ProductID: 2, Country: "SK", Price: 5},
+	); err != nil {
+		t.Fatal(err)
+	}
+	// A line cut short by a killed run is skipped
+	f, err := os.OpenFile(s.historyPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time":"2026-03-01T14:00:00Z","productId":1,"coun` + "\n")
+	_ = f.Close()
+	if err := s.Append(Observation{Time: t0.Add(2 * time.Hour), ProductID: 1, Country: "CZ", Price: 500}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := s.History(1, "sk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Price != 21 || history[1].Price != 20 {
+		t.Errorf("History(1, sk) = %+v, want 21 then 20", history)
+	}
+	if history, _ := s.History(1, "CZ"); len(history) != 1 {
+		t.Errorf("History(1, CZ) = %+v", history)
+	}
+	histories, err := s.Histories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(histories) != 3 || len(histories[HistoryKey{1, "SK"}]) != 2 || histories[HistoryKey{1, "SK"}][0].Price != 21 || len(histories[HistoryKey{1, "CZ"}]) != 1 {
+		t.Errorf("Histories() = %+v", histories)
+	}
+	if info, err := os.Stat(s.historyPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("history file mode = %v, %v", info.Mode(), err)
+	}
+
+	if history, err := newTestStore(t).History(1, "SK"); history != nil || err != nil {
+		t.Errorf("History() without a file = %v, %v", history, err)
+	}
+	if histories, err := newTestStore(t).Histories(); histories != nil || err != nil {
+		t.Errorf("Histories() without a file = %v, %v", histories, err)
+	}
+}
+
+func TestDropPercent(t *testing.T) {
+	if got := DropPercent(200, 150); got != 25 {
+		t.Errorf("DropPercent(200, 150) = %v, want 25", got)
+	}
+	if got := DropPercent(0, 150); got != 0 {
+		t.Errorf("DropPercent(0, 150) = %v, want 0", got)
+	}
+}
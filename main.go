@@ -7,21 +7,116 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/kuringer/alza-cli/client"
 	"github.com/kuringer/alza-cli/internal/chromecookies"
+	"github.com/kuringer/alza-cli/internal/notify"
 )
 
 // Globals contains shared configuration
 type Globals struct {
-	Format string `help:"Output format (text|json)" enum:"text,json" default:"text"`
-	Debug  bool   `help:"Enable debug mode" short:"d"`
+	Format  string `help:"Output format (text|json)" enum:"text,json" default:"text"`
+	Debug   bool   `help:"Enable debug mode" short:"d"`
+	Country string `help:"Alza storefront country (SK|CZ|HU|AT|DE), default from config.env or SK" env:"ALZA_COUNTRY"`
+	Profile string `help:"Account profile (see alza profile list), default from alza profile use" env:"ALZA_PROFILE"`
+	Retries int    `help:"Attempts for read-only requests on 429/5xx/network errors (1 disables retries)" default:"3" env:"ALZA_RETRIES"`
+
+	RefreshFrom   string        `help:"Where to get a new token when it expires: a browser (chrome, chromium, brave, vivaldi, edge, firefox), cookies (jar saved by --cookies-file), ssh:<host>, a token serve URL (http(s)://, unix:) or off" default:"chrome" env:"ALZA_REFRESH_FROM"`
+	RefreshMargin time.Duration `help:"Refresh the token this long before its JWT expiry (0 waits for a 401)" default:"5m" env:"ALZA_REFRESH_MARGIN"`
+	TokenStore    string        `help:"Where the token is kept: auto (keyring, else passphrase-encrypted file), keyring (Secret Service via the secret-tool command, which must be on PATH), encrypted (ALZA_TOKEN_PASSPHRASE) or plaintext" enum:"auto,keyring,encrypted,plaintext" default:"auto" env:"ALZA_TOKEN_STORE"`
+
+	RateLimit       float64 `help:"Max requests per second per host (0 disables throttling)" default:"2" env:"ALZA_RATE_LIMIT"`
+	SharedRateLimit bool    `help:"Share the rate limit with other alza processes (lock files in ~/.config/alza/ratelimit)" env:"ALZA_SHARED_RATE_LIMIT"`
+
+	Record string `help:"Append every HTTP exchange to this JSONL file (auth and cookies redacted)" type:"path" xor:"trace"`
+	Replay string `help:"Answer requests from a --record file instead of the network (no token needed)" type:"path" xor:"trace"`
+
+	// Point the CLI at a fake backend (client/alzatest) instead of the live storefront
+	BaseURL   string `help:"Override the storefront origin" hidden:"" env:"ALZA_BASE_URL"`
+	WebAPIURL string `help:"Override the webapi origin" hidden:"" env:"ALZA_WEBAPI_URL"`
+
+	ctx      context.Context   `kong:"-"`
+	rec      *client.Recorder  `kong:"-"`
+	tokens   client.TokenStore `kong:"-"`
+	account  *client.Profile   `kong:"-"`
+	debugOut io.Writer         `kong:"-"` // Where the client's --debug output goes; stdout when nil
+}
+
+// Context returns the CLI-wide context, cancelled on Ctrl+C / SIGTERM
+func (g *Globals) Context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
+// recorder opens the --record file once and shares it between clients of one command
+func (g *Globals) recorder() (*client.Recorder, error) {
+	if g.rec == nil {
+		rec, err := client.NewRecorder(g.Record)
+		if err != nil {
+			return nil, err
+		}
+		g.rec = rec
+	}
+	return g.rec, nil
+}
+
+// accountProfile resolves --profile / ALZA_PROFILE / `alza profile use` once per command
+func (g *Globals) accountProfile() (client.Profile, error) {
+	if g.account == nil {
+		p, err := client.LoadProfile(strings.TrimSpace(g.Profile))
+		if err != nil {
+			return client.Profile{}, err
+		}
+		g.account = &p
+	}
+	return *g.account, nil
+}
+
+// profileSettings reads config.env of the active profile
+func (g *Globals) profileSettings() (client.ProfileSettings, error) {
+	p, err := g.accountProfile()
+	if err != nil {
+		return client.ProfileSettings{}, err
+	}
+	return p.Settings()
+}
+
+// tokenStore opens the --token-store backend of the active profile once per command
+func (g *Globals) tokenStore() (client.TokenStore, error) {
+	if g.tokens == nil {
+		p, err := g.accountProfile()
+		if err != nil {
+			return nil, err
+		}
+		store, err := p.TokenStore(client.TokenStoreKind(g.TokenStore))
+		if err != nil {
+			return nil, err
+		}
+		g.tokens = store
+	}
+	return g.tokens, nil
+}
+
+// Close releases resources opened for the command (the --record file)
+func (g *Globals) Close() error {
+	if g.rec == nil {
+		return nil
+	}
+	err := g.rec.Close()
+	g.rec = nil
+	return err
 }
 
 // CLI is the main command structure
@@ -30,7 +125,9 @@ var CLI struct {
 
 	Whoami    WhoamiCmd    `cmd:"" help:"Show logged in user info"`
 	Search    SearchCmd    `cmd:"" help:"Search for products"`
+	Category  CategoryCmd  `cmd:"" help:"Browse categories: subcategories, products, top producers and promos"`
 	Product   ProductCmd   `cmd:"" help:"Show product detail"`
+	Compare   CompareCmd   `cmd:"" help:"Compare products side by side: parameters, prices, rating, complaint rate"`
 	Reviews   ReviewsCmd   `cmd:"" help:"Show product reviews"`
 	Cart      CartCmd      `cmd:"" help:"Manage shopping cart"`
 	Favorites FavoritesCmd `cmd:"" help:"Manage favorites list"`
@@ -38,6 +135,11 @@ var CLI struct {
 	Orders    OrdersCmd    `cmd:"" help:"View order history"`
 	Quickbuy  QuickbuyCmd  `cmd:"" help:"Quick order to AlzaBox (WILL CHARGE YOUR CARD!)"`
 	Token     TokenCmd     `cmd:"" help:"Manage auth token"`
+	Profiles  ProfileCmd   `cmd:"" name:"profile" help:"Manage account profiles"`
+	MCP       McpCmd       `cmd:"" name:"mcp" help:"Serve search, cart, lists, orders and quickbuy as MCP tools over stdio"`
+	Watch     WatchCmd     `cmd:"" help:"Watch product prices and keep their history"`
+	Notify    NotifyCmd    `cmd:"" help:"Show and test notification sinks and routes (notify.json)"`
+	Serve     ServeCmd     `cmd:"" help:"Serve the client as a local REST/JSON API with an OpenAPI document"`
 	Version   VersionCmd   `cmd:"" help:"Show version info"`
 }
 
@@ -49,58 +151,191 @@ func (c *VersionCmd) Run(g *Globals) error {
 	return nil
 }
 
+// resolveStorefront picks the storefront from --country/ALZA_COUNTRY, then the profile's config.env, then SK
+func resolveStorefront(g *Globals) (client.Storefront, error) {
+	country := strings.TrimSpace(g.Country)
+	if country == "" {
+		p, err := g.accountProfile()
+		if err != nil {
+			return client.Storefront{}, err
+		}
+		fromFile, err := client.CountryFromEnvFile(p.SettingsPath())
+		if err != nil {
+			return client.Storefront{}, err
+		}
+		country = fromFile
+	}
+	if country == "" {
+		return client.DefaultStorefront(), nil
+	}
+	return client.StorefrontFor(country)
+}
+
 func newClient(g *Globals) (*client.TLSClient, error) {
-	return client.NewTLSClient(g.Debug)
+	store, err := resolveStorefront(g)
+	if err != nil {
+		return nil, err
+	}
+	return openClient(g, store)
+}
+
+// openClient creates a live, recording or replaying client depending on --record / --replay
+func openClient(g *Globals, store client.Storefront) (*client.TLSClient, error) {
+	opts, err := clientOptions(g, store)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(g.Context(), opts...)
 }
 
-// newClientWithAutoRefresh creates a client, auto-refreshing token if expired
-func newClientWithAutoRefresh(g *Globals) (*client.TLSClient, error) {
-	cl, err := client.NewTLSClient(g.Debug)
-	if err == nil {
-		return cl, nil
+// clientOptions maps global flags to client options
+func clientOptions(g *Globals, store client.Storefront) ([]client.Option, error) {
+	limit, err := rateLimit(g)
+	if err != nil {
+		return nil, err
+	}
+	opts := []client.Option{
+		client.WithStorefront(store),
+		client.WithDebug(g.Debug),
+		client.WithRetryPolicy(retryPolicy(g)),
+		client.WithRateLimit(limit),
+	}
+	if g.BaseURL != "" {
+		opts = append(opts, client.WithBaseURL(g.BaseURL))
+	}
+	if g.WebAPIURL != "" {
+		opts = append(opts, client.WithWebAPIURL(g.WebAPIURL))
+	}
+	if g.debugOut != nil {
+		opts = append(opts, client.WithDebugOutput(g.debugOut))
 	}
 
-	// Check if it's a token expiration error
-	if !isTokenExpiredError(err) {
+	refresher, err := tokenRefresher(g, store)
+	if err != nil {
 		return nil, err
 	}
+	if refresher != nil {
+		opts = append(opts, client.WithTokenRefresher(refresher), client.WithRefreshMargin(g.RefreshMargin))
+	}
 
-	fmt.Println("🔄 Token expiroval, skúšam automatický refresh...")
+	switch {
+	case g.Replay != "":
+		rep, err := client.LoadReplay(g.Replay)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithReplay(rep))
+		return opts, nil
+	case g.Record != "":
+		rec, err := g.recorder()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithRecorder(rec))
+	}
 
-	// Try to refresh token
-	if refreshErr := doAutoRefresh(g); refreshErr != nil {
-		// Return original error with refresh failure info
-		return nil, fmt.Errorf("%w\n\nAuto-refresh zlyhal: %v", err, refreshErr)
+	tokens, err := g.tokenStore()
+	if err != nil {
+		return nil, err
 	}
+	return append(opts, client.WithTokenSource(tokens)), nil
+}
 
-	fmt.Println("✓ Token refreshnutý, pokračujem...")
+// rateLimit builds the per-host throttling config from --rate-limit / --shared-rate-limit
+func rateLimit(g *Globals) (client.RateLimit, error) {
+	limit := client.DefaultRateLimit()
+	limit.PerSecond = g.RateLimit
+	if g.SharedRateLimit {
+		dir, err := client.RateLimitDir()
+		if err != nil {
+			return client.RateLimit{}, fmt.Errorf("failed to resolve rate limit dir: %w", err)
+		}
+		limit.StateDir = dir
+	}
+	return limit, nil
+}
 
-	// Retry with new token
-	return client.NewTLSClient(g.Debug)
+// retryPolicy applies --retries on top of the client defaults
+func retryPolicy(g *Globals) client.RetryPolicy {
+	p := client.DefaultRetryPolicy()
+	if g.Retries > 0 {
+		p.MaxAttempts = g.Retries
+	}
+	return p
 }
 
-func isTokenExpiredError(err error) bool {
-	// Use sentinel errors from client package
-	if errors.Is(err, client.ErrTokenExpired) || errors.Is(err, client.ErrAuthRequired) {
-		return true
+// tokenRefresher maps --refresh-from to the source the client asks for a new
+// token when the current one expires, at startup or mid-session
+func tokenRefresher(g *Globals, store client.Storefront) (client.TokenRefresher, error) {
+	if g.Replay != "" {
+		return nil, nil
+	}
+	refresh, err := refreshSource(g, store)
+	if refresh == nil || err != nil {
+		return nil, err
 	}
-	// Fallback: check error message for TOKEN EXPIROVAL (from validateToken)
-	return strings.Contains(err.Error(), "TOKEN EXPIROVAL")
+
+	return client.RefreshFunc(func(ctx context.Context) (string, error) {
+		// stderr keeps --format=json output on stdout parseable
+		fmt.Fprintln(os.Stderr, "🔄 Token expiroval alebo čoskoro expiruje, skúšam automatický refresh...")
+		token, err := refresh(ctx)
+		if err != nil {
+			return "", err
+		}
+		// The new token works whether or not it can be kept (e.g. --token-store
+		// auto with no keyring and no passphrase); failing to save only costs
+		// another refresh next time
+		if err := saveToken(ctx, g, token); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; nový token platí len pre tento príkaz\n", err)
+		}
+		fmt.Fprintln(os.Stderr, "✓ Token refreshnutý, pokračujem...")
+		return token, nil
+	}), nil
 }
 
-func doAutoRefresh(g *Globals) error {
+// refreshSource maps --refresh-from to a function fetching a new token; nil for off
+func refreshSource(g *Globals, store client.Storefront) (client.RefreshFunc, error) {
+	from := strings.TrimSpace(g.RefreshFrom)
+	switch {
+	case from == "off":
+		return nil, nil
+	case from == "" || isBrowser(from):
+		browser := chromecookies.Browser(from)
+		return func(ctx context.Context) (string, error) {
+			return browserToken(ctx, g, store, browser)
+		}, nil
+	case from == "cookies":
+		return func(ctx context.Context) (string, error) {
+			return cookieJarToken(ctx, g, store)
+		}, nil
+	case strings.HasPrefix(from, "ssh:") && len(from) > len("ssh:"):
+		host := strings.TrimPrefix(from, "ssh:")
+		return func(ctx context.Context) (string, error) {
+			return pullToken(ctx, host, defaultRemoteTokenPath, 15*time.Second)
+		}, nil
+	case isBrokerURL(from):
+		return func(ctx context.Context) (string, error) {
+			return fetchBrokerToken(ctx, from, brokerClientFromEnv(), 15*time.Second)
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid --refresh-from %q (%s, cookies, ssh:<host>, http(s)://<broker>, unix:<socket> or off)", from, browserNames())
+}
+
+// browserToken exchanges the storefront cookies of the browser's default profile for a new token
+func browserToken(ctx context.Context, g *Globals, store client.Storefront, browser chromecookies.Browser) (string, error) {
 	cacheDir, err := expandHomePath("~/.cache/alza/chromecookies")
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	profile, err := defaultChromeProfile()
+	profile, err := profileBrowserProfile(g, browser)
 	if err != nil {
-		return fmt.Errorf("nepodarilo sa zistiť Chrome profil: %w", err)
+		return "", fmt.Errorf("nepodarilo sa zistiť %s profil: %w", browser.Name(), err)
 	}
 
 	opts := chromecookies.Options{
-		TargetURL:     client.BaseURL,
+		TargetURL:     store.HomeURL(),
+		Browser:       browser,
 		ChromeProfile: profile,
 		CacheDir:      cacheDir,
 		Timeout:       15 * time.Second,
@@ -109,23 +344,55 @@ func doAutoRefresh(g *Globals) error {
 		opts.LogWriter = os.Stderr
 	}
 
-	res, err := chromecookies.LoadCookieHeader(context.Background(), opts)
+	res, err := chromecookies.LoadCookieHeader(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("nepodarilo sa načítať cookies: %w", err)
+		return "", fmt.Errorf("nepodarilo sa načítať cookies: %w", err)
 	}
 	if strings.TrimSpace(res.CookieHeader) == "" {
-		return fmt.Errorf("žiadne cookies (si prihlásený v Chrome?)")
+		return "", fmt.Errorf("žiadne cookies (si prihlásený v %s?)", browser.Name())
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	token, err := client.RefreshTokenWithCookies(ctx, res.CookieHeader, g.Debug)
+	return client.RefreshTokenForStorefront(ctx, store, res.CookieHeader, g.Debug)
+}
+
+// cookieJarToken exchanges the session cookies saved by `token refresh --cookies-file` for a new token
+func cookieJarToken(ctx context.Context, g *Globals, store client.Storefront) (string, error) {
+	p, err := g.accountProfile()
 	if err != nil {
-		return err
+		return "", err
 	}
+	jar := p.CookieJarPath()
 
-	return client.SaveToken(token)
+	res, err := chromecookies.LoadCookieHeader(ctx, chromecookies.Options{
+		TargetURL:   store.HomeURL(),
+		CookiesFile: jar,
+	})
+	if err != nil {
+		return "", fmt.Errorf("nepodarilo sa načítať uložené cookies (spusti alza token refresh --cookies-file): %w", err)
+	}
+	if strings.TrimSpace(res.CookieHeader) == "" {
+		return "", fmt.Errorf("uložené cookies v %s expirovali (spusti alza token refresh --cookies-file)", jar)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return client.RefreshTokenForStorefront(ctx, store, res.CookieHeader, g.Debug)
+}
+
+// saveToken keeps a new token in the --token-store backend
+func saveToken(ctx context.Context, g *Globals, token string) error {
+	store, err := g.tokenStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Save(ctx, token); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return nil
 }
 
 func outputJSON(v interface{}) {
@@ -133,16 +400,23 @@ func outputJSON(v interface{}) {
 	fmt.Println(string(data))
 }
 
-func resolveFavoritesList(cl *client.TLSClient) (*client.CommodityList, error) {
-	lists, err := cl.GetLists()
+func resolveFavoritesList(g *Globals, cl *client.TLSClient) (*client.CommodityList, error) {
+	lists, err := cl.GetListsContext(g.Context())
 	if err != nil {
 		return nil, err
 	}
 
+	settings, err := g.profileSettings()
+	if err != nil {
+		return nil, err
+	}
 	names := []string{}
 	if envName := strings.TrimSpace(os.Getenv("ALZA_FAVORITES_LIST")); envName != "" {
 		names = append(names, envName)
 	}
+	if settings.FavoritesList != "" {
+		names = append(names, settings.FavoritesList)
+	}
 	names = append(names, "AGENT", "AGENTS")
 
 	for _, name := range names {
@@ -180,6 +454,40 @@ func expandHomePath(input string) (string, error) {
 	return input, nil
 }
 
+// isBrowser reports whether name is a --browser value
+func isBrowser(name string) bool {
+	return slices.Contains(chromecookies.Browsers(), chromecookies.Browser(name))
+}
+
+func browserNames() string {
+	names := make([]string, 0, len(chromecookies.Browsers()))
+	for _, b := range chromecookies.Browsers() {
+		names = append(names, string(b))
+	}
+	return strings.Join(names, ", ")
+}
+
+// profileBrowserProfile prefers ALZA_CHROME_PROFILE from the account profile's config.env
+func profileBrowserProfile(g *Globals, browser chromecookies.Browser) (string, error) {
+	settings, err := g.profileSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.ChromeProfile != "" {
+		return expandHomePath(settings.ChromeProfile)
+	}
+	return defaultBrowserProfile(browser)
+}
+
+// defaultBrowserProfile only has an opinion about Chrome (the remote-login
+// profile); other browsers start from their own default profile
+func defaultBrowserProfile(browser chromecookies.Browser) (string, error) {
+	if browser != "" && browser != chromecookies.BrowserChrome {
+		return "", nil
+	}
+	return defaultChromeProfile()
+}
+
 func defaultChromeProfile() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -199,19 +507,113 @@ func defaultChromeProfile() (string, error) {
 // === TOKEN ===
 
 type TokenCmd struct {
-	Refresh TokenRefreshCmd `cmd:"" help:"Refresh auth token from Chrome cookies"`
-	Pull    TokenPullCmd    `cmd:"" help:"Pull auth token from a remote host via SSH"`
+	Status  TokenStatusCmd  `cmd:"" help:"Show auth token claims and remaining lifetime (no network)"`
+	Refresh TokenRefreshCmd `cmd:"" help:"Refresh auth token from browser cookies"`
+	Pull    TokenPullCmd    `cmd:"" help:"Pull auth token from a remote host via SSH or alza token serve"`
+	Serve   TokenServeCmd   `cmd:"" help:"Keep the token fresh and serve it to other machines (Unix socket or HTTPS)"`
+}
+
+type TokenStatusCmd struct{}
+
+// tokenStatus is the JSON form of `alza token status`
+type tokenStatus struct {
+	Path string `json:"path"` // auth_token.txt path or the keyring entry
+	*client.TokenClaims
+	RemainingSeconds int64 `json:"remainingSeconds"`
+	Expired          bool  `json:"expired"`
+}
+
+func (c *TokenStatusCmd) Run(g *Globals) error {
+	tokens, err := g.tokenStore()
+	if err != nil {
+		return err
+	}
+	token, err := tokens.Token(g.Context())
+	if err != nil {
+		return err
+	}
+	path := tokens.String()
+	claims, err := client.ParseTokenClaims(token)
+	if err != nil {
+		return fmt.Errorf("%w\nRun `alza token refresh` to get a new token", err)
+	}
+
+	now := time.Now()
+	remaining := claims.Remaining(now)
+	if g.Format == "json" {
+		outputJSON(tokenStatus{
+			Path:             path,
+			TokenClaims:      claims,
+			RemainingSeconds: int64(remaining / time.Second),
+			Expired:          claims.Expired(now),
+		})
+		return nil
+	}
+
+	fmt.Print(formatTokenStatus(path, claims, now, g.RefreshMargin))
+	return nil
+}
+
+func formatTokenStatus(path string, claims *client.TokenClaims, now time.Time, margin time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Token:    %s\n", path)
+	if claims.UserID != "" {
+		fmt.Fprintf(&b, "User ID:  %s\n", claims.UserID)
+	}
+	if claims.Subject != "" && claims.Subject != claims.UserID {
+		fmt.Fprintf(&b, "Subject:  %s\n", claims.Subject)
+	}
+	if claims.ClientID != "" {
+		fmt.Fprintf(&b, "Client:   %s\n", claims.ClientID)
+	}
+	if claims.Issuer != "" {
+		fmt.Fprintf(&b, "Issuer:   %s\n", claims.Issuer)
+	}
+	if !claims.IssuedAt.IsZero() {
+		fmt.Fprintf(&b, "Issued:   %s (%s ago)\n", claims.IssuedAt.Local().Format("2006-01-02 15:04:05"), now.Sub(claims.IssuedAt).Round(time.Second))
+	}
+	if claims.ExpiresAt.IsZero() {
+		b.WriteString("Expires:  unknown (no exp claim)\n")
+	} else {
+		remaining := claims.Remaining(now).Round(time.Second)
+		if claims.Expired(now) {
+			fmt.Fprintf(&b, "Expires:  %s (%s ago)\n", claims.ExpiresAt.Local().Format("2006-01-02 15:04:05"), -remaining)
+		} else {
+			fmt.Fprintf(&b, "Expires:  %s (in %s)\n", claims.ExpiresAt.Local().Format("2006-01-02 15:04:05"), remaining)
+		}
+	}
+	if len(claims.Scopes) > 0 {
+		fmt.Fprintf(&b, "Scopes:   %s\n", strings.Join(claims.Scopes, ", "))
+	}
+
+	switch {
+	case claims.Expired(now):
+		b.WriteString("Status:   ✗ expired - next command refreshes it (or run `alza token refresh`)\n")
+	case !claims.ExpiresAt.IsZero() && claims.Remaining(now) <= margin:
+		b.WriteString("Status:   ⚠ expires soon - next command refreshes it\n")
+	default:
+		b.WriteString("Status:   ✓ valid\n")
+	}
+	return b.String()
 }
 
 type TokenRefreshCmd struct {
-	ChromeProfile string        `help:"Chrome profile name or path (auto-detect if empty)"`
-	CookiePath    string        `help:"Explicit path to Chrome Cookies DB" type:"path"`
-	CacheDir      string        `help:"Cache dir for chrome-cookies-secure" default:"~/.cache/alza/chromecookies" type:"path"`
+	Browser       string        `help:"Browser to read cookies from (chrome, chromium, brave, vivaldi, edge, firefox)" enum:"chrome,chromium,brave,vivaldi,edge,firefox" default:"chrome" env:"ALZA_BROWSER"`
+	ChromeProfile string        `help:"Browser profile name or path (default: ALZA_CHROME_PROFILE of the account profile, else auto-detect; Firefox: profiles.ini name)" aliases:"browser-profile"`
+	CookiePath    string        `help:"Explicit path to the Cookies DB (Firefox: cookies.sqlite)" type:"path"`
+	CacheDir      string        `help:"Cache dir for chrome-cookies-secure (Node backend)" default:"~/.cache/alza/chromecookies" type:"path"`
+	Backend       string        `help:"Cookie reader: auto (Go on Linux, Node as fallback), native, node" enum:"auto,native,node" default:"auto"`
 	Timeout       time.Duration `help:"Timeout for cookie read" default:"15s"`
-	URL           string        `help:"Target URL to match cookies" default:"https://www.alza.sk/"`
+	URL           string        `help:"Target URL to match cookies (default: storefront home page)"`
+	CookiesFile   string        `help:"Netscape cookies.txt or HAR export to take cookies from instead of a browser (saved for --refresh-from cookies)" type:"path"`
 }
 
 func (c *TokenRefreshCmd) Run(g *Globals) error {
+	store, err := resolveStorefront(g)
+	if err != nil {
+		return err
+	}
+
 	cacheDir, err := expandHomePath(c.CacheDir)
 	if err != nil {
 		return err
@@ -220,9 +622,10 @@ func (c *TokenRefreshCmd) Run(g *Globals) error {
 		return fmt.Errorf("cache dir is empty")
 	}
 
+	browser := chromecookies.Browser(c.Browser)
 	profile := strings.TrimSpace(c.ChromeProfile)
-	if profile == "" {
-		profile, err = defaultChromeProfile()
+	if profile == "" && c.CookiesFile == "" {
+		profile, err = profileBrowserProfile(g, browser)
 		if err != nil {
 			return err
 		}
@@ -230,55 +633,97 @@ func (c *TokenRefreshCmd) Run(g *Globals) error {
 
 	targetURL := strings.TrimSpace(c.URL)
 	if targetURL == "" {
-		targetURL = client.BaseURL
+		targetURL = store.HomeURL()
 	}
 
 	opts := chromecookies.Options{
 		TargetURL:          targetURL,
+		Browser:            browser,
 		ChromeProfile:      profile,
 		ExplicitCookiePath: c.CookiePath,
 		CacheDir:           cacheDir,
 		Timeout:            c.Timeout,
+		Backend:            chromecookies.Backend(c.Backend),
+		CookiesFile:        c.CookiesFile,
 	}
 	if g.Debug {
 		opts.LogWriter = os.Stderr
 	}
 
-	res, err := chromecookies.LoadCookieHeader(context.Background(), opts)
+	res, err := chromecookies.LoadCookieHeader(g.Context(), opts)
+	if err != nil && c.CookiesFile != "" {
+		return err
+	}
 	if err != nil {
-		return formatTokenRefreshError(err, profile, c.CookiePath)
+		return formatTokenRefreshError(err, store, browser, profile, c.CookiePath)
+	}
+	if strings.TrimSpace(res.CookieHeader) == "" && c.CookiesFile != "" {
+		return fmt.Errorf("no cookies for %s in %s (export them while signed in to %s)", targetURL, c.CookiesFile, store.HomeURL())
 	}
 	if strings.TrimSpace(res.CookieHeader) == "" {
-		return formatTokenRefreshError(fmt.Errorf("no cookies found for %s (are you logged in in Chrome?)", targetURL), profile, c.CookiePath)
+		return formatTokenRefreshError(fmt.Errorf("no cookies found for %s (are you logged in in %s?)", targetURL, browser.Name()), store, browser, profile, c.CookiePath)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	ctx, cancel := context.WithTimeout(g.Context(), c.Timeout)
 	defer cancel()
 
-	token, err := client.RefreshTokenWithCookies(ctx, res.CookieHeader, g.Debug)
+	token, err := client.RefreshTokenForStorefront(ctx, store, res.CookieHeader, g.Debug)
 	if err != nil {
-		return formatTokenRefreshError(err, profile, c.CookiePath)
+		return formatTokenRefreshError(err, store, browser, profile, c.CookiePath)
 	}
-	if err := client.SaveToken(token); err != nil {
-		return fmt.Errorf("failed to save token: %w", err)
+	if err := saveToken(g.Context(), g, token); err != nil {
+		return err
+	}
+
+	if c.CookiesFile == "" {
+		fmt.Printf("✓ Token refreshed from %s cookies (%d cookies)\n", browser.Name(), res.CookieCount)
+		return nil
 	}
 
-	fmt.Printf("✓ Token refreshed from Chrome cookies (%d cookies)\n", res.CookieCount)
+	fmt.Printf("✓ Token refreshed from %s (%d cookies)\n", c.CookiesFile, res.CookieCount)
+	account, err := g.accountProfile()
+	if err != nil {
+		return err
+	}
+	jar := account.CookieJarPath()
+	if same, _ := sameFile(jar, c.CookiesFile); !same {
+		if err := chromecookies.WriteCookieJar(jar, res.Cookies); err != nil {
+			return fmt.Errorf("failed to save session cookies: %w", err)
+		}
+	}
+	fmt.Printf("✓ Session cookies saved to %s (use --refresh-from cookies)\n", jar)
 	return nil
 }
 
-func formatTokenRefreshError(err error, profile, cookiePath string) error {
+// sameFile reports whether a and b are the same existing file.
+func sameFile(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+func formatTokenRefreshError(err error, store client.Storefront, browser chromecookies.Browser, profile, cookiePath string) error {
 	msg := err.Error()
 	if !needsLoginGuidance(msg) {
 		return err
 	}
 
+	refreshCmd := "alza token refresh"
+	if browser != "" && browser != chromecookies.BrowserChrome {
+		refreshCmd += " --browser " + string(browser)
+	}
 	lines := []string{
 		"Login required to refresh token.",
 		"",
 		"Local (Mac/Linux desktop):",
-		"1) Open Chrome/Chromium and sign in to https://www.alza.sk/",
-		"2) Run: alza token refresh",
+		"1) Open " + browser.Name() + " and sign in to " + store.HomeURL(),
+		"2) Run: " + refreshCmd,
 		"",
 		"Headless server:",
 		"1) Run: ./scripts/remote-login.sh",
@@ -314,44 +759,64 @@ func needsLoginGuidance(msg string) bool {
 	return false
 }
 
+// defaultRemoteTokenPath is where `alza token refresh` saves the token on a remote host
+const defaultRemoteTokenPath = "~/.config/alza/auth_token.txt"
+
 type TokenPullCmd struct {
-	From       string        `help:"SSH host (from ~/.ssh/config or user@host)"`
-	RemotePath string        `help:"Remote auth_token.txt path" default:"~/.config/alza/auth_token.txt"`
-	Timeout    time.Duration `help:"SSH timeout" default:"15s"`
+	From       string        `help:"SSH host (from ~/.ssh/config or user@host), or an alza token serve URL (http(s)://host:port, unix:/path)"`
+	RemotePath string        `help:"Remote auth_token.txt path (SSH)" default:"~/.config/alza/auth_token.txt"`
+	Timeout    time.Duration `help:"SSH / HTTP timeout" default:"15s"`
+
+	BrokerClientFlags `embed:""`
 }
 
 func (c *TokenPullCmd) Run(g *Globals) error {
 	if c.From == "" {
-		return fmt.Errorf("missing --from (SSH host)")
+		return fmt.Errorf("missing --from (SSH host or token serve URL)")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	var token string
+	var err error
+	if isBrokerURL(c.From) {
+		token, err = fetchBrokerToken(g.Context(), c.From, c.BrokerClientFlags, c.Timeout)
+	} else {
+		token, err = pullToken(g.Context(), c.From, c.RemotePath, c.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := saveToken(g.Context(), g, token); err != nil {
+		return err
+	}
+
+	tokens, _ := g.tokenStore()
+	fmt.Printf("✓ Token pulled and saved to %s\n", tokens)
+	return nil
+}
+
+// pullToken reads the token saved on another host over SSH
+func pullToken(ctx context.Context, host, remotePath string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var stdout bytes.Buffer
-	cmd := exec.CommandContext(ctx, "ssh", c.From, "cat", "--", c.RemotePath)
+	cmd := exec.CommandContext(ctx, "ssh", host, "cat", "--", remotePath)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = &stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("ssh timed out after %s", timeout)
+		}
 		if ctx.Err() != nil {
-			return fmt.Errorf("ssh timed out after %s", c.Timeout)
+			return "", ctx.Err()
 		}
-		return fmt.Errorf("ssh failed: %w", err)
-	}
-
-	token, err := extractBearerToken(stdout.String())
-	if err != nil {
-		return err
-	}
-
-	if err := client.SaveToken(token); err != nil {
-		return fmt.Errorf("failed to save token: %w", err)
+		return "", fmt.Errorf("ssh failed: %w", err)
 	}
 
-	fmt.Println("✓ Token pulled and saved to ~/.config/alza/auth_token.txt")
-	return nil
+	return extractBearerToken(stdout.String())
 }
 
 // === WHOAMI ===
@@ -359,12 +824,12 @@ func (c *TokenPullCmd) Run(g *Globals) error {
 type WhoamiCmd struct{}
 
 func (c *WhoamiCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	status, err := cl.GetUserStatus()
+	status, err := cl.GetUserStatusContext(g.Context())
 	if err != nil {
 		return err
 	}
@@ -388,17 +853,38 @@ func (c *WhoamiCmd) Run(g *Globals) error {
 // === SEARCH ===
 
 type SearchCmd struct {
-	Query string `arg:"" help:"Search query"`
-	Limit int    `help:"Max results to show" default:"10" short:"n"`
+	Query        string  `arg:"" help:"Search query"`
+	Limit        int     `help:"Max results to show" default:"10" short:"n"`
+	Page         int     `help:"Page of --limit results to show (1 = first). Alza answers a search with one batch of results; pages past it fail" short:"p"`
+	Offset       int     `help:"Skip the first N matching results (within the one batch Alza returns)"`
+	Sort         string  `help:"Order: relevance, price-asc, price-desc, rating, bestselling (rating and bestselling fetch the rating of the first 50 matches and rank only those)" enum:"relevance,price-asc,price-desc,rating,bestselling" default:"relevance"`
+	MinPrice     float64 `help:"Only products costing at least this much"`
+	MaxPrice     float64 `help:"Only products costing at most this much"`
+	InStock      bool    `help:"Only products in stock"`
+	Manufacturer string  `help:"Only products whose name starts with this brand, e.g. Apple"`
+	Category     string  `help:"Only products in this category URL slug, e.g. mobily"`
 }
 
 func (c *SearchCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	if c.Page > 0 && c.Offset > 0 {
+		return fmt.Errorf("--page and --offset are mutually exclusive")
+	}
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	results, err := cl.Search(c.Query, c.Limit)
+	results, err := cl.SearchWithOptionsContext(g.Context(), c.Query, client.SearchOptions{
+		Limit:        c.Limit,
+		Offset:       c.Offset,
+		Page:         c.Page,
+		Sort:         client.SearchSort(c.Sort),
+		MinPrice:     c.MinPrice,
+		MaxPrice:     c.MaxPrice,
+		InStockOnly:  c.InStock,
+		Manufacturer: c.Manufacturer,
+		Category:     c.Category,
+	})
 	if err != nil {
 		return err
 	}
@@ -413,10 +899,17 @@ func (c *SearchCmd) Run(g *Globals) error {
 		return nil
 	}
 
+	first := c.Offset
+	if c.Page > 0 {
+		first = (c.Page - 1) * c.Limit
+	}
 	for i, r := range results {
-		fmt.Printf("%d. [%d] %s\n", i+1, r.ID, r.Name)
-		fmt.Printf("   Price: %s | %s\n", r.PriceStr, r.Availability)
-		fmt.Printf("   %s\n\n", r.URL)
+		fmt.Printf("%d. [%d] %s\n", first+i+1, r.ID, r.Name)
+		fmt.Printf("   Price: %s | %s", r.PriceStr, r.Availability)
+		if r.RatingCount > 0 {
+			fmt.Printf(" | ★ %.1f (%d)", r.Rating, r.RatingCount)
+		}
+		fmt.Printf("\n   %s\n\n", r.URL)
 	}
 
 	return nil
@@ -425,25 +918,135 @@ func (c *SearchCmd) Run(g *Globals) error {
 // === PRODUCT ===
 
 type ProductCmd struct {
-	ProductID int `arg:"" help:"Product ID"`
+	Products     []string `arg:"" name:"product" help:"Product IDs, URLs, Alza codes or EANs; - reads them from stdin, one or more per line"`
+	History      bool     `help:"Show the price history recorded by alza watch run instead of fetching the product"`
+	Availability bool     `help:"Fetch availability" default:"true" negatable:""`
+	Description  bool     `help:"Fetch the description page" default:"true" negatable:""`
+	Reviews      bool     `help:"Fetch the rating summary" default:"true" negatable:""`
+	Workers      int      `help:"Products fetched at once (requests still follow the rate limit)" default:"4" short:"j"`
+}
+
+// resolveProductID resolves ref, opening a client only for the codes and barcodes
+// that need a search, so offline commands stay offline for IDs and URLs
+func resolveProductID(g *Globals, ref client.ProductRef) (int, error) {
+	if ref.ID > 0 {
+		return ref.ID, nil
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return 0, err
+	}
+	return cl.ResolveProductContext(g.Context(), ref)
+}
+
+// readProductRefs parses product arguments, expanding "-" to the references
+// on stdin. Stdin entries are separated by whitespace or commas; lines
+// starting with # are skipped.
+func readProductRefs(args []string, stdin io.Reader) ([]client.ProductRef, error) {
+	var refs []client.ProductRef
+	for _, arg := range args {
+		if arg != "-" {
+			ref, err := client.ParseProductRef(arg)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+			continue
+		}
+		scanner := bufio.NewScanner(stdin)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(text, "#") {
+				continue
+			}
+			for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				ref, err := client.ParseProductRef(field)
+				if err != nil {
+					return nil, fmt.Errorf("stdin line %d: %w", line, err)
+				}
+				refs = append(refs, ref)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, errors.New("no products given")
+	}
+	return refs, nil
 }
 
 func (c *ProductCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	refs, err := readProductRefs(c.Products, os.Stdin)
 	if err != nil {
 		return err
 	}
+	if c.Workers < 1 {
+		return fmt.Errorf("invalid --workers %d", c.Workers)
+	}
+	if c.History {
+		if len(refs) > 1 {
+			return errors.New("--history takes a single product")
+		}
+		id, err := resolveProductID(g, refs[0])
+		if err != nil {
+			return err
+		}
+		return productHistory(g, id)
+	}
 
-	product, err := cl.GetProduct(c.ProductID)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
+	results := cl.GetProductRefsContext(g.Context(), refs, client.ProductOptions{
+		Availability: c.Availability,
+		Description:  c.Description,
+		Reviews:      c.Reviews,
+		Workers:      c.Workers,
+	})
+
+	// A single product keeps the plain detail output
+	if len(results) == 1 && !slices.Contains(c.Products, "-") {
+		if results[0].Err != nil {
+			return results[0].Err
+		}
+		if g.Format == "json" {
+			outputJSON(results[0].Product)
+			return nil
+		}
+		printProduct(results[0].Product)
+		return nil
+	}
 
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
 	if g.Format == "json" {
-		outputJSON(product)
-		return nil
+		outputJSON(results)
+	} else {
+		for i, r := range results {
+			if i > 0 {
+				fmt.Printf("\n%s\n\n", strings.Repeat("─", 40))
+			}
+			if r.Err != nil {
+				fmt.Printf("[%s] Error: %v\n", r.Ref, r.Err)
+				continue
+			}
+			printProduct(r.Product)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d products failed", failed, len(results))
 	}
+	return nil
+}
 
+func printProduct(product *client.ProductDetail) {
 	fmt.Printf("[%d] %s\n", product.ID, product.Name)
 
 	if product.Price != "" {
@@ -538,27 +1141,29 @@ func (c *ProductCmd) Run(g *Globals) error {
 			fmt.Printf("  %s [%d] %s\n", marker, variant.ID, variant.Name)
 		}
 	}
-
-	return nil
 }
 
 // === REVIEWS ===
 
 type ReviewsCmd struct {
-	ProductID int  `arg:"" help:"Product ID"`
-	Limit     int  `help:"Number of reviews to show" default:"10" short:"n"`
-	Offset    int  `help:"Skip first N reviews" default:"0"`
-	Stats     bool `help:"Show only stats, no individual reviews" short:"s"`
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN"`
+	Limit   int               `help:"Number of reviews to show" default:"10" short:"n"`
+	Offset  int               `help:"Skip first N reviews" default:"0"`
+	Stats   bool              `help:"Show only stats, no individual reviews" short:"s"`
 }
 
 func (c *ReviewsCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
 	// Always fetch stats
-	stats, err := cl.GetReviewStats(c.ProductID)
+	stats, err := cl.GetReviewStatsContext(g.Context(), id)
 	if err != nil {
 		return fmt.Errorf("failed to fetch review stats: %w", err)
 	}
@@ -569,7 +1174,7 @@ func (c *ReviewsCmd) Run(g *Globals) error {
 			return nil
 		}
 		// Fetch reviews for JSON output
-		reviews, err := cl.GetReviews(c.ProductID, c.Offset, c.Limit)
+		reviews, err := cl.GetReviewsContext(g.Context(), id, c.Offset, c.Limit)
 		if err != nil {
 			return err
 		}
@@ -581,7 +1186,7 @@ func (c *ReviewsCmd) Run(g *Globals) error {
 	}
 
 	// Text output - stats header
-	fmt.Printf("Reviews for product %d\n", c.ProductID)
+	fmt.Printf("Reviews for product %d\n", id)
 	fmt.Println(strings.Repeat("=", 50))
 	fmt.Println()
 
@@ -631,7 +1236,7 @@ func (c *ReviewsCmd) Run(g *Globals) error {
 	}
 
 	// Fetch and display individual reviews
-	reviews, err := cl.GetReviews(c.ProductID, c.Offset, c.Limit)
+	reviews, err := cl.GetReviewsContext(g.Context(), id, c.Offset, c.Limit)
 	if err != nil {
 		return err
 	}
@@ -692,7 +1297,7 @@ func (c *ReviewsCmd) Run(g *Globals) error {
 
 	// Pagination hint
 	if c.Offset+c.Limit < reviews.TotalCount {
-		fmt.Printf("\n... ďalšie recenzie: alza reviews %d --offset %d\n", c.ProductID, c.Offset+c.Limit)
+		fmt.Printf("\n... ďalšie recenzie: alza reviews %d --offset %d\n", id, c.Offset+c.Limit)
 	}
 
 	return nil
@@ -738,12 +1343,12 @@ type CartCmd struct {
 type CartShowCmd struct{}
 
 func (c *CartShowCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	items, err := cl.GetCart()
+	items, err := cl.GetCartContext(g.Context())
 	if err != nil {
 		return err
 	}
@@ -776,51 +1381,59 @@ func (c *CartShowCmd) Run(g *Globals) error {
 }
 
 type CartAddCmd struct {
-	ProductID int `arg:"" help:"Product ID to add"`
-	Quantity  int `help:"Quantity" default:"1" short:"q"`
+	Product  client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN to add"`
+	Quantity int               `help:"Quantity" default:"1" short:"q"`
 }
 
 func (c *CartAddCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.AddToCart(c.ProductID, c.Quantity); err != nil {
+	if err := cl.AddToCartContext(g.Context(), id, c.Quantity); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Added product %d to cart (qty: %d)\n", c.ProductID, c.Quantity)
+	fmt.Printf("✓ Added product %d to cart (qty: %d)\n", id, c.Quantity)
 	return nil
 }
 
 type CartRemoveCmd struct {
-	ProductID int `arg:"" help:"Product ID to remove"`
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN to remove"`
 }
 
 func (c *CartRemoveCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.RemoveFromCart(c.ProductID); err != nil {
+	if err := cl.RemoveFromCartContext(g.Context(), id); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Removed product %d from cart\n", c.ProductID)
+	fmt.Printf("✓ Removed product %d from cart\n", id)
 	return nil
 }
 
 type CartClearCmd struct{}
 
 func (c *CartClearCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.ClearCart(); err != nil {
+	if err := cl.ClearCartContext(g.Context()); err != nil {
 		return err
 	}
 
@@ -839,17 +1452,17 @@ type FavoritesCmd struct {
 type FavoritesShowCmd struct{}
 
 func (c *FavoritesShowCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	list, err := resolveFavoritesList(cl)
+	list, err := resolveFavoritesList(g, cl)
 	if err != nil {
 		return err
 	}
 
-	items, err := cl.GetListItems(list.ID)
+	items, err := cl.GetListItemsContext(g.Context(), list.ID)
 	if err != nil {
 		return err
 	}
@@ -876,48 +1489,56 @@ func (c *FavoritesShowCmd) Run(g *Globals) error {
 }
 
 type FavoritesAddCmd struct {
-	ProductID int `arg:"" help:"Product ID to add"`
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN to add"`
 }
 
 func (c *FavoritesAddCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
-	list, err := resolveFavoritesList(cl)
+	list, err := resolveFavoritesList(g, cl)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.AddToList(list.ID, c.ProductID); err != nil {
+	if err := cl.AddToListContext(g.Context(), list.ID, id); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Added product %d to list '%s'\n", c.ProductID, list.Name)
+	fmt.Printf("✓ Added product %d to list '%s'\n", id, list.Name)
 	return nil
 }
 
 type FavoritesRemoveCmd struct {
-	ProductID int `arg:"" help:"Product ID to remove"`
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN to remove"`
 }
 
 func (c *FavoritesRemoveCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
-	list, err := resolveFavoritesList(cl)
+	list, err := resolveFavoritesList(g, cl)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.RemoveFromList(list.ID, c.ProductID); err != nil {
+	if err := cl.RemoveFromListContext(g.Context(), list.ID, id); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Removed product %d from list '%s'\n", c.ProductID, list.Name)
+	fmt.Printf("✓ Removed product %d from list '%s'\n", id, list.Name)
 	return nil
 }
 
@@ -933,12 +1554,12 @@ type ListsCmd struct {
 type ListsShowCmd struct{}
 
 func (c *ListsShowCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	lists, err := cl.GetLists()
+	lists, err := cl.GetListsContext(g.Context())
 	if err != nil {
 		return err
 	}
@@ -973,12 +1594,12 @@ type ListsItemsCmd struct {
 }
 
 func (c *ListsItemsCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	items, err := cl.GetListItems(c.ListID)
+	items, err := cl.GetListItemsContext(g.Context(), c.ListID)
 	if err != nil {
 		return err
 	}
@@ -1009,12 +1630,12 @@ type ListsCreateCmd struct {
 }
 
 func (c *ListsCreateCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	list, err := cl.CreateList(c.Name)
+	list, err := cl.CreateListContext(g.Context(), c.Name)
 	if err != nil {
 		return err
 	}
@@ -1029,21 +1650,25 @@ func (c *ListsCreateCmd) Run(g *Globals) error {
 }
 
 type ListsAddCmd struct {
-	ListID    int `arg:"" help:"List ID"`
-	ProductID int `arg:"" help:"Product ID to add"`
+	ListID  int               `arg:"" help:"List ID"`
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN to add"`
 }
 
 func (c *ListsAddCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
 	if err != nil {
 		return err
 	}
 
-	if err := cl.AddToList(c.ListID, c.ProductID); err != nil {
+	if err := cl.AddToListContext(g.Context(), c.ListID, id); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Produkt %d pridaný do listu %d\n", c.ProductID, c.ListID)
+	fmt.Printf("✓ Produkt %d pridaný do listu %d\n", id, c.ListID)
 	return nil
 }
 
@@ -1053,73 +1678,67 @@ type OrdersCmd struct {
 	Limit     int    `help:"Max orders to show" default:"10" short:"n"`
 	WithItems bool   `help:"Show item lines under each order" name:"with-items"`
 	Query     string `help:"Filter past orders by item name"`
+	Track     bool   `help:"Remember the order statuses and report (and notify) the ones that changed since the last --track"`
 }
 
 func (c *OrdersCmd) Run(g *Globals) error {
-	cl, err := newClientWithAutoRefresh(g)
+	if c.Track && strings.TrimSpace(c.Query) != "" {
+		return fmt.Errorf("--track can't be combined with --query")
+	}
+	cl, err := newClient(g)
 	if err != nil {
 		return err
 	}
 
-	query := strings.TrimSpace(c.Query)
-	if query != "" {
-		archiveOrders, historyTotal, err := collectArchiveOrders(cl.GetArchiveOrdersPage, archiveOrdersPageSize)
+	res, err := fetchOrders(g.Context(), cl, c.Limit, c.WithItems, c.Query)
+	if err != nil {
+		return err
+	}
+	if c.Track {
+		account, err := g.accountProfile()
 		if err != nil {
 			return err
 		}
-
-		filtered := filterOrdersByQuery(archiveOrders, query)
-		orders := limitOrders(filtered, c.Limit)
-
-		if g.Format == "json" {
-			outputJSON(map[string]interface{}{
-				"orders":              ordersForJSON(orders, true),
-				"totalCount":          len(filtered),
-				"historyCount":        historyTotal,
-				"query":               query,
-				"searchesArchiveOnly": true,
-			})
-			return nil
+		if res.StatusChanges, err = trackOrderStatuses(account.OrderStatusPath(), res.Orders); err != nil {
+			return err
 		}
-
-		fmt.Print(formatOrdersText(orders, len(filtered), query, true))
-		return nil
-	}
-
-	orders, total, err := cl.GetOrders(c.Limit)
-	if err != nil {
-		return err
 	}
 
 	if g.Format == "json" {
-		outputJSON(map[string]interface{}{
-			"orders":     ordersForJSON(orders, c.WithItems),
-			"totalCount": total,
-		})
-		return nil
+		outputJSON(res)
+	} else {
+		fmt.Print(formatOrdersText(res.Orders, res.TotalCount, res.Query, c.WithItems))
+		if len(res.StatusChanges) > 0 {
+			fmt.Println()
+			fmt.Print(formatOrderStatusChanges(res.StatusChanges))
+		}
 	}
 
-	fmt.Print(formatOrdersText(orders, total, "", c.WithItems))
-	return nil
+	events := make([]notify.Event, 0, len(res.StatusChanges))
+	for _, change := range res.StatusChanges {
+		events = append(events, orderStatusEvent(change))
+	}
+	// The change is saved already, so a lost notification has to fail the run
+	return publish(g, events...)
 }
 
 // === QUICKBUY ===
 
 type QuickbuyCmd struct {
-	ProductIDs []int    `arg:"" name:"product-id" help:"Product ID to order (only 1 supported)"`
-	Quantity   int      `help:"Quantity" default:"1" short:"q"`
-	Yes        bool     `help:"Skip countdown (DANGEROUS!)" short:"y"`
-	DryRun     bool     `help:"Simulate only, don't actually order" name:"dry-run"`
-	QuoteOnly  bool     `help:"Get price quote only (no order)" name:"quote"`
-	Timeout    int      `help:"Countdown seconds before ordering" default:"10" short:"t"`
-	AlzaBoxID  int      `help:"AlzaBox location ID (required unless --dry-run)" env:"ALZA_QUICKBUY_ALZABOX_ID"`
-	DeliveryID int      `help:"Delivery type ID (required unless --dry-run)" env:"ALZA_QUICKBUY_DELIVERY_ID"`
-	PaymentID  string   `help:"Payment method ID (required unless --dry-run)" env:"ALZA_QUICKBUY_PAYMENT_ID"`
-	CardID     string   `help:"Saved card ID (required unless --dry-run)" env:"ALZA_QUICKBUY_CARD_ID"`
-	VisitorID  string   `help:"Device fingerprint/visitor ID (required unless --dry-run)" env:"ALZA_QUICKBUY_VISITOR_ID"`
-	AlzaPlus   bool     `help:"Use AlzaPlus+ pricing" env:"ALZA_QUICKBUY_ALZAPLUS"`
-	Coupons    []string `help:"Promo code(s), comma-separated or repeated" name:"coupon" sep:"," env:"ALZA_QUICKBUY_COUPON"`
-	NoCoupon   bool     `help:"Explicitly proceed without coupon" name:"no-coupon"`
+	Products   []client.ProductRef `arg:"" name:"product" help:"Product ID, URL, Alza code or EAN to order (only 1 supported)"`
+	Quantity   int                 `help:"Quantity" default:"1" short:"q"`
+	Yes        bool                `help:"Skip countdown (DANGEROUS!)" short:"y"`
+	DryRun     bool                `help:"Simulate only, don't actually order" name:"dry-run"`
+	QuoteOnly  bool                `help:"Get price quote only (no order)" name:"quote"`
+	Timeout    int                 `help:"Countdown seconds before ordering" default:"10" short:"t"`
+	AlzaBoxID  int                 `help:"AlzaBox location ID (required unless --dry-run)" env:"ALZA_QUICKBUY_ALZABOX_ID"`
+	DeliveryID int                 `help:"Delivery type ID (required unless --dry-run)" env:"ALZA_QUICKBUY_DELIVERY_ID"`
+	PaymentID  string              `help:"Payment method ID (required unless --dry-run)" env:"ALZA_QUICKBUY_PAYMENT_ID"`
+	CardID     string              `help:"Saved card ID (required unless --dry-run)" env:"ALZA_QUICKBUY_CARD_ID"`
+	VisitorID  string              `help:"Device fingerprint/visitor ID (required unless --dry-run)" env:"ALZA_QUICKBUY_VISITOR_ID"`
+	AlzaPlus   bool                `help:"Use AlzaPlus+ pricing" env:"ALZA_QUICKBUY_ALZAPLUS"`
+	Coupons    []string            `help:"Promo code(s), comma-separated or repeated" name:"coupon" sep:"," env:"ALZA_QUICKBUY_COUPON"`
+	NoCoupon   bool                `help:"Explicitly proceed without coupon" name:"no-coupon"`
 }
 
 func buildQuickbuyConfig(cmd *QuickbuyCmd, envCfg client.QuickBuyConfig) client.QuickBuyConfig {
@@ -1141,35 +1760,51 @@ func buildQuickbuyConfig(cmd *QuickbuyCmd, envCfg client.QuickBuyConfig) client.
 	return config
 }
 
-func (c *QuickbuyCmd) Run(g *Globals) error {
-	// Validate single product - quickbuy only supports 1 product at a time
-	if len(c.ProductIDs) == 0 {
-		return fmt.Errorf("product ID is required")
-	}
-	if len(c.ProductIDs) > 1 {
-		return fmt.Errorf("quickbuy supports only 1 product at a time\nFor multiple products, run quickbuy separately for each or use cart")
+// config merges the flags with the profile's quickbuy.env and enforces the coupon rule
+func (c *QuickbuyCmd) config(g *Globals) (client.QuickBuyConfig, error) {
+	account, err := g.accountProfile()
+	if err != nil {
+		return client.QuickBuyConfig{}, err
 	}
-	productID := c.ProductIDs[0]
-
-	// Load env config first (before auth) to validate coupon requirement
-	envCfg, err := client.QuickbuyConfigFromEnvFile("")
+	envCfg, err := client.QuickbuyConfigFromEnvFile(account.QuickbuyEnvPath())
 	if err != nil {
-		return err
+		return client.QuickBuyConfig{}, err
 	}
 
 	config := buildQuickbuyConfig(c, envCfg)
 
 	// Coupon is required unless --no-coupon is explicitly set (not for dry-run)
 	if len(config.PromoCodes) == 0 && !c.NoCoupon && !c.DryRun {
-		return fmt.Errorf("coupon is required\nUse --coupon <CODE> or --no-coupon to proceed without discount")
+		return client.QuickBuyConfig{}, fmt.Errorf("coupon is required\nUse --coupon <CODE> or --no-coupon to proceed without discount")
 	}
 
 	if err := config.Validate(); err != nil {
-		return fmt.Errorf("%w\nSet required flags or env vars: ALZA_QUICKBUY_ALZABOX_ID, ALZA_QUICKBUY_DELIVERY_ID, ALZA_QUICKBUY_PAYMENT_ID, ALZA_QUICKBUY_CARD_ID, ALZA_QUICKBUY_VISITOR_ID", err)
+		return client.QuickBuyConfig{}, fmt.Errorf("%w\nSet required flags or env vars: ALZA_QUICKBUY_ALZABOX_ID, ALZA_QUICKBUY_DELIVERY_ID, ALZA_QUICKBUY_PAYMENT_ID, ALZA_QUICKBUY_CARD_ID, ALZA_QUICKBUY_VISITOR_ID", err)
+	}
+	return config, nil
+}
+
+func (c *QuickbuyCmd) Run(g *Globals) error {
+	// Validate single product - quickbuy only supports 1 product at a time
+	if len(c.Products) == 0 {
+		return fmt.Errorf("product ID is required")
+	}
+	if len(c.Products) > 1 {
+		return fmt.Errorf("quickbuy supports only 1 product at a time\nFor multiple products, run quickbuy separately for each or use cart")
+	}
+
+	// Load env config first (before auth) to validate coupon requirement
+	config, err := c.config(g)
+	if err != nil {
+		return err
 	}
 
 	// Create client (requires auth)
-	cl, err := newClientWithAutoRefresh(g)
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	productID, err := cl.ResolveProductContext(g.Context(), c.Products[0])
 	if err != nil {
 		return err
 	}
@@ -1211,38 +1846,41 @@ func (c *QuickbuyCmd) Run(g *Globals) error {
 		cancelled := make(chan bool, 1)
 
 		// Listen for Enter key to cancel
+		reader := bufio.NewReader(os.Stdin)
 		go func() {
-			reader := bufio.NewReader(os.Stdin)
 			reader.ReadString('\n')
 			cancelled <- true
 		}()
 
+		ctx := g.Context()
 		for i := c.Timeout; i > 0; i-- {
+			// Build progress bar
+			progress := c.Timeout - i
+			total := c.Timeout
+			barWidth := 40
+			filled := (progress * barWidth) / total
+			bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+
+			// Color based on urgency
+			var emoji string
+			if i <= 3 {
+				emoji = "🔴"
+			} else if i <= 6 {
+				emoji = "🟡"
+			} else {
+				emoji = "🟢"
+			}
+
+			fmt.Printf("\r  %s Objednávka za %2d sekúnd [%s] (Enter = zrušiť)", emoji, i, bar)
+
 			select {
 			case <-cancelled:
 				fmt.Println("\n\n❌ ZRUŠENÉ používateľom")
 				return nil
-			default:
-				// Build progress bar
-				progress := c.Timeout - i
-				total := c.Timeout
-				barWidth := 40
-				filled := (progress * barWidth) / total
-				bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
-
-				// Color based on urgency
-				var emoji string
-				if i <= 3 {
-					emoji = "🔴"
-				} else if i <= 6 {
-					emoji = "🟡"
-				} else {
-					emoji = "🟢"
-				}
-
-				fmt.Printf("\r  %s Objednávka za %2d sekúnd [%s] (Enter = zrušiť)", emoji, i, bar)
-
-				time.Sleep(1 * time.Second)
+			case <-ctx.Done():
+				fmt.Println("\n\n❌ ZRUŠENÉ (Ctrl+C)")
+				return ctx.Err()
+			case <-time.After(1 * time.Second):
 			}
 		}
 		fmt.Println()
@@ -1255,7 +1893,11 @@ func (c *QuickbuyCmd) Run(g *Globals) error {
 		fmt.Println("⏳ Vytváram objednávku...")
 	}
 
-	result, err := cl.QuickBuy(productID, c.Quantity, config)
+	result, err := cl.QuickBuyContext(g.Context(), productID, c.Quantity, config)
+	if !c.DryRun && !c.QuoteOnly {
+		// The order is placed (or not) either way; a failed notification only warns
+		publishOrWarn(g, quickbuyEvent(productID, c.Quantity, result, err, cl.Storefront()))
+	}
 	if err != nil {
 		return fmt.Errorf("quickbuy failed: %w", err)
 	}
@@ -1278,7 +1920,7 @@ func (c *QuickbuyCmd) Run(g *Globals) error {
 	} else {
 		fmt.Printf("║  Číslo objednávky: %-40s ║\n", result.OrderID)
 	}
-	fmt.Printf("║  Celková suma:     %.2f €%s ║\n", result.TotalPrice, strings.Repeat(" ", 34-len(fmt.Sprintf("%.2f", result.TotalPrice))))
+	fmt.Printf("║  Celková suma:     %-40s ║\n", fmt.Sprintf("%.2f %s", result.TotalPrice, cl.Storefront().CurrencySymbol))
 	if !c.QuoteOnly {
 		fmt.Println("║                                                           ║")
 		fmt.Println("║  Doručenie: AlzaBox Žilina - Obvodová (Tesco)             ║")
@@ -1289,8 +1931,39 @@ func (c *QuickbuyCmd) Run(g *Globals) error {
 	return nil
 }
 
+// quickbuyNotice is the data of quickbuy.ordered and quickbuy.failed events
+type quickbuyNotice struct {
+	ProductID  int     `json:"productId"`
+	Quantity   int     `json:"quantity"`
+	OrderID    string  `json:"orderId,omitempty"`
+	TotalPrice float64 `json:"totalPrice,omitempty"`
+	Currency   string  `json:"currency"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// quickbuyEvent is the notification for a real (not --dry-run or --quote) quickbuy
+func quickbuyEvent(productID, quantity int, result *client.QuickBuyResult, err error, sf client.Storefront) notify.Event {
+	notice := quickbuyNotice{ProductID: productID, Quantity: quantity, Currency: sf.Currency}
+	if err != nil {
+		notice.Error = err.Error()
+		return notify.Event{
+			Type:    notify.EventQuickbuyFailed,
+			Title:   fmt.Sprintf("Quickbuy of %d failed", productID),
+			Message: err.Error(),
+			Data:    notice,
+		}
+	}
+	notice.OrderID, notice.TotalPrice = result.OrderID, result.TotalPrice
+	return notify.Event{
+		Type:    notify.EventQuickbuyOrdered,
+		Title:   "Order #" + result.OrderID,
+		Message: fmt.Sprintf("Ordered %dx product %d for %.2f %s", quantity, productID, result.TotalPrice, sf.CurrencySymbol),
+		Data:    notice,
+	}
+}
+
 func main() {
-	ctx := kong.Parse(&CLI,
+	kctx := kong.Parse(&CLI,
 		kong.Name("alza"),
 		kong.Description("CLI for Alza.sk - search products, manage cart and favorites"),
 		kong.UsageOnError(),
@@ -1301,9 +1974,28 @@ func main() {
 		kong.Vars{"version": client.Version},
 	)
 
-	err := ctx.Run(&CLI.Globals)
+	// Ctrl+C cancels in-flight requests; a second Ctrl+C kills the process as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	CLI.Globals.ctx = ctx
+
+	err := kctx.Run(&CLI.Globals)
+	stop()
+	if closeErr := CLI.Globals.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+			os.Exit(130)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, client.ErrChallenge) {
+			fmt.Fprintln(os.Stderr, "Alza (Cloudflare) vyžaduje overenie prehliadača. Otvor alza v Chrome, počkaj chvíľu a skús `alza token refresh`.")
+		}
 		os.Exit(1)
 	}
 
@@ -1,6 +1,19 @@
 package main
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+	"github.com/kuringer/alza-cli/internal/chromecookies"
+)
 
 func TestRenderStars(t *testing.T) {
 	tests := []struct {
@@ -33,3 +46,277 @@ func TestRenderStars(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveStorefrontPrefersFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := resolveStorefront(&Globals{Country: "cz"})
+	if err != nil {
+		t.Fatalf("resolveStorefront() error: %v", err)
+	}
+	if store.Domain != "www.alza.cz" {
+		t.Errorf("Domain = %q, want www.alza.cz", store.Domain)
+	}
+}
+
+func TestResolveStorefrontReadsConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".config", "alza")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.env"), []byte("ALZA_COUNTRY=HU\n"), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	store, err := resolveStorefront(&Globals{})
+	if err != nil {
+		t.Fatalf("resolveStorefront() error: %v", err)
+	}
+	if store.Country != "HU" {
+		t.Errorf("Country = %q, want HU", store.Country)
+	}
+
+	// Flag wins over config file
+	store, err = resolveStorefront(&Globals{Country: "AT"})
+	if err != nil {
+		t.Fatalf("resolveStorefront() error: %v", err)
+	}
+	if store.Country != "AT" {
+		t.Errorf("Country = %q, want AT", store.Country)
+	}
+}
+
+func TestResolveStorefrontDefaultsToSK(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := resolveStorefront(&Globals{})
+	if err != nil {
+		t.Fatalf("resolveStorefront() error: %v", err)
+	}
+	if store.Country != "SK" {
+		t.Errorf("Country = %q, want SK", store.Country)
+	}
+}
+
+func TestResolveStorefrontRejectsUnknownCountry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := resolveStorefront(&Globals{Country: "PL"}); err == nil {
+		t.Fatal("expected error for unsupported country")
+	}
+}
+
+func TestGlobalsContextDefaultsToBackground(t *testing.T) {
+	g := &Globals{}
+	if g.Context() == nil {
+		t.Fatal("Context() returned nil")
+	}
+	if g.Context().Err() != nil {
+		t.Fatalf("Context() unexpectedly done: %v", g.Context().Err())
+	}
+}
+
+func TestRateLimitFromGlobals(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	limit, err := rateLimit(&Globals{RateLimit: 5})
+	if err != nil {
+		t.Fatalf("rateLimit() error: %v", err)
+	}
+	if limit.PerSecond != 5 || limit.StateDir != "" {
+		t.Errorf("rateLimit() = %+v, want 5 req/s, no shared state", limit)
+	}
+
+	limit, err = rateLimit(&Globals{RateLimit: 1, SharedRateLimit: true})
+	if err != nil {
+		t.Fatalf("rateLimit() error: %v", err)
+	}
+	if want := filepath.Join(home, ".config", "alza", "ratelimit"); limit.StateDir != want {
+		t.Errorf("StateDir = %q, want %q", limit.StateDir, want)
+	}
+}
+
+func TestRetryPolicyFromGlobals(t *testing.T) {
+	if got := retryPolicy(&Globals{Retries: 1}).MaxAttempts; got != 1 {
+		t.Errorf("MaxAttempts = %d, want 1", got)
+	}
+	if got := retryPolicy(&Globals{}).MaxAttempts; got != 3 {
+		t.Errorf("MaxAttempts with unset flag = %d, want default 3", got)
+	}
+}
+
+func TestOpenClientReplayNeedsNoToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	g := &Globals{Replay: filepath.Join("client", "testdata", "replay_session.jsonl")}
+	cl, err := newClient(g)
+	if err != nil {
+		t.Fatalf("newClient() in replay mode error: %v", err)
+	}
+	if cl.GetUserID() != "42" {
+		t.Errorf("GetUserID() = %q, want 42 from the recording", cl.GetUserID())
+	}
+}
+
+func TestGlobalsRecorderIsSharedAndClosed(t *testing.T) {
+	g := &Globals{Record: filepath.Join(t.TempDir(), "trace.jsonl")}
+
+	first, err := g.recorder()
+	if err != nil {
+		t.Fatalf("recorder() error: %v", err)
+	}
+	second, err := g.recorder()
+	if err != nil {
+		t.Fatalf("recorder() error: %v", err)
+	}
+	if first != second {
+		t.Error("recorder() should reuse the open file")
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Close() error: %v", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("second Close() error: %v", err)
+	}
+	if _, err := os.Stat(g.Record); err != nil {
+		t.Errorf("record file not created: %v", err)
+	}
+}
+
+func TestTokenRefresherFromGlobals(t *testing.T) {
+	store := client.DefaultStorefront()
+	tests := []struct {
+		name    string
+		g       Globals
+		want    bool
+		wantErr bool
+	}{
+		{"default is chrome", Globals{}, true, false},
+		{"chrome", Globals{RefreshFrom: "chrome"}, true, false},
+		{"ssh host", Globals{RefreshFrom: "ssh:nas"}, true, false},
+		{"off", Globals{RefreshFrom: "off"}, false, false},
+		{"replay never refreshes", Globals{RefreshFrom: "chrome", Replay: "trace.jsonl"}, false, false},
+		{"ssh without host", Globals{RefreshFrom: "ssh:"}, false, true},
+		{"firefox", Globals{RefreshFrom: "firefox"}, true, false},
+		{"brave", Globals{RefreshFrom: "brave"}, true, false},
+		{"saved cookie jar", Globals{RefreshFrom: "cookies"}, true, false},
+		{"token serve over https", Globals{RefreshFrom: "https://broker:8443"}, true, false},
+		{"token serve over unix socket", Globals{RefreshFrom: "unix:/run/alza/token.sock"}, true, false},
+		{"unknown", Globals{RefreshFrom: "netscape"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tokenRefresher(&tt.g, store)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenRefresher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (r != nil) != tt.want {
+				t.Errorf("tokenRefresher() = %v, want refresher: %v", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRefresherWithoutSecureStore(t *testing.T) {
+	// --token-store auto on a headless box: no secret-tool, no passphrase
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("ALZA_TOKEN_PASSPHRASE", "")
+	t.Setenv("ALZA_TOKEN_PASSPHRASE_FILE", "")
+	t.Setenv("ALZA_BROKER_SECRET_FILE", "")
+	t.Setenv("ALZA_PROFILE", "")
+
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	b := newTestBroker(time.Now(), func(context.Context) (string, error) { return token, nil })
+	b.load(token)
+	srv := httptest.NewServer(b.handler())
+	defer srv.Close()
+
+	g := &Globals{RefreshFrom: srv.URL, TokenStore: "auto"}
+	if err := saveToken(context.Background(), g, token); !errors.Is(err, client.ErrNoSecureStore) {
+		t.Fatalf("saveToken() error = %v, want ErrNoSecureStore", err)
+	}
+	r, err := tokenRefresher(g, client.DefaultStorefront())
+	if err != nil || r == nil {
+		t.Fatalf("tokenRefresher() = %v, %v", r, err)
+	}
+	got, err := r.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if got != token {
+		t.Errorf("Refresh() = %q, want the refreshed token", got)
+	}
+}
+
+func TestFormatTokenStatus(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	claims, err := client.ParseTokenClaims(alzatest.JWT(now.Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("ParseTokenClaims() error: %v", err)
+	}
+
+	out := formatTokenStatus("/tmp/auth_token.txt", claims, now, 5*time.Minute)
+	for _, want := range []string{"User ID:  1234567", "Client:   alza-web", "(30m0s ago)", "(in 1h0m0s)", "openid, profile, alza_api", "✓ valid"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Subject:") {
+		t.Errorf("numeric subject repeated next to User ID:\n%s", out)
+	}
+
+	if out := formatTokenStatus("", claims, now.Add(57*time.Minute), 5*time.Minute); !strings.Contains(out, "⚠ expires soon") {
+		t.Errorf("status within margin:\n%s", out)
+	}
+	if out := formatTokenStatus("", claims, now.Add(2*time.Hour), 5*time.Minute); !strings.Contains(out, "(1h0m0s ago)") || !strings.Contains(out, "✗ expired") {
+		t.Errorf("status after expiry:\n%s", out)
+	}
+	if out := formatTokenStatus("", &client.TokenClaims{}, now, 5*time.Minute); !strings.Contains(out, "no exp claim") || !strings.Contains(out, "✓ valid") {
+		t.Errorf("status without exp:\n%s", out)
+	}
+}
+
+func TestFormatTokenRefreshErrorNamesBrowser(t *testing.T) {
+	store := client.DefaultStorefront()
+	err := formatTokenRefreshError(errors.New("chromecookies: No Cookies DB found under /x"), store, chromecookies.BrowserFirefox, "", "")
+	for _, want := range []string{"1) Open Firefox and sign in to https://www.alza.sk/", "2) Run: alza token refresh --browser firefox", "Original error:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+
+	plain := errors.New("HTTP 500")
+	if got := formatTokenRefreshError(plain, store, chromecookies.BrowserChrome, "Default", ""); got != plain {
+		t.Errorf("non-login error was rewritten: %v", got)
+	}
+}
+
+func TestDefaultBrowserProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for browser, want := range map[chromecookies.Browser]string{
+		chromecookies.BrowserChrome:  "Default",
+		chromecookies.BrowserFirefox: "",
+		chromecookies.BrowserBrave:   "",
+	} {
+		if got, err := defaultBrowserProfile(browser); err != nil || got != want {
+			t.Errorf("defaultBrowserProfile(%s) = %q, %v; want %q", browser, got, err, want)
+		}
+	}
+}
+
+func TestFormatProfiles(t *testing.T) {
+	out := formatProfiles([]profileInfo{
+		{Name: "default", Dir: "/home/u/.config/alza"},
+		{Name: "firma", Dir: "/home/u/.config/alza/profiles/firma", Current: true, ProfileSettings: client.ProfileSettings{Country: "CZ", ChromeProfile: "Profile 2"}},
+	})
+	want := "  default      /home/u/.config/alza\n" +
+		"* firma        /home/u/.config/alza/profiles/firma  country=CZ  chrome-profile=\"Profile 2\"\n"
+	if out != want {
+		t.Errorf("formatProfiles() =\n%s\nwant\n%s", out, want)
+	}
+}
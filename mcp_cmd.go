@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/internal/mcp"
+)
+
+// McpCmd serves the client as Model Context Protocol tools over stdio
+type McpCmd struct {
+	AllowPurchase bool `help:"Expose the quickbuy tool that places real orders (WILL CHARGE YOUR CARD!)" env:"ALZA_MCP_ALLOW_PURCHASE"`
+}
+
+const mcpInstructions = `Alza.sk e-shop of the logged-in user. Prices are in the storefront currency. ` +
+	`Use search to find product IDs, then product/reviews for details. ` +
+	`cart_* and list_add change the user's account. quickbuy_quote prices an order without placing it.`
+
+func (c *McpCmd) Run(g *Globals, kctx *kong.Context) error {
+	// stdout carries the protocol; the client's debug output goes to stderr
+	// like the token refresh messages
+	g.debugOut = os.Stderr
+	quickbuy, err := quickbuyFlags(kctx)
+	if err != nil {
+		return err
+	}
+
+	// Tool calls run concurrently; resolve what Globals caches lazily up front
+	if _, err := g.accountProfile(); err != nil {
+		return err
+	}
+	_, _ = g.tokenStore()
+
+	err = newMCPServer(g, c.AllowPurchase, quickbuy).Serve(g.Context(), os.Stdin, os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// mcpSession opens the client on the first tool call, so the server starts
+// (and lists its tools) without a valid token. Tool calls run concurrently and
+// share the client, which caches the user and basket IDs without locking:
+// like serve's apiServer, tools that may set them (and every cart operation,
+// which reads the basket and then changes it) hold session exclusively.
+type mcpSession struct {
+	g             *Globals
+	quickbuyFlags QuickbuyCmd // As parsed, with the ALZA_QUICKBUY_* variables applied
+
+	session sync.RWMutex
+
+	mu sync.Mutex
+	cl *client.TLSClient
+}
+
+// shared holds the session alongside other shared tools; call the returned func to release it
+func (s *mcpSession) shared() func() {
+	s.session.RLock()
+	return s.session.RUnlock
+}
+
+// exclusive holds the session alone; call the returned func to release it
+func (s *mcpSession) exclusive() func() {
+	s.session.Lock()
+	return s.session.Unlock
+}
+
+func (s *mcpSession) client() (*client.TLSClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cl == nil {
+		cl, err := newClient(s.g)
+		if err != nil {
+			return nil, err
+		}
+		s.cl = cl
+	}
+	return s.cl, nil
+}
+
+type mcpProductArgs struct {
+	ProductID int `json:"productId" jsonschema:"Alza product ID (from search)"`
+}
+
+type mcpSearchArgs struct {
+	Query string `json:"query" jsonschema:"Search phrase"`
+	Limit int    `json:"limit,omitempty" jsonschema:"Max results (default 10)"`
+}
+
+type searchResult struct {
+	Results []client.SearchResult `json:"results"`
+}
+
+type mcpReviewsArgs struct {
+	ProductID int  `json:"productId" jsonschema:"Alza product ID"`
+	Limit     int  `json:"limit,omitempty" jsonschema:"Number of reviews (default 10)"`
+	Offset    int  `json:"offset,omitempty" jsonschema:"Skip the first N reviews"`
+	StatsOnly bool `json:"statsOnly,omitempty" jsonschema:"Only rating stats, no reviews"`
+}
+
+type reviewsResult struct {
+	Stats   *client.ReviewStats     `json:"stats"`
+	Reviews *client.ReviewsResponse `json:"reviews,omitempty"`
+}
+
+type mcpCartAddArgs struct {
+	ProductID int `json:"productId" jsonschema:"Alza product ID"`
+	Quantity  int `json:"quantity,omitempty" jsonschema:"Quantity (default 1)"`
+}
+
+type cartResult struct {
+	Items []client.CartItem `json:"items"`
+}
+
+type listsResult struct {
+	Lists []client.CommodityList `json:"lists"`
+}
+
+type mcpListArgs struct {
+	ListID int `json:"listId" jsonschema:"Commodity list ID (from lists)"`
+}
+
+type listItemsResult struct {
+	Items []client.ListItem `json:"items"`
+}
+
+type mcpListAddArgs struct {
+	ListID    int `json:"listId" jsonschema:"Commodity list ID (from lists)"`
+	ProductID int `json:"productId" jsonschema:"Alza product ID"`
+}
+
+type mcpOrdersArgs struct {
+	Limit     int    `json:"limit,omitempty" jsonschema:"Max orders (default 10)"`
+	WithItems bool   `json:"withItems,omitempty" jsonschema:"Include item lines"`
+	Query     string `json:"query,omitempty" jsonschema:"Only archived orders with an item name containing this"`
+}
+
+type mcpQuoteArgs struct {
+	ProductID int      `json:"productId" jsonschema:"Alza product ID"`
+	Quantity  int      `json:"quantity,omitempty" jsonschema:"Quantity (default 1)"`
+	Coupons   []string `json:"coupons,omitempty" jsonschema:"Promo codes (default ALZA_QUICKBUY_COUPON from quickbuy.env)"`
+	NoCoupon  bool     `json:"noCoupon,omitempty" jsonschema:"Explicitly proceed without a coupon; one is required otherwise"`
+}
+
+type mcpQuickbuyArgs struct {
+	mcpQuoteArgs
+	Confirm bool `json:"confirm" jsonschema:"Must be true: the user confirmed this exact order in the conversation"`
+	DryRun  bool `json:"dryRun,omitempty" jsonschema:"Simulate only, nothing is ordered"`
+}
+
+// newMCPServer registers one tool per CLI command; the purchasing quickbuy
+// tool only with allowPurchase
+func newMCPServer(g *Globals, allowPurchase bool, quickbuy QuickbuyCmd) *mcp.Server {
+	s := &mcpSession{g: g, quickbuyFlags: quickbuy}
+	srv := mcp.NewServer("alza", client.Version, mcpInstructions)
+
+	readOnly := &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: true}
+	write := &mcp.ToolAnnotations{OpenWorldHint: true}
+	add := func(t mcp.Tool, title string, hints *mcp.ToolAnnotations) {
+		t.Title, t.Annotations = title, hints
+		srv.AddTool(t)
+	}
+
+	add(mcp.NewTool("search", "Search products by name", func(ctx context.Context, in mcpSearchArgs) (searchResult, error) {
+		defer s.shared()()
+		cl, err := s.client()
+		if err != nil {
+			return searchResult{}, err
+		}
+		if in.Limit <= 0 {
+			in.Limit = 10
+		}
+		results, err := cl.SearchContext(ctx, in.Query, in.Limit)
+		return searchResult{Results: results}, err
+	}), "Search", readOnly)
+
+	add(mcp.NewTool("product", "Product detail: price, promos, availability, parameters, variants, rating", func(ctx context.Context, in mcpProductArgs) (*client.ProductDetail, error) {
+		defer s.exclusive()() // Availability looks up the user ID when it isn't known yet
+		cl, err := s.client()
+		if err != nil {
+			return nil, err
+		}
+		return cl.GetProductContext(ctx, in.ProductID)
+	}), "Product detail", readOnly)
+
+	add(mcp.NewTool("reviews", "Product rating stats and user reviews", func(ctx context.Context, in mcpReviewsArgs) (reviewsResult, error) {
+		defer s.shared()()
+		cl, err := s.client()
+		if err != nil {
+			return reviewsResult{}, err
+		}
+		stats, err := cl.GetReviewStatsContext(ctx, in.ProductID)
+		if err != nil {
+			return reviewsResult{}, fmt.Errorf("failed to fetch review stats: %w", err)
+		}
+		res := reviewsResult{Stats: stats}
+		if !in.StatsOnly {
+			if in.Limit <= 0 {
+				in.Limit = 10
+			}
+			if res.Reviews, err = cl.GetReviewsContext(ctx, in.ProductID, in.Offset, in.Limit); err != nil {
+				return reviewsResult{}, err
+			}
+		}
+		return res, nil
+	}), "Reviews", readOnly)
+
+	add(mcp.NewTool("cart_show", "Items in the shopping cart", func(ctx context.Context, _ struct{}) (cartResult, error) {
+		defer s.exclusive()()
+		return s.cart(ctx)
+	}), "Show cart", readOnly)
+
+	add(mcp.NewTool("cart_add", "Add a product to the cart; returns the updated cart", func(ctx context.Context, in mcpCartAddArgs) (cartResult, error) {
+		defer s.exclusive()()
+		cl, err := s.client()
+		if err != nil {
+			return cartResult{}, err
+		}
+		if in.Quantity <= 0 {
+			in.Quantity = 1
+		}
+		if err := cl.AddToCartContext(ctx, in.ProductID, in.Quantity); err != nil {
+			return cartResult{}, err
+		}
+		return s.cart(ctx)
+	}), "Add to cart", write)
+
+	add(mcp.NewTool("cart_remove", "Remove a product from the cart; returns the updated cart", func(ctx context.Context, in mcpProductArgs) (cartResult, error) {
+		defer s.exclusive()()
+		cl, err := s.client()
+		if err != nil {
+			return cartResult{}, err
+		}
+		if err := cl.RemoveFromCartContext(ctx, in.ProductID); err != nil {
+			return cartResult{}, err
+		}
+		return s.cart(ctx)
+	}), "Remove from cart", &mcp.ToolAnnotations{DestructiveHint: true, IdempotentHint: true, OpenWorldHint: true})
+
+	add(mcp.NewTool("lists", "The user's commodity lists (favorites, custom lists)", func(ctx context.Context, _ struct{}) (listsResult, error) {
+		defer s.exclusive()() // Sets the user ID
+		cl, err := s.client()
+		if err != nil {
+			return listsResult{}, err
+		}
+		lists, err := cl.GetListsContext(ctx)
+		return listsResult{Lists: lists}, err
+	}), "Lists", readOnly)
+
+	add(mcp.NewTool("list_items", "Items of a commodity list", func(ctx context.Context, in mcpListArgs) (listItemsResult, error) {
+		defer s.shared()()
+		cl, err := s.client()
+		if err != nil {
+			return listItemsResult{}, err
+		}
+		items, err := cl.GetListItemsContext(ctx, in.ListID)
+		return listItemsResult{Items: items}, err
+	}), "List items", readOnly)
+
+	add(mcp.NewTool("list_add", "Add a product to a commodity list", func(ctx context.Context, in mcpListAddArgs) (listItemsResult, error) {
+		defer s.exclusive()()
+		cl, err := s.client()
+		if err != nil {
+			return listItemsResult{}, err
+		}
+		if err := cl.AddToListContext(ctx, in.ListID, in.ProductID); err != nil {
+			return listItemsResult{}, err
+		}
+		items, err := cl.GetListItemsContext(ctx, in.ListID)
+		return listItemsResult{Items: items}, err
+	}), "Add to list", &mcp.ToolAnnotations{IdempotentHint: true, OpenWorldHint: true})
+
+	add(mcp.NewTool("orders", "Order history, or archived orders containing an item", func(ctx context.Context, in mcpOrdersArgs) (ordersResult, error) {
+		defer s.exclusive()()
+		cl, err := s.client()
+		if err != nil {
+			return ordersResult{}, err
+		}
+		return fetchOrders(ctx, cl, in.Limit, in.WithItems, in.Query)
+	}), "Orders", readOnly)
+
+	add(mcp.NewTool("quickbuy_quote", "Price of a quickbuy order to the configured AlzaBox (nothing is ordered)", func(ctx context.Context, in mcpQuoteArgs) (*client.QuickBuyResult, error) {
+		defer s.exclusive()()
+		cmd := in.quickbuyCmd(s.quickbuyFlags)
+		cmd.QuoteOnly = true
+		return s.quickbuy(ctx, cmd)
+	}), "Quickbuy quote", &mcp.ToolAnnotations{IdempotentHint: true, OpenWorldHint: true})
+
+	if allowPurchase {
+		add(mcp.NewTool("quickbuy", "Order and pay for a product with the saved card (REAL PURCHASE). Quote first and ask the user to confirm the price.", func(ctx context.Context, in mcpQuickbuyArgs) (*client.QuickBuyResult, error) {
+			defer s.exclusive()()
+			if !in.Confirm && !in.DryRun {
+				return nil, fmt.Errorf("quickbuy needs confirm: true after the user confirmed the order")
+			}
+			cmd := in.quickbuyCmd(s.quickbuyFlags)
+			cmd.DryRun, cmd.Yes = in.DryRun, true
+			return s.quickbuy(ctx, cmd)
+		}), "Quickbuy (charges the card)", &mcp.ToolAnnotations{DestructiveHint: true, OpenWorldHint: true})
+	}
+	return srv
+}
+
+func (s *mcpSession) cart(ctx context.Context) (cartResult, error) {
+	cl, err := s.client()
+	if err != nil {
+		return cartResult{}, err
+	}
+	items, err := cl.GetCartContext(ctx)
+	return cartResult{Items: items}, err
+}
+
+// quickbuy applies the same quickbuy.env, coupon and config rules as `alza quickbuy`
+func (s *mcpSession) quickbuy(ctx context.Context, cmd QuickbuyCmd) (*client.QuickBuyResult, error) {
+	config, err := cmd.config(s.g)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	productID, err := cl.ResolveProductContext(ctx, cmd.Products[0])
+	if err != nil {
+		return nil, err
+	}
+	result, err := cl.QuickBuyContext(ctx, productID, cmd.Quantity, config)
+	if !cmd.DryRun && !cmd.QuoteOnly {
+		publishOrWarn(s.g, quickbuyEvent(productID, cmd.Quantity, result, err, cl.Storefront()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("quickbuy failed: %w", err)
+	}
+	return result, nil
+}
+
+// quickbuyFlags returns the `alza quickbuy` flags kong parsed alongside the
+// selected command: their ALZA_QUICKBUY_* variables are applied even though
+// quickbuy itself wasn't run
+func quickbuyFlags(kctx *kong.Context) (QuickbuyCmd, error) {
+	for _, node := range kctx.Model.Children {
+		if cmd, ok := node.Target.Addr().Interface().(*QuickbuyCmd); ok {
+			return *cmd, nil
+		}
+	}
+	return QuickbuyCmd{}, errors.New("quickbuy command not found")
+}
+
+// quickbuyCmd applies the tool arguments to the parsed quickbuy flags;
+// coupons fall back to ALZA_QUICKBUY_COUPON
+func (in mcpQuoteArgs) quickbuyCmd(flags QuickbuyCmd) QuickbuyCmd {
+	cmd := flags
+	cmd.Products = []client.ProductRef{{ID: in.ProductID}}
+	cmd.Quantity = max(in.Quantity, 1)
+	cmd.NoCoupon = in.NoCoupon
+	if len(in.Coupons) > 0 {
+		cmd.Coupons = in.Coupons
+	}
+	return cmd
+}
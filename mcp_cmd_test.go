@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/internal/mcp"
+)
+
+type mcpTestResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// runMCP feeds requests to `alza mcp` on stdin and returns the responses by
+// request ID. Tool calls run concurrently, so one session shouldn't depend on
+// the order of its calls.
+func runMCP(t *testing.T, args []string, requests ...string) map[string]mcpTestResponse {
+	t.Helper()
+	in := filepath.Join(t.TempDir(), "requests.jsonl")
+	if err := os.WriteFile(in, []byte(strings.Join(requests, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	out := mustRunCLI(t, append([]string{"mcp"}, args...)...)
+	responses := map[string]mcpTestResponse{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var msg struct {
+			ID json.RawMessage `json:"id"`
+			mcpTestResponse
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("bad MCP output line %q: %v", line, err)
+		}
+		responses[string(msg.ID)] = msg.mcpTestResponse
+	}
+	return responses
+}
+
+func mcpCall(id int, tool, args string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, id, tool, args)
+}
+
+// toolResult decodes a tools/call result, failing on protocol errors
+func toolResult(t *testing.T, resp mcpTestResponse, structured any) mcp.CallToolResult {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("tools/call error: %+v", resp.Error)
+	}
+	var res struct {
+		mcp.CallToolResult
+		StructuredContent json.RawMessage `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(resp.Result, &res); err != nil {
+		t.Fatalf("decode result %s: %v", resp.Result, err)
+	}
+	if structured != nil && !res.IsError {
+		if err := json.Unmarshal(res.StructuredContent, structured); err != nil {
+			t.Fatalf("decode structuredContent %s: %v", res.StructuredContent, err)
+		}
+	}
+	return res.CallToolResult
+}
+
+func TestCLIMCPToolsList(t *testing.T) {
+	startFakeAlza(t)
+	list := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	names := func(resp mcpTestResponse) []string {
+		var res struct {
+			Tools []mcp.Tool `json:"tools"`
+		}
+		if err := json.Unmarshal(resp.Result, &res); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, tool := range res.Tools {
+			if tool.InputSchema == nil || tool.Annotations == nil {
+				t.Errorf("tool %s without input schema or annotations", tool.Name)
+			}
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	got := strings.Join(names(runMCP(t, nil, list)["1"]), " ")
+	want := "search product reviews cart_show cart_add cart_remove lists list_items list_add orders quickbuy_quote"
+	if got != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+
+	got = strings.Join(names(runMCP(t, []string{"--allow-purchase"}, list)["1"]), " ")
+	if !strings.HasSuffix(got, "quickbuy_quote quickbuy") {
+		t.Errorf("tools with --allow-purchase = %s, want quickbuy", got)
+	}
+}
+
+func TestCLIMCPReadTools(t *testing.T) {
+	startFakeAlza(t)
+	responses := runMCP(t, nil,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		mcpCall(2, "search", `{"query":"kávovar","limit":2}`),
+		mcpCall(3, "product", `{"productId":7816725}`),
+		mcpCall(4, "reviews", `{"productId":7816725,"statsOnly":true}`),
+		mcpCall(5, "lists", `{}`),
+		mcpCall(6, "orders", `{"limit":5}`),
+		mcpCall(7, "search", `{}`),
+	)
+
+	if !strings.Contains(string(responses["1"].Result), `"name":"alza"`) {
+		t.Errorf("initialize = %s", responses["1"].Result)
+	}
+
+	var search searchResult
+	toolResult(t, responses["2"], &search)
+	if len(search.Results) == 0 || len(search.Results) > 2 {
+		t.Errorf("search results = %+v", search.Results)
+	}
+
+	var product client.ProductDetail
+	toolResult(t, responses["3"], &product)
+	if product.ID != 7816725 || product.Name == "" {
+		t.Errorf("product = %+v", product)
+	}
+
+	var reviews reviewsResult
+	toolResult(t, responses["4"], &reviews)
+	if reviews.Stats == nil || reviews.Reviews != nil {
+		t.Errorf("reviews statsOnly = %+v", reviews)
+	}
+
+	var lists listsResult
+	toolResult(t, responses["5"], &lists)
+	if len(lists.Lists) == 0 {
+		t.Error("lists returned nothing")
+	}
+
+	var orders ordersResult
+	toolResult(t, responses["6"], &orders)
+	if orders.TotalCount == 0 || len(orders.Orders) == 0 {
+		t.Errorf("orders = %+v", orders)
+	}
+
+	if resp := responses["7"]; resp.Error == nil || resp.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("search without query = %+v, want invalid params", resp)
+	}
+}
+
+func TestCLIMCPCart(t *testing.T) {
+	srv := startFakeAlza(t)
+	responses := runMCP(t, nil, mcpCall(1, "cart_add", `{"productId":7816725,"quantity":2}`))
+	var cart cartResult
+	toolResult(t, responses["1"], &cart)
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != 7816725 || cart.Items[0].Count != 2 {
+		t.Errorf("cart after add = %+v", cart.Items)
+	}
+
+	responses = runMCP(t, nil, mcpCall(1, "cart_remove", `{"productId":7816725}`))
+	toolResult(t, responses["1"], &cart)
+	if len(cart.Items) != 0 || len(srv.Cart()) != 0 {
+		t.Errorf("cart after remove = %+v, server %+v", cart.Items, srv.Cart())
+	}
+}
+
+// schemaMismatch returns where the decoded JSON value v breaks schema s, "" if
+// nowhere. It checks types and required properties, which is what strict
+// clients of the MCP outputSchema and the OpenAPI document trip over.
+func schemaMismatch(s *mcp.Schema, v any, path string) string {
+	if s == nil || s.Type == "" {
+		return ""
+	}
+	ok := false
+	switch s.Type {
+	case "object":
+		_, ok = v.(map[string]any)
+	case "array":
+		_, ok = v.([]any)
+	case "string":
+		_, ok = v.(string)
+	case "boolean":
+		_, ok = v.(bool)
+	case "number":
+		_, ok = v.(float64)
+	case "integer":
+		f, isNumber := v.(float64)
+		ok = isNumber && f == float64(int64(f))
+	}
+	if !ok {
+		return fmt.Sprintf("%s: %v is not %s", path, v, s.Type)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Sprintf("%s.%s: required, missing", path, name)
+			}
+		}
+		for name, prop := range s.Properties {
+			if pv, ok := v[name]; ok {
+				if msg := schemaMismatch(prop, pv, path+"."+name); msg != "" {
+					return msg
+				}
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if msg := schemaMismatch(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+// checkSchema fails t when the JSON data doesn't match s
+func checkSchema(t *testing.T, s *mcp.Schema, data []byte) {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	if msg := schemaMismatch(s, v, "$"); msg != "" {
+		t.Errorf("%s doesn't match its schema: %s", data, msg)
+	}
+}
+
+func TestCLIMCPEmptyCartMatchesSchema(t *testing.T) {
+	startFakeAlza(t)
+	runMCP(t, nil, mcpCall(1, "cart_add", `{"productId":7816725}`))
+	responses := runMCP(t, nil,
+		mcpCall(1, "cart_remove", `{"productId":7816725}`),
+		mcpCall(2, "list_items", `{"listId":49098229}`), // The empty favorites list
+	)
+	var cart, items json.RawMessage
+	toolResult(t, responses["1"], &cart)
+	checkSchema(t, mcp.SchemaFor[cartResult](), cart)
+	toolResult(t, responses["2"], &items)
+	checkSchema(t, mcp.SchemaFor[listItemsResult](), items)
+
+	responses = runMCP(t, nil, mcpCall(1, "cart_show", `{}`))
+	toolResult(t, responses["1"], &cart)
+	checkSchema(t, mcp.SchemaFor[cartResult](), cart)
+}
+
+// TestCLIMCPConcurrentWrites overlaps cart and product calls on the shared
+// client; run with -race to catch unguarded user and basket ID writes
+func TestCLIMCPConcurrentWrites(t *testing.T) {
+	srv := startFakeAlza(t)
+	var requests []string
+	for i := 1; i <= 12; i += 3 {
+		requests = append(requests,
+			mcpCall(i, "cart_add", `{"productId":7816725}`),
+			mcpCall(i+1, "product", `{"productId":12345678}`),
+			mcpCall(i+2, "cart_show", `{}`),
+		)
+	}
+	responses := runMCP(t, nil, requests...)
+	for id, resp := range responses {
+		if res := toolResult(t, resp, nil); res.IsError {
+			t.Errorf("call %s failed: %+v", id, res.Content)
+		}
+	}
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].Count != 4 {
+		t.Errorf("cart after 4 concurrent adds = %+v", cart)
+	}
+}
+
+func TestCLIMCPDebugGoesToStderr(t *testing.T) {
+	startFakeAlza(t)
+	errPath := filepath.Join(t.TempDir(), "stderr")
+	f, err := os.Create(errPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() { os.Stderr = stderr; _ = f.Close() })
+
+	// runMCP fails on any stdout line that isn't a JSON-RPC message
+	responses := runMCP(t, []string{"--debug"}, mcpCall(1, "cart_show", `{}`))
+	if res := toolResult(t, responses["1"], nil); res.IsError {
+		t.Errorf("cart_show = %+v", res)
+	}
+	if debug, _ := os.ReadFile(errPath); !strings.Contains(string(debug), "[DEBUG] GET ") {
+		t.Errorf("stderr = %q, want the debug output", debug)
+	}
+}
+
+func TestCLIMCPToolErrorWithoutToken(t *testing.T) {
+	startFakeAlza(t)
+	if err := os.Remove(filepath.Join(os.Getenv("HOME"), ".config", "alza", "auth_token.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := runMCP(t, nil,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		mcpCall(2, "cart_show", `{}`),
+	)
+	if responses["1"].Error != nil {
+		t.Errorf("tools/list without token = %+v", responses["1"].Error)
+	}
+	if res := toolResult(t, responses["2"], nil); !res.IsError {
+		t.Errorf("cart_show without token = %+v, want tool error", res)
+	}
+}
+
+func TestCLIMCPQuickbuy(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddCoupon("ZLAVA10", 10)
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "1009905")
+	t.Setenv("ALZA_QUICKBUY_DELIVERY_ID", "2680")
+	t.Setenv("ALZA_QUICKBUY_PAYMENT_ID", "216")
+	t.Setenv("ALZA_QUICKBUY_CARD_ID", "card-1")
+	t.Setenv("ALZA_QUICKBUY_VISITOR_ID", "visitor-1")
+
+	responses := runMCP(t, []string{"--allow-purchase"},
+		mcpCall(1, "quickbuy_quote", `{"productId":7816725}`),
+		mcpCall(2, "quickbuy_quote", `{"productId":7816725,"coupons":["ZLAVA10"]}`),
+		mcpCall(3, "quickbuy", `{"productId":7816725,"coupons":["ZLAVA10"],"confirm":false}`),
+		mcpCall(4, "quickbuy", `{"productId":7816725,"confirm":true}`),
+	)
+	if res := toolResult(t, responses["1"], nil); !res.IsError || !strings.Contains(res.Content[0].Text, "coupon is required") {
+		t.Errorf("quote without coupon = %+v, want coupon error", res)
+	}
+	var quote client.QuickBuyResult
+	toolResult(t, responses["2"], &quote)
+	if quote.TotalPrice == 0 {
+		t.Errorf("quote = %+v", quote)
+	}
+	if res := toolResult(t, responses["3"], nil); !res.IsError || !strings.Contains(res.Content[0].Text, "confirm") {
+		t.Errorf("quickbuy without confirm = %+v, want error", res)
+	}
+	if res := toolResult(t, responses["4"], nil); !res.IsError || !strings.Contains(res.Content[0].Text, "coupon is required") {
+		t.Errorf("quickbuy without coupon = %+v, want coupon error", res)
+	}
+	if payments := srv.Payments(); len(payments) != 0 {
+		t.Fatalf("Payments() = %+v, want none before a confirmed order", payments)
+	}
+
+	responses = runMCP(t, []string{"--allow-purchase"},
+		mcpCall(1, "quickbuy", `{"productId":7816725,"coupons":["ZLAVA10"],"confirm":true}`))
+	var order client.QuickBuyResult
+	toolResult(t, responses["1"], &order)
+	if order.OrderID == "" {
+		t.Errorf("quickbuy = %+v", order)
+	}
+	if payments := srv.Payments(); len(payments) != 1 || payments[0].CardID != "card-1" {
+		t.Errorf("Payments() = %+v, want one card payment", payments)
+	}
+
+	// Without --allow-purchase the tool doesn't exist
+	responses = runMCP(t, nil, mcpCall(1, "quickbuy", `{"productId":7816725,"coupons":["ZLAVA10"],"confirm":true}`))
+	if resp := responses["1"]; resp.Error == nil || resp.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("quickbuy without --allow-purchase = %+v, want unknown tool", resp)
+	}
+}
+
+func TestMCPQuoteArgsReadQuickbuyEnv(t *testing.T) {
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "1009905")
+	t.Setenv("ALZA_QUICKBUY_DELIVERY_ID", "2680")
+	t.Setenv("ALZA_QUICKBUY_ALZAPLUS", "true")
+	t.Setenv("ALZA_QUICKBUY_COUPON", "A,B")
+
+	parser, err := kong.New(newCLI(CLI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kctx, err := parser.Parse([]string{"mcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, err := quickbuyFlags(kctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := mcpQuoteArgs{ProductID: 1}.quickbuyCmd(flags)
+	if cmd.AlzaBoxID != 1009905 || cmd.DeliveryID != 2680 || !cmd.AlzaPlus || strings.Join(cmd.Coupons, ",") != "A,B" || cmd.Quantity != 1 || cmd.Products[0].ID != 1 {
+		t.Errorf("quickbuyCmd() = %+v", cmd)
+	}
+
+	cmd = mcpQuoteArgs{ProductID: 1, Coupons: []string{"C"}}.quickbuyCmd(flags)
+	if strings.Join(cmd.Coupons, ",") != "C" {
+		t.Errorf("explicit coupons = %v, want C", cmd.Coupons)
+	}
+
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "box")
+	if _, err := parser.Parse([]string{"mcp"}); err == nil {
+		t.Error("alza mcp with ALZA_QUICKBUY_ALZABOX_ID=box parsed")
+	}
+}
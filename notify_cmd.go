@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kuringer/alza-cli/internal/notify"
+)
+
+// NotifyCmd shows and tests the notification routes in the profile's notify.json
+type NotifyCmd struct {
+	Show NotifyShowCmd `cmd:"" default:"1" help:"Show the configured sinks and routes"`
+	Test NotifyTestCmd `cmd:"" help:"Publish a test event through the matching routes"`
+}
+
+// notifier loads the active profile's notify.json; without one events go nowhere
+func notifier(g *Globals) (*notify.Notifier, error) {
+	p, err := g.accountProfile()
+	if err != nil {
+		return nil, err
+	}
+	return notify.Load(p.NotifyConfigPath())
+}
+
+// publish sends events through the profile's notification routes. Every event
+// is tried; the error joins the failed deliveries.
+func publish(g *Globals, events ...notify.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	n, err := notifier(g)
+	if err != nil {
+		return err
+	}
+	p, err := g.accountProfile()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, ev := range events {
+		ev.Profile = p.Name
+		errs = append(errs, n.Publish(g.Context(), ev))
+	}
+	return errors.Join(errs...)
+}
+
+// publishOrWarn is publish for commands whose own work already succeeded:
+// a failed notification is reported on stderr and doesn't fail the command
+func publishOrWarn(g *Globals, events ...notify.Event) {
+	if err := publish(g, events...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+type NotifyShowCmd struct{}
+
+type notifyShowResult struct {
+	Path   string                       `json:"path"`
+	Sinks  map[string]notify.SinkConfig `json:"sinks"`
+	Routes []notify.Route               `json:"routes"`
+}
+
+func (c *NotifyShowCmd) Run(g *Globals) error {
+	p, err := g.accountProfile()
+	if err != nil {
+		return err
+	}
+	path := p.NotifyConfigPath()
+	cfg, err := notify.ReadConfig(path)
+	if err != nil {
+		return err
+	}
+	// Surface config mistakes here rather than at the first event
+	if _, err := notify.New(cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	res := notifyShowResult{Path: path, Sinks: map[string]notify.SinkConfig{}, Routes: cfg.Routes}
+	for name, sc := range cfg.Sinks {
+		res.Sinks[name] = sc.Redacted()
+	}
+	if res.Routes == nil {
+		res.Routes = []notify.Route{}
+	}
+	if g.Format == "json" {
+		outputJSON(res)
+		return nil
+	}
+	fmt.Print(formatNotifyConfig(res))
+	return nil
+}
+
+func formatNotifyConfig(res notifyShowResult) string {
+	var b strings.Builder
+	if len(res.Sinks) == 0 && len(res.Routes) == 0 {
+		fmt.Fprintf(&b, "No notifications configured (%s)\n", res.Path)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s\n\nSinks:\n", res.Path)
+	names := make([]string, 0, len(res.Sinks))
+	for name := range res.Sinks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s: %s\n", name, sinkSummary(res.Sinks[name]))
+	}
+	b.WriteString("\nRoutes:\n")
+	if len(res.Routes) == 0 {
+		b.WriteString("  (none, nothing is sent)\n")
+	}
+	for i, r := range res.Routes {
+		events := "all events"
+		if len(r.Events) > 0 {
+			events = strings.Join(r.Events, ", ")
+		}
+		fmt.Fprintf(&b, "  %d. %s → %s\n", i+1, events, strings.Join(r.Sinks, ", "))
+	}
+	return b.String()
+}
+
+func sinkSummary(sc notify.SinkConfig) string {
+	switch sc.Type {
+	case "webhook", "ntfy":
+		return sc.Type + " " + sc.URL
+	case "smtp":
+		return fmt.Sprintf("smtp %s → %s", sc.Addr, strings.Join(sc.To, ", "))
+	case "exec":
+		return "exec " + strings.Join(sc.Command, " ")
+	}
+	return sc.Type
+}
+
+type NotifyTestCmd struct {
+	Event string `arg:"" optional:"" default:"test" help:"Event type to send, e.g. watch.in_stock to try that route (default: test)"`
+}
+
+func (c *NotifyTestCmd) Run(g *Globals) error {
+	n, err := notifier(g)
+	if err != nil {
+		return err
+	}
+	routes := 0
+	for _, r := range n.Routes() {
+		if r.Matches(c.Event) {
+			routes++
+		}
+	}
+	if routes == 0 {
+		return fmt.Errorf("no route in notify.json takes %s events (alza notify show)", c.Event)
+	}
+	p, err := g.accountProfile()
+	if err != nil {
+		return err
+	}
+	err = publish(g, notify.Event{
+		Type:    c.Event,
+		Title:   "alza notify test",
+		Message: fmt.Sprintf("Test %s notification from profile %s", c.Event, p.Name),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Sent a %s event through %d route(s)\n", c.Event, routes)
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+	"github.com/kuringer/alza-cli/internal/notify"
+	"github.com/kuringer/alza-cli/internal/notify/notifytest"
+)
+
+// writeNotifyConfig writes notify.json of the default profile
+func writeNotifyConfig(t *testing.T, cfg string) {
+	t.Helper()
+	p, err := client.LoadProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.NotifyConfigPath()), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p.NotifyConfigPath(), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hookEvents decodes the events a webhook stand-in received
+func hookEvents(t *testing.T, hook *notifytest.HTTPServer) []notify.Event {
+	t.Helper()
+	var events []notify.Event
+	for _, r := range hook.Requests() {
+		var ev notify.Event
+		if err := json.Unmarshal([]byte(r.Body), &ev); err != nil {
+			t.Fatalf("webhook body %q: %v", r.Body, err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestCLIWatchRunNotifies(t *testing.T) {
+	srv := startFakeAlza(t)
+	hook := notifytest.NewHTTPServer()
+	defer hook.Close()
+	push := notifytest.NewHTTPServer()
+	defer push.Close()
+	writeNotifyConfig(t, `{
+		"sinks": {
+			"hook": {"type": "webhook", "url": "`+hook.URL+`"},
+			"phone": {"type": "ntfy", "url": "`+push.URL+`/alza", "priority": "high"}
+		},
+		"routes": [
+			{"sinks": ["hook"]},
+			{"events": ["watch.below"], "sinks": ["phone"], "title": "Cheap: {{.Data.watch.name}}", "message": "{{.Message}}"}
+		]
+	}`)
+
+	mustRunCLI(t, "watch", "add", "7816725", "--below", "15")
+	setPrice(srv, 7816725, 14.5)
+	mustRunCLI(t, "watch", "run")
+
+	events := hookEvents(t, hook)
+	if len(events) != 1 || events[0].Type != "watch.below" || events[0].Profile != "default" || !strings.Contains(events[0].Message, "14.50 € is at or below 15.00 €") {
+		t.Fatalf("webhook events = %+v", events)
+	}
+	if data, _ := events[0].Data.(map[string]any); data["kind"] != "below" {
+		t.Errorf("webhook event data = %+v", events[0].Data)
+	}
+	reqs := push.Requests()
+	if len(reqs) != 1 || reqs[0].Header.Get("Title") != "=?utf-8?q?Cheap:_GymBeam_Kreat=C3=ADn_monohydr=C3=A1t_500_g?=" || reqs[0].Header.Get("Priority") != "high" {
+		t.Errorf("ntfy requests = %+v", reqs)
+	}
+
+	// The alert fired once; a failing sink on the next crossing fails the run
+	setPrice(srv, 7816725, 16)
+	mustRunCLI(t, "watch", "run")
+	hook.SetStatus(500)
+	setPrice(srv, 7816725, 14)
+	out, err := runCLI(t, "watch", "run")
+	if err == nil || !strings.Contains(err.Error(), "notify watch.below via hook: HTTP 500") {
+		t.Errorf("watch run with a failing webhook error = %v", err)
+	}
+	if !strings.Contains(out, "🔔 [7816725]") {
+		t.Errorf("watch run output = %q", out)
+	}
+	if got := len(push.Requests()); got != 2 {
+		t.Errorf("ntfy got %d requests, want 2 despite the failing webhook", got)
+	}
+}
+
+func TestCLIOrdersTrack(t *testing.T) {
+	srv := startFakeAlza(t)
+	mail := notifytest.NewSMTPServer()
+	defer mail.Close()
+	writeNotifyConfig(t, `{
+		"sinks": {"mail": {"type": "smtp", "addr": "`+mail.Addr+`", "from": "alza@example.com", "to": ["me@example.com"]}},
+		"routes": [{"events": ["order.status"], "sinks": ["mail"], "title": "Alza: {{.Title}}"}]
+	}`)
+
+	// The first run only records
+	if out := mustRunCLI(t, "orders", "--track"); strings.Contains(out, "🔔") {
+		t.Errorf("first orders --track = %q", out)
+	}
+	if out := mustRunCLI(t, "orders", "--track"); strings.Contains(out, "🔔") {
+		t.Errorf("orders --track without changes = %q", out)
+	}
+
+	srv.SetOrderStatus("1059887711", "Reklamácia", false)
+	out := mustRunCLI(t, "orders", "--track")
+	if !strings.Contains(out, "🔔 #1059887711: Vybavená → Reklamácia") {
+		t.Errorf("orders --track after a change = %q", out)
+	}
+	mails := mail.Mails()
+	if len(mails) != 1 || !strings.Contains(mails[0].Data, "Subject: Alza: Order #1059887711\r\n") || !strings.Contains(mails[0].Data, "Vybaven=C3=A1 =E2=86=92 Reklam=C3=A1cia") {
+		t.Fatalf("mails = %+v", mails)
+	}
+
+	var res ordersResult
+	srv.SetOrderStatus("1058001234", "Stornovaná", false)
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "orders", "--track")), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.StatusChanges) != 1 || res.StatusChanges[0].Previous != "Vybavená" || res.StatusChanges[0].Order.Status != "Stornovaná" {
+		t.Errorf("orders --track JSON changes = %+v", res.StatusChanges)
+	}
+
+	if _, err := runCLI(t, "orders", "--track", "--query", "kávovar"); err == nil {
+		t.Error("orders --track --query succeeded")
+	}
+}
+
+func TestCLIQuickbuyNotifies(t *testing.T) {
+	srv := startFakeAlza(t)
+	srv.AddCoupon("ZLAVA10", 10)
+	t.Setenv("ALZA_QUICKBUY_ALZABOX_ID", "1009905")
+	t.Setenv("ALZA_QUICKBUY_DELIVERY_ID", "2680")
+	t.Setenv("ALZA_QUICKBUY_PAYMENT_ID", "216")
+	t.Setenv("ALZA_QUICKBUY_CARD_ID", "card-1")
+	t.Setenv("ALZA_QUICKBUY_VISITOR_ID", "visitor-1")
+	hook := notifytest.NewHTTPServer()
+	defer hook.Close()
+	writeNotifyConfig(t, `{"sinks": {"hook": {"type": "webhook", "url": "`+hook.URL+`"}}, "routes": [{"events": ["quickbuy.*"], "sinks": ["hook"]}]}`)
+
+	mustRunCLI(t, "quickbuy", "7816725", "--dry-run", "--coupon", "ZLAVA10")
+	if got := len(hook.Requests()); got != 0 {
+		t.Errorf("dry run sent %d notifications", got)
+	}
+
+	mustRunCLI(t, "quickbuy", "7816725", "--coupon", "ZLAVA10", "-y")
+	srv.InjectFault(alzatest.Fault{Path: "FastOrderSend", Status: 500})
+	if _, err := runCLI(t, "quickbuy", "7816725", "--coupon", "ZLAVA10", "-y"); err == nil {
+		t.Fatal("quickbuy with a failing order succeeded")
+	}
+
+	events := hookEvents(t, hook)
+	if len(events) != 2 || events[0].Type != notify.EventQuickbuyOrdered || events[1].Type != notify.EventQuickbuyFailed {
+		t.Fatalf("events = %+v", events)
+	}
+	if data, _ := events[0].Data.(map[string]any); data["orderId"] == "" || data["totalPrice"] != 16.11 || data["currency"] != "EUR" {
+		t.Errorf("quickbuy.ordered data = %+v", events[0].Data)
+	}
+}
+
+func TestCLINotifyShowAndTest(t *testing.T) {
+	startFakeAlza(t)
+	if out := mustRunCLI(t, "notify"); !strings.Contains(out, "No notifications configured") {
+		t.Errorf("notify without config = %q", out)
+	}
+
+	push := notifytest.NewHTTPServer()
+	defer push.Close()
+	writeNotifyConfig(t, `{
+		"sinks": {"phone": {"type": "ntfy", "url": "`+push.URL+`/alza", "token": "tk_secret"}},
+		"routes": [{"events": ["watch.*", "order.status"], "sinks": ["phone"]}]
+	}`)
+	out := mustRunCLI(t, "notify", "show")
+	if !strings.Contains(out, "phone: ntfy "+push.URL+"/alza") || !strings.Contains(out, "1. watch.*, order.status → phone") {
+		t.Errorf("notify show = %q", out)
+	}
+	if out := mustRunCLI(t, "--format=json", "notify", "show"); strings.Contains(out, "tk_secret") {
+		t.Errorf("notify show JSON leaks the token: %q", out)
+	}
+
+	if _, err := runCLI(t, "notify", "test"); err == nil || !strings.Contains(err.Error(), "no route") {
+		t.Errorf("notify test without a matching route error = %v", err)
+	}
+	if out := mustRunCLI(t, "notify", "test", "watch.in_stock"); !strings.Contains(out, "through 1 route") {
+		t.Errorf("notify test = %q", out)
+	}
+	if reqs := push.Requests(); len(reqs) != 1 || reqs[0].Header.Get("Authorization") != "Bearer tk_secret" || !strings.Contains(reqs[0].Body, "watch.in_stock") {
+		t.Errorf("ntfy requests = %+v", reqs)
+	}
+
+	writeNotifyConfig(t, `{"sinks": {}, "routes": [{"sinks": ["phone"]}]}`)
+	if _, err := runCLI(t, "notify", "show"); err == nil || !strings.Contains(err.Error(), `unknown sink "phone"`) {
+		t.Errorf("notify show of a broken config error = %v", err)
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 
 	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/internal/notify"
 )
 
 const (
@@ -14,6 +20,106 @@ const (
 
 type archiveOrdersPageFetcher func(offset, limit int) ([]client.Order, int, error)
 
+// ordersResult is the JSON form of `alza orders`
+type ordersResult struct {
+	Orders              []client.Order      `json:"orders"`
+	TotalCount          int                 `json:"totalCount"`
+	HistoryCount        int                 `json:"historyCount,omitempty" jsonschema:"Orders in the archive (with query)"`
+	Query               string              `json:"query,omitempty"`
+	SearchesArchiveOnly bool                `json:"searchesArchiveOnly,omitempty" jsonschema:"Query only searches archived (completed) orders"`
+	StatusChanges       []orderStatusChange `json:"statusChanges,omitempty" jsonschema:"With --track: orders whose status changed since the last --track"`
+}
+
+// orderStatusChange is an order whose status differs from what the last
+// `alza orders --track` saw
+type orderStatusChange struct {
+	Order    client.Order `json:"order"`
+	Previous string       `json:"previous,omitempty"` // Empty for an order not seen before
+}
+
+// trackOrderStatuses compares orders with the statuses saved at path and
+// saves the new ones. The first run only records, so it reports nothing.
+// Orders no longer listed keep their saved status.
+func trackOrderStatuses(path string, orders []client.Order) ([]orderStatusChange, error) {
+	saved := map[string]string{}
+	first := false
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		first = true
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	var changes []orderStatusChange
+	for _, o := range orders {
+		prev, seen := saved[o.ID]
+		if !first && (!seen || prev != o.Status) {
+			changes = append(changes, orderStatusChange{Order: o, Previous: prev})
+		}
+		saved[o.ID] = o.Status
+	}
+	data, err = json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := client.WritePrivateFile(path, append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// orderStatusEvent is the notification for an order status change
+func orderStatusEvent(c orderStatusChange) notify.Event {
+	return notify.Event{
+		Type:    notify.EventOrderStatus,
+		Title:   "Order #" + c.Order.ID,
+		Message: orderStatusText(c),
+		Data:    c,
+	}
+}
+
+func orderStatusText(c orderStatusChange) string {
+	if c.Previous == "" {
+		return fmt.Sprintf("new order: %s (%s)", c.Order.Status, c.Order.TotalPrice)
+	}
+	return fmt.Sprintf("%s → %s", c.Previous, c.Order.Status)
+}
+
+// fetchOrders returns the latest orders, or with a query the archived orders
+// with matching items (the whole archive is scanned)
+func fetchOrders(ctx context.Context, cl *client.TLSClient, limit int, withItems bool, query string) (ordersResult, error) {
+	query = strings.TrimSpace(query)
+	if query != "" {
+		fetchPage := func(offset, limit int) ([]client.Order, int, error) {
+			return cl.GetArchiveOrdersPageContext(ctx, offset, limit)
+		}
+		archiveOrders, historyTotal, err := collectArchiveOrders(fetchPage, archiveOrdersPageSize)
+		if err != nil {
+			return ordersResult{}, err
+		}
+
+		filtered := filterOrdersByQuery(archiveOrders, query)
+		return ordersResult{
+			Orders:              ordersForJSON(limitOrders(filtered, limit), true),
+			TotalCount:          len(filtered),
+			HistoryCount:        historyTotal,
+			Query:               query,
+			SearchesArchiveOnly: true,
+		}, nil
+	}
+
+	orders, total, err := cl.GetOrdersContext(ctx, limit)
+	if err != nil {
+		return ordersResult{}, err
+	}
+	return ordersResult{Orders: ordersForJSON(orders, withItems), TotalCount: total}, nil
+}
+
 func filterOrdersByQuery(orders []client.Order, query string) []client.Order {
 	query = strings.TrimSpace(strings.ToLower(query))
 	if query == "" {
@@ -117,3 +223,11 @@ func formatOrdersText(orders []client.Order, total int, query string, withItems
 
 	return b.String()
 }
+
+func formatOrderStatusChanges(changes []orderStatusChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		fmt.Fprintf(&b, "🔔 #%s: %s\n", c.Order.ID, orderStatusText(c))
+	}
+	return b.String()
+}
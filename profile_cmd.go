@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// ProfileCmd manages account profiles (separate token, quickbuy.env and settings per Alza account)
+type ProfileCmd struct {
+	List   ProfileListCmd   `cmd:"" help:"List account profiles"`
+	Add    ProfileAddCmd    `cmd:"" help:"Create an account profile (country from --country)"`
+	Remove ProfileRemoveCmd `cmd:"" help:"Delete an account profile with its token"`
+	Use    ProfileUseCmd    `cmd:"" help:"Switch the account profile used without --profile"`
+}
+
+// profileInfo is the JSON form of one `alza profile list` entry
+type profileInfo struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Current bool   `json:"current"`
+	client.ProfileSettings
+}
+
+type ProfileListCmd struct{}
+
+func (c *ProfileListCmd) Run(g *Globals) error {
+	profiles, err := client.ListProfiles()
+	if err != nil {
+		return err
+	}
+	current := strings.TrimSpace(g.Profile)
+	if current == "" {
+		if current, err = client.CurrentProfileName(); err != nil {
+			return err
+		}
+	}
+
+	infos := make([]profileInfo, 0, len(profiles))
+	for _, p := range profiles {
+		settings, err := p.Settings()
+		if err != nil {
+			return err
+		}
+		infos = append(infos, profileInfo{Name: p.Name, Dir: p.Dir, Current: p.Name == current, ProfileSettings: settings})
+	}
+
+	if g.Format == "json" {
+		outputJSON(infos)
+		return nil
+	}
+	fmt.Print(formatProfiles(infos))
+	return nil
+}
+
+func formatProfiles(infos []profileInfo) string {
+	var b strings.Builder
+	for _, p := range infos {
+		marker := " "
+		if p.Current {
+			marker = "*"
+		}
+		details := []string{p.Dir}
+		if p.Country != "" {
+			details = append(details, "country="+p.Country)
+		}
+		if p.FavoritesList != "" {
+			details = append(details, "favorites="+p.FavoritesList)
+		}
+		if p.ChromeProfile != "" {
+			details = append(details, fmt.Sprintf("chrome-profile=%q", p.ChromeProfile))
+		}
+		fmt.Fprintf(&b, "%s %-12s %s\n", marker, p.Name, strings.Join(details, "  "))
+	}
+	return b.String()
+}
+
+type ProfileAddCmd struct {
+	Name          string `arg:"" help:"Profile name (letters, digits, '.', '_', '-')"`
+	FavoritesList string `help:"Commodity list used by favorites (ALZA_FAVORITES_LIST)"`
+	ChromeProfile string `help:"Browser profile name or path for token refresh (ALZA_CHROME_PROFILE)"`
+	Use           bool   `help:"Switch to the new profile"`
+}
+
+func (c *ProfileAddCmd) Run(g *Globals) error {
+	p, err := client.CreateProfile(c.Name, client.ProfileSettings{
+		Country:       strings.TrimSpace(g.Country),
+		FavoritesList: strings.TrimSpace(c.FavoritesList),
+		ChromeProfile: strings.TrimSpace(c.ChromeProfile),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Profile %s created in %s\n", p.Name, p.Dir)
+
+	if c.Use {
+		if err := client.SetCurrentProfile(p.Name); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Using profile %s\n", p.Name)
+	}
+	fmt.Printf("Next: alza --profile %s token refresh\n", p.Name)
+	return nil
+}
+
+type ProfileRemoveCmd struct {
+	Name string `arg:"" help:"Profile to delete"`
+}
+
+func (c *ProfileRemoveCmd) Run(g *Globals) error {
+	if err := client.RemoveProfile(g.Context(), c.Name); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Profile %s removed\n", c.Name)
+	return nil
+}
+
+type ProfileUseCmd struct {
+	Name string `arg:"" help:"Profile to use by default (\"default\" for ~/.config/alza)"`
+}
+
+func (c *ProfileUseCmd) Run(g *Globals) error {
+	if err := client.SetCurrentProfile(c.Name); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Using profile %s\n", c.Name)
+	return nil
+}
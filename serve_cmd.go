@@ -0,0 +1,636 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/internal/mcp"
+)
+
+// ServeCmd exposes one logged-in client as a local REST/JSON API
+type ServeCmd struct {
+	Listen    string        `help:"host:port or unix:<path>" default:"127.0.0.1:8080"`
+	KeyFile   string        `help:"File with the API key clients send as Bearer (or ALZA_SERVE_KEY); required on TCP" env:"ALZA_SERVE_KEY_FILE" type:"path"`
+	TLSCert   string        `help:"Server certificate (HTTPS)" env:"ALZA_SERVE_TLS_CERT" type:"path"`
+	TLSKey    string        `help:"Server private key (HTTPS)" env:"ALZA_SERVE_TLS_KEY" type:"path"`
+	Keepalive time.Duration `help:"Check the session at least this often (sooner when the JWT is about to expire, so it is refreshed before it lapses)" default:"30m"`
+}
+
+func (c *ServeCmd) Run(g *Globals) error {
+	ctx := g.Context()
+	key, err := readSecret("ALZA_SERVE_KEY", c.KeyFile, "API key")
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := serverTLSConfig(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return err
+	}
+	listen := strings.TrimSpace(c.Listen)
+	if err := checkServeListen(listen, tlsConfig != nil, key != ""); err != nil {
+		return err
+	}
+
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	s := &apiServer{
+		cl:        cl,
+		key:       key,
+		keepalive: c.Keepalive,
+		margin:    g.RefreshMargin,
+		now:       time.Now,
+		logf:      serveLog,
+	}
+
+	ln, err := brokerListener(listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s.handler(), TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
+	go s.run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	serveLog("serving the API on %s (OpenAPI at /openapi.json)", listen)
+	if tlsConfig != nil {
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		serveLog("stopped")
+		return nil
+	}
+	return err
+}
+
+// checkServeListen refuses to expose the account on TCP without an API key,
+// or in cleartext beyond loopback; a Unix socket is protected by its file mode
+func checkServeListen(listen string, withTLS, withKey bool) error {
+	if strings.HasPrefix(listen, "unix:") {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return fmt.Errorf("invalid --listen %q (host:port or unix:<path>): %w", listen, err)
+	}
+	if !withKey {
+		return fmt.Errorf("refusing to serve the API on %s without a key: set --key-file or ALZA_SERVE_KEY", listen)
+	}
+	if !withTLS && !isLoopback(host) {
+		return fmt.Errorf("refusing to serve the API over plain HTTP on %s: use --tls-cert/--tls-key or listen on 127.0.0.1", listen)
+	}
+	return nil
+}
+
+func serveLog(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s serve: %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// apiServer shares one client between all requests. The client caches the
+// user and basket IDs without locking, so requests that set them (and every
+// cart operation, which reads the basket and then changes it) run exclusively;
+// the rest run concurrently.
+type apiServer struct {
+	cl        *client.TLSClient
+	key       string
+	keepalive time.Duration
+	margin    time.Duration
+	now       func() time.Time
+	logf      func(format string, args ...any)
+
+	session sync.RWMutex
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+// nextCheck is when the session should be checked again: just after the token
+// enters the refresh margin (the client then refreshes it before the request),
+// at least every keepalive, and a minute after a failure
+func (s *apiServer) nextCheck() time.Duration {
+	s.mu.Lock()
+	failed := s.lastErr != nil
+	s.mu.Unlock()
+	if failed {
+		return brokerRetryDelay
+	}
+	wait := s.keepalive
+	if claims, err := s.cl.TokenClaims(); err == nil && !claims.ExpiresAt.IsZero() {
+		// With --refresh-margin 0 the client waits for a 401, so check just after expiry
+		due := claims.ExpiresAt.Add(-s.margin / 2)
+		if s.margin <= 0 {
+			due = claims.ExpiresAt.Add(time.Second)
+		}
+		wait = min(wait, due.Sub(s.now()))
+	}
+	return max(wait, brokerRetryDelay)
+}
+
+// check makes a cheap authenticated call, which lets the client refresh a
+// token that is about to expire (or was rejected) while nobody is asking
+func (s *apiServer) check(ctx context.Context) error {
+	s.session.Lock()
+	status, err := s.cl.GetUserStatusContext(ctx)
+	s.session.Unlock()
+	if err == nil && status.UserID <= 0 {
+		err = client.ErrTokenExpired
+	}
+	s.mu.Lock()
+	s.lastCheck, s.lastErr = s.now(), err
+	s.mu.Unlock()
+	return err
+}
+
+func (s *apiServer) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.nextCheck()):
+		}
+		if err := s.check(ctx); err != nil && ctx.Err() == nil {
+			s.logf("session check failed: %v", err)
+		}
+	}
+}
+
+// apiError carries the HTTP status for errors of the caller's making
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string { return e.err.Error() }
+func (e *apiError) Unwrap() error { return e.err }
+
+func badRequest(format string, args ...any) error {
+	return &apiError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+// apiStatus maps a failure to a status: the caller's mistakes are 4xx, an
+// unusable Alza session is 503 and other upstream failures are 502
+func apiStatus(err error) int {
+	var apiErr *apiError
+	var httpErr *client.HTTPError
+	var challenge *client.ChallengeError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.status
+	case errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound:
+		return http.StatusNotFound
+	case errors.Is(err, client.ErrAuthRequired), errors.Is(err, client.ErrTokenExpired), errors.As(err, &challenge),
+		errors.As(err, &httpErr) && (httpErr.Status == http.StatusUnauthorized || httpErr.Status == http.StatusForbidden):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+// apiParam is a path or query parameter
+type apiParam struct {
+	Name        string
+	In          string // path or query
+	Type        string // integer, boolean or string
+	Description string
+	Required    bool
+}
+
+func pathParam(name, description string) apiParam {
+	return apiParam{Name: name, In: "path", Type: "integer", Description: description, Required: true}
+}
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{Name: name, In: "query", Type: typ, Description: description}
+}
+
+// apiRoute is one endpoint; its OpenAPI operation is derived from the same fields
+type apiRoute struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Params      []apiParam
+	Body        *mcp.Schema // POST only
+	Result      *mcp.Schema
+	Public      bool // no API key (health check)
+	Exclusive   bool // touches the client's cached user or basket ID
+
+	handle func(r *http.Request) (any, error)
+}
+
+// newRoute derives the request body schema from In (for POST) and the
+// response schema from Out; bodies are decoded like MCP tool arguments
+func newRoute[In, Out any](method, path, operationID, summary string, fn func(r *http.Request, in In) (Out, error)) apiRoute {
+	rt := apiRoute{Method: method, Path: path, OperationID: operationID, Summary: summary, Result: mcp.SchemaFor[Out]()}
+	if method == http.MethodPost {
+		rt.Body = mcp.SchemaFor[In]()
+		rt.Body.AdditionalProperties = false
+	}
+	body := rt.Body
+	rt.handle = func(r *http.Request) (any, error) {
+		var in In
+		if body != nil {
+			data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+			if err != nil {
+				return nil, badRequest("read body: %v", err)
+			}
+			if err := mcp.DecodeArgs(data, body, &in); err != nil {
+				return nil, badRequest("invalid body: %v", err)
+			}
+		}
+		return fn(r, in)
+	}
+	return rt
+}
+
+func (rt apiRoute) with(params ...apiParam) apiRoute {
+	rt.Params = append(rt.Params, params...)
+	return rt
+}
+
+func (rt apiRoute) public() apiRoute {
+	rt.Public = true
+	return rt
+}
+
+func (rt apiRoute) exclusive() apiRoute {
+	rt.Exclusive = true
+	return rt
+}
+
+type apiHealth struct {
+	OK             bool      `json:"ok"`
+	TokenExpiresAt time.Time `json:"tokenExpiresAt,omitzero"`
+	LastCheck      time.Time `json:"lastCheck,omitzero"`
+	Error          string    `json:"error,omitempty"`
+}
+
+type apiCartAddBody struct {
+	ProductID int `json:"productId" jsonschema:"Alza product ID"`
+	Quantity  int `json:"quantity,omitempty" jsonschema:"Quantity (default 1)"`
+}
+
+type apiListCreateBody struct {
+	Name string `json:"name" jsonschema:"Name of the new list"`
+}
+
+type apiListAddBody struct {
+	ProductID int `json:"productId" jsonschema:"Alza product ID"`
+}
+
+type apiOK struct {
+	OK bool `json:"ok"`
+}
+
+func (s *apiServer) routes() []apiRoute {
+	cl := s.cl
+	return []apiRoute{
+		newRoute(http.MethodGet, "/healthz", "health", "Whether the Alza session is usable (no network call)", func(r *http.Request, _ struct{}) (apiHealth, error) {
+			return s.health(), nil
+		}).public(),
+
+		newRoute(http.MethodGet, "/v1/search", "search", "Search products by name", func(r *http.Request, _ struct{}) (searchResult, error) {
+			q := strings.TrimSpace(r.URL.Query().Get("q"))
+			if q == "" {
+				return searchResult{}, badRequest("missing query parameter q")
+			}
+			limit, err := queryInt(r, "limit", 10)
+			if err != nil {
+				return searchResult{}, err
+			}
+			results, err := cl.SearchContext(r.Context(), q, limit)
+			return searchResult{Results: results}, err
+		}).with(
+			apiParam{Name: "q", In: "query", Type: "string", Description: "Search phrase", Required: true},
+			queryParam("limit", "integer", "Max results (default 10)"),
+		),
+
+		newRoute(http.MethodGet, "/v1/products/{id}", "getProduct", "Product detail: price, promos, availability, parameters, variants, rating", func(r *http.Request, _ struct{}) (*client.ProductDetail, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			return cl.GetProductContext(r.Context(), id)
+		}).with(pathParam("id", "Alza product ID")),
+
+		newRoute(http.MethodGet, "/v1/products/{id}/reviews", "getReviews", "Product rating stats and user reviews", func(r *http.Request, _ struct{}) (reviewsResult, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return reviewsResult{}, err
+			}
+			limit, err := queryInt(r, "limit", 10)
+			if err != nil {
+				return reviewsResult{}, err
+			}
+			offset, err := queryInt(r, "offset", 0)
+			if err != nil {
+				return reviewsResult{}, err
+			}
+			statsOnly, err := queryBool(r, "statsOnly")
+			if err != nil {
+				return reviewsResult{}, err
+			}
+			stats, err := cl.GetReviewStatsContext(r.Context(), id)
+			if err != nil {
+				return reviewsResult{}, fmt.Errorf("failed to fetch review stats: %w", err)
+			}
+			res := reviewsResult{Stats: stats}
+			if !statsOnly {
+				if res.Reviews, err = cl.GetReviewsContext(r.Context(), id, offset, limit); err != nil {
+					return reviewsResult{}, err
+				}
+			}
+			return res, nil
+		}).with(
+			pathParam("id", "Alza product ID"),
+			queryParam("limit", "integer", "Number of reviews (default 10)"),
+			queryParam("offset", "integer", "Skip the first N reviews"),
+			queryParam("statsOnly", "boolean", "Only rating stats, no reviews"),
+		),
+
+		newRoute(http.MethodGet, "/v1/cart", "getCart", "Items in the shopping cart", func(r *http.Request, _ struct{}) (cartResult, error) {
+			items, err := cl.GetCartContext(r.Context())
+			return cartResult{Items: items}, err
+		}).exclusive(),
+
+		newRoute(http.MethodPost, "/v1/cart/items", "addToCart", "Add a product to the cart; returns the updated cart", func(r *http.Request, in apiCartAddBody) (cartResult, error) {
+			if in.ProductID <= 0 {
+				return cartResult{}, badRequest("invalid productId %d", in.ProductID)
+			}
+			if err := cl.AddToCartContext(r.Context(), in.ProductID, max(in.Quantity, 1)); err != nil {
+				return cartResult{}, err
+			}
+			items, err := cl.GetCartContext(r.Context())
+			return cartResult{Items: items}, err
+		}).exclusive(),
+
+		newRoute(http.MethodDelete, "/v1/cart/items/{id}", "removeFromCart", "Remove a product from the cart; returns the updated cart", func(r *http.Request, _ struct{}) (cartResult, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return cartResult{}, err
+			}
+			items, err := cl.GetCartContext(r.Context())
+			if err != nil {
+				return cartResult{}, err
+			}
+			if !inCart(items, id) {
+				return cartResult{}, &apiError{status: http.StatusNotFound, err: fmt.Errorf("product %d not found in cart", id)}
+			}
+			if err := cl.RemoveFromCartContext(r.Context(), id); err != nil {
+				return cartResult{}, err
+			}
+			items, err = cl.GetCartContext(r.Context())
+			return cartResult{Items: items}, err
+		}).with(pathParam("id", "Alza product ID")).exclusive(),
+
+		newRoute(http.MethodDelete, "/v1/cart", "clearCart", "Remove all items from the cart", func(r *http.Request, _ struct{}) (cartResult, error) {
+			if err := cl.ClearCartContext(r.Context()); err != nil {
+				return cartResult{}, err
+			}
+			return cartResult{Items: []client.CartItem{}}, nil
+		}).exclusive(),
+
+		newRoute(http.MethodGet, "/v1/lists", "getLists", "The user's commodity lists (favorites, custom lists)", func(r *http.Request, _ struct{}) (listsResult, error) {
+			lists, err := cl.GetListsContext(r.Context())
+			return listsResult{Lists: lists}, err
+		}).exclusive(),
+
+		newRoute(http.MethodPost, "/v1/lists", "createList", "Create a commodity list", func(r *http.Request, in apiListCreateBody) (*client.CommodityList, error) {
+			if strings.TrimSpace(in.Name) == "" {
+				return nil, badRequest("list name is empty")
+			}
+			return cl.CreateListContext(r.Context(), strings.TrimSpace(in.Name))
+		}),
+
+		newRoute(http.MethodGet, "/v1/lists/{id}/items", "getListItems", "Items of a commodity list", func(r *http.Request, _ struct{}) (listItemsResult, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return listItemsResult{}, err
+			}
+			items, err := cl.GetListItemsContext(r.Context(), id)
+			return listItemsResult{Items: items}, err
+		}).with(pathParam("id", "Commodity list ID")),
+
+		newRoute(http.MethodPost, "/v1/lists/{id}/items", "addToList", "Add a product to a commodity list; returns its items", func(r *http.Request, in apiListAddBody) (listItemsResult, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return listItemsResult{}, err
+			}
+			if in.ProductID <= 0 {
+				return listItemsResult{}, badRequest("invalid productId %d", in.ProductID)
+			}
+			if err := cl.AddToListContext(r.Context(), id, in.ProductID); err != nil {
+				return listItemsResult{}, err
+			}
+			items, err := cl.GetListItemsContext(r.Context(), id)
+			return listItemsResult{Items: items}, err
+		}).with(pathParam("id", "Commodity list ID")),
+
+		newRoute(http.MethodDelete, "/v1/lists/{id}/items/{productId}", "removeFromList", "Remove a product from a commodity list", func(r *http.Request, _ struct{}) (apiOK, error) {
+			id, err := pathInt(r, "id")
+			if err != nil {
+				return apiOK{}, err
+			}
+			productID, err := pathInt(r, "productId")
+			if err != nil {
+				return apiOK{}, err
+			}
+			return apiOK{OK: true}, cl.RemoveFromListContext(r.Context(), id, productID)
+		}).with(pathParam("id", "Commodity list ID"), pathParam("productId", "Alza product ID")),
+
+		newRoute(http.MethodGet, "/v1/orders", "getOrders", "Order history, or archived orders containing an item", func(r *http.Request, _ struct{}) (ordersResult, error) {
+			limit, err := queryInt(r, "limit", 10)
+			if err != nil {
+				return ordersResult{}, err
+			}
+			withItems, err := queryBool(r, "withItems")
+			if err != nil {
+				return ordersResult{}, err
+			}
+			return fetchOrders(r.Context(), cl, limit, withItems, r.URL.Query().Get("q"))
+		}).with(
+			queryParam("limit", "integer", "Max orders (default 10)"),
+			queryParam("withItems", "boolean", "Include item lines"),
+			queryParam("q", "string", "Only archived orders with an item name containing this"),
+		),
+	}
+}
+
+func inCart(items []client.CartItem, productID int) bool {
+	for _, item := range items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *apiServer) health() apiHealth {
+	var h apiHealth
+	if claims, err := s.cl.TokenClaims(); err == nil {
+		h.TokenExpiresAt = claims.ExpiresAt
+	}
+	s.mu.Lock()
+	h.LastCheck = s.lastCheck
+	if s.lastErr != nil {
+		h.Error = s.lastErr.Error()
+	}
+	s.mu.Unlock()
+	h.OK = h.Error == "" && (h.TokenExpiresAt.IsZero() || s.now().Before(h.TokenExpiresAt))
+	return h
+}
+
+func (s *apiServer) handler() http.Handler {
+	routes := s.routes()
+	mux := http.NewServeMux()
+	openapi, err := json.Marshal(openAPIDocument(routes))
+	if err != nil {
+		panic(err) // The document is built from static routes
+	}
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openapi)
+	})
+	for _, rt := range routes {
+		mux.HandleFunc(rt.Method+" "+rt.Path, s.serve(rt))
+	}
+	return mux
+}
+
+func (s *apiServer) serve(rt apiRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rt.Public && s.key != "" && !hasBearer(r, s.key) {
+			writeHTTPError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return
+		}
+		if rt.Exclusive {
+			s.session.Lock()
+			defer s.session.Unlock()
+		} else {
+			s.session.RLock()
+			defer s.session.RUnlock()
+		}
+		out, err := rt.handle(r)
+		if err != nil {
+			writeHTTPError(w, apiStatus(err), err.Error())
+			return
+		}
+		status := http.StatusOK
+		if h, ok := out.(apiHealth); ok && !h.OK {
+			status = http.StatusServiceUnavailable
+		}
+		writeHTTPJSON(w, status, out)
+	}
+}
+
+func pathInt(r *http.Request, name string) (int, error) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || n <= 0 {
+		return 0, badRequest("invalid %s %q", name, r.PathValue(name))
+	}
+	return n, nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, badRequest("invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+func queryBool(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, badRequest("invalid %s %q", name, v)
+	}
+	return on, nil
+}
+
+// openAPIDocument describes routes as OpenAPI 3.1 (whose schemas are JSON Schema)
+func openAPIDocument(routes []apiRoute) map[string]any {
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content": map[string]any{"application/json": map[string]any{"schema": &mcp.Schema{
+			Type:       "object",
+			Properties: map[string]*mcp.Schema{"error": {Type: "string"}},
+			Required:   []string{"error"},
+		}}},
+	}
+
+	paths := map[string]map[string]any{}
+	for _, rt := range routes {
+		op := map[string]any{
+			"operationId": rt.OperationID,
+			"summary":     rt.Summary,
+			"responses": map[string]any{
+				"200":     map[string]any{"description": "OK", "content": map[string]any{"application/json": map[string]any{"schema": rt.Result}}},
+				"default": errorResponse,
+			},
+		}
+		if rt.Public {
+			op["security"] = []any{}
+		}
+		var params []map[string]any
+		for _, p := range rt.Params {
+			param := map[string]any{"name": p.Name, "in": p.In, "required": p.Required, "schema": &mcp.Schema{Type: p.Type}}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if rt.Body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": rt.Body}},
+			}
+		}
+		if paths[rt.Path] == nil {
+			paths[rt.Path] = map[string]any{}
+		}
+		paths[rt.Path][strings.ToLower(rt.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "alza",
+			"version":     client.Version,
+			"description": "The alza CLI client as a REST/JSON API. Prices are in the storefront currency.",
+		},
+		"components": map[string]any{
+			"securitySchemes": map[string]any{"apiKey": map[string]any{"type": "http", "scheme": "bearer"}},
+		},
+		"security": []any{map[string]any{"apiKey": []string{}}},
+		"paths":    paths,
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+	"github.com/kuringer/alza-cli/internal/mcp"
+)
+
+// newTestAPI serves a client logged in to a fresh alzatest server with the API key "k3y"
+func newTestAPI(t *testing.T) (*apiServer, *alzatest.Server, *httptest.Server) {
+	t.Helper()
+	srv := alzatest.NewServer()
+	t.Cleanup(srv.Close)
+	cl, err := client.New(context.Background(),
+		client.WithBaseURL(srv.URL),
+		client.WithWebAPIURL(srv.URL),
+		client.WithToken(alzatest.DefaultToken),
+		client.WithRetryPolicy(client.NoRetry()),
+		client.WithRateLimit(client.RateLimit{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &apiServer{
+		cl:        cl,
+		key:       "k3y",
+		keepalive: 30 * time.Minute,
+		margin:    5 * time.Minute,
+		now:       time.Now,
+		logf:      func(string, ...any) {},
+	}
+	api := httptest.NewServer(s.handler())
+	t.Cleanup(api.Close)
+	return s, srv, api
+}
+
+// apiCall sends body (JSON, or none if "") with the test key and decodes the answer into out
+func apiCall(t *testing.T, api *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, api.URL+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer k3y")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServeAuth(t *testing.T) {
+	_, _, api := newTestAPI(t)
+	tests := []struct {
+		path, auth string
+		want       int
+	}{
+		{"/v1/cart", "", http.StatusUnauthorized},
+		{"/v1/cart", "Bearer nope", http.StatusUnauthorized},
+		{"/v1/cart", "k3y", http.StatusUnauthorized},
+		{"/v1/cart", "Bearer k3y", http.StatusOK},
+		{"/healthz", "", http.StatusOK},
+		{"/openapi.json", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, api.URL+tt.path, nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("GET %s with %q = %d, want %d", tt.path, tt.auth, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestServeReadEndpoints(t *testing.T) {
+	_, _, api := newTestAPI(t)
+
+	var search searchResult
+	if code := apiCall(t, api, "GET", "/v1/search?q=k%C3%A1vovar&limit=2", "", &search); code != http.StatusOK || len(search.Results) == 0 || len(search.Results) > 2 {
+		t.Errorf("search = %d %+v", code, search.Results)
+	}
+	var product client.ProductDetail
+	if code := apiCall(t, api, "GET", "/v1/products/7816725", "", &product); code != http.StatusOK || product.ID != 7816725 {
+		t.Errorf("product = %d %+v", code, product)
+	}
+	var reviews reviewsResult
+	if code := apiCall(t, api, "GET", "/v1/products/7816725/reviews?statsOnly=true", "", &reviews); code != http.StatusOK || reviews.Stats == nil || reviews.Reviews != nil {
+		t.Errorf("reviews statsOnly = %d %+v", code, reviews)
+	}
+	var lists listsResult
+	if code := apiCall(t, api, "GET", "/v1/lists", "", &lists); code != http.StatusOK || len(lists.Lists) == 0 {
+		t.Errorf("lists = %d %+v", code, lists)
+	}
+	var orders ordersResult
+	if code := apiCall(t, api, "GET", "/v1/orders?limit=5", "", &orders); code != http.StatusOK || orders.TotalCount == 0 {
+		t.Errorf("orders = %d %+v", code, orders)
+	}
+}
+
+func TestServeBadRequests(t *testing.T) {
+	_, _, api := newTestAPI(t)
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/v1/search", "", http.StatusBadRequest},
+		{"GET", "/v1/search?q=x&limit=many", "", http.StatusBadRequest},
+		{"GET", "/v1/products/abc", "", http.StatusBadRequest},
+		{"GET", "/v1/products/7816725/reviews?statsOnly=maybe", "", http.StatusBadRequest},
+		{"POST", "/v1/cart/items", `{}`, http.StatusBadRequest},
+		{"POST", "/v1/cart/items", `{"productId":7816725,"color":"red"}`, http.StatusBadRequest},
+		{"POST", "/v1/cart/items", `not json`, http.StatusBadRequest},
+		{"POST", "/v1/lists", `{"name":" "}`, http.StatusBadRequest},
+		{"DELETE", "/v1/cart/items/7816725", "", http.StatusNotFound},
+		{"PUT", "/v1/cart", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if got := apiCall(t, api, tt.method, tt.path, tt.body, nil); got != tt.want {
+			t.Errorf("%s %s %s = %d, want %d", tt.method, tt.path, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestServeCart(t *testing.T) {
+	_, srv, api := newTestAPI(t)
+
+	var cart cartResult
+	if code := apiCall(t, api, "POST", "/v1/cart/items", `{"productId":7816725,"quantity":2}`, &cart); code != http.StatusOK {
+		t.Fatalf("add = %d", code)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != 7816725 || cart.Items[0].Count != 2 {
+		t.Errorf("cart after add = %+v", cart.Items)
+	}
+	if code := apiCall(t, api, "DELETE", "/v1/cart/items/7816725", "", &cart); code != http.StatusOK || len(cart.Items) != 0 {
+		t.Errorf("remove = %d %+v", code, cart.Items)
+	}
+
+	apiCall(t, api, "POST", "/v1/cart/items", `{"productId":12345678}`, nil)
+	if code := apiCall(t, api, "DELETE", "/v1/cart", "", &cart); code != http.StatusOK || len(srv.Cart()) != 0 {
+		t.Errorf("clear = %d, server cart %+v", code, srv.Cart())
+	}
+}
+
+func TestServeEmptyCartMatchesSchema(t *testing.T) {
+	s, _, api := newTestAPI(t)
+	result := func(method, path string) *mcp.Schema {
+		for _, rt := range s.routes() {
+			if rt.Method == method && rt.Path == path {
+				return rt.Result
+			}
+		}
+		t.Fatalf("no route %s %s", method, path)
+		return nil
+	}
+
+	apiCall(t, api, "POST", "/v1/cart/items", `{"productId":7816725}`, nil)
+	var body json.RawMessage
+	if code := apiCall(t, api, "DELETE", "/v1/cart/items/7816725", "", &body); code != http.StatusOK {
+		t.Fatalf("remove = %d", code)
+	}
+	checkSchema(t, result("DELETE", "/v1/cart/items/{id}"), body)
+	if code := apiCall(t, api, "GET", "/v1/cart", "", &body); code != http.StatusOK {
+		t.Fatalf("cart = %d", code)
+	}
+	checkSchema(t, result("GET", "/v1/cart"), body)
+	// The favorites list is empty
+	if code := apiCall(t, api, "GET", "/v1/lists/49098229/items", "", &body); code != http.StatusOK {
+		t.Fatalf("list items = %d", code)
+	}
+	checkSchema(t, result("GET", "/v1/lists/{id}/items"), body)
+}
+
+func TestServeCartConcurrent(t *testing.T) {
+	_, srv, api := newTestAPI(t)
+	var wg sync.WaitGroup
+	for _, id := range []int{7816725, 12345678, 7816725, 12345678} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code := apiCall(t, api, "POST", "/v1/cart/items", fmt.Sprintf(`{"productId":%d}`, id), nil); code != http.StatusOK {
+				t.Errorf("add %d = %d", id, code)
+			}
+			apiCall(t, api, "GET", "/v1/search?q=k%C3%A1vovar", "", nil)
+		}()
+	}
+	wg.Wait()
+
+	counts := map[int]int{}
+	for _, line := range srv.Cart() {
+		counts[line.ProductID] += line.Count
+	}
+	if counts[7816725] != 2 || counts[12345678] != 2 {
+		t.Errorf("cart = %+v, want two of each", srv.Cart())
+	}
+}
+
+func TestServeLists(t *testing.T) {
+	_, _, api := newTestAPI(t)
+
+	var list client.CommodityList
+	if code := apiCall(t, api, "POST", "/v1/lists", `{"name":"Darčeky"}`, &list); code != http.StatusOK || list.ID == 0 {
+		t.Fatalf("create = %d %+v", code, list)
+	}
+	path := fmt.Sprintf("/v1/lists/%d/items", list.ID)
+	var items listItemsResult
+	if code := apiCall(t, api, "POST", path, `{"productId":7816725}`, &items); code != http.StatusOK || len(items.Items) != 1 {
+		t.Errorf("add to list = %d %+v", code, items)
+	}
+	if code := apiCall(t, api, "DELETE", path+"/7816725", "", nil); code != http.StatusOK {
+		t.Errorf("remove from list = %d", code)
+	}
+	if code := apiCall(t, api, "GET", path, "", &items); code != http.StatusOK || len(items.Items) != 0 {
+		t.Errorf("list items after remove = %d %+v", code, items)
+	}
+}
+
+func TestServeUpstreamErrors(t *testing.T) {
+	_, srv, api := newTestAPI(t)
+
+	srv.InjectFault(alzatest.CloudflareChallenge("/api/"))
+	if code := apiCall(t, api, "GET", "/v1/products/7816725", "", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("product behind a challenge = %d, want 503", code)
+	}
+	srv.ClearFaults()
+
+	srv.SetToken("Bearer other")
+	if code := apiCall(t, api, "GET", "/v1/cart", "", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("cart with a rejected token = %d, want 503", code)
+	}
+}
+
+func TestServeOpenAPIDocument(t *testing.T) {
+	s, _, api := newTestAPI(t)
+	resp, err := http.Get(api.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	for _, rt := range s.routes() {
+		op := doc.Paths[rt.Path][strings.ToLower(rt.Method)]
+		if op == nil || op["operationId"] != rt.OperationID {
+			t.Errorf("%s %s missing from the document", rt.Method, rt.Path)
+			continue
+		}
+		if _, hasBody := op["requestBody"]; hasBody != (rt.Method == http.MethodPost) {
+			t.Errorf("%s %s requestBody = %v", rt.Method, rt.Path, op["requestBody"])
+		}
+		if _, public := op["security"]; public != rt.Public {
+			t.Errorf("%s %s security override = %v", rt.Method, rt.Path, op["security"])
+		}
+	}
+
+	add, _ := json.Marshal(doc.Paths["/v1/cart/items"]["post"]["requestBody"])
+	if !strings.Contains(string(add), `"required":["productId"]`) || !strings.Contains(string(add), `"additionalProperties":false`) {
+		t.Errorf("addToCart requestBody = %s", add)
+	}
+}
+
+func TestServeNextCheck(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expires time.Time
+		margin  time.Duration
+		lastErr error
+		want    time.Duration
+	}{
+		{"keepalive before expiry", now.Add(2 * time.Hour), 5 * time.Minute, nil, 30 * time.Minute},
+		{"inside the margin before expiry", now.Add(20 * time.Minute), 10 * time.Minute, nil, 15 * time.Minute},
+		{"after expiry without a margin", now.Add(20 * time.Minute), 0, nil, 20*time.Minute + time.Second},
+		{"overdue waits the minimum", now.Add(time.Minute), 5 * time.Minute, nil, brokerRetryDelay},
+		{"retry after failure", now.Add(2 * time.Hour), 5 * time.Minute, errors.New("HTTP 500"), brokerRetryDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl, err := client.New(context.Background(), client.WithToken(alzatest.JWT(tt.expires)), client.WithoutValidation(), client.WithDoer(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &apiServer{cl: cl, keepalive: 30 * time.Minute, margin: tt.margin, now: func() time.Time { return now }, lastErr: tt.lastErr}
+			if got := s.nextCheck(); got != tt.want {
+				t.Errorf("nextCheck() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeCheckRefreshesExpiringToken(t *testing.T) {
+	srv := alzatest.NewServer()
+	t.Cleanup(srv.Close)
+	old := alzatest.JWT(time.Now().Add(30 * time.Minute))
+	fresh := alzatest.JWT(time.Now().Add(2 * time.Hour))
+	srv.SetToken(old)
+
+	// A margin wider than the remaining lifetime makes the next call refresh early
+	cl, err := client.New(context.Background(),
+		client.WithBaseURL(srv.URL), client.WithWebAPIURL(srv.URL), client.WithToken(old),
+		client.WithRateLimit(client.RateLimit{}), client.WithRefreshMargin(time.Hour), client.WithoutValidation(),
+		client.WithTokenRefresher(client.RefreshFunc(func(context.Context) (string, error) {
+			srv.SetToken(fresh)
+			return fresh, nil
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &apiServer{cl: cl, keepalive: time.Hour, margin: time.Hour, now: time.Now, logf: func(string, ...any) {}}
+	if err := s.check(context.Background()); err != nil {
+		t.Fatalf("check() error: %v", err)
+	}
+	claims, err := cl.TokenClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Now().Add(2 * time.Hour); claims.ExpiresAt.Before(want.Add(-time.Minute)) {
+		t.Errorf("token expires at %s after check, want the refreshed one", claims.ExpiresAt)
+	}
+	if h := s.health(); !h.OK || h.LastCheck.IsZero() {
+		t.Errorf("health() = %+v", h)
+	}
+}
+
+func TestServeCheckReportsLoggedOut(t *testing.T) {
+	s, srv, api := newTestAPI(t)
+	srv.SetToken("Bearer other")
+	if err := s.check(context.Background()); !errors.Is(err, client.ErrTokenExpired) {
+		t.Errorf("check() with a rejected token = %v, want ErrTokenExpired", err)
+	}
+	if code := apiCall(t, api, "GET", "/healthz", "", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("healthz after a failed check = %d, want 503", code)
+	}
+}
+
+func TestCheckServeListen(t *testing.T) {
+	tests := []struct {
+		listen  string
+		tls     bool
+		key     bool
+		wantErr string
+	}{
+		{"unix:/tmp/alza.sock", false, false, ""},
+		{"127.0.0.1:8080", false, true, ""},
+		{"localhost:8080", false, true, ""},
+		{"127.0.0.1:8080", false, false, "without a key"},
+		{"0.0.0.0:8080", false, true, "plain HTTP"},
+		{"0.0.0.0:8080", true, true, ""},
+		{"8080", false, true, "invalid --listen"},
+	}
+	for _, tt := range tests {
+		err := checkServeListen(tt.listen, tt.tls, tt.key)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("checkServeListen(%q, %v, %v) = %v, want %q", tt.listen, tt.tls, tt.key, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCLIServeNeedsKey(t *testing.T) {
+	startFakeAlza(t)
+	t.Setenv("ALZA_SERVE_KEY", "")
+	_, err := runCLI(t, "serve")
+	if err == nil || !strings.Contains(err.Error(), "ALZA_SERVE_KEY") {
+		t.Errorf("serve without a key error = %v", err)
+	}
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+)
+
+// brokerRetryDelay is how long token serve waits after a failed refresh
+const brokerRetryDelay = time.Minute
+
+// TokenServeCmd keeps the token fresh on the machine with the browser session
+// and hands it out to CI agents and other hosts
+type TokenServeCmd struct {
+	Listen     string        `help:"unix:<path> or host:port (default: unix socket token.sock in the profile dir)"`
+	Interval   time.Duration `help:"Refresh at least this often (sooner when the JWT is about to expire)" default:"30m"`
+	SecretFile string        `help:"File with the shared secret clients send as Bearer (or ALZA_BROKER_SECRET); required on TCP without --client-ca" env:"ALZA_BROKER_SECRET_FILE" type:"path"`
+	TLSCert    string        `help:"Server certificate (HTTPS)" env:"ALZA_BROKER_TLS_CERT" type:"path"`
+	TLSKey     string        `help:"Server private key (HTTPS)" env:"ALZA_BROKER_TLS_KEY" type:"path"`
+	ClientCA   string        `help:"Require client certificates signed by this CA (mTLS)" env:"ALZA_BROKER_CLIENT_CA" type:"path"`
+}
+
+func (c *TokenServeCmd) Run(g *Globals) error {
+	ctx := g.Context()
+	store, err := resolveStorefront(g)
+	if err != nil {
+		return err
+	}
+	refresh, err := refreshSource(g, store)
+	if err != nil {
+		return err
+	}
+	if refresh == nil {
+		return fmt.Errorf("token serve needs a token source, --refresh-from can't be off")
+	}
+	secret, err := brokerSecret(c.SecretFile)
+	if err != nil {
+		return err
+	}
+
+	listen := strings.TrimSpace(c.Listen)
+	if listen == "" {
+		p, err := g.accountProfile()
+		if err != nil {
+			return err
+		}
+		listen = "unix:" + filepath.Join(p.Dir, "token.sock")
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if err := checkBrokerListen(listen, tlsConfig != nil, secret != "" || c.ClientCA != ""); err != nil {
+		return err
+	}
+
+	b := &tokenBroker{
+		refresh:  refresh,
+		save:     func(ctx context.Context, token string) error { return saveToken(ctx, g, token) },
+		interval: c.Interval,
+		margin:   g.RefreshMargin,
+		secret:   secret,
+		now:      time.Now,
+		logf:     brokerLog,
+	}
+	if tokens, err := g.tokenStore(); err == nil {
+		if token, err := tokens.Token(ctx); err == nil {
+			b.load(token)
+		}
+	}
+
+	ln, err := brokerListener(listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: b.handler(), TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
+	go b.run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	brokerLog("serving token on %s", listen)
+	if tlsConfig != nil {
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		brokerLog("stopped")
+		return nil
+	}
+	return err
+}
+
+// tlsConfig is nil without --tls-cert (Unix socket or loopback HTTP)
+func (c *TokenServeCmd) tlsConfig() (*tls.Config, error) {
+	if c.TLSCert == "" && c.TLSKey == "" && c.ClientCA != "" {
+		return nil, fmt.Errorf("--client-ca needs --tls-cert and --tls-key")
+	}
+	cfg, err := serverTLSConfig(c.TLSCert, c.TLSKey)
+	if cfg == nil || err != nil {
+		return nil, err
+	}
+	if c.ClientCA != "" {
+		pool, err := loadCertPool(c.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// serverTLSConfig loads the HTTPS certificate; nil without one
+func serverTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load --tls-cert/--tls-key: %w", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// checkBrokerListen refuses to hand out tokens over the network unauthenticated
+// or in cleartext; a Unix socket is protected by its file mode
+func checkBrokerListen(listen string, withTLS, authenticated bool) error {
+	if strings.HasPrefix(listen, "unix:") {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return fmt.Errorf("invalid --listen %q (unix:<path> or host:port): %w", listen, err)
+	}
+	if !authenticated {
+		return fmt.Errorf("refusing to serve the token on %s without authentication: set --secret-file / ALZA_BROKER_SECRET or --client-ca", listen)
+	}
+	if !withTLS && !isLoopback(host) {
+		return fmt.Errorf("refusing to serve the token over plain HTTP on %s: use --tls-cert/--tls-key or listen on 127.0.0.1", listen)
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// brokerListener opens a Unix socket only the current user can connect to, or a TCP port
+func brokerListener(listen string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(listen, "unix:")
+	if !ok {
+		return net.Listen("tcp", listen)
+	}
+	path, err := expandHomePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	// A socket left behind by a killed server; never remove anything else
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+func brokerLog(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s token serve: %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// tokenBroker holds the current token and refreshes it on a schedule
+type tokenBroker struct {
+	refresh  client.RefreshFunc
+	save     func(ctx context.Context, token string) error
+	interval time.Duration
+	margin   time.Duration
+	secret   string // Empty: no Authorization check (Unix socket, mTLS)
+	now      func() time.Time
+	logf     func(format string, args ...any)
+
+	refreshMu sync.Mutex // one refresh at a time
+
+	mu          sync.RWMutex
+	token       string
+	expiresAt   time.Time // Zero if the token isn't a JWT
+	refreshedAt time.Time
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// brokerToken is the JSON body of GET /token and POST /refresh
+type brokerToken struct {
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expiresAt,omitzero"`
+	RefreshedAt time.Time `json:"refreshedAt,omitzero"`
+}
+
+// brokerHealth is the JSON body of GET /healthz
+type brokerHealth struct {
+	OK          bool      `json:"ok"`
+	ExpiresAt   time.Time `json:"expiresAt,omitzero"`
+	RefreshedAt time.Time `json:"refreshedAt,omitzero"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// load takes a token saved earlier; its age comes from the iat claim
+func (b *tokenBroker) load(token string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token = token
+	b.expiresAt, b.refreshedAt = time.Time{}, b.now()
+	if claims, err := client.ParseTokenClaims(token); err == nil {
+		b.expiresAt = claims.ExpiresAt
+		if !claims.IssuedAt.IsZero() {
+			b.refreshedAt = claims.IssuedAt
+		}
+	}
+}
+
+// refreshNow fetches and saves a new token; a failure keeps the old one
+func (b *tokenBroker) refreshNow(ctx context.Context) error {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	token, err := b.refresh(ctx)
+	b.mu.Lock()
+	b.lastAttempt, b.lastErr = b.now(), err
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	b.load(token)
+	b.mu.Lock()
+	b.refreshedAt = b.now()
+	expiresAt := b.expiresAt
+	b.mu.Unlock()
+
+	if err := b.save(ctx, token); err != nil {
+		b.logf("token refreshed but not saved: %v", err)
+	}
+	if expiresAt.IsZero() {
+		b.logf("token refreshed")
+	} else {
+		b.logf("token refreshed, expires %s", expiresAt.Local().Format("15:04:05"))
+	}
+	return nil
+}
+
+// nextRefresh is the wait until the token is interval old or within margin of
+// its expiry, whichever comes first; brokerRetryDelay after a failure
+func (b *tokenBroker) nextRefresh() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	now := b.now()
+	if b.lastErr != nil {
+		return b.lastAttempt.Add(brokerRetryDelay).Sub(now)
+	}
+	if b.token == "" {
+		return 0
+	}
+	due := b.refreshedAt.Add(b.interval)
+	if !b.expiresAt.IsZero() && b.expiresAt.Add(-b.margin).Before(due) {
+		due = b.expiresAt.Add(-b.margin)
+	}
+	return due.Sub(now)
+}
+
+func (b *tokenBroker) run(ctx context.Context) {
+	for {
+		if wait := b.nextRefresh(); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		if err := b.refreshNow(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logf("refresh failed, retrying in %s: %v", brokerRetryDelay, err)
+		}
+	}
+}
+
+func (b *tokenBroker) current() (brokerToken, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	fresh := b.token != "" && (b.expiresAt.IsZero() || b.now().Before(b.expiresAt))
+	return brokerToken{Token: b.token, ExpiresAt: b.expiresAt, RefreshedAt: b.refreshedAt}, fresh
+}
+
+func (b *tokenBroker) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		tok, fresh := b.current()
+		health := brokerHealth{OK: fresh, ExpiresAt: tok.ExpiresAt, RefreshedAt: tok.RefreshedAt}
+		b.mu.RLock()
+		if b.lastErr != nil {
+			health.Error = b.lastErr.Error()
+		}
+		b.mu.RUnlock()
+		status := http.StatusOK
+		if !fresh {
+			status = http.StatusServiceUnavailable
+		}
+		writeHTTPJSON(w, status, health)
+	})
+	mux.HandleFunc("GET /token", b.authorized(func(w http.ResponseWriter, r *http.Request) {
+		tok, fresh := b.current()
+		if !fresh {
+			// Expired between scheduled refreshes (laptop asleep); try once now
+			if err := b.refreshNow(r.Context()); err != nil {
+				writeHTTPError(w, http.StatusServiceUnavailable, fmt.Sprintf("no valid token: %v", err))
+				return
+			}
+			tok, _ = b.current()
+		}
+		writeHTTPJSON(w, http.StatusOK, tok)
+	}))
+	mux.HandleFunc("POST /refresh", b.authorized(func(w http.ResponseWriter, r *http.Request) {
+		if err := b.refreshNow(r.Context()); err != nil {
+			writeHTTPError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		tok, _ := b.current()
+		writeHTTPJSON(w, http.StatusOK, tok)
+	}))
+	return mux
+}
+
+// authorized checks the shared secret in "Authorization: Bearer <secret>"
+func (b *tokenBroker) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if b.secret != "" && !hasBearer(r, b.secret) {
+			writeHTTPError(w, http.StatusUnauthorized, "invalid or missing broker secret")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func hasBearer(r *http.Request, secret string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+func writeHTTPJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, msg string) {
+	writeHTTPJSON(w, status, map[string]string{"error": msg})
+}
+
+// === CLIENT ===
+
+// BrokerClientFlags authenticate `token pull` against alza token serve; the
+// same environment variables apply to --refresh-from <broker URL>
+type BrokerClientFlags struct {
+	BrokerSecretFile string `help:"File with the token serve shared secret (or ALZA_BROKER_SECRET)" env:"ALZA_BROKER_SECRET_FILE" type:"path"`
+	BrokerCA         string `help:"CA certificate that signed the token serve HTTPS certificate" env:"ALZA_BROKER_CA" type:"path"`
+	BrokerCert       string `help:"Client certificate for token serve mTLS" env:"ALZA_BROKER_CERT" type:"path"`
+	BrokerKey        string `help:"Client private key for token serve mTLS" env:"ALZA_BROKER_KEY" type:"path"`
+}
+
+func brokerClientFromEnv() BrokerClientFlags {
+	return BrokerClientFlags{
+		BrokerSecretFile: os.Getenv("ALZA_BROKER_SECRET_FILE"),
+		BrokerCA:         os.Getenv("ALZA_BROKER_CA"),
+		BrokerCert:       os.Getenv("ALZA_BROKER_CERT"),
+		BrokerKey:        os.Getenv("ALZA_BROKER_KEY"),
+	}
+}
+
+// isBrokerURL tells a token serve address from an SSH host
+func isBrokerURL(from string) bool {
+	return strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") || strings.HasPrefix(from, "unix:")
+}
+
+// brokerSecret prefers ALZA_BROKER_SECRET over the first line of file; "" means none
+func brokerSecret(file string) (string, error) {
+	return readSecret("ALZA_BROKER_SECRET", file, "broker secret")
+}
+
+// readSecret prefers the env variable over the first line of file; "" means none
+func readSecret(env, file, what string) (string, error) {
+	if s := os.Getenv(env); s != "" {
+		return s, nil
+	}
+	if file == "" {
+		return "", nil
+	}
+	path, err := expandHomePath(file)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", what, err)
+	}
+	secret, _, _ := strings.Cut(string(data), "\n")
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", fmt.Errorf("%s file %s is empty", what, path)
+	}
+	return secret, nil
+}
+
+// fetchBrokerToken gets the current token from alza token serve at from
+// (http(s)://host:port or unix:/path/to/token.sock)
+func fetchBrokerToken(ctx context.Context, from string, flags BrokerClientFlags, timeout time.Duration) (string, error) {
+	secret, err := brokerSecret(flags.BrokerSecretFile)
+	if err != nil {
+		return "", err
+	}
+
+	transport := &http.Transport{}
+	endpoint := strings.TrimRight(from, "/") + "/token"
+	if path, ok := strings.CutPrefix(from, "unix:"); ok {
+		if path, err = expandHomePath(path); err != nil {
+			return "", err
+		}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		endpoint = "http://token-serve/token"
+	} else {
+		u, err := url.Parse(from)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid token serve URL %q", from)
+		}
+		if transport.TLSClientConfig, err = flags.tlsConfig(); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("token serve at %s timed out after %s", from, timeout)
+		}
+		return "", fmt.Errorf("token serve at %s: %w", from, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return "", fmt.Errorf("token serve at %s: %s (HTTP %d)", from, e.Error, resp.StatusCode)
+		}
+		return "", fmt.Errorf("token serve at %s: HTTP %d", from, resp.StatusCode)
+	}
+
+	var tok brokerToken
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("token serve at %s: decode response: %w", from, err)
+	}
+	if strings.TrimSpace(tok.Token) == "" {
+		return "", fmt.Errorf("token serve at %s returned an empty token", from)
+	}
+	return strings.TrimSpace(tok.Token), nil
+}
+
+// tlsConfig trusts --broker-ca and presents --broker-cert for mTLS; nil uses the system roots
+func (f BrokerClientFlags) tlsConfig() (*tls.Config, error) {
+	if f.BrokerCA == "" && f.BrokerCert == "" && f.BrokerKey == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if f.BrokerCA != "" {
+		pool, err := loadCertPool(f.BrokerCA)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if f.BrokerCert != "" || f.BrokerKey != "" {
+		cert, err := tls.LoadX509KeyPair(f.BrokerCert, f.BrokerKey)
+		if err != nil {
+			return nil, fmt.Errorf("load --broker-cert/--broker-key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client/alzatest"
+)
+
+func newTestBroker(now time.Time, refresh func(context.Context) (string, error)) *tokenBroker {
+	return &tokenBroker{
+		refresh:  refresh,
+		save:     func(context.Context, string) error { return nil },
+		interval: 30 * time.Minute,
+		margin:   5 * time.Minute,
+		now:      func() time.Time { return now },
+		logf:     func(string, ...any) {},
+	}
+}
+
+func TestTokenBrokerNextRefresh(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		setup func(b *tokenBroker)
+		want  time.Duration
+	}{
+		{"no token refreshes now", func(b *tokenBroker) {}, 0},
+		{"interval before expiry", func(b *tokenBroker) {
+			b.load(alzatest.JWT(now.Add(2 * time.Hour)))
+			b.refreshedAt = now.Add(-10 * time.Minute)
+		}, 20 * time.Minute},
+		{"expiry before interval", func(b *tokenBroker) {
+			b.load(alzatest.JWT(now.Add(15 * time.Minute)))
+			b.refreshedAt = now
+		}, 10 * time.Minute},
+		{"overdue since iat", func(b *tokenBroker) {
+			b.load(alzatest.JWT(now.Add(time.Hour))) // iat 30m ago
+		}, 0},
+		{"retry after failure", func(b *tokenBroker) {
+			b.load(alzatest.JWT(now.Add(time.Minute)))
+			b.lastAttempt, b.lastErr = now.Add(-20*time.Second), errors.New("chrome locked")
+		}, 40 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(now, nil)
+			tt.setup(b)
+			if got := b.nextRefresh(); got != tt.want {
+				t.Errorf("nextRefresh() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenBrokerLoadUsesIssuedAt(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	b := newTestBroker(now, nil)
+	b.load(alzatest.JWT(now.Add(time.Hour)))
+	if want := now.Add(-30 * time.Minute); !b.refreshedAt.Equal(want) {
+		t.Errorf("refreshedAt = %s, want iat %s", b.refreshedAt, want)
+	}
+	if want := now.Add(time.Hour); !b.expiresAt.Equal(want) {
+		t.Errorf("expiresAt = %s, want %s", b.expiresAt, want)
+	}
+}
+
+func TestTokenBrokerHandlerAuth(t *testing.T) {
+	now := time.Now()
+	token := alzatest.JWT(now.Add(time.Hour))
+	b := newTestBroker(now, func(context.Context) (string, error) { return token, nil })
+	b.secret = "s3cret"
+	b.load(token)
+	srv := httptest.NewServer(b.handler())
+	defer srv.Close()
+
+	get := func(method, path, auth string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret"} {
+		if resp, _ := get("GET", "/token", auth); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET /token with %q = %d, want 401", auth, resp.StatusCode)
+		}
+	}
+	if resp, _ := get("POST", "/refresh", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /refresh without secret = %d, want 401", resp.StatusCode)
+	}
+
+	resp, body := get("GET", "/token", "Bearer s3cret")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /token = %d: %s", resp.StatusCode, body)
+	}
+	var got brokerToken
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Token != token || got.ExpiresAt.IsZero() {
+		t.Errorf("GET /token = %+v, want the loaded token with expiry", got)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+
+	resp, body = get("GET", "/healthz", "")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"ok":true`) {
+		t.Errorf("GET /healthz = %d %s, want ok", resp.StatusCode, body)
+	}
+	if strings.Contains(body, token) {
+		t.Error("GET /healthz leaks the token")
+	}
+}
+
+func TestTokenBrokerRefreshesExpiredTokenOnRequest(t *testing.T) {
+	now := time.Now()
+	fresh := alzatest.JWT(now.Add(time.Hour))
+	calls := 0
+	b := newTestBroker(now, func(context.Context) (string, error) {
+		calls++
+		return fresh, nil
+	})
+	var saved string
+	b.save = func(_ context.Context, token string) error { saved = token; return nil }
+	b.load(alzatest.JWT(now.Add(-time.Minute)))
+
+	rec := httptest.NewRecorder()
+	b.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/token", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), fresh) {
+		t.Fatalf("GET /token = %d %s, want the refreshed token", rec.Code, rec.Body)
+	}
+	if calls != 1 || saved != fresh {
+		t.Errorf("refresh calls = %d, saved = %q; want one refresh, saved", calls, saved)
+	}
+}
+
+func TestTokenBrokerRefreshFailureKeepsToken(t *testing.T) {
+	now := time.Now()
+	token := alzatest.JWT(now.Add(time.Hour))
+	b := newTestBroker(now, func(context.Context) (string, error) { return "", errors.New("not logged in") })
+	b.load(token)
+
+	rec := httptest.NewRecorder()
+	b.handler().ServeHTTP(rec, httptest.NewRequest("POST", "/refresh", nil))
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "not logged in") {
+		t.Errorf("POST /refresh = %d %s, want 502 with the error", rec.Code, rec.Body)
+	}
+	if got, fresh := b.current(); got.Token != token || !fresh {
+		t.Errorf("current() = %q, %v; want the old token kept", got.Token, fresh)
+	}
+
+	rec = httptest.NewRecorder()
+	b.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if !strings.Contains(rec.Body.String(), "not logged in") {
+		t.Errorf("GET /healthz = %s, want the last refresh error", rec.Body)
+	}
+}
+
+func TestCheckBrokerListen(t *testing.T) {
+	tests := []struct {
+		listen        string
+		tls, authed   bool
+		wantErrSubstr string
+	}{
+		{"unix:/run/alza/token.sock", false, false, ""},
+		{"127.0.0.1:8765", false, true, ""},
+		{"localhost:8765", false, true, ""},
+		{"[::1]:8765", false, true, ""},
+		{"127.0.0.1:8765", false, false, "without authentication"},
+		{"0.0.0.0:8765", false, true, "plain HTTP"},
+		{"0.0.0.0:8765", true, true, ""},
+		{"0.0.0.0:8765", true, false, "without authentication"},
+		{"/run/alza.sock", false, true, "invalid --listen"},
+	}
+	for _, tt := range tests {
+		err := checkBrokerListen(tt.listen, tt.tls, tt.authed)
+		if tt.wantErrSubstr == "" && err != nil {
+			t.Errorf("checkBrokerListen(%q, %v, %v) = %v", tt.listen, tt.tls, tt.authed, err)
+		}
+		if tt.wantErrSubstr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr)) {
+			t.Errorf("checkBrokerListen(%q, %v, %v) = %v, want %q", tt.listen, tt.tls, tt.authed, err, tt.wantErrSubstr)
+		}
+	}
+}
+
+func TestFetchBrokerTokenUnixSocket(t *testing.T) {
+	// Socket paths are limited to ~100 bytes, t.TempDir() can be longer
+	dir, err := os.MkdirTemp("", "alza")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "token.sock")
+
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	b := newTestBroker(time.Now(), nil)
+	b.load(token)
+
+	// A stale socket from a killed server is replaced
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := brokerListener("unix:" + sock)
+	if err != nil {
+		t.Fatalf("brokerListener() error: %v", err)
+	}
+	srv := &http.Server{Handler: b.handler()}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+
+	got, err := fetchBrokerToken(context.Background(), "unix:"+sock, BrokerClientFlags{}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("fetchBrokerToken() error: %v", err)
+	}
+	if got != token {
+		t.Errorf("fetchBrokerToken() = %q, want %q", got, token)
+	}
+}
+
+func TestFetchBrokerTokenSecret(t *testing.T) {
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	b := newTestBroker(time.Now(), nil)
+	b.secret = "s3cret"
+	b.load(token)
+	srv := httptest.NewServer(b.handler())
+	defer srv.Close()
+
+	t.Setenv("ALZA_BROKER_SECRET", "")
+	_, err := fetchBrokerToken(context.Background(), srv.URL, BrokerClientFlags{}, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("fetchBrokerToken() without secret error = %v, want HTTP 401", err)
+	}
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fetchBrokerToken(context.Background(), srv.URL+"/", BrokerClientFlags{BrokerSecretFile: secretFile}, 5*time.Second)
+	if err != nil || got != token {
+		t.Errorf("fetchBrokerToken() with secret file = %q, %v", got, err)
+	}
+
+	t.Setenv("ALZA_BROKER_SECRET", "s3cret")
+	if got, err := fetchBrokerToken(context.Background(), srv.URL, BrokerClientFlags{}, 5*time.Second); err != nil || got != token {
+		t.Errorf("fetchBrokerToken() with ALZA_BROKER_SECRET = %q, %v", got, err)
+	}
+}
+
+func TestFetchBrokerTokenMTLS(t *testing.T) {
+	dir := t.TempDir()
+	pki := newTestPKI(t, dir)
+
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	b := newTestBroker(time.Now(), nil)
+	b.load(token)
+
+	serve := TokenServeCmd{TLSCert: pki.serverCert, TLSKey: pki.serverKey, ClientCA: pki.ca}
+	tlsConfig, err := serve.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig() error: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(b.handler())
+	srv.TLS = tlsConfig
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	flags := BrokerClientFlags{BrokerCA: pki.ca, BrokerCert: pki.clientCert, BrokerKey: pki.clientKey}
+	got, err := fetchBrokerToken(context.Background(), srv.URL, flags, 5*time.Second)
+	if err != nil {
+		t.Fatalf("fetchBrokerToken() with client cert error: %v", err)
+	}
+	if got != token {
+		t.Errorf("fetchBrokerToken() = %q, want %q", got, token)
+	}
+
+	if _, err := fetchBrokerToken(context.Background(), srv.URL, BrokerClientFlags{BrokerCA: pki.ca}, 5*time.Second); err == nil {
+		t.Error("fetchBrokerToken() without client cert succeeded, want TLS error")
+	}
+	if _, err := fetchBrokerToken(context.Background(), srv.URL, BrokerClientFlags{}, 5*time.Second); err == nil {
+		t.Error("fetchBrokerToken() without --broker-ca succeeded, want unknown authority")
+	}
+}
+
+func TestCLITokenPullFromBroker(t *testing.T) {
+	srv := startFakeAlza(t)
+	token := alzatest.JWT(time.Now().Add(time.Hour))
+	srv.SetToken(token)
+
+	b := newTestBroker(time.Now(), nil)
+	b.load(token)
+	broker := httptest.NewServer(b.handler())
+	defer broker.Close()
+
+	out := mustRunCLI(t, "token", "pull", "--from", broker.URL)
+	if !strings.Contains(out, "Token pulled") {
+		t.Errorf("token pull output = %q", out)
+	}
+	if out := mustRunCLI(t, "whoami"); !strings.Contains(out, alzatest.DefaultUserName) {
+		t.Errorf("whoami after pull = %q", out)
+	}
+}
+
+func TestCLITokenServeNeedsRefreshSource(t *testing.T) {
+	startFakeAlza(t)
+	_, err := runCLI(t, "token", "serve")
+	if err == nil || !strings.Contains(err.Error(), "--refresh-from") {
+		t.Errorf("token serve with ALZA_REFRESH_FROM=off error = %v", err)
+	}
+}
+
+type testPKI struct {
+	ca, serverCert, serverKey, clientCert, clientKey string
+}
+
+// newTestPKI writes a CA and a server (127.0.0.1) and client certificate it signed
+func newTestPKI(t *testing.T, dir string) testPKI {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "alza test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	writePEM := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return writePEM(name+".crt", "CERTIFICATE", der), writePEM(name+".key", "EC PRIVATE KEY", keyDER)
+	}
+
+	pki := testPKI{ca: writePEM("ca.crt", "CERTIFICATE", caDER)}
+	pki.serverCert, pki.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	pki.clientCert, pki.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth)
+	return pki
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/internal/notify"
+	"github.com/kuringer/alza-cli/internal/pricewatch"
+)
+
+// WatchCmd records product prices and availability over time and reports
+// threshold crossings and stock changes
+type WatchCmd struct {
+	Add    WatchAddCmd    `cmd:"" help:"Watch a product's price or availability"`
+	List   WatchListCmd   `cmd:"" default:"1" help:"Show watched products with their last price"`
+	Remove WatchRemoveCmd `cmd:"" help:"Stop watching a product (its price history is kept)"`
+	Run    WatchRunCmd    `cmd:"" help:"Check all watched products, record them and report threshold crossings and stock changes"`
+}
+
+// watchStore opens the watch files of the active profile
+func watchStore(g *Globals) (*pricewatch.Store, error) {
+	p, err := g.accountProfile()
+	if err != nil {
+		return nil, err
+	}
+	return pricewatch.NewStore(p.WatchesPath(), p.PriceHistoryPath()), nil
+}
+
+type WatchAddCmd struct {
+	Product  client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN"`
+	Below    float64           `help:"Alert when the price drops to or below this (storefront currency)"`
+	Drop     string            `help:"Alert when the price falls this much under today's, e.g. 10%"`
+	Stock    bool              `help:"Alert when the product comes back in stock or its expected stock date changes"`
+	AutoCart bool              `help:"Add the product to the cart once it is in stock (implies --stock)"`
+	Quantity int               `help:"Quantity for --auto-cart" default:"1" short:"q"`
+}
+
+func (c *WatchAddCmd) Run(g *Globals) error {
+	if c.Below < 0 {
+		return fmt.Errorf("invalid --below %.2f", c.Below)
+	}
+	drop, err := parsePercent(c.Drop)
+	if err != nil {
+		return err
+	}
+	if c.AutoCart && c.Quantity < 1 {
+		return fmt.Errorf("invalid --quantity %d", c.Quantity)
+	}
+	store, err := watchStore(g)
+	if err != nil {
+		return err
+	}
+	cl, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	id, err := cl.ResolveProductContext(g.Context(), c.Product)
+	if err != nil {
+		return err
+	}
+	product, err := cl.GetProductContext(g.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	sf := cl.Storefront()
+	obs := pricewatch.ObservationOf(product, sf.Country, time.Now())
+	w := pricewatch.Watch{
+		ProductID:   id,
+		Name:        product.Name,
+		Country:     sf.Country,
+		Below:       c.Below,
+		DropPercent: drop,
+		BasePrice:   obs.Price,
+		Stock:       c.Stock || c.AutoCart,
+		AddedAt:     obs.Time,
+	}
+	if c.AutoCart {
+		w.AutoCart = c.Quantity
+	}
+	if drop > 0 && w.BasePrice <= 0 {
+		return fmt.Errorf("product %d has no price now, --drop needs one to compare against", id)
+	}
+	if w.CartDue(obs) {
+		return fmt.Errorf("product %d is in stock now (%s), add it with alza cart add %d", id, obs.Availability, id)
+	}
+	if err := store.Add(w); err != nil {
+		return err
+	}
+	if err := store.Append(obs); err != nil {
+		return err
+	}
+	// Check alerts on crossing, and this observation is the first run's
+	// previous one, so a price already at the threshold stays quiet
+	if w.Below > 0 && obs.Price > 0 && obs.Price <= w.Below {
+		fmt.Fprintf(os.Stderr, "Warning: the price %s is already at or below %s; watch run alerts only after it rises above and drops again\n", formatWatchPrice(obs.Price, sf), formatWatchPrice(w.Below, sf))
+	}
+
+	if g.Format == "json" {
+		outputJSON(w)
+		return nil
+	}
+	fmt.Printf("✓ Watching [%d] %s at %s%s%s\n", w.ProductID, w.Name, formatWatchPrice(obs.Price, sf), formatAvailability(w, obs), formatThresholds(w, sf))
+	return nil
+}
+
+// parsePercent accepts "10%" or "10"; "" is no threshold
+func parsePercent(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	p, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, fmt.Errorf("invalid --drop %q (a percentage between 0 and 100, e.g. 10%%)", s)
+	}
+	return p, nil
+}
+
+func formatWatchPrice(price float64, sf client.Storefront) string {
+	if price <= 0 {
+		return "no price"
+	}
+	return fmt.Sprintf("%.2f %s", price, sf.CurrencySymbol)
+}
+
+func formatThresholds(w pricewatch.Watch, sf client.Storefront) string {
+	var parts []string
+	if w.Below > 0 {
+		parts = append(parts, "alert at or below "+formatWatchPrice(w.Below, sf))
+	}
+	if w.DropPercent > 0 {
+		parts = append(parts, fmt.Sprintf("alert on a %g%% drop from %s", w.DropPercent, formatWatchPrice(w.BasePrice, sf)))
+	}
+	switch {
+	case !w.CartedAt.IsZero():
+		parts = append(parts, "added to the cart "+w.CartedAt.Local().Format("2006-01-02 15:04"))
+	case w.AutoCart > 0:
+		parts = append(parts, fmt.Sprintf("add %d to the cart when in stock", w.AutoCart))
+	case w.Stock:
+		parts = append(parts, "alert when back in stock")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// formatAvailability adds the availability to a price for watches that track it
+func formatAvailability(w pricewatch.Watch, o pricewatch.Observation) string {
+	if !w.Stock || o.Availability == "" {
+		return ""
+	}
+	s := " · " + o.Availability
+	if !o.InStock && o.ExpectedStockDate != "" {
+		s += ", expected " + o.ExpectedStockDate
+	}
+	return s
+}
+
+type WatchListCmd struct{}
+
+type watchListItem struct {
+	pricewatch.Watch
+	Last *pricewatch.Observation `json:"last,omitempty"`
+}
+
+func (c *WatchListCmd) Run(g *Globals) error {
+	store, err := watchStore(g)
+	if err != nil {
+		return err
+	}
+	watches, err := store.Watches()
+	if err != nil {
+		return err
+	}
+	items := make([]watchListItem, 0, len(watches))
+	for _, w := range watches {
+		history, err := store.History(w.ProductID, w.Country)
+		if err != nil {
+			return err
+		}
+		item := watchListItem{Watch: w}
+		if len(history) > 0 {
+			item.Last = &history[len(history)-1]
+		}
+		items = append(items, item)
+	}
+
+	if g.Format == "json" {
+		outputJSON(items)
+		return nil
+	}
+	if len(items) == 0 {
+		fmt.Println("No watched products (alza watch add <id>)")
+		return nil
+	}
+	for i, item := range items {
+		sf, err := client.StorefrontFor(item.Country)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d. [%d] %s (%s)\n", i+1, item.ProductID, item.Name, item.Country)
+		if item.Last != nil {
+			fmt.Printf("   Price: %s%s | checked %s\n", formatWatchPrice(item.Last.Price, sf), formatAvailability(item.Watch, *item.Last), item.Last.Time.Local().Format("2006-01-02 15:04"))
+		}
+		if t := formatThresholds(item.Watch, sf); t != "" {
+			fmt.Printf("   %s\n", strings.Trim(t, " ()"))
+		}
+	}
+	return nil
+}
+
+type WatchRemoveCmd struct {
+	Product client.ProductRef `arg:"" help:"Product ID, URL, Alza code or EAN"`
+}
+
+func (c *WatchRemoveCmd) Run(g *Globals) error {
+	sf, err := resolveStorefront(g)
+	if err != nil {
+		return err
+	}
+	store, err := watchStore(g)
+	if err != nil {
+		return err
+	}
+	id, err := resolveProductID(g, c.Product)
+	if err != nil {
+		return err
+	}
+	removed, err := store.Remove(id, sf.Country)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("product %d is not watched on %s", id, sf.Domain)
+	}
+	fmt.Printf("✓ Stopped watching product %d\n", id)
+	return nil
+}
+
+type WatchRunCmd struct{}
+
+// watchCheck is the result of one watched product in `alza watch run`
+type watchCheck struct {
+	Watch       pricewatch.Watch        `json:"watch"`
+	Observation *pricewatch.Observation `json:"observation,omitempty"`
+	Previous    *pricewatch.Observation `json:"previous,omitempty"`
+	Alerts      []pricewatch.Alert      `json:"alerts,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+type watchRunResult struct {
+	Checks []watchCheck       `json:"checks"`
+	Alerts []pricewatch.Alert `json:"alerts"`
+}
+
+func (c *WatchRunCmd) Run(g *Globals) error {
+	store, err := watchStore(g)
+	if err != nil {
+		return err
+	}
+	sf, err := resolveStorefront(g)
+	if err != nil {
+		return err
+	}
+	all, err := store.Watches()
+	if err != nil {
+		return err
+	}
+	// Prices differ per storefront; other countries are checked with --country
+	var watches []pricewatch.Watch
+	for _, w := range all {
+		if w.Country == sf.Country {
+			watches = append(watches, w)
+		}
+	}
+	if len(watches) == 0 {
+		if g.Format == "json" {
+			outputJSON(watchRunResult{Checks: []watchCheck{}, Alerts: []pricewatch.Alert{}})
+			return nil
+		}
+		fmt.Printf("No watched products on %s\n", sf.Domain)
+		return nil
+	}
+
+	cl, err := openClient(g, sf)
+	if err != nil {
+		return err
+	}
+	// Observations need the price and availability only
+	ids := make([]int, len(watches))
+	for i, w := range watches {
+		ids[i] = w.ProductID
+	}
+	products := cl.GetProductsContext(g.Context(), ids, client.ProductOptions{Availability: true})
+	histories, err := store.Histories()
+	if err != nil {
+		return err
+	}
+
+	res := watchRunResult{Checks: make([]watchCheck, 0, len(watches)), Alerts: []pricewatch.Alert{}}
+	var failed int
+	for i, w := range watches {
+		history := histories[pricewatch.HistoryKey{ProductID: w.ProductID, Country: w.Country}]
+		check := runWatch(g, cl, store, w, history, products[i])
+		if check.Error != "" {
+			failed++
+		}
+		res.Alerts = append(res.Alerts, check.Alerts...)
+		res.Checks = append(res.Checks, check)
+	}
+
+	if g.Format == "json" {
+		outputJSON(res)
+	} else {
+		fmt.Print(formatWatchRun(res, sf))
+	}
+	var errs []error
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d watched products failed", failed, len(watches)))
+	}
+	events := make([]notify.Event, 0, len(res.Alerts))
+	for _, alert := range res.Alerts {
+		events = append(events, alertEvent(alert, sf))
+	}
+	// An alert fires once, so a lost notification fails the run where cron can see it
+	errs = append(errs, publish(g, events...))
+	return errors.Join(errs...)
+}
+
+// alertEvent is the notification for a watch alert
+func alertEvent(a pricewatch.Alert, sf client.Storefront) notify.Event {
+	return notify.Event{
+		Type:    notify.EventWatchPrefix + a.Kind,
+		Time:    a.Observation.Time,
+		Title:   fmt.Sprintf("[%d] %s", a.Watch.ProductID, a.Watch.Name),
+		Message: alertText(a, sf),
+		Data:    a,
+	}
+}
+
+// runWatch appends the fetched product's price to its history, checks the
+// thresholds and stock changes and does a due auto-cart
+func runWatch(g *Globals, cl *client.TLSClient, store *pricewatch.Store, w pricewatch.Watch, history []pricewatch.Observation, fetched client.ProductResult) watchCheck {
+	check := watchCheck{Watch: w}
+	if len(history) > 0 {
+		check.Previous = &history[len(history)-1]
+	}
+	if fetched.Err != nil {
+		check.Error = fetched.Err.Error()
+		return check
+	}
+	obs := pricewatch.ObservationOf(fetched.Product, w.Country, time.Now())
+	if err := store.Append(obs); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Observation = &obs
+	check.Alerts = append(pricewatch.Check(w, check.Previous, obs), pricewatch.CheckStock(w, pricewatch.LastKnownAvailability(history), obs)...)
+
+	if w.CartDue(obs) {
+		if err := cl.AddToCartContext(g.Context(), w.ProductID, w.AutoCart); err != nil {
+			// Not marked as done, so the next run tries again
+			check.Error = "add to cart: " + err.Error()
+			return check
+		}
+		w.CartedAt = time.Now()
+		check.Watch = w
+		check.Alerts = append(check.Alerts, pricewatch.Alert{
+			Kind: pricewatch.AlertCarted, Watch: w, Observation: obs,
+			Message: fmt.Sprintf("added %d to the cart", w.AutoCart),
+		})
+		if err := store.Add(w); err != nil {
+			check.Error = err.Error()
+		}
+	}
+	return check
+}
+
+func formatWatchRun(res watchRunResult, sf client.Storefront) string {
+	var b strings.Builder
+	for _, check := range res.Checks {
+		w := check.Watch
+		if check.Observation != nil {
+			fmt.Fprintf(&b, "[%d] %s: %s", w.ProductID, w.Name, formatWatchPrice(check.Observation.Price, sf))
+			if prev := check.Previous; prev != nil && prev.Price > 0 && check.Observation.Price > 0 && prev.Price != check.Observation.Price {
+				fmt.Fprintf(&b, " (was %s)", formatWatchPrice(prev.Price, sf))
+			}
+			b.WriteString(formatAvailability(w, *check.Observation) + "\n")
+		}
+		if check.Error != "" {
+			fmt.Fprintf(&b, "✗ [%d] %s: %s\n", w.ProductID, w.Name, check.Error)
+		}
+	}
+	for _, alert := range res.Alerts {
+		fmt.Fprintf(&b, "🔔 [%d] %s: %s\n", alert.Watch.ProductID, alert.Watch.Name, alertText(alert, sf))
+	}
+	return b.String()
+}
+
+func alertText(a pricewatch.Alert, sf client.Storefront) string {
+	price := formatWatchPrice(a.Observation.Price, sf)
+	switch a.Kind {
+	case pricewatch.AlertBelow:
+		return fmt.Sprintf("%s is at or below %s", price, formatWatchPrice(a.Watch.Below, sf))
+	case pricewatch.AlertDrop:
+		return fmt.Sprintf("%s is %.0f%% under %s", price, pricewatch.DropPercent(a.Watch.BasePrice, a.Observation.Price), formatWatchPrice(a.Watch.BasePrice, sf))
+	case pricewatch.AlertInStock:
+		return fmt.Sprintf("back in stock (%s) at %s", a.Observation.Availability, price)
+	case pricewatch.AlertCarted:
+		return fmt.Sprintf("added %d to the cart at %s", a.Watch.AutoCart, price)
+	}
+	return a.Message
+}
+
+// productHistory prints the prices `alza watch run` recorded for a product, no network needed
+func productHistory(g *Globals, productID int) error {
+	sf, err := resolveStorefront(g)
+	if err != nil {
+		return err
+	}
+	store, err := watchStore(g)
+	if err != nil {
+		return err
+	}
+	history, err := store.History(productID, sf.Country)
+	if err != nil {
+		return err
+	}
+	if g.Format == "json" {
+		if history == nil {
+			history = []pricewatch.Observation{}
+		}
+		outputJSON(history)
+		return nil
+	}
+	if len(history) == 0 {
+		fmt.Printf("No price history for product %d on %s (alza watch add %d)\n", productID, sf.Domain, productID)
+		return nil
+	}
+	name := ""
+	if watches, err := store.Watches(); err == nil {
+		for _, w := range watches {
+			if w.ProductID == productID && w.Country == sf.Country {
+				name = " " + w.Name
+			}
+		}
+	}
+	fmt.Printf("[%d]%s\n", productID, name)
+	fmt.Print(formatPriceHistory(history, sf))
+	return nil
+}
+
+// priceBarWidth is the length of the bar for the highest price in the history chart
+const priceBarWidth = 30
+
+// formatPriceHistory prints one line per observation with a bar scaled between
+// the lowest and highest recorded price, so changes stand out
+func formatPriceHistory(history []pricewatch.Observation, sf client.Storefront) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, o := range history {
+		if o.Price > 0 {
+			lo, hi = min(lo, o.Price), max(hi, o.Price)
+		}
+	}
+
+	var b strings.Builder
+	for _, o := range history {
+		fmt.Fprintf(&b, "%s  %12s  ", o.Time.Local().Format("2006-01-02 15:04"), formatWatchPrice(o.Price, sf))
+		if o.Price > 0 {
+			width := priceBarWidth
+			if hi > lo {
+				// The lowest price still gets a bar, so it doesn't look like a missing value
+				width = 1 + int(math.Round((o.Price-lo)/(hi-lo)*float64(priceBarWidth-1)))
+			}
+			b.WriteString(strings.Repeat("█", width))
+		}
+		if o.PromoPrice > 0 && o.PromoPrice < o.Price {
+			fmt.Fprintf(&b, "  promo %s", formatWatchPrice(o.PromoPrice, sf))
+		}
+		b.WriteString("\n")
+	}
+	if !math.IsInf(lo, 0) {
+		last := history[len(history)-1]
+		fmt.Fprintf(&b, "Min %s | Max %s | Last %s (%d observations)\n",
+			formatWatchPrice(lo, sf), formatWatchPrice(hi, sf), formatWatchPrice(last.Price, sf), len(history))
+	}
+	return b.String()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuringer/alza-cli/client"
+	"github.com/kuringer/alza-cli/client/alzatest"
+	"github.com/kuringer/alza-cli/internal/pricewatch"
+)
+
+// setPrice changes the catalog price of a default fake product
+func setPrice(srv *alzatest.Server, productID int, price float64) {
+	for _, p := range alzatest.DefaultProducts() {
+		if p.ID == productID {
+			p.Price = price
+			srv.AddProduct(p)
+		}
+	}
+}
+
+func TestCLIWatch(t *testing.T) {
+	srv := startFakeAlza(t)
+
+	out := mustRunCLI(t, "watch", "add", "7816725", "--below", "15", "--drop", "10%")
+	if !strings.Contains(out, "Watching [7816725] GymBeam") || !strings.Contains(out, "17.90 €") || !strings.Contains(out, "10% drop") {
+		t.Errorf("watch add output = %q", out)
+	}
+	mustRunCLI(t, "watch", "add", "12345678")
+
+	out = mustRunCLI(t, "watch", "list")
+	if !strings.Contains(out, "1. [7816725]") || !strings.Contains(out, "2. [12345678]") || !strings.Contains(out, "alert at or below 15.00 €") {
+		t.Errorf("watch list output = %q", out)
+	}
+
+	// 17.90 -> 16.00 is a 10.6% drop, still above 15
+	setPrice(srv, 7816725, 16)
+	srv.ResetRequests()
+	out = mustRunCLI(t, "watch", "run")
+	for _, r := range srv.Requests() {
+		if strings.Contains(r.Path, "/reviewStats") {
+			t.Errorf("watch run fetched %s, it needs the price and availability only", r.Path)
+		}
+	}
+	if !strings.Contains(out, "16.00 € (was 17.90 €)") || !strings.Contains(out, "🔔 [7816725] GymBeam Kreatín monohydrát 500 g: 16.00 € is 11% under 17.90 €") {
+		t.Errorf("watch run output = %q", out)
+	}
+	if strings.Contains(out, "at or below") {
+		t.Errorf("watch run alerted below 15 at 16: %q", out)
+	}
+
+	setPrice(srv, 7816725, 14.5)
+	var res watchRunResult
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "watch", "run")), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Checks) != 2 || len(res.Alerts) != 1 || res.Alerts[0].Kind != pricewatch.AlertBelow {
+		t.Errorf("watch run JSON = %+v, want only the below alert", res)
+	}
+
+	// Nothing new crossed
+	if out := mustRunCLI(t, "watch", "run"); strings.Contains(out, "🔔") {
+		t.Errorf("second watch run at the same price alerted: %q", out)
+	}
+
+	out = mustRunCLI(t, "product", "7816725", "--history")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 6 || !strings.HasPrefix(lines[0], "[7816725] GymBeam") {
+		t.Errorf("product --history output = %q, want header, 4 observations and a summary", out)
+	}
+	if !strings.Contains(out, "Min 14.50 € | Max 17.90 € | Last 14.50 € (4 observations)") {
+		t.Errorf("product --history summary = %q", out)
+	}
+	var history []pricewatch.Observation
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "product", "7816725", "--history")), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 || history[3].Price != 14.5 || history[3].Country != "SK" {
+		t.Errorf("product --history JSON = %+v", history)
+	}
+
+	mustRunCLI(t, "watch", "remove", "7816725")
+	if _, err := runCLI(t, "watch", "remove", "7816725"); err == nil {
+		t.Error("watch remove of an unwatched product succeeded")
+	}
+	if out := mustRunCLI(t, "watch", "list"); strings.Contains(out, "7816725") {
+		t.Errorf("watch list after remove = %q", out)
+	}
+	// The history outlives the watch
+	if out := mustRunCLI(t, "product", "7816725", "--history"); !strings.Contains(out, "4 observations") {
+		t.Errorf("product --history after remove = %q", out)
+	}
+}
+
+func TestCLIWatchAddAlreadyBelow(t *testing.T) {
+	startFakeAlza(t)
+	errPath := filepath.Join(t.TempDir(), "stderr")
+	f, err := os.Create(errPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() { os.Stderr = stderr; _ = f.Close() })
+
+	mustRunCLI(t, "watch", "add", "7816725", "--below", "20")
+	mustRunCLI(t, "watch", "add", "12345678", "--below", "1")
+	warnings, err := os.ReadFile(errPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(warnings); strings.Count(got, "Warning:") != 1 || !strings.Contains(got, "the price 17.90 € is already at or below 20.00 €") {
+		t.Errorf("watch add warnings = %q, want one for 7816725", got)
+	}
+}
+
+func TestCLIWatchRunOtherStorefront(t *testing.T) {
+	startFakeAlza(t)
+	mustRunCLI(t, "watch", "add", "7816725")
+	if out := mustRunCLI(t, "--country", "CZ", "watch", "run"); !strings.Contains(out, "No watched products on www.alza.cz") {
+		t.Errorf("watch run on CZ = %q", out)
+	}
+	if out := mustRunCLI(t, "--country", "CZ", "product", "7816725", "--history"); !strings.Contains(out, "No price history") {
+		t.Errorf("product --history on CZ = %q", out)
+	}
+}
+
+func TestCLIWatchRunReportsFailures(t *testing.T) {
+	srv := startFakeAlza(t)
+	mustRunCLI(t, "watch", "add", "7816725")
+	mustRunCLI(t, "watch", "add", "12345678")
+
+	srv.InjectFault(alzatest.Fault{Path: "12345678", Status: 500})
+	out, err := runCLI(t, "watch", "run")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("watch run error = %v", err)
+	}
+	if !strings.Contains(out, "[7816725] GymBeam Kreatín monohydrát 500 g: 17.90 €") || !strings.Contains(out, "✗ [12345678]") {
+		t.Errorf("watch run output = %q", out)
+	}
+}
+
+func TestCLIWatchStock(t *testing.T) {
+	srv := startFakeAlza(t)
+	iphone := alzatest.DefaultProducts()[2]
+	setAvailability := func(availability, expected string) {
+		iphone.Availability, iphone.ExpectedStockDate = availability, expected
+		srv.AddProduct(iphone)
+	}
+
+	if _, err := runCLI(t, "watch", "add", "7816725", "--auto-cart"); err == nil || !strings.Contains(err.Error(), "in stock now") {
+		t.Errorf("watch add --auto-cart of an in-stock product error = %v", err)
+	}
+
+	out := mustRunCLI(t, "watch", "add", "8123456", "--auto-cart", "-q", "2")
+	if !strings.Contains(out, "· Na objednávku") || !strings.Contains(out, "add 2 to the cart when in stock") {
+		t.Errorf("watch add output = %q", out)
+	}
+	if out := mustRunCLI(t, "watch", "run"); strings.Contains(out, "🔔") {
+		t.Errorf("watch run without a change alerted: %q", out)
+	}
+
+	setAvailability("Na objednávku", "2026-03-10")
+	out = mustRunCLI(t, "watch", "run")
+	if !strings.Contains(out, "Na objednávku, expected 2026-03-10") || !strings.Contains(out, "🔔 [8123456] Apple iPhone 15 Pro 128GB čierny titán: expected in stock 2026-03-10") {
+		t.Errorf("watch run after a new stock date = %q", out)
+	}
+
+	// A run that can't fetch the availability doesn't count as sold out
+	srv.InjectFault(alzatest.Fault{Path: "productAvailability", Status: 500})
+	mustRunCLI(t, "watch", "run")
+	srv.ClearFaults()
+
+	setAvailability("Skladom 3 ks", "")
+	var res watchRunResult
+	if err := json.Unmarshal([]byte(mustRunCLI(t, "--format=json", "watch", "run")), &res); err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, a := range res.Alerts {
+		kinds = append(kinds, a.Kind)
+	}
+	if strings.Join(kinds, ",") != "in_stock,carted" || res.Checks[0].Watch.CartedAt.IsZero() {
+		t.Errorf("watch run when back in stock = %+v", res)
+	}
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].ProductID != 8123456 || cart[0].Count != 2 {
+		t.Errorf("cart = %+v, want 2x 8123456", cart)
+	}
+
+	// Carted once: out of stock and back again only alerts
+	setAvailability("Vypredané", "")
+	mustRunCLI(t, "watch", "run")
+	setAvailability("Skladom 1 ks", "")
+	out = mustRunCLI(t, "watch", "run")
+	if !strings.Contains(out, "back in stock (Skladom 1 ks)") || strings.Contains(out, "added") {
+		t.Errorf("second back in stock = %q", out)
+	}
+	if cart := srv.Cart(); len(cart) != 1 || cart[0].Count != 2 {
+		t.Errorf("cart after a second back in stock = %+v", cart)
+	}
+	if out := mustRunCLI(t, "watch", "list"); !strings.Contains(out, "added to the cart 20") {
+		t.Errorf("watch list = %q", out)
+	}
+}
+
+func TestCLIWatchAutoCartRetries(t *testing.T) {
+	srv := startFakeAlza(t)
+	iphone := alzatest.DefaultProducts()[2]
+	mustRunCLI(t, "watch", "add", "8123456", "--auto-cart")
+	iphone.Availability = "Skladom 1 ks"
+	srv.AddProduct(iphone)
+
+	srv.InjectFault(alzatest.Fault{Path: "OrderCommodity", Status: 500})
+	out, err := runCLI(t, "watch", "run")
+	if err == nil || !strings.Contains(out, "✗ [8123456] Apple iPhone 15 Pro 128GB čierny titán: add to cart:") || !strings.Contains(out, "back in stock") {
+		t.Errorf("watch run with a failing cart = %q, %v", out, err)
+	}
+	srv.ClearFaults()
+
+	if out := mustRunCLI(t, "watch", "run"); !strings.Contains(out, "added 1 to the cart") {
+		t.Errorf("watch run after the cart recovered = %q", out)
+	}
+	if cart := srv.Cart(); len(cart) != 1 {
+		t.Errorf("cart = %+v", cart)
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"10%", 10, false},
+		{" 12.5 ", 12.5, false},
+		{"0%", 0, true},
+		{"100%", 0, true},
+		{"ten", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePercent(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parsePercent(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestFormatPriceHistory(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	history := []pricewatch.Observation{
+		{Time: t0, Price: 20},
+		{Time: t0.Add(time.Hour)},
+		{Time: t0.Add(2 * time.Hour), Price: 10, PromoPrice: 9},
+	}
+	got := formatPriceHistory(history, client.DefaultStorefront())
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("formatPriceHistory() = %q", got)
+	}
+	if n := strings.Count(lines[0], "█"); n != priceBarWidth {
+		t.Errorf("highest price bar = %d, want %d", n, priceBarWidth)
+	}
+	if !strings.Contains(lines[1], "no price") || strings.Contains(lines[1], "█") {
+		t.Errorf("missing price line = %q", lines[1])
+	}
+	if n := strings.Count(lines[2], "█"); n != 1 || !strings.HasSuffix(lines[2], "promo 9.00 €") {
+		t.Errorf("lowest price line = %q", lines[2])
+	}
+	if lines[3] != "Min 10.00 € | Max 20.00 € | Last 10.00 € (3 observations)" {
+		t.Errorf("summary = %q", lines[3])
+	}
+}